@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type certificateOptions struct {
+	documentDBName  string
+	namespace       string
+	kubeContext     string
+	expiryThreshold time.Duration
+	caFile          string
+}
+
+func newCertificateCommand() *cobra.Command {
+	opts := &certificateOptions{namespace: defaultDocumentDBNamespace}
+
+	cmd := &cobra.Command{
+		Use:     "certificate",
+		Aliases: []string{"cert"},
+		Short:   "Inspect the gateway TLS certificate of a DocumentDB resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(); err != nil {
+				return err
+			}
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().DurationVar(&opts.expiryThreshold, "expiry-threshold", 30*24*time.Hour, "Warn when the certificate expires within this duration")
+	cmd.Flags().StringVar(&opts.caFile, "ca-file", opts.caFile, "Write the CA bundle from status.tls.caBundle to this file, e.g. for mongosh --tlsCAFile")
+
+	_ = cmd.MarkFlagRequired("documentdb")
+
+	return cmd
+}
+
+func (o *certificateOptions) complete() error {
+	o.documentDBName = strings.TrimSpace(o.documentDBName)
+	if o.documentDBName == "" {
+		return errors.New("--documentdb is required")
+	}
+	o.namespace = strings.TrimSpace(o.namespace)
+	if o.namespace == "" {
+		o.namespace = defaultDocumentDBNamespace
+	}
+	o.caFile = strings.TrimSpace(o.caFile)
+	if o.expiryThreshold < 0 {
+		o.expiryThreshold = 0
+	}
+	return nil
+}
+
+func (o *certificateOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	config, _, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+
+	document, err := dynClient.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+	}
+
+	secretName, _, _ := unstructured.NestedString(document.Object, "status", "tls", "secretName")
+	if secretName == "" {
+		return fmt.Errorf("DocumentDB %s/%s does not report a TLS secret in status.tls.secretName", o.namespace, o.documentDBName)
+	}
+
+	clientset, err := kubernetesClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+
+	secret, err := clientset.CoreV1().Secrets(o.namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get TLS secret %q: %w", secretName, err)
+	}
+
+	cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return fmt.Errorf("failed to parse %s from secret %q: %w", corev1.TLSCertKey, secretName, err)
+	}
+
+	now := time.Now()
+	printCertificate(cmd.OutOrStdout(), secretName, cert, o.expiryThreshold, now)
+
+	caBundle, _, _ := unstructured.NestedString(document.Object, "status", "tls", "caBundle")
+	printCAVerification(cmd.OutOrStdout(), caBundle, cert, now)
+
+	if o.caFile != "" {
+		if caBundle == "" {
+			return fmt.Errorf("DocumentDB %s/%s does not report a CA bundle in status.tls.caBundle", o.namespace, o.documentDBName)
+		}
+		if err := os.WriteFile(o.caFile, []byte(caBundle), 0o644); err != nil {
+			return fmt.Errorf("failed to write CA bundle to %q: %w", o.caFile, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "CA bundle written to %s; connect with: mongosh --tls --tlsCAFile %s\n", o.caFile, o.caFile)
+	}
+	return nil
+}
+
+// parseCertificate decodes the first PEM encoded certificate (the leaf) from the given data.
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	for len(data) > 0 {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		return x509.ParseCertificate(block.Bytes)
+	}
+	return nil, errors.New("no PEM encoded certificate found")
+}
+
+func printCertificate(out io.Writer, secretName string, cert *x509.Certificate, threshold time.Duration, now time.Time) {
+	ipSANs := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ipSANs = append(ipSANs, ip.String())
+	}
+
+	fmt.Fprintf(out, "Secret: %s\n", secretName)
+	fmt.Fprintf(out, "Subject: %s\n", cert.Subject.String())
+	fmt.Fprintf(out, "Issuer: %s\n", cert.Issuer.String())
+	fmt.Fprintf(out, "DNS SANs: %s\n", safeValue(strings.Join(cert.DNSNames, ", ")))
+	fmt.Fprintf(out, "IP SANs: %s\n", safeValue(strings.Join(ipSANs, ", ")))
+	fmt.Fprintf(out, "Not before: %s\n", cert.NotBefore.UTC().Format(time.RFC3339))
+	fmt.Fprintf(out, "Not after: %s\n", cert.NotAfter.UTC().Format(time.RFC3339))
+
+	remaining := cert.NotAfter.Sub(now)
+	switch {
+	case remaining <= 0:
+		fmt.Fprintf(out, "WARNING: certificate expired %s ago\n", (-remaining).Round(time.Second))
+	case remaining <= threshold:
+		fmt.Fprintf(out, "WARNING: certificate expires in %s\n", remaining.Round(time.Second))
+	default:
+		fmt.Fprintf(out, "Expires in: %s\n", remaining.Round(time.Second))
+	}
+}
+
+// printCAVerification reports whether the certificate chains to the CA bundle published in the DocumentDB status.
+func printCAVerification(out io.Writer, caBundle string, cert *x509.Certificate, now time.Time) {
+	if caBundle == "" {
+		fmt.Fprintln(out, "CA bundle: not reported, clients must trust the issuer themselves")
+		return
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(caBundle)) {
+		fmt.Fprintln(out, "WARNING: status.tls.caBundle contains no PEM encoded certificates")
+		return
+	}
+	opts := x509.VerifyOptions{Roots: roots, CurrentTime: now, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+	if _, err := cert.Verify(opts); err != nil {
+		fmt.Fprintf(out, "WARNING: certificate does not verify against status.tls.caBundle: %v\n", err)
+		return
+	}
+	fmt.Fprintln(out, "CA bundle: certificate verified")
+}
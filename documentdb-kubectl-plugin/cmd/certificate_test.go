@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestCertificateRunPrintsParsedFields(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "documentdb-sample"
+	secretName := "documentdb-sample-gateway-cert-tls"
+
+	doc := newDocument(docName, namespace, "cluster-a", "Ready")
+	if err := unstructured.SetNestedField(doc.Object, secretName, "status", "tls", "secretName"); err != nil {
+		t.Fatalf("failed to set tls secret name: %v", err)
+	}
+
+	certPEM := newTestCertificatePEM(t, "documentdb-sample-gateway", []string{"gateway.example.com", "localhost"}, []net.IP{net.ParseIP("10.0.0.1")}, 10*24*time.Hour)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+	}
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "cluster"}, "cluster", nil
+	}
+	dynamicClientForConfig = func(*rest.Config) (dynamic.Interface, error) {
+		return newFakeDynamicClient(doc.DeepCopy()), nil
+	}
+	kubernetesClientForConfig = func(*rest.Config) (kubernetes.Interface, error) {
+		return kubefake.NewSimpleClientset(secret), nil
+	}
+
+	cmd := &cobra.Command{}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	opts := &certificateOptions{
+		documentDBName:  docName,
+		namespace:       namespace,
+		expiryThreshold: 30 * 24 * time.Hour,
+	}
+	if err := opts.run(context.Background(), cmd); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	output := stdout.String()
+	for _, substring := range []string{
+		"Secret: " + secretName,
+		"Subject: CN=documentdb-sample-gateway",
+		"Issuer: CN=documentdb-sample-gateway",
+		"DNS SANs: gateway.example.com, localhost",
+		"IP SANs: 10.0.0.1",
+		"WARNING: certificate expires in",
+	} {
+		if !strings.Contains(output, substring) {
+			t.Fatalf("expected output to contain %q, got: %s", substring, output)
+		}
+	}
+}
+
+func TestCertificateRunVerifiesAgainstCABundle(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "documentdb-sample"
+	secretName := "documentdb-sample-gateway-cert-tls"
+
+	certPEM := newTestCertificatePEM(t, "documentdb-sample-gateway", []string{"gateway.example.com"}, nil, 90*24*time.Hour)
+	doc := newDocument(docName, namespace, "cluster-a", "Ready")
+	if err := unstructured.SetNestedField(doc.Object, secretName, "status", "tls", "secretName"); err != nil {
+		t.Fatalf("failed to set tls secret name: %v", err)
+	}
+	if err := unstructured.SetNestedField(doc.Object, string(certPEM), "status", "tls", "caBundle"); err != nil {
+		t.Fatalf("failed to set tls CA bundle: %v", err)
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: namespace},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: certPEM},
+	}
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "cluster"}, "cluster", nil
+	}
+	dynamicClientForConfig = func(*rest.Config) (dynamic.Interface, error) {
+		return newFakeDynamicClient(doc.DeepCopy()), nil
+	}
+	kubernetesClientForConfig = func(*rest.Config) (kubernetes.Interface, error) {
+		return kubefake.NewSimpleClientset(secret), nil
+	}
+
+	cmd := &cobra.Command{}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	opts := &certificateOptions{
+		documentDBName:  docName,
+		namespace:       namespace,
+		expiryThreshold: 30 * 24 * time.Hour,
+		caFile:          caFile,
+	}
+	if err := opts.run(context.Background(), cmd); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	output := stdout.String()
+	if !strings.Contains(output, "CA bundle: certificate verified") {
+		t.Fatalf("expected certificate to verify against CA bundle, got: %s", output)
+	}
+	written, err := os.ReadFile(caFile)
+	if err != nil {
+		t.Fatalf("failed to read CA file: %v", err)
+	}
+	if !bytes.Equal(written, certPEM) {
+		t.Fatalf("expected CA file to contain the CA bundle, got: %s", written)
+	}
+}
+
+func TestPrintCAVerificationRejectsUnrelatedCA(t *testing.T) {
+	t.Parallel()
+
+	leaf, err := parseCertificate(newTestCertificatePEM(t, "gateway", nil, nil, 24*time.Hour))
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	otherCA := newTestCertificatePEM(t, "other-ca", nil, nil, 24*time.Hour)
+
+	var out bytes.Buffer
+	printCAVerification(&out, string(otherCA), leaf, time.Now())
+	if !strings.Contains(out.String(), "WARNING: certificate does not verify") {
+		t.Fatalf("expected verification warning, got: %s", out.String())
+	}
+}
+
+func TestCertificateRunRequiresTLSStatus(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+	}()
+
+	doc := newDocument("sample", defaultDocumentDBNamespace, "cluster-a", "Ready")
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "cluster"}, "cluster", nil
+	}
+	dynamicClientForConfig = func(*rest.Config) (dynamic.Interface, error) {
+		return newFakeDynamicClient(doc), nil
+	}
+
+	opts := &certificateOptions{documentDBName: "sample", namespace: defaultDocumentDBNamespace}
+	if err := opts.run(context.Background(), &cobra.Command{}); err == nil {
+		t.Fatal("expected error when status.tls.secretName is missing")
+	}
+}
+
+func TestPrintCertificateExpiryWarnings(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		notAfter time.Time
+		expected string
+	}{
+		{name: "valid", notAfter: now.Add(90 * 24 * time.Hour), expected: "Expires in:"},
+		{name: "expiring", notAfter: now.Add(24 * time.Hour), expected: "WARNING: certificate expires in"},
+		{name: "expired", notAfter: now.Add(-time.Hour), expected: "WARNING: certificate expired"},
+	}
+
+	for _, tc := range testCases {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "test"}, NotAfter: tc.notAfter}
+		var out bytes.Buffer
+		printCertificate(&out, "secret", cert, 7*24*time.Hour, now)
+		if !strings.Contains(out.String(), tc.expected) {
+			t.Fatalf("case %q: expected output to contain %q, got: %s", tc.name, tc.expected, out.String())
+		}
+	}
+}
+
+func TestParseCertificateRejectsInvalidData(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseCertificate([]byte("not a certificate")); err == nil {
+		t.Fatal("expected error for invalid PEM data")
+	}
+}
+
+func newTestCertificatePEM(t *testing.T, commonName string, dnsNames []string, ips []net.IP, validFor time.Duration) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		IPAddresses:  ips,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// clonePhaseCompleted and clonePhaseFailed mirror the terminal phases reported in status.clone.phase
+	clonePhaseCompleted = "Completed"
+	clonePhaseFailed    = "Failed"
+)
+
+type cloneOptions struct {
+	documentDBName string
+	name           string
+	namespace      string
+	kubeContext    string
+	wait           bool
+	waitTimeout    time.Duration
+	pollInterval   time.Duration
+}
+
+func newCloneCommand() *cobra.Command {
+	opts := &cloneOptions{namespace: defaultDocumentDBNamespace}
+
+	cmd := &cobra.Command{
+		Use:   "clone",
+		Short: "Create a new DocumentDB resource from a backup of a live one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(); err != nil {
+				return err
+			}
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource to clone")
+	cmd.Flags().StringVar(&opts.name, "name", opts.name, "Name of the DocumentDB resource to create")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().BoolVar(&opts.wait, "wait", false, "Wait for the clone to complete")
+	cmd.Flags().DurationVar(&opts.waitTimeout, "wait-timeout", 30*time.Minute, "Maximum time to wait for the clone to complete")
+	cmd.Flags().DurationVar(&opts.pollInterval, "poll-interval", 10*time.Second, "Polling interval while waiting for the clone to complete")
+
+	_ = cmd.MarkFlagRequired("documentdb")
+	_ = cmd.MarkFlagRequired("name")
+
+	return cmd
+}
+
+func (o *cloneOptions) complete() error {
+	o.documentDBName = strings.TrimSpace(o.documentDBName)
+	if o.documentDBName == "" {
+		return errors.New("--documentdb is required")
+	}
+	o.name = strings.TrimSpace(o.name)
+	if o.name == "" {
+		return errors.New("--name is required")
+	}
+	if o.name == o.documentDBName {
+		return errors.New("--name must differ from --documentdb")
+	}
+	o.namespace = strings.TrimSpace(o.namespace)
+	if o.namespace == "" {
+		o.namespace = defaultDocumentDBNamespace
+	}
+	if o.waitTimeout <= 0 {
+		o.waitTimeout = 30 * time.Minute
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = 10 * time.Second
+	}
+	return nil
+}
+
+func (o *cloneOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	config, contextName, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if contextName == "" {
+		contextName = "(current)"
+	}
+
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	if err := o.createClone(ctx, dynClient); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Created DocumentDB %s/%s as a clone of %s (context %s)\n",
+		o.namespace, o.name, o.documentDBName, contextName)
+
+	if !o.wait {
+		return nil
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Waiting for the clone to complete...")
+	if err := o.waitForClone(ctx, dynClient); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Clone completed successfully.")
+	return nil
+}
+
+// createClone creates the new DocumentDB with the spec of the source and a clone bootstrap pointing at it.
+func (o *cloneOptions) createClone(ctx context.Context, dyn dynamic.Interface) error {
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+
+	source, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+	}
+
+	clone, err := o.buildClone(source)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dyn.Resource(gvr).Namespace(o.namespace).Create(ctx, clone, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create DocumentDB %q: %w", o.name, err)
+	}
+	return nil
+}
+
+// buildClone copies the spec of the source, dropping its bootstrap and replication settings which only apply to
+// the source itself.
+func (o *cloneOptions) buildClone(source *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	spec, _, err := unstructured.NestedMap(source.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec of DocumentDB %q: %w", o.documentDBName, err)
+	}
+	if spec == nil {
+		spec = map[string]any{}
+	}
+	delete(spec, "bootstrap")
+	delete(spec, "clusterReplication")
+	spec["bootstrap"] = map[string]any{
+		"clone": map[string]any{
+			"source": map[string]any{
+				"name": o.documentDBName,
+			},
+		},
+	}
+
+	clone := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": source.GetAPIVersion(),
+		"kind":       source.GetKind(),
+		"spec":       spec,
+	}}
+	clone.SetName(o.name)
+	clone.SetNamespace(o.namespace)
+	return clone, nil
+}
+
+// waitForClone polls the new DocumentDB until the operator reports the clone as completed or failed.
+func (o *cloneOptions) waitForClone(ctx context.Context, dyn dynamic.Interface) error {
+	ctx, cancel := context.WithTimeout(ctx, o.waitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the clone to complete after %s", o.waitTimeout)
+		case <-ticker.C:
+			document, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get DocumentDB %q: %w", o.name, err)
+			}
+			phase, _, _ := unstructured.NestedString(document.Object, "status", "clone", "phase")
+			switch phase {
+			case clonePhaseCompleted:
+				return nil
+			case clonePhaseFailed:
+				message, _, _ := unstructured.NestedString(document.Object, "status", "clone", "message")
+				return fmt.Errorf("clone of DocumentDB %q failed: %s", o.documentDBName, message)
+			}
+		}
+	}
+}
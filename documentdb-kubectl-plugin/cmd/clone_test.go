@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCloneCreatesDocumentDBFromSource(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	source := newDocument("sample", namespace, "member-1", "Cluster in healthy state")
+	_ = unstructured.SetNestedField(source.Object, int64(2), "spec", "instancesPerNode")
+	_ = unstructured.SetNestedField(source.Object, "backup-1", "spec", "bootstrap", "recovery", "backup", "name")
+	client := newFakeDynamicClient(source)
+
+	opts := &cloneOptions{documentDBName: "sample", name: "sample-copy", namespace: namespace}
+	if err := opts.createClone(context.Background(), client); err != nil {
+		t.Fatalf("createClone returned error: %v", err)
+	}
+
+	clone, err := client.Resource(documentDBGVR()).Namespace(namespace).Get(context.Background(), "sample-copy", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch cloned DocumentDB: %v", err)
+	}
+	if clone.GetKind() != "DocumentDB" {
+		t.Fatalf("expected kind DocumentDB, got %q", clone.GetKind())
+	}
+	if instances, _, _ := unstructured.NestedInt64(clone.Object, "spec", "instancesPerNode"); instances != 2 {
+		t.Fatalf("expected spec.instancesPerNode 2 to be copied, got %d", instances)
+	}
+	if sourceName, _, _ := unstructured.NestedString(clone.Object, "spec", "bootstrap", "clone", "source", "name"); sourceName != "sample" {
+		t.Fatalf("expected spec.bootstrap.clone.source.name sample, got %q", sourceName)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(clone.Object, "spec", "bootstrap", "recovery"); found {
+		t.Fatal("expected the recovery bootstrap of the source to be dropped")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(clone.Object, "spec", "clusterReplication"); found {
+		t.Fatal("expected spec.clusterReplication to be dropped")
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(clone.Object, "status"); found {
+		t.Fatal("expected the status of the source not to be copied")
+	}
+}
+
+func TestCloneMissingSource(t *testing.T) {
+	t.Parallel()
+
+	opts := &cloneOptions{documentDBName: "missing", name: "copy", namespace: defaultDocumentDBNamespace}
+	if err := opts.createClone(context.Background(), newFakeDynamicClient()); err == nil {
+		t.Fatal("expected error when the source DocumentDB does not exist")
+	}
+}
+
+func TestCloneExistingTarget(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(newDocument("sample", namespace, "", ""), newDocument("copy", namespace, "", ""))
+
+	opts := &cloneOptions{documentDBName: "sample", name: "copy", namespace: namespace}
+	if err := opts.createClone(context.Background(), client); err == nil {
+		t.Fatal("expected error when the target DocumentDB already exists")
+	}
+}
+
+func TestCloneOptionsComplete(t *testing.T) {
+	t.Parallel()
+
+	o := &cloneOptions{documentDBName: " sample ", name: " copy ", namespace: " "}
+	if err := o.complete(); err != nil {
+		t.Fatalf("complete returned error: %v", err)
+	}
+	if o.documentDBName != "sample" || o.name != "copy" || o.namespace != defaultDocumentDBNamespace {
+		t.Fatalf("unexpected options: documentdb=%q name=%q namespace=%q", o.documentDBName, o.name, o.namespace)
+	}
+
+	same := &cloneOptions{documentDBName: "sample", name: "sample"}
+	if err := same.complete(); err == nil {
+		t.Fatal("expected error when the clone has the name of its source")
+	}
+}
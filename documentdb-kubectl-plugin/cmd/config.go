@@ -3,3 +3,6 @@ package cmd
 const (
 	defaultDocumentDBNamespace = "documentdb-preview-ns"
 )
+
+// kubeconfigPath is set by the global --kubeconfig flag and consumed by loadConfig.
+var kubeconfigPath string
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+type demoteOptions struct {
+	documentDBName string
+	namespace      string
+	hubContext     string
+	targetCluster  string
+	targetContext  string
+	skipWait       bool
+	waitTimeout    time.Duration
+	pollInterval   time.Duration
+}
+
+func newDemoteCommand() *cobra.Command {
+	opts := &demoteOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "demote",
+		Short: "Step down the current primary cluster, reverting a previous promotion",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(); err != nil {
+				return err
+			}
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource to demote")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", defaultDocumentDBNamespace, "Namespace containing the DocumentDB resource")
+	cmd.Flags().StringVar(&opts.hubContext, "hub-context", opts.hubContext, "Kubeconfig context for the fleet hub (defaults to current context)")
+	cmd.Flags().StringVar(&opts.targetCluster, "target-cluster", opts.targetCluster, "Name of the cluster that should become primary (defaults to the primary before the last promotion)")
+	cmd.Flags().StringVar(&opts.targetContext, "cluster-context", opts.targetContext, "Kubeconfig context for verifying member status (defaults to current context)")
+	cmd.Flags().BoolVar(&opts.skipWait, "skip-wait", opts.skipWait, "Return immediately after submitting the demotion request")
+	cmd.Flags().DurationVar(&opts.waitTimeout, "wait-timeout", 10*time.Minute, "Maximum time to wait for the demotion to complete")
+	cmd.Flags().DurationVar(&opts.pollInterval, "poll-interval", 10*time.Second, "Polling interval while waiting for the demotion to complete")
+
+	_ = cmd.MarkFlagRequired("documentdb")
+
+	return cmd
+}
+
+func (o *demoteOptions) complete() error {
+	o.documentDBName = strings.TrimSpace(o.documentDBName)
+	if o.documentDBName == "" {
+		return errors.New("--documentdb is required")
+	}
+
+	o.namespace = strings.TrimSpace(o.namespace)
+	if o.namespace == "" {
+		o.namespace = defaultDocumentDBNamespace
+	}
+
+	o.hubContext = strings.TrimSpace(o.hubContext)
+	o.targetCluster = strings.TrimSpace(o.targetCluster)
+	o.targetContext = strings.TrimSpace(o.targetContext)
+
+	if o.waitTimeout <= 0 {
+		o.waitTimeout = 10 * time.Minute
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = 10 * time.Second
+	}
+
+	return nil
+}
+
+func (o *demoteOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	cmd.PrintErrln("Starting DocumentDB demotion workflow...")
+
+	hubConfig, hubContextName, err := loadConfigFunc(o.hubContext)
+	if err != nil {
+		return fmt.Errorf("failed to load hub kubeconfig: %w", err)
+	}
+	if o.targetContext == "" {
+		o.targetContext = hubContextName
+	}
+	if hubContextName == "" {
+		hubContextName = "(current)"
+	}
+
+	dynHub, err := dynamicClientForConfig(hubConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create hub dynamic client: %w", err)
+	}
+
+	promote, err := o.resolvePromotion(ctx, cmd, dynHub)
+	if err != nil {
+		return err
+	}
+
+	if err := promote.patchDocumentDB(ctx, dynHub); err != nil {
+		return err
+	}
+
+	if o.skipWait {
+		fmt.Fprintln(cmd.OutOrStdout(), "Demotion request submitted. Skipping wait as requested.")
+		return nil
+	}
+
+	if err := promote.waitForConvergence(ctx, cmd, dynHub, hubContextName); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Demotion completed successfully.")
+	return nil
+}
+
+// resolvePromotion determines which cluster should take over as primary and returns the equivalent promotion.
+func (o *demoteOptions) resolvePromotion(ctx context.Context, cmd *cobra.Command, dyn dynamic.Interface) (*promoteOptions, error) {
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+
+	document, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DocumentDB %q: %w", o.documentDBName, err)
+	}
+
+	currentPrimary, _, _ := unstructured.NestedString(document.Object, "spec", "clusterReplication", "primary")
+
+	target := o.targetCluster
+	if target == "" {
+		target = document.GetAnnotations()[previousPrimaryAnnotation]
+	}
+	if target == "" {
+		return nil, fmt.Errorf("no previous primary recorded on DocumentDB %q; specify --target-cluster", o.documentDBName)
+	}
+	if target == currentPrimary {
+		return nil, fmt.Errorf("cluster %q is already the primary of DocumentDB %q", target, o.documentDBName)
+	}
+
+	// The promotion token may not have been consumed yet, in which case reverting aborts the promotion
+	if !isDocumentReady(document, currentPrimary) {
+		cmd.PrintErrf("Promotion to %q has not completed yet; aborting it and reverting to %q\n", currentPrimary, target)
+	}
+
+	return &promoteOptions{
+		documentDBName: o.documentDBName,
+		namespace:      o.namespace,
+		hubContext:     o.hubContext,
+		targetCluster:  target,
+		targetContext:  o.targetContext,
+		skipWait:       o.skipWait,
+		waitTimeout:    o.waitTimeout,
+		pollInterval:   o.pollInterval,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDemoteRevertsPreviousPromotion(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "sample"
+	client := newFakeDynamicClient(withClusterList(newDocument(docName, namespace, "cluster-a", "Ready"), "cluster-a", "cluster-b"))
+
+	promote := &promoteOptions{documentDBName: docName, namespace: namespace, targetCluster: "cluster-b"}
+	if err := promote.patchDocumentDB(context.Background(), client); err != nil {
+		t.Fatalf("patchDocumentDB returned error: %v", err)
+	}
+	assertPrimary(t, client.Resource(documentDBGVR()).Namespace(namespace), docName, "cluster-b", "cluster-a")
+
+	// Simulate a promotion that the operator has not converged on yet
+	if err := setDocumentState(context.Background(), client, documentDBGVR(), namespace, docName, "cluster-b", "Switchover in progress"); err != nil {
+		t.Fatalf("failed to update document state: %v", err)
+	}
+
+	demote := &demoteOptions{documentDBName: docName, namespace: namespace}
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	reverted, err := demote.resolvePromotion(context.Background(), cmd, client)
+	if err != nil {
+		t.Fatalf("resolvePromotion returned error: %v", err)
+	}
+	if reverted.targetCluster != "cluster-a" {
+		t.Fatalf("expected demotion target cluster-a, got %q", reverted.targetCluster)
+	}
+	if !strings.Contains(stderr.String(), "has not completed yet") {
+		t.Fatalf("expected in-progress promotion warning, got %q", stderr.String())
+	}
+
+	if err := reverted.patchDocumentDB(context.Background(), client); err != nil {
+		t.Fatalf("patchDocumentDB returned error: %v", err)
+	}
+	assertPrimary(t, client.Resource(documentDBGVR()).Namespace(namespace), docName, "cluster-a", "cluster-b")
+}
+
+func TestDemoteExplicitTarget(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(withClusterList(newDocument("sample", namespace, "cluster-a", "Ready"), "cluster-a", "cluster-b", "cluster-c"))
+
+	demote := &demoteOptions{documentDBName: "sample", namespace: namespace, targetCluster: "cluster-c"}
+	cmd := &cobra.Command{}
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+
+	reverted, err := demote.resolvePromotion(context.Background(), cmd, client)
+	if err != nil {
+		t.Fatalf("resolvePromotion returned error: %v", err)
+	}
+	if reverted.targetCluster != "cluster-c" {
+		t.Fatalf("expected demotion target cluster-c, got %q", reverted.targetCluster)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("expected no warning for a converged promotion, got %q", stderr.String())
+	}
+}
+
+func TestDemoteRequiresTarget(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(withClusterList(newDocument("sample", namespace, "cluster-a", "Ready"), "cluster-a", "cluster-b", "cluster-c"))
+
+	testCases := []struct {
+		name string
+		opts demoteOptions
+	}{
+		{name: "no previous primary", opts: demoteOptions{documentDBName: "sample", namespace: namespace}},
+		{name: "target already primary", opts: demoteOptions{documentDBName: "sample", namespace: namespace, targetCluster: "cluster-a"}},
+	}
+
+	for _, tc := range testCases {
+		if _, err := tc.opts.resolvePromotion(context.Background(), &cobra.Command{}, client); err == nil {
+			t.Fatalf("expected error for case %q", tc.name)
+		}
+	}
+}
+
+func assertPrimary(t *testing.T, client interface {
+	Get(context.Context, string, metav1.GetOptions, ...string) (*unstructured.Unstructured, error)
+}, name, expectedPrimary, expectedPrevious string) {
+	t.Helper()
+
+	doc, err := client.Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch document: %v", err)
+	}
+	primary, _, _ := unstructured.NestedString(doc.Object, "spec", "clusterReplication", "primary")
+	if primary != expectedPrimary {
+		t.Fatalf("expected primary %q, got %q", expectedPrimary, primary)
+	}
+	if previous := doc.GetAnnotations()[previousPrimaryAnnotation]; previous != expectedPrevious {
+		t.Fatalf("expected previous primary annotation %q, got %q", expectedPrevious, previous)
+	}
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	certManagerGroupVersion = "cert-manager.io/v1"
+
+	defaultSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+var volumeSnapshotClassGVR = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotclasses"}
+
+type checkStatus string
+
+const (
+	checkOK      checkStatus = "OK"
+	checkWarning checkStatus = "WARN"
+	checkFailed  checkStatus = "FAIL"
+)
+
+type checkResult struct {
+	Name   string
+	Status checkStatus
+	Detail string
+}
+
+type doctorOptions struct {
+	kubeContext string
+}
+
+func newDoctorCommand() *cobra.Command {
+	opts := &doctorOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "doctor",
+		Aliases: []string{"check"},
+		Short:   "Check that the cluster is ready to run DocumentDB",
+		Long: `Check connectivity to the cluster and the presence of the DocumentDB CRD, cert-manager
+and a default VolumeSnapshotClass. Exits with an error when a required component is missing.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+
+	return cmd
+}
+
+func (o *doctorOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	config, _, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetesClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	results := runDoctorChecks(ctx, clientset.Discovery(), dynClient)
+	printCheckResults(cmd.OutOrStdout(), results)
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == checkFailed {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// runDoctorChecks runs the environment checks in order. The remaining checks are skipped when the
+// API server cannot be reached, as they would all fail for the same reason.
+func runDoctorChecks(ctx context.Context, discoveryClient discovery.DiscoveryInterface, dynClient dynamic.Interface) []checkResult {
+	serverVersion, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return []checkResult{{Name: "API server", Status: checkFailed, Detail: fmt.Sprintf("cannot reach the cluster: %v", err)}}
+	}
+
+	return []checkResult{
+		{Name: "API server", Status: checkOK, Detail: fmt.Sprintf("reachable, Kubernetes %s", serverVersion.GitVersion)},
+		checkAPIResource(discoveryClient, "DocumentDB CRD", documentDBGVRGroup+"/"+documentDBGVRVersion, documentDBGVRResource,
+			"install the documentdb-operator Helm chart"),
+		checkAPIResource(discoveryClient, "cert-manager", certManagerGroupVersion, "certificates",
+			"install cert-manager, which the operator uses to issue TLS certificates"),
+		checkDefaultVolumeSnapshotClass(ctx, discoveryClient, dynClient),
+	}
+}
+
+// checkAPIResource fails unless the cluster serves the resource in the given group version.
+func checkAPIResource(discoveryClient discovery.DiscoveryInterface, name, groupVersion, resource, remedy string) checkResult {
+	resources, err := discoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return checkResult{Name: name, Status: checkFailed, Detail: fmt.Sprintf("failed to discover %s: %v", groupVersion, err)}
+	}
+	if err == nil {
+		for _, r := range resources.APIResources {
+			if r.Name == resource {
+				return checkResult{Name: name, Status: checkOK, Detail: fmt.Sprintf("%s %s found", groupVersion, resource)}
+			}
+		}
+	}
+	return checkResult{Name: name, Status: checkFailed, Detail: fmt.Sprintf("%s %s not found; %s", groupVersion, resource, remedy)}
+}
+
+// checkDefaultVolumeSnapshotClass warns when no VolumeSnapshotClass is marked as default, since only backups need one.
+func checkDefaultVolumeSnapshotClass(ctx context.Context, discoveryClient discovery.DiscoveryInterface, dynClient dynamic.Interface) checkResult {
+	const name = "VolumeSnapshotClass"
+
+	check := checkAPIResource(discoveryClient, name, volumeSnapshotClassGVR.GroupVersion().String(), volumeSnapshotClassGVR.Resource,
+		"install the CSI snapshot CRDs and controller to take backups")
+	if check.Status != checkOK {
+		check.Status = checkWarning
+		return check
+	}
+
+	classes, err := dynClient.Resource(volumeSnapshotClassGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return checkResult{Name: name, Status: checkWarning, Detail: fmt.Sprintf("failed to list VolumeSnapshotClasses: %v", err)}
+	}
+
+	var defaults []string
+	for _, class := range classes.Items {
+		if class.GetAnnotations()[defaultSnapshotClassAnnotation] == "true" {
+			defaults = append(defaults, class.GetName())
+		}
+	}
+	switch len(defaults) {
+	case 0:
+		return checkResult{Name: name, Status: checkWarning,
+			Detail: fmt.Sprintf("no default VolumeSnapshotClass among %d class(es); backups need one unless spec.environment lets the operator create it", len(classes.Items))}
+	case 1:
+		return checkResult{Name: name, Status: checkOK, Detail: fmt.Sprintf("default is %s", defaults[0])}
+	default:
+		return checkResult{Name: name, Status: checkWarning, Detail: fmt.Sprintf("several default VolumeSnapshotClasses: %s", strings.Join(defaults, ", "))}
+	}
+}
+
+func printCheckResults(out io.Writer, results []checkResult) {
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "CHECK\tSTATUS\tDETAILS")
+	for _, result := range results {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", result.Name, result.Status, result.Detail)
+	}
+	_ = writer.Flush()
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func documentDBAPIResources() *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		GroupVersion: documentDBGVRGroup + "/" + documentDBGVRVersion,
+		APIResources: []metav1.APIResource{{Name: documentDBGVRResource, Kind: "DocumentDB", Namespaced: true}},
+	}
+}
+
+func certManagerAPIResources() *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		GroupVersion: certManagerGroupVersion,
+		APIResources: []metav1.APIResource{{Name: "certificates", Kind: "Certificate", Namespaced: true}},
+	}
+}
+
+func snapshotAPIResources() *metav1.APIResourceList {
+	return &metav1.APIResourceList{
+		GroupVersion: volumeSnapshotClassGVR.GroupVersion().String(),
+		APIResources: []metav1.APIResource{{Name: volumeSnapshotClassGVR.Resource, Kind: "VolumeSnapshotClass"}},
+	}
+}
+
+func newVolumeSnapshotClass(name string, isDefault bool) *unstructured.Unstructured {
+	class := &unstructured.Unstructured{}
+	class.SetAPIVersion(volumeSnapshotClassGVR.GroupVersion().String())
+	class.SetKind("VolumeSnapshotClass")
+	class.SetName(name)
+	if isDefault {
+		class.SetAnnotations(map[string]string{defaultSnapshotClassAnnotation: "true"})
+	}
+	return class
+}
+
+func newFakeDiscovery(resources ...*metav1.APIResourceList) discovery.DiscoveryInterface {
+	clientset := kubefake.NewSimpleClientset()
+	fakeDiscovery := clientset.Discovery().(*discoveryfake.FakeDiscovery)
+	fakeDiscovery.Resources = resources
+	return fakeDiscovery
+}
+
+func checkStatuses(results []checkResult) map[string]checkStatus {
+	statuses := make(map[string]checkStatus, len(results))
+	for _, result := range results {
+		statuses[result.Name] = result.Status
+	}
+	return statuses
+}
+
+func TestRunDoctorChecks(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		classes   []*unstructured.Unstructured
+		expected  map[string]checkStatus
+	}{
+		{
+			name:      "healthy cluster",
+			resources: []*metav1.APIResourceList{documentDBAPIResources(), certManagerAPIResources(), snapshotAPIResources()},
+			classes:   []*unstructured.Unstructured{newVolumeSnapshotClass("csi-snapclass", true), newVolumeSnapshotClass("other", false)},
+			expected: map[string]checkStatus{
+				"API server": checkOK, "DocumentDB CRD": checkOK, "cert-manager": checkOK, "VolumeSnapshotClass": checkOK,
+			},
+		},
+		{
+			name:      "operator and cert-manager missing",
+			resources: []*metav1.APIResourceList{snapshotAPIResources()},
+			classes:   []*unstructured.Unstructured{newVolumeSnapshotClass("csi-snapclass", true)},
+			expected: map[string]checkStatus{
+				"API server": checkOK, "DocumentDB CRD": checkFailed, "cert-manager": checkFailed, "VolumeSnapshotClass": checkOK,
+			},
+		},
+		{
+			name:      "snapshot CRDs missing",
+			resources: []*metav1.APIResourceList{documentDBAPIResources(), certManagerAPIResources()},
+			expected: map[string]checkStatus{
+				"API server": checkOK, "DocumentDB CRD": checkOK, "cert-manager": checkOK, "VolumeSnapshotClass": checkWarning,
+			},
+		},
+		{
+			name:      "no default snapshot class",
+			resources: []*metav1.APIResourceList{documentDBAPIResources(), certManagerAPIResources(), snapshotAPIResources()},
+			classes:   []*unstructured.Unstructured{newVolumeSnapshotClass("csi-snapclass", false)},
+			expected: map[string]checkStatus{
+				"API server": checkOK, "DocumentDB CRD": checkOK, "cert-manager": checkOK, "VolumeSnapshotClass": checkWarning,
+			},
+		},
+		{
+			name:      "several default snapshot classes",
+			resources: []*metav1.APIResourceList{documentDBAPIResources(), certManagerAPIResources(), snapshotAPIResources()},
+			classes:   []*unstructured.Unstructured{newVolumeSnapshotClass("a", true), newVolumeSnapshotClass("b", true)},
+			expected: map[string]checkStatus{
+				"API server": checkOK, "DocumentDB CRD": checkOK, "cert-manager": checkOK, "VolumeSnapshotClass": checkWarning,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := runDoctorChecks(context.Background(), newFakeDiscovery(tt.resources...), newFakeDynamicClient(tt.classes...))
+			got := checkStatuses(results)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %d checks, got %+v", len(tt.expected), results)
+			}
+			for name, status := range tt.expected {
+				if got[name] != status {
+					t.Errorf("expected %s to be %s, got %s (%+v)", name, status, got[name], results)
+				}
+			}
+		})
+	}
+}
+
+func TestRunDoctorChecksStopsWhenClusterUnreachable(t *testing.T) {
+	clientset := kubefake.NewSimpleClientset()
+	clientset.PrependReactor("get", "version", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	results := runDoctorChecks(context.Background(), clientset.Discovery(), newFakeDynamicClient())
+	if len(results) != 1 || results[0].Status != checkFailed {
+		t.Fatalf("expected a single failed connectivity check, got %+v", results)
+	}
+	if !strings.Contains(results[0].Detail, "connection refused") {
+		t.Errorf("expected the connection error in the details, got %q", results[0].Detail)
+	}
+}
+
+func TestDoctorRunFailsOnMissingComponents(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+		kubernetesClientForConfig = prevKube
+	}()
+
+	clientset := kubefake.NewSimpleClientset()
+	clientset.Discovery().(*discoveryfake.FakeDiscovery).Resources = []*metav1.APIResourceList{certManagerAPIResources()}
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "cluster"}, "cluster", nil
+	}
+	dynamicClientForConfig = func(*rest.Config) (dynamic.Interface, error) {
+		return newFakeDynamicClient(), nil
+	}
+	kubernetesClientForConfig = func(*rest.Config) (kubernetes.Interface, error) {
+		return clientset, nil
+	}
+
+	cmd := &cobra.Command{}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	err := (&doctorOptions{}).run(context.Background(), cmd)
+	if err == nil || !strings.Contains(err.Error(), "1 check(s) failed") {
+		t.Fatalf("expected one failed check, got %v", err)
+	}
+
+	output := stdout.String()
+	for _, expected := range []string{"CHECK", "DocumentDB CRD", "FAIL", "install the documentdb-operator Helm chart", "cert-manager", "VolumeSnapshotClass", "WARN"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
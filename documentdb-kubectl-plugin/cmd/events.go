@@ -4,21 +4,39 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	eventKindDocumentDB = "DocumentDB"
+	eventKindCluster    = "Cluster"
+	eventKindPod        = "Pod"
+
+	cnpgClusterLabel = "cnpg.io/cluster"
 )
 
 type eventsOptions struct {
-	documentDBName string
-	namespace      string
-	kubeContext    string
-	follow         bool
-	since          time.Duration
+	documentDBName  string
+	namespace       string
+	kubeContext     string
+	cnpgClusterName string
+	kinds           []string
+	follow          bool
+	since           time.Duration
+	watchRetryDelay time.Duration
 }
 
 func newEventsCommand() *cobra.Command {
@@ -26,7 +44,7 @@ func newEventsCommand() *cobra.Command {
 
 	cmd := &cobra.Command{
 		Use:   "events",
-		Short: "Stream events associated with a DocumentDB resource",
+		Short: "Stream events associated with a DocumentDB resource, its CNPG cluster and pods",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := opts.complete(); err != nil {
 				return err
@@ -38,6 +56,8 @@ func newEventsCommand() *cobra.Command {
 	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource to inspect")
 	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
 	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().StringVar(&opts.cnpgClusterName, "cnpg-cluster", opts.cnpgClusterName, "Name of the CNPG Cluster backing the DocumentDB (defaults to the DocumentDB name; use the member cluster name for replicated deployments)")
+	cmd.Flags().StringSliceVar(&opts.kinds, "kind", opts.kinds, "Only show events for these involved object kinds (DocumentDB, Cluster, Pod); defaults to all")
 	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", true, "Stream events until interrupted")
 	cmd.Flags().DurationVar(&opts.since, "since", 0, "Only show events newer than this duration (e.g. 1h); 0 shows all available")
 
@@ -55,9 +75,38 @@ func (o *eventsOptions) complete() error {
 	if o.namespace == "" {
 		o.namespace = defaultDocumentDBNamespace
 	}
+	o.cnpgClusterName = strings.TrimSpace(o.cnpgClusterName)
+	if o.cnpgClusterName == "" {
+		o.cnpgClusterName = o.documentDBName
+	}
+
+	kinds := make([]string, 0, len(o.kinds))
+	for _, kind := range o.kinds {
+		switch strings.ToLower(strings.TrimSpace(kind)) {
+		case "":
+			continue
+		case "documentdb":
+			kinds = append(kinds, eventKindDocumentDB)
+		case "cluster":
+			kinds = append(kinds, eventKindCluster)
+		case "pod":
+			kinds = append(kinds, eventKindPod)
+		default:
+			return fmt.Errorf("unsupported --kind %q; expected one of %s, %s, %s", kind, eventKindDocumentDB, eventKindCluster, eventKindPod)
+		}
+	}
+	o.kinds = kinds
 	return nil
 }
 
+// eventKinds returns the involved object kinds to report; all supported kinds when --kind is not set.
+func (o *eventsOptions) eventKinds() []string {
+	if len(o.kinds) == 0 {
+		return []string{eventKindDocumentDB, eventKindCluster, eventKindPod}
+	}
+	return o.kinds
+}
+
 func (o *eventsOptions) run(ctx context.Context, cmd *cobra.Command) error {
 	config, contextName, err := loadConfigFunc(o.kubeContext)
 	if err != nil {
@@ -74,32 +123,29 @@ func (o *eventsOptions) run(ctx context.Context, cmd *cobra.Command) error {
 
 	fmt.Fprintf(cmd.OutOrStdout(), "Watching events for DocumentDB %s/%s (context %s)\n", o.namespace, o.documentDBName, contextName)
 
-	listOptions := metav1.ListOptions{
-		FieldSelector: fields.AndSelectors(
-			fields.OneTermEqualSelector("involvedObject.kind", "DocumentDB"),
-			fields.OneTermEqualSelector("involvedObject.name", o.documentDBName),
-		).String(),
+	involved, err := o.involvedObjects(ctx, clientset)
+	if err != nil {
+		return err
 	}
 
 	evtClient := clientset.CoreV1().Events(o.namespace)
-	evtList, err := evtClient.List(ctx, listOptions)
-	if err != nil {
-		return fmt.Errorf("failed to list events: %w", err)
-	}
 
 	filterSince := time.Time{}
 	if o.since > 0 {
 		filterSince = time.Now().Add(-o.since)
 	}
 
-	printedEvents := 0
-	for idx := range evtList.Items {
-		if !eventAfter(&evtList.Items[idx], filterSince) {
-			continue
-		}
-		printEvent(cmd.OutOrStdout(), &evtList.Items[idx])
-		printedEvents++
+	events, resourceVersion, err := o.listEvents(ctx, evtClient, involved, filterSince)
+	if err != nil {
+		return err
+	}
+
+	seen := map[types.UID]string{}
+	for idx := range events {
+		printEvent(cmd.OutOrStdout(), &events[idx])
+		seen[events[idx].UID] = events[idx].ResourceVersion
 	}
+	printedEvents := len(events)
 
 	if !o.follow {
 		if printedEvents == 0 {
@@ -112,31 +158,164 @@ func (o *eventsOptions) run(ctx context.Context, cmd *cobra.Command) error {
 		fmt.Fprintln(cmd.OutOrStdout(), "No events found yet; watching for new events...")
 	}
 
-	listOptions.ResourceVersion = evtList.ResourceVersion
-	watcher, err := evtClient.Watch(ctx, listOptions)
-	if err != nil {
-		return fmt.Errorf("failed to watch events: %w", err)
+	return o.watchEvents(ctx, cmd, clientset, resourceVersion, involved, filterSince, seen)
+}
+
+// listEvents lists the events of every selected kind that belong to the involved objects, sorted by timestamp.
+// It also returns the most recent list resource version to start a watch from.
+func (o *eventsOptions) listEvents(ctx context.Context, evtClient typedcorev1.EventInterface, involved involvedObjectSet, filterSince time.Time) ([]corev1.Event, string, error) {
+	var events []corev1.Event
+	resourceVersion := ""
+	for _, kind := range o.eventKinds() {
+		evtList, err := evtClient.List(ctx, metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("involvedObject.kind", kind).String()})
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to list %s events: %w", kind, err)
+		}
+		for idx := range evtList.Items {
+			if involved.matches(&evtList.Items[idx]) && eventAfter(&evtList.Items[idx], filterSince) {
+				events = append(events, evtList.Items[idx])
+			}
+		}
+		resourceVersion = evtList.ResourceVersion
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return mostRecentEventTime(&events[i]).Before(mostRecentEventTime(&events[j]))
+	})
+
+	return events, resourceVersion, nil
+}
+
+// watchEvents streams new events until the context is cancelled. The watch is re-established when the server closes
+// it, and the events are re-listed when the resource version has expired. Events already printed are skipped.
+func (o *eventsOptions) watchEvents(ctx context.Context, cmd *cobra.Command, clientset kubernetes.Interface, resourceVersion string, involved involvedObjectSet, filterSince time.Time, seen map[types.UID]string) error {
+	evtClient := clientset.CoreV1().Events(o.namespace)
+
+	retryDelay := o.watchRetryDelay
+	if retryDelay <= 0 {
+		retryDelay = time.Second
+	}
+
+	printIfNew := func(evt *corev1.Event) {
+		if !involved.matches(evt) || !eventAfter(evt, filterSince) {
+			return
+		}
+		if rv, ok := seen[evt.UID]; ok && rv == evt.ResourceVersion {
+			return
+		}
+		seen[evt.UID] = evt.ResourceVersion
+		printEvent(cmd.OutOrStdout(), evt)
 	}
-	defer watcher.Stop()
 
+	relist := false
 	for {
+		if relist {
+			var err error
+			if involved, err = o.involvedObjects(ctx, clientset); err != nil {
+				return err
+			}
+			events, listResourceVersion, err := o.listEvents(ctx, evtClient, involved, filterSince)
+			if err != nil {
+				return err
+			}
+			for idx := range events {
+				printIfNew(&events[idx])
+			}
+			resourceVersion = listResourceVersion
+			relist = false
+		}
+
+		listOptions := metav1.ListOptions{ResourceVersion: resourceVersion, AllowWatchBookmarks: true}
+		if kinds := o.eventKinds(); len(kinds) == 1 {
+			listOptions.FieldSelector = fields.OneTermEqualSelector("involvedObject.kind", kinds[0]).String()
+		}
+		watcher, err := evtClient.Watch(ctx, listOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to watch events: %w", err)
+		}
+
+		expired := false
+	watchLoop:
+		for {
+			select {
+			case <-ctx.Done():
+				watcher.Stop()
+				return nil
+			case evt, ok := <-watcher.ResultChan():
+				if !ok {
+					break watchLoop
+				}
+				switch evt.Type {
+				case watch.Error:
+					statusErr := apierrors.FromObject(evt.Object)
+					if apierrors.IsResourceExpired(statusErr) || apierrors.IsGone(statusErr) {
+						expired = true
+					} else {
+						cmd.PrintErrf("Event watch error: %v\n", statusErr)
+					}
+					break watchLoop
+				case watch.Bookmark:
+					if obj, err := meta.Accessor(evt.Object); err == nil {
+						resourceVersion = obj.GetResourceVersion()
+					}
+					continue
+				}
+				k8sEvent, ok := evt.Object.(*corev1.Event)
+				if !ok {
+					continue
+				}
+				resourceVersion = k8sEvent.ResourceVersion
+				printIfNew(k8sEvent)
+			}
+		}
+		watcher.Stop()
+
+		if expired {
+			relist = true
+		}
+
 		select {
 		case <-ctx.Done():
 			return nil
-		case evt, ok := <-watcher.ResultChan():
-			if !ok {
-				return io.EOF
-			}
-			k8sEvent, ok := evt.Object.(*corev1.Event)
-			if !ok {
-				continue
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// involvedObjectSet holds the names of the objects, per kind, whose events are reported.
+type involvedObjectSet map[string]map[string]bool
+
+func (s involvedObjectSet) matches(evt *corev1.Event) bool {
+	return s[evt.InvolvedObject.Kind][evt.InvolvedObject.Name]
+}
+
+// involvedObjects resolves the DocumentDB, its CNPG Cluster and the cluster's pods, limited to the selected kinds.
+func (o *eventsOptions) involvedObjects(ctx context.Context, clientset kubernetes.Interface) (involvedObjectSet, error) {
+	involved := involvedObjectSet{}
+	for _, kind := range o.eventKinds() {
+		switch kind {
+		case eventKindDocumentDB:
+			involved[kind] = map[string]bool{o.documentDBName: true}
+		case eventKindCluster:
+			involved[kind] = map[string]bool{o.cnpgClusterName: true}
+		case eventKindPod:
+			pods, err := clientset.CoreV1().Pods(o.namespace).List(ctx, metav1.ListOptions{
+				LabelSelector: fmt.Sprintf("%s=%s", cnpgClusterLabel, o.cnpgClusterName),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list pods for CNPG Cluster %q: %w", o.cnpgClusterName, err)
 			}
-			if !eventAfter(k8sEvent, filterSince) {
-				continue
+			names := map[string]bool{}
+			for _, pod := range pods.Items {
+				names[pod.Name] = true
 			}
-			printEvent(cmd.OutOrStdout(), k8sEvent)
+			involved[kind] = names
 		}
 	}
+	return involved, nil
 }
 
 func eventAfter(evt *corev1.Event, threshold time.Time) bool {
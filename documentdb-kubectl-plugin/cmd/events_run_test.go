@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -90,3 +91,110 @@ func TestEventsRunPrintsExistingEvents(t *testing.T) {
 		}
 	}
 }
+
+func TestEventsRunMergesClusterAndPodEvents(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "documentdb-sample"
+	base := time.Now().Add(-time.Hour)
+
+	newEvent := func(name, kind, involvedName, message string, offset time.Duration) *corev1.Event {
+		evt := &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: involvedName, Namespace: namespace},
+			Message:        message,
+			Type:           corev1.EventTypeNormal,
+		}
+		evt.LastTimestamp = metav1.NewTime(base.Add(offset))
+		return evt
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      docName + "-1",
+		Namespace: namespace,
+		Labels:    map[string]string{cnpgClusterLabel: docName},
+	}}
+
+	objects := []runtime.Object{
+		pod,
+		newEvent("ddb-event", eventKindDocumentDB, docName, "third: document reconciled", 3*time.Minute),
+		newEvent("cluster-event", eventKindCluster, docName, "first: cluster created", time.Minute),
+		newEvent("pod-event", eventKindPod, docName+"-1", "second: pod scheduled", 2*time.Minute),
+		newEvent("other-pod-event", eventKindPod, "unrelated-pod", "unrelated pod event", 2*time.Minute),
+	}
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "events"}, "events-context", nil
+	}
+	kubernetesClientForConfig = func(cfg *rest.Config) (kubernetes.Interface, error) {
+		return kubefake.NewSimpleClientset(objects...), nil
+	}
+
+	testCases := []struct {
+		name     string
+		kinds    []string
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "all kinds ordered by time",
+			expected: []string{"first: cluster created", "second: pod scheduled", "third: document reconciled"},
+			absent:   []string{"unrelated pod event"},
+		},
+		{
+			name:     "pod kind only",
+			kinds:    []string{"pod"},
+			expected: []string{"second: pod scheduled"},
+			absent:   []string{"first: cluster created", "third: document reconciled", "unrelated pod event"},
+		},
+	}
+
+	for _, tc := range testCases {
+		opts := &eventsOptions{documentDBName: docName, namespace: namespace, kinds: tc.kinds}
+		if err := opts.complete(); err != nil {
+			t.Fatalf("case %q: complete returned error: %v", tc.name, err)
+		}
+		opts.follow = false
+
+		cmd := &cobra.Command{}
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+
+		if err := opts.run(context.Background(), cmd); err != nil {
+			t.Fatalf("case %q: run returned error: %v", tc.name, err)
+		}
+
+		output := stdout.String()
+		lastIndex := -1
+		for _, expected := range tc.expected {
+			idx := strings.Index(output, expected)
+			if idx < 0 {
+				t.Fatalf("case %q: expected output to contain %q, got: %s", tc.name, expected, output)
+			}
+			if idx < lastIndex {
+				t.Fatalf("case %q: expected %q to be printed in timestamp order, got: %s", tc.name, expected, output)
+			}
+			lastIndex = idx
+		}
+		for _, absent := range tc.absent {
+			if strings.Contains(output, absent) {
+				t.Fatalf("case %q: expected output not to contain %q, got: %s", tc.name, absent, output)
+			}
+		}
+	}
+}
+
+func TestEventsOptionsCompleteRejectsUnknownKind(t *testing.T) {
+	t.Parallel()
+
+	o := &eventsOptions{documentDBName: "sample", kinds: []string{"Service"}}
+	if err := o.complete(); err == nil {
+		t.Fatal("expected error for unsupported kind")
+	}
+}
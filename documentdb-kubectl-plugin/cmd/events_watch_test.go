@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// syncBuffer guards a bytes.Buffer so the test can read output while the watch loop writes it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestEventsRunFollowReestablishesWatch(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "documentdb-sample"
+
+	newEvent := func(uid, message string) *corev1.Event {
+		evt := &corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            uid,
+				Namespace:       namespace,
+				UID:             types.UID(uid),
+				ResourceVersion: uid,
+			},
+			InvolvedObject: corev1.ObjectReference{Kind: eventKindDocumentDB, Name: docName, Namespace: namespace},
+			Message:        message,
+			Type:           corev1.EventTypeNormal,
+		}
+		evt.LastTimestamp = metav1.NewTime(time.Now())
+		return evt
+	}
+
+	kubeClient := kubefake.NewSimpleClientset()
+	watchers := make(chan *watch.FakeWatcher, 3)
+	kubeClient.PrependWatchReactor("events", func(k8stesting.Action) (bool, watch.Interface, error) {
+		w := watch.NewFake()
+		watchers <- w
+		return true, w, nil
+	})
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "events"}, "events-context", nil
+	}
+	kubernetesClientForConfig = func(*rest.Config) (kubernetes.Interface, error) {
+		return kubeClient, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cmd := &cobra.Command{}
+	stdout := &syncBuffer{}
+	cmd.SetOut(stdout)
+	cmd.SetErr(&bytes.Buffer{})
+
+	opts := &eventsOptions{
+		documentDBName:  docName,
+		namespace:       namespace,
+		kinds:           []string{eventKindDocumentDB},
+		follow:          true,
+		watchRetryDelay: time.Millisecond,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- opts.run(ctx, cmd)
+	}()
+
+	waitForOutput := func(expected string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !strings.Contains(stdout.String(), expected) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q, got: %s", expected, stdout.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// First watch delivers an event, then the server closes it
+	first := <-watchers
+	first.Add(newEvent("event-a", "first watch event"))
+	waitForOutput("first watch event")
+	first.Stop()
+
+	// Second watch reports an expired resource version, forcing a re-list
+	second := <-watchers
+	if _, err := kubeClient.CoreV1().Events(namespace).Create(ctx, newEvent("event-b", "relisted event"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to create event: %v", err)
+	}
+	expiredStatus := apierrors.NewResourceExpired("too old resource version").Status()
+	second.Error(&expiredStatus)
+	waitForOutput("relisted event")
+
+	// Third watch keeps streaming; duplicates are suppressed
+	third := <-watchers
+	third.Add(newEvent("event-a", "first watch event"))
+	third.Add(newEvent("event-c", "third watch event"))
+	waitForOutput("third watch event")
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	if count := strings.Count(stdout.String(), "first watch event"); count != 1 {
+		t.Fatalf("expected first event to be printed once, got %d times: %s", count, stdout.String())
+	}
+}
+
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"sort"
 	"sync"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -47,7 +50,7 @@ func newFakeDynamicClient(objs ...*unstructured.Unstructured) dynamic.Interface
 		if obj == nil {
 			continue
 		}
-		key := namespacedName(obj.GetNamespace(), obj.GetName())
+		key := objectKey(obj.GroupVersionKind().Group, obj.GetNamespace(), obj.GetName())
 		c.objects[key] = obj.DeepCopy()
 	}
 	return c
@@ -90,8 +93,9 @@ func (r *fakeNamespaceableResource) Get(context.Context, string, metav1.GetOptio
 	return nil, fmt.Errorf("not implemented")
 }
 
-func (r *fakeNamespaceableResource) List(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error) {
-	return nil, fmt.Errorf("not implemented")
+func (r *fakeNamespaceableResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	// Cluster-scoped objects are stored without a namespace
+	return r.Namespace("").List(ctx, opts)
 }
 
 func (r *fakeNamespaceableResource) Watch(context.Context, metav1.ListOptions) (watch.Interface, error) {
@@ -133,7 +137,25 @@ type fakeResource struct {
 }
 
 func (r *fakeResource) Create(ctx context.Context, obj *unstructured.Unstructured, opts metav1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
-	return nil, fmt.Errorf("not implemented")
+	if len(subresources) != 0 {
+		return nil, fmt.Errorf("not implemented")
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("nil object")
+	}
+	name := obj.GetName()
+	if name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	key := objectKey(r.gvr.Group, r.namespace, name)
+
+	r.client.mu.Lock()
+	defer r.client.mu.Unlock()
+	if _, exists := r.client.objects[key]; exists {
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Group: r.gvr.Group, Resource: r.gvr.Resource}, name)
+	}
+	r.client.objects[key] = obj.DeepCopy()
+	return obj.DeepCopy(), nil
 }
 
 func (r *fakeResource) Update(ctx context.Context, obj *unstructured.Unstructured, opts metav1.UpdateOptions, subresources ...string) (*unstructured.Unstructured, error) {
@@ -147,7 +169,7 @@ func (r *fakeResource) Update(ctx context.Context, obj *unstructured.Unstructure
 	if name == "" {
 		return nil, fmt.Errorf("missing name")
 	}
-	key := namespacedName(r.namespace, name)
+	key := objectKey(r.gvr.Group, r.namespace, name)
 
 	r.client.mu.Lock()
 	defer r.client.mu.Unlock()
@@ -171,7 +193,7 @@ func (r *fakeResource) Get(ctx context.Context, name string, opts metav1.GetOpti
 	if len(subresources) != 0 {
 		return nil, fmt.Errorf("not implemented")
 	}
-	key := namespacedName(r.namespace, name)
+	key := objectKey(r.gvr.Group, r.namespace, name)
 
 	r.client.mu.RLock()
 	defer r.client.mu.RUnlock()
@@ -183,7 +205,32 @@ func (r *fakeResource) Get(ctx context.Context, name string, opts metav1.GetOpti
 }
 
 func (r *fakeResource) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
-	return nil, fmt.Errorf("not implemented")
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parse label selector: %w", err)
+	}
+
+	r.client.mu.RLock()
+	defer r.client.mu.RUnlock()
+
+	keys := make([]string, 0, len(r.client.objects))
+	for key := range r.client.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	list := &unstructured.UnstructuredList{}
+	for _, key := range keys {
+		obj := r.client.objects[key]
+		if obj.GetNamespace() != r.namespace || obj.GroupVersionKind().Group != r.gvr.Group {
+			continue
+		}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		list.Items = append(list.Items, *obj.DeepCopy())
+	}
+	return list, nil
 }
 
 func (r *fakeResource) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
@@ -191,7 +238,8 @@ func (r *fakeResource) Watch(ctx context.Context, opts metav1.ListOptions) (watc
 }
 
 func (r *fakeResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
-	if len(subresources) != 0 {
+	// The status subresource is merged like the object itself, as the fake keeps a single copy of each object
+	if len(subresources) != 0 && !slices.Equal(subresources, []string{"status"}) {
 		return nil, fmt.Errorf("not implemented")
 	}
 	if pt != types.MergePatchType {
@@ -203,7 +251,7 @@ func (r *fakeResource) Patch(ctx context.Context, name string, pt types.PatchTyp
 		return nil, fmt.Errorf("unmarshal patch: %w", err)
 	}
 
-	key := namespacedName(r.namespace, name)
+	key := objectKey(r.gvr.Group, r.namespace, name)
 
 	r.client.mu.Lock()
 	defer r.client.mu.Unlock()
@@ -241,8 +289,10 @@ func (r *fakeResource) PatchSubresource(context.Context, string, string, types.P
 	return nil, fmt.Errorf("not implemented")
 }
 
-func namespacedName(namespace, name string) string {
-	return namespace + "/" + name
+// objectKey identifies an object by API group as well as namespace and name, so that e.g. a DocumentDB and its
+// CNPG Cluster of the same name can coexist.
+func objectKey(group, namespace, name string) string {
+	return group + "/" + namespace + "/" + name
 }
 
 func mergeMaps(dst map[string]any, patch map[string]any) {
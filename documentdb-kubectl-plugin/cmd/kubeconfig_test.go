@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigFromPathsMergesContexts(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	hubPath := writeTestKubeconfig(t, dir, "hub.yaml", "hub", "https://hub.example.com")
+	memberPath := writeTestKubeconfig(t, dir, "member.yaml", "member", "https://member.example.com")
+
+	paths := kubeconfigPrecedence(hubPath+","+memberPath, "")
+
+	cfg, contextName, err := loadConfigFromPaths(paths, "")
+	if err != nil {
+		t.Fatalf("loadConfigFromPaths returned error: %v", err)
+	}
+	if contextName != "hub" || cfg.Host != "https://hub.example.com" {
+		t.Fatalf("expected current context hub from first file, got %q (%s)", contextName, cfg.Host)
+	}
+
+	cfg, contextName, err = loadConfigFromPaths(paths, "member")
+	if err != nil {
+		t.Fatalf("loadConfigFromPaths returned error: %v", err)
+	}
+	if contextName != "member" || cfg.Host != "https://member.example.com" {
+		t.Fatalf("expected member context from second file, got %q (%s)", contextName, cfg.Host)
+	}
+
+	if _, _, err := loadConfigFromPaths(paths, "missing"); err == nil {
+		t.Fatal("expected error for unknown context")
+	}
+}
+
+func TestKubeconfigPrecedence(t *testing.T) {
+	t.Parallel()
+
+	list := string(os.PathListSeparator)
+	testCases := []struct {
+		name     string
+		flag     string
+		env      string
+		expected []string
+	}{
+		{name: "empty", expected: []string{}},
+		{name: "env path list", env: "/a" + list + "/b", expected: []string{"/a", "/b"}},
+		{name: "env comma separated", env: "/a, /b", expected: []string{"/a", "/b"}},
+		{name: "flag overrides env", flag: "/flag", env: "/a", expected: []string{"/flag"}},
+	}
+
+	for _, tc := range testCases {
+		if got := kubeconfigPrecedence(tc.flag, tc.env); !reflect.DeepEqual(got, tc.expected) {
+			t.Fatalf("case %q: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func writeTestKubeconfig(t *testing.T, dir, fileName, contextName, server string) string {
+	t.Helper()
+
+	content := `apiVersion: v1
+kind: Config
+current-context: ` + contextName + `
+clusters:
+- name: ` + contextName + `
+  cluster:
+    server: ` + server + `
+contexts:
+- name: ` + contextName + `
+  context:
+    cluster: ` + contextName + `
+    user: ` + contextName + `
+users:
+- name: ` + contextName + `
+  user:
+    token: test-token
+`
+	path := filepath.Join(dir, fileName)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	return path
+}
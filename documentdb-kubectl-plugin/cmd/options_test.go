@@ -29,6 +29,28 @@ func TestStatusOptionsCompleteRequiresDocument(t *testing.T) {
 	}
 }
 
+func TestStatusOptionsCompleteSelectionModes(t *testing.T) {
+	t.Parallel()
+
+	if err := (&statusOptions{all: true}).complete(); err != nil {
+		t.Fatalf("expected --all without --documentdb to be valid, got %v", err)
+	}
+	if err := (&statusOptions{selector: " team=a "}).complete(); err != nil {
+		t.Fatalf("expected --selector without --documentdb to be valid, got %v", err)
+	}
+
+	invalid := []statusOptions{
+		{all: true, selector: "team=a"},
+		{documentDBName: "sample", all: true},
+		{documentDBName: "sample", selector: "team=a"},
+	}
+	for _, o := range invalid {
+		if err := o.complete(); err == nil {
+			t.Fatalf("expected error for options %+v", o)
+		}
+	}
+}
+
 func TestEventsOptionsCompleteDefaults(t *testing.T) {
 	t.Parallel()
 
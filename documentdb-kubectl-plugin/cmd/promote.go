@@ -5,12 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
@@ -22,6 +25,9 @@ const (
 	documentDBGVRGroup    = "documentdb.io"
 	documentDBGVRVersion  = "preview"
 	documentDBGVRResource = "dbs"
+
+	// previousPrimaryAnnotation records the primary cluster that was replaced by the last promotion
+	previousPrimaryAnnotation = "documentdb.io/previous-primary"
 )
 
 type promoteOptions struct {
@@ -122,6 +128,16 @@ func (o *promoteOptions) run(ctx context.Context, cmd *cobra.Command) error {
 		return nil
 	}
 
+	if err := o.waitForConvergence(ctx, cmd, dynHub, hubContextName); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Promotion completed successfully.")
+	return nil
+}
+
+// waitForConvergence loads the target context and blocks until both the hub and target report the new primary.
+func (o *promoteOptions) waitForConvergence(ctx context.Context, cmd *cobra.Command, dynHub dynamic.Interface, hubContextName string) error {
 	targetConfig, targetContextName, err := loadConfigFunc(o.targetContext)
 	if err != nil {
 		return fmt.Errorf("failed to load target kubeconfig: %w", err)
@@ -137,17 +153,27 @@ func (o *promoteOptions) run(ctx context.Context, cmd *cobra.Command) error {
 
 	fmt.Fprintf(cmd.OutOrStdout(), "Waiting for DocumentDB replication to converge (hub context %q, target context %q)...\n", hubContextName, targetContextName)
 
-	if err := o.waitForPromotion(ctx, dynHub, dynTarget); err != nil {
-		return err
-	}
-
-	fmt.Fprintln(cmd.OutOrStdout(), "Promotion completed successfully.")
-	return nil
+	return o.waitForPromotion(ctx, dynHub, dynTarget)
 }
 
 func (o *promoteOptions) patchDocumentDB(ctx context.Context, dyn dynamic.Interface) error {
 	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
 
+	document, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get DocumentDB %q: %w", o.documentDBName, err)
+	}
+	currentPrimary, _, _ := unstructured.NestedString(document.Object, "spec", "clusterReplication", "primary")
+
+	members, err := clusterListMembers(document)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(members, o.targetCluster) {
+		return fmt.Errorf("target cluster %q is not a member of DocumentDB %q spec.clusterReplication.clusterList (valid members: %s)",
+			o.targetCluster, o.documentDBName, strings.Join(members, ", "))
+	}
+
 	patch := map[string]any{
 		"spec": map[string]any{
 			"clusterReplication": map[string]any{
@@ -155,6 +181,14 @@ func (o *promoteOptions) patchDocumentDB(ctx context.Context, dyn dynamic.Interf
 			},
 		},
 	}
+	// Remember the outgoing primary so that demote can revert to it
+	if currentPrimary != "" && currentPrimary != o.targetCluster {
+		patch["metadata"] = map[string]any{
+			"annotations": map[string]any{
+				previousPrimaryAnnotation: currentPrimary,
+			},
+		}
+	}
 
 	patchBytes, err := json.Marshal(patch)
 	if err != nil {
@@ -169,6 +203,29 @@ func (o *promoteOptions) patchDocumentDB(ctx context.Context, dyn dynamic.Interf
 	return nil
 }
 
+// clusterListMembers returns the cluster names listed in spec.clusterReplication.clusterList.
+func clusterListMembers(document *unstructured.Unstructured) ([]string, error) {
+	clusterListRaw, found, err := unstructured.NestedSlice(document.Object, "spec", "clusterReplication", "clusterList")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec.clusterReplication.clusterList: %w", err)
+	}
+	if !found || len(clusterListRaw) == 0 {
+		return nil, errors.New("DocumentDB spec.clusterReplication.clusterList is empty")
+	}
+
+	members := make([]string, 0, len(clusterListRaw))
+	for _, clusterObj := range clusterListRaw {
+		cluster, ok := clusterObj.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, ok := cluster["name"].(string); ok && name != "" {
+			members = append(members, name)
+		}
+	}
+	return members, nil
+}
+
 func (o *promoteOptions) waitForPromotion(ctx context.Context, dynHub, dynTarget dynamic.Interface) error {
 	ctx, cancel := context.WithTimeout(ctx, o.waitTimeout)
 	defer cancel()
@@ -210,7 +267,31 @@ func (o *promoteOptions) waitForPromotion(ctx context.Context, dynHub, dynTarget
 }
 
 func loadConfig(contextName string) (*rest.Config, string, error) {
+	return loadConfigFromPaths(kubeconfigPrecedence(kubeconfigPath, os.Getenv(clientcmd.RecommendedConfigPathEnvVar)), contextName)
+}
+
+// kubeconfigPrecedence returns the kubeconfig files to merge, in precedence order. The --kubeconfig flag wins over
+// the KUBECONFIG environment variable; both accept comma or path-list separated values.
+func kubeconfigPrecedence(flagValue, envValue string) []string {
+	value := flagValue
+	if strings.TrimSpace(value) == "" {
+		value = envValue
+	}
+
+	paths := []string{}
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == os.PathListSeparator }) {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			paths = append(paths, entry)
+		}
+	}
+	return paths
+}
+
+func loadConfigFromPaths(paths []string, contextName string) (*rest.Config, string, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if len(paths) > 0 {
+		loadingRules.Precedence = paths
+	}
 	overrides := &clientcmd.ConfigOverrides{}
 	if contextName != "" {
 		overrides.CurrentContext = contextName
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -67,7 +68,7 @@ func TestPatchDocumentDB(t *testing.T) {
 	namespace := defaultDocumentDBNamespace
 	docName := "sample"
 
-	doc := newDocument(docName, namespace, "cluster-a", "Ready")
+	doc := withClusterList(newDocument(docName, namespace, "cluster-a", "Ready"), "cluster-a", "cluster-b")
 
 	client := newFakeDynamicClient(doc.DeepCopy())
 
@@ -95,6 +96,48 @@ func TestPatchDocumentDB(t *testing.T) {
 	}
 }
 
+func TestPatchDocumentDBRejectsUnknownTarget(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "sample"
+
+	doc := withClusterList(newDocument(docName, namespace, "cluster-a", "Ready"), "cluster-a", "cluster-b")
+	client := newFakeDynamicClient(doc.DeepCopy())
+
+	opts := &promoteOptions{
+		documentDBName: docName,
+		namespace:      namespace,
+		targetCluster:  "cluster-typo",
+	}
+
+	err := opts.patchDocumentDB(context.Background(), client)
+	if err == nil {
+		t.Fatal("expected error for target cluster outside clusterList")
+	}
+	if !strings.Contains(err.Error(), "valid members: cluster-a, cluster-b") {
+		t.Fatalf("expected error to list valid members, got %v", err)
+	}
+
+	unchanged, err := client.Resource(documentDBGVR()).Namespace(namespace).Get(context.Background(), docName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch document: %v", err)
+	}
+	primary, _, _ := unstructured.NestedString(unchanged.Object, "spec", "clusterReplication", "primary")
+	if primary != "cluster-a" {
+		t.Fatalf("expected primary to remain cluster-a, got %q", primary)
+	}
+}
+
+func withClusterList(doc *unstructured.Unstructured, clusters ...string) *unstructured.Unstructured {
+	clusterList := make([]interface{}, 0, len(clusters))
+	for _, cluster := range clusters {
+		clusterList = append(clusterList, map[string]interface{}{"name": cluster})
+	}
+	_ = unstructured.SetNestedSlice(doc.Object, clusterList, "spec", "clusterReplication", "clusterList")
+	return doc
+}
+
 func setDocumentState(ctx context.Context, client dynamic.Interface, gvr schema.GroupVersionResource, namespace, name, primary, phase string) error {
 	for {
 		obj, err := client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
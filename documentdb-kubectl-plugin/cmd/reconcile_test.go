@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcilePatchDocumentDBSetsAnnotation(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	doc := newDocument("sample", namespace, "", "")
+	doc.SetAnnotations(map[string]string{reconcileTriggerAnnotation: "2024-01-01T00:00:00Z", "keep": "me"})
+	client := newFakeDynamicClient(doc)
+
+	opts := &reconcileOptions{documentDBName: "sample", namespace: namespace}
+
+	for _, now := range []time.Time{
+		time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		time.Date(2025, 1, 2, 3, 4, 6, 500, time.UTC),
+	} {
+		if err := opts.patchDocumentDB(context.Background(), client, now); err != nil {
+			t.Fatalf("patchDocumentDB returned error: %v", err)
+		}
+
+		patched, err := client.Resource(documentDBGVR()).Namespace(namespace).Get(context.Background(), "sample", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to fetch patched DocumentDB: %v", err)
+		}
+		annotations := patched.GetAnnotations()
+		if got := annotations[reconcileTriggerAnnotation]; got != now.Format(time.RFC3339Nano) {
+			t.Fatalf("expected reconcile trigger annotation %q, got %q", now.Format(time.RFC3339Nano), got)
+		}
+		if annotations["keep"] != "me" {
+			t.Fatalf("expected other annotations to be preserved, got %v", annotations)
+		}
+	}
+}
+
+func TestReconcilePatchDocumentDBMissingDocument(t *testing.T) {
+	t.Parallel()
+
+	opts := &reconcileOptions{documentDBName: "missing", namespace: defaultDocumentDBNamespace}
+	if err := opts.patchDocumentDB(context.Background(), newFakeDynamicClient(), time.Now()); err == nil {
+		t.Fatal("expected error when DocumentDB does not exist")
+	}
+}
+
+func TestReconcileOptionsComplete(t *testing.T) {
+	t.Parallel()
+
+	o := &reconcileOptions{documentDBName: " sample ", namespace: " "}
+	if err := o.complete(); err != nil {
+		t.Fatalf("complete returned error: %v", err)
+	}
+	if o.documentDBName != "sample" || o.namespace != defaultDocumentDBNamespace {
+		t.Fatalf("unexpected options after complete: %+v", o)
+	}
+
+	if err := (&reconcileOptions{}).complete(); err == nil {
+		t.Fatal("expected error for missing documentDBName")
+	}
+}
@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	cnpgClusterGVRGroup    = "postgresql.cnpg.io"
+	cnpgClusterGVRVersion  = "v1"
+	cnpgClusterGVRResource = "clusters"
+
+	// cnpgRestartAnnotation is the annotation CNPG watches to trigger a rolling restart of a Cluster. CNPG copies it
+	// onto every instance pod once that pod has been restarted.
+	cnpgRestartAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+	// cnpgPodRoleLabel and cnpgPodRoleInstance select the instance pods of a CNPG Cluster, leaving out job pods
+	cnpgPodRoleLabel    = "cnpg.io/podRole"
+	cnpgPodRoleInstance = "instance"
+
+	cnpgHealthyPhase = "Cluster in healthy state"
+
+	// cnpgSwitchoverPhase is the phase CNPG expects alongside a new status.targetPrimary to switch over to it
+	cnpgSwitchoverPhase = "Switchover in progress"
+)
+
+type restartOptions struct {
+	documentDBName  string
+	namespace       string
+	kubeContext     string
+	cnpgClusterName string
+	inPlace         bool
+	wait            bool
+	waitTimeout     time.Duration
+	pollInterval    time.Duration
+}
+
+func newRestartCommand() *cobra.Command {
+	opts := &restartOptions{namespace: defaultDocumentDBNamespace}
+
+	cmd := &cobra.Command{
+		Use:   "restart",
+		Short: "Trigger a rolling restart of the CNPG cluster backing a DocumentDB resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(); err != nil {
+				return err
+			}
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource to restart")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().StringVar(&opts.cnpgClusterName, "cnpg-cluster", opts.cnpgClusterName, "Name of the CNPG Cluster to restart (defaults to the DocumentDB name; use the member cluster name for replicated deployments)")
+	cmd.Flags().BoolVar(&opts.inPlace, "in-place", false, "Restart the primary in place instead of switching over to a ready replica first")
+	cmd.Flags().BoolVar(&opts.wait, "wait", false, "Wait for the rolling restart to complete")
+	cmd.Flags().DurationVar(&opts.waitTimeout, "wait-timeout", 10*time.Minute, "Maximum time to wait for the restart to complete")
+	cmd.Flags().DurationVar(&opts.pollInterval, "poll-interval", 10*time.Second, "Polling interval while waiting for the restart to complete")
+
+	_ = cmd.MarkFlagRequired("documentdb")
+
+	return cmd
+}
+
+func (o *restartOptions) complete() error {
+	o.documentDBName = strings.TrimSpace(o.documentDBName)
+	if o.documentDBName == "" {
+		return errors.New("--documentdb is required")
+	}
+	o.namespace = strings.TrimSpace(o.namespace)
+	if o.namespace == "" {
+		o.namespace = defaultDocumentDBNamespace
+	}
+	o.cnpgClusterName = strings.TrimSpace(o.cnpgClusterName)
+	if o.cnpgClusterName == "" {
+		o.cnpgClusterName = o.documentDBName
+	}
+	if o.waitTimeout <= 0 {
+		o.waitTimeout = 10 * time.Minute
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = 10 * time.Second
+	}
+	return nil
+}
+
+func (o *restartOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	config, contextName, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if contextName == "" {
+		contextName = "(current)"
+	}
+
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+	if _, err := dynClient.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+	}
+
+	if !o.inPlace {
+		target, err := o.switchover(ctx, dynClient)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Switching over CNPG Cluster %s/%s to instance %s...\n", o.namespace, o.cnpgClusterName, target)
+		if err := o.waitForSwitchover(ctx, dynClient, target); err != nil {
+			return err
+		}
+	}
+
+	restartedAt := time.Now().UTC().Format(time.RFC3339)
+	if err := o.patchCluster(ctx, dynClient, restartedAt); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Restart requested for CNPG Cluster %s/%s (context %s)\n",
+		o.namespace, o.cnpgClusterName, contextName)
+
+	if !o.wait {
+		return nil
+	}
+
+	clientset, err := kubernetesClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Waiting for the rolling restart to complete...")
+	if err := o.waitForRestart(ctx, dynClient, clientset, restartedAt); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Restart completed successfully.")
+	return nil
+}
+
+// switchover asks CNPG to promote a ready replica of the Cluster, the same way the CNPG plugin's promote does, and
+// returns the name of that instance. It fails when no replica is ready, as the primary can only restart in place then.
+func (o *restartOptions) switchover(ctx context.Context, dyn dynamic.Interface) (string, error) {
+	gvr := schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}
+	cluster, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.cnpgClusterName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get CNPG Cluster %q: %w", o.cnpgClusterName, err)
+	}
+	target := switchoverTarget(cluster)
+	if target == "" {
+		return "", fmt.Errorf("CNPG Cluster %q has no ready replica to switch over to; use --in-place to restart the primary in place", o.cnpgClusterName)
+	}
+
+	patch := map[string]any{
+		"status": map[string]any{
+			"targetPrimary": target,
+			"phase":         cnpgSwitchoverPhase,
+			"phaseReason":   "Switching over to " + target,
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	_, err = dyn.Resource(gvr).Namespace(o.namespace).Patch(ctx, o.cnpgClusterName, types.MergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return "", fmt.Errorf("failed to switch over CNPG Cluster %q: %w", o.cnpgClusterName, err)
+	}
+	return target, nil
+}
+
+// switchoverTarget returns the first healthy instance of the Cluster other than its current primary, or "" if none.
+func switchoverTarget(cluster *unstructured.Unstructured) string {
+	currentPrimary, _, _ := unstructured.NestedString(cluster.Object, "status", "currentPrimary")
+	healthy, _, _ := unstructured.NestedStringSlice(cluster.Object, "status", "instancesStatus", "healthy")
+	slices.Sort(healthy)
+	for _, instance := range healthy {
+		if instance != currentPrimary {
+			return instance
+		}
+	}
+	return ""
+}
+
+// waitForSwitchover polls until target is the current primary of the CNPG Cluster and the Cluster is healthy again,
+// so the restart annotation is only set once the switchover has completed.
+func (o *restartOptions) waitForSwitchover(ctx context.Context, dyn dynamic.Interface, target string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.waitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	gvr := schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the switchover to %q to complete after %s", target, o.waitTimeout)
+		case <-ticker.C:
+			cluster, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.cnpgClusterName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get CNPG Cluster %q: %w", o.cnpgClusterName, err)
+			}
+			currentPrimary, _, _ := unstructured.NestedString(cluster.Object, "status", "currentPrimary")
+			if currentPrimary == target && isClusterHealthy(cluster) {
+				return nil
+			}
+		}
+	}
+}
+
+// patchCluster sets the restart annotation on the CNPG Cluster. The spec is left to the operator, so the primary is
+// updated with the primaryUpdateMethod of the DocumentDB.
+func (o *restartOptions) patchCluster(ctx context.Context, dyn dynamic.Interface, restartedAt string) error {
+	gvr := schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}
+
+	patch := map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{
+				cnpgRestartAnnotation: restartedAt,
+			},
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	_, err = dyn.Resource(gvr).Namespace(o.namespace).Patch(ctx, o.cnpgClusterName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch CNPG Cluster %q: %w", o.cnpgClusterName, err)
+	}
+
+	return nil
+}
+
+// waitForRestart polls until every instance pod carries the restart annotation requested at restartedAt and the CNPG
+// Cluster is healthy with all instances ready, so a restart completing between two polls is still detected.
+func (o *restartOptions) waitForRestart(ctx context.Context, dyn dynamic.Interface, clientset kubernetes.Interface, restartedAt string) error {
+	ctx, cancel := context.WithTimeout(ctx, o.waitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	gvr := schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}
+	selector := fmt.Sprintf("%s=%s,%s=%s", cnpgClusterLabel, o.cnpgClusterName, cnpgPodRoleLabel, cnpgPodRoleInstance)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for restart to complete after %s", o.waitTimeout)
+		case <-ticker.C:
+			cluster, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.cnpgClusterName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get CNPG Cluster %q: %w", o.cnpgClusterName, err)
+			}
+			if !isClusterHealthy(cluster) {
+				continue
+			}
+			pods, err := clientset.CoreV1().Pods(o.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return fmt.Errorf("failed to list pods of CNPG Cluster %q: %w", o.cnpgClusterName, err)
+			}
+			if podsRestarted(pods.Items, restartedAt) {
+				return nil
+			}
+		}
+	}
+}
+
+// podsRestarted reports whether CNPG has restarted every pod since the restart requested at restartedAt.
+func podsRestarted(pods []corev1.Pod, restartedAt string) bool {
+	if len(pods) == 0 {
+		return false
+	}
+	for _, pod := range pods {
+		if pod.Annotations[cnpgRestartAnnotation] != restartedAt {
+			return false
+		}
+	}
+	return true
+}
+
+func isClusterHealthy(cluster *unstructured.Unstructured) bool {
+	phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+	if phase != cnpgHealthyPhase {
+		return false
+	}
+	instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+	readyInstances, _, _ := unstructured.NestedInt64(cluster.Object, "status", "readyInstances")
+	return readyInstances >= instances
+}
@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestRestartPatchClusterSetsAnnotationOnly(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	cluster := newCNPGCluster("sample", namespace, 3, 3, cnpgHealthyPhase)
+	if err := unstructured.SetNestedField(cluster.Object, "switchover", "spec", "primaryUpdateMethod"); err != nil {
+		t.Fatalf("failed to set primaryUpdateMethod: %v", err)
+	}
+	client := newFakeDynamicClient(cluster)
+
+	opts := &restartOptions{documentDBName: "sample", namespace: namespace, cnpgClusterName: "sample"}
+	restartedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC).Format(time.RFC3339)
+	if err := opts.patchCluster(context.Background(), client, restartedAt); err != nil {
+		t.Fatalf("patchCluster returned error: %v", err)
+	}
+
+	patched, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Get(context.Background(), "sample", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch patched cluster: %v", err)
+	}
+	if got := patched.GetAnnotations()[cnpgRestartAnnotation]; got != restartedAt {
+		t.Fatalf("expected restart annotation %q, got %q", restartedAt, got)
+	}
+	// The primary update method belongs to the operator, which reconciles it from the DocumentDB
+	if method, _, _ := unstructured.NestedString(patched.Object, "spec", "primaryUpdateMethod"); method != "switchover" {
+		t.Fatalf("expected primaryUpdateMethod to be left unchanged, got %q", method)
+	}
+}
+
+func TestRestartPatchClusterMissingCluster(t *testing.T) {
+	t.Parallel()
+
+	opts := &restartOptions{namespace: defaultDocumentDBNamespace, cnpgClusterName: "missing"}
+	if err := opts.patchCluster(context.Background(), newFakeDynamicClient(), time.Now().Format(time.RFC3339)); err == nil {
+		t.Fatal("expected error when CNPG Cluster does not exist")
+	}
+}
+
+func TestRestartRunPatches(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+	}()
+
+	testCases := []struct {
+		name                  string
+		inPlace               bool
+		expectedTargetPrimary string
+	}{
+		{name: "switchover first", inPlace: false, expectedTargetPrimary: "sample-2"},
+		{name: "in place", inPlace: true, expectedTargetPrimary: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			namespace := defaultDocumentDBNamespace
+			cluster := newReplicatedCNPGCluster("sample", namespace, "sample-1", "sample-1", "sample-2", "sample-3")
+			if err := unstructured.SetNestedField(cluster.Object, "restart", "spec", "primaryUpdateMethod"); err != nil {
+				t.Fatalf("failed to set primaryUpdateMethod: %v", err)
+			}
+			client := newFakeDynamicClient(newDocument("sample", namespace, "sample", "Ready"), cluster)
+			loadConfigFunc = func(string) (*rest.Config, string, error) {
+				return &rest.Config{}, "test", nil
+			}
+			dynamicClientForConfig = func(*rest.Config) (dynamic.Interface, error) {
+				return client, nil
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			// Act as CNPG, promoting the requested instance
+			go func() {
+				for ctx.Err() == nil {
+					obj, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Get(ctx, "sample", metav1.GetOptions{})
+					if err == nil {
+						if target, _, _ := unstructured.NestedString(obj.Object, "status", "targetPrimary"); target != "" {
+							_ = unstructured.SetNestedField(obj.Object, target, "status", "currentPrimary")
+							_ = unstructured.SetNestedField(obj.Object, cnpgHealthyPhase, "status", "phase")
+							_, _ = client.Resource(cnpgClusterGVR()).Namespace(namespace).Update(ctx, obj, metav1.UpdateOptions{})
+							return
+						}
+					}
+					time.Sleep(5 * time.Millisecond)
+				}
+			}()
+
+			opts := &restartOptions{documentDBName: "sample", namespace: namespace, inPlace: tc.inPlace}
+			if err := opts.complete(); err != nil {
+				t.Fatalf("complete returned error: %v", err)
+			}
+			opts.pollInterval = 10 * time.Millisecond
+			cmd := &cobra.Command{}
+			cmd.SetOut(&bytes.Buffer{})
+			if err := opts.run(ctx, cmd); err != nil {
+				t.Fatalf("run returned error: %v", err)
+			}
+
+			patched, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Get(ctx, "sample", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to fetch patched cluster: %v", err)
+			}
+			if target, _, _ := unstructured.NestedString(patched.Object, "status", "targetPrimary"); target != tc.expectedTargetPrimary {
+				t.Fatalf("expected targetPrimary %q, got %q", tc.expectedTargetPrimary, target)
+			}
+			if patched.GetAnnotations()[cnpgRestartAnnotation] == "" {
+				t.Fatal("expected the restart annotation to be set")
+			}
+			if method, _, _ := unstructured.NestedString(patched.Object, "spec", "primaryUpdateMethod"); method != "restart" {
+				t.Fatalf("expected primaryUpdateMethod to be left unchanged, got %q", method)
+			}
+		})
+	}
+}
+
+func TestRestartSwitchoverRequestsReadyReplica(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(newReplicatedCNPGCluster("sample", namespace, "sample-2", "sample-3", "sample-2", "sample-1"))
+
+	opts := &restartOptions{namespace: namespace, cnpgClusterName: "sample"}
+	target, err := opts.switchover(context.Background(), client)
+	if err != nil {
+		t.Fatalf("switchover returned error: %v", err)
+	}
+	if target != "sample-1" {
+		t.Fatalf("expected switchover to the first ready replica sample-1, got %q", target)
+	}
+
+	patched, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Get(context.Background(), "sample", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch patched cluster: %v", err)
+	}
+	if got, _, _ := unstructured.NestedString(patched.Object, "status", "targetPrimary"); got != "sample-1" {
+		t.Fatalf("expected targetPrimary sample-1, got %q", got)
+	}
+	if phase, _, _ := unstructured.NestedString(patched.Object, "status", "phase"); phase != cnpgSwitchoverPhase {
+		t.Fatalf("expected phase %q, got %q", cnpgSwitchoverPhase, phase)
+	}
+	if _, ok := patched.GetAnnotations()[cnpgRestartAnnotation]; ok {
+		t.Fatal("expected the switchover to leave the restart annotation unset")
+	}
+}
+
+func TestRestartSwitchoverWithoutReadyReplica(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(newReplicatedCNPGCluster("sample", namespace, "sample-1", "sample-1"))
+
+	opts := &restartOptions{namespace: namespace, cnpgClusterName: "sample"}
+	if _, err := opts.switchover(context.Background(), client); err == nil {
+		t.Fatal("expected error when the CNPG Cluster has no ready replica")
+	}
+}
+
+func TestWaitForRestart(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	restartedAt := "2025-01-02T03:04:05Z"
+	// The cluster never reports an unhealthy phase, as when the restart completes between two polls
+	client := newFakeDynamicClient(newCNPGCluster("sample", namespace, 2, 2, cnpgHealthyPhase))
+	clientset := kubefake.NewSimpleClientset(
+		newInstancePod("sample-1", namespace, "sample", "2024-12-01T00:00:00Z"),
+		newInstancePod("sample-2", namespace, "sample", "2024-12-01T00:00:00Z"),
+	)
+
+	opts := &restartOptions{
+		namespace:       namespace,
+		cnpgClusterName: "sample",
+		waitTimeout:     2 * time.Second,
+		pollInterval:    10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		for _, name := range []string{"sample-1", "sample-2"} {
+			pod := newInstancePod(name, namespace, "sample", restartedAt)
+			if _, err := clientset.CoreV1().Pods(namespace).Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		errCh <- nil
+	}()
+
+	if err := opts.waitForRestart(ctx, client, clientset, restartedAt); err != nil {
+		t.Fatalf("waitForRestart returned error: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("failed to update pods: %v", err)
+	}
+}
+
+func TestWaitForRestartTimesOutWhilePodsAreStale(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(newCNPGCluster("sample", namespace, 2, 2, cnpgHealthyPhase))
+	clientset := kubefake.NewSimpleClientset(
+		newInstancePod("sample-1", namespace, "sample", "2025-01-02T03:04:05Z"),
+		newInstancePod("sample-2", namespace, "sample", "2024-12-01T00:00:00Z"),
+	)
+
+	opts := &restartOptions{
+		namespace:       namespace,
+		cnpgClusterName: "sample",
+		waitTimeout:     100 * time.Millisecond,
+		pollInterval:    10 * time.Millisecond,
+	}
+	if err := opts.waitForRestart(context.Background(), client, clientset, "2025-01-02T03:04:05Z"); err == nil {
+		t.Fatal("expected timeout while an instance pod has not been restarted")
+	}
+}
+
+func TestRestartOptionsCompleteDefaults(t *testing.T) {
+	t.Parallel()
+
+	o := &restartOptions{documentDBName: " sample ", namespace: " "}
+	if err := o.complete(); err != nil {
+		t.Fatalf("complete returned error: %v", err)
+	}
+	if o.cnpgClusterName != "sample" {
+		t.Fatalf("expected cnpgClusterName to default to document name, got %q", o.cnpgClusterName)
+	}
+	if o.namespace != defaultDocumentDBNamespace {
+		t.Fatalf("expected namespace default %q, got %q", defaultDocumentDBNamespace, o.namespace)
+	}
+	if o.waitTimeout <= 0 || o.pollInterval <= 0 {
+		t.Fatalf("expected positive wait settings, got timeout=%v interval=%v", o.waitTimeout, o.pollInterval)
+	}
+
+	if err := (&restartOptions{}).complete(); err == nil {
+		t.Fatal("expected error for missing documentDBName")
+	}
+}
+
+func cnpgClusterGVR() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}
+}
+
+func newCNPGCluster(name, namespace string, instances, readyInstances int64, phase string) *unstructured.Unstructured {
+	cluster := &unstructured.Unstructured{Object: map[string]any{
+		"spec": map[string]any{
+			"instances": instances,
+		},
+		"status": map[string]any{
+			"phase":          phase,
+			"readyInstances": readyInstances,
+		},
+	}}
+	cluster.SetGroupVersionKind(schema.GroupVersionKind{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Kind: "Cluster"})
+	cluster.SetName(name)
+	cluster.SetNamespace(namespace)
+	return cluster
+}
+
+func newReplicatedCNPGCluster(name, namespace, currentPrimary string, healthy ...string) *unstructured.Unstructured {
+	cluster := newCNPGCluster(name, namespace, int64(len(healthy)), int64(len(healthy)), cnpgHealthyPhase)
+	status := cluster.Object["status"].(map[string]any)
+	status["currentPrimary"] = currentPrimary
+	instances := make([]any, 0, len(healthy))
+	for _, instance := range healthy {
+		instances = append(instances, instance)
+	}
+	status["instancesStatus"] = map[string]any{"healthy": instances}
+	return cluster
+}
+
+func newInstancePod(name, namespace, cluster, restartedAt string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      map[string]string{cnpgClusterLabel: cluster, cnpgPodRoleLabel: cnpgPodRoleInstance},
+		Annotations: map[string]string{cnpgRestartAnnotation: restartedAt},
+	}}
+}
+
+func setClusterState(ctx context.Context, client dynamic.Interface, namespace, name string, readyInstances int64, phase string) error {
+	obj, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(obj.Object, readyInstances, "status", "readyInstances"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(obj.Object, phase, "status", "phase"); err != nil {
+		return err
+	}
+	_, err = client.Resource(cnpgClusterGVR()).Namespace(namespace).Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
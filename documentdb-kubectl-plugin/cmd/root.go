@@ -24,7 +24,18 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig", kubeconfigPath, "Path to the kubeconfig file(s) to use; separate multiple files with commas (defaults to $KUBECONFIG or ~/.kube/config)")
+
 	rootCmd.AddCommand(newPromoteCommand())
+	rootCmd.AddCommand(newDemoteCommand())
 	rootCmd.AddCommand(newStatusCommand())
 	rootCmd.AddCommand(newEventsCommand())
+	rootCmd.AddCommand(newRestartCommand())
+	rootCmd.AddCommand(newReconcileCommand())
+	rootCmd.AddCommand(newScaleCommand())
+	rootCmd.AddCommand(newCloneCommand())
+	rootCmd.AddCommand(newCertificateCommand())
+	rootCmd.AddCommand(newVersionCommand())
+	rootCmd.AddCommand(newDoctorCommand())
+	rootCmd.AddCommand(newSupportBundleCommand())
 }
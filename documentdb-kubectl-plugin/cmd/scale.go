@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// minInstancesPerNode and maxInstancesPerNode mirror the validation of spec.instancesPerNode in the DocumentDB CRD
+	minInstancesPerNode = 1
+	maxInstancesPerNode = 3
+)
+
+type scaleOptions struct {
+	documentDBName  string
+	namespace       string
+	kubeContext     string
+	cnpgClusterName string
+	instances       int
+	wait            bool
+	waitTimeout     time.Duration
+	pollInterval    time.Duration
+}
+
+func newScaleCommand() *cobra.Command {
+	opts := &scaleOptions{namespace: defaultDocumentDBNamespace}
+
+	cmd := &cobra.Command{
+		Use:   "scale",
+		Short: "Change the number of instances of a DocumentDB resource",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(); err != nil {
+				return err
+			}
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource to scale")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().IntVar(&opts.instances, "instances", opts.instances, fmt.Sprintf("Number of instances per node (%d-%d)", minInstancesPerNode, maxInstancesPerNode))
+	cmd.Flags().StringVar(&opts.cnpgClusterName, "cnpg-cluster", opts.cnpgClusterName, "Name of the CNPG Cluster to wait for (defaults to the DocumentDB name; use the member cluster name for replicated deployments)")
+	cmd.Flags().BoolVar(&opts.wait, "wait", false, "Wait for the new instances to become ready")
+	cmd.Flags().DurationVar(&opts.waitTimeout, "wait-timeout", 10*time.Minute, "Maximum time to wait for the instances to become ready")
+	cmd.Flags().DurationVar(&opts.pollInterval, "poll-interval", 10*time.Second, "Polling interval while waiting for the instances to become ready")
+
+	_ = cmd.MarkFlagRequired("documentdb")
+	_ = cmd.MarkFlagRequired("instances")
+
+	return cmd
+}
+
+func (o *scaleOptions) complete() error {
+	o.documentDBName = strings.TrimSpace(o.documentDBName)
+	if o.documentDBName == "" {
+		return errors.New("--documentdb is required")
+	}
+	if o.instances < minInstancesPerNode || o.instances > maxInstancesPerNode {
+		return fmt.Errorf("--instances must be between %d and %d, got %d", minInstancesPerNode, maxInstancesPerNode, o.instances)
+	}
+	o.namespace = strings.TrimSpace(o.namespace)
+	if o.namespace == "" {
+		o.namespace = defaultDocumentDBNamespace
+	}
+	o.cnpgClusterName = strings.TrimSpace(o.cnpgClusterName)
+	if o.cnpgClusterName == "" {
+		o.cnpgClusterName = o.documentDBName
+	}
+	if o.waitTimeout <= 0 {
+		o.waitTimeout = 10 * time.Minute
+	}
+	if o.pollInterval <= 0 {
+		o.pollInterval = 10 * time.Second
+	}
+	return nil
+}
+
+func (o *scaleOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	config, contextName, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if contextName == "" {
+		contextName = "(current)"
+	}
+
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	previous, err := o.patchDocumentDB(ctx, dynClient)
+	if err != nil {
+		return err
+	}
+
+	if previous == int64(o.instances) {
+		fmt.Fprintf(cmd.OutOrStdout(), "DocumentDB %s/%s already has %d instance(s) per node (context %s)\n",
+			o.namespace, o.documentDBName, o.instances, contextName)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Scaled DocumentDB %s/%s from %d to %d instance(s) per node (context %s)\n",
+			o.namespace, o.documentDBName, previous, o.instances, contextName)
+	}
+
+	if !o.wait {
+		return nil
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Waiting for the instances to become ready...")
+	if err := o.waitForInstances(ctx, dynClient); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Scale completed successfully.")
+	return nil
+}
+
+// patchDocumentDB sets spec.instancesPerNode and returns the previous value.
+func (o *scaleOptions) patchDocumentDB(ctx context.Context, dyn dynamic.Interface) (int64, error) {
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+
+	document, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+	}
+	previous, _, _ := unstructured.NestedInt64(document.Object, "spec", "instancesPerNode")
+	if previous == int64(o.instances) {
+		return previous, nil
+	}
+
+	patch := map[string]any{
+		"spec": map[string]any{
+			"instancesPerNode": o.instances,
+		},
+	}
+
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	_, err = dyn.Resource(gvr).Namespace(o.namespace).Patch(ctx, o.documentDBName, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to patch DocumentDB %q: %w", o.documentDBName, err)
+	}
+
+	return previous, nil
+}
+
+// waitForInstances polls the CNPG Cluster until the operator has applied the new instance count and all instances are ready.
+func (o *scaleOptions) waitForInstances(ctx context.Context, dyn dynamic.Interface) error {
+	ctx, cancel := context.WithTimeout(ctx, o.waitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	gvr := schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %d instance(s) to become ready after %s", o.instances, o.waitTimeout)
+		case <-ticker.C:
+			cluster, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, o.cnpgClusterName, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get CNPG Cluster %q: %w", o.cnpgClusterName, err)
+			}
+			instances, _, _ := unstructured.NestedInt64(cluster.Object, "spec", "instances")
+			if instances == int64(o.instances) && isClusterHealthy(cluster) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newScalableDocument(name, namespace string, instancesPerNode int64) *unstructured.Unstructured {
+	doc := newDocument(name, namespace, "", "")
+	_ = unstructured.SetNestedField(doc.Object, instancesPerNode, "spec", "instancesPerNode")
+	return doc
+}
+
+func TestScalePatchDocumentDBSetsInstances(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(newScalableDocument("sample", namespace, 1))
+
+	opts := &scaleOptions{documentDBName: "sample", namespace: namespace, instances: 3}
+	previous, err := opts.patchDocumentDB(context.Background(), client)
+	if err != nil {
+		t.Fatalf("patchDocumentDB returned error: %v", err)
+	}
+	if previous != 1 {
+		t.Fatalf("expected previous instance count 1, got %d", previous)
+	}
+
+	patched, err := client.Resource(documentDBGVR()).Namespace(namespace).Get(context.Background(), "sample", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to fetch patched DocumentDB: %v", err)
+	}
+	value, found, err := unstructured.NestedFieldNoCopy(patched.Object, "spec", "instancesPerNode")
+	if err != nil || !found {
+		t.Fatalf("expected spec.instancesPerNode to be set, found=%v err=%v", found, err)
+	}
+	if fmt.Sprint(value) != "3" {
+		t.Fatalf("expected spec.instancesPerNode 3, got %v", value)
+	}
+}
+
+func TestScalePatchDocumentDBMissingDocument(t *testing.T) {
+	t.Parallel()
+
+	opts := &scaleOptions{documentDBName: "missing", namespace: defaultDocumentDBNamespace, instances: 2}
+	if _, err := opts.patchDocumentDB(context.Background(), newFakeDynamicClient()); err == nil {
+		t.Fatal("expected error when DocumentDB does not exist")
+	}
+}
+
+func TestScaleOptionsComplete(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		instances int
+		expectErr bool
+	}{
+		{name: "below range", instances: 0, expectErr: true},
+		{name: "minimum", instances: minInstancesPerNode},
+		{name: "maximum", instances: maxInstancesPerNode},
+		{name: "above range", instances: maxInstancesPerNode + 1, expectErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			o := &scaleOptions{documentDBName: " sample ", namespace: " ", instances: tc.instances}
+			err := o.complete()
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error for %d instances", tc.instances)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("complete returned error: %v", err)
+			}
+			if o.cnpgClusterName != "sample" || o.namespace != defaultDocumentDBNamespace {
+				t.Fatalf("unexpected defaults: cnpgClusterName=%q namespace=%q", o.cnpgClusterName, o.namespace)
+			}
+		})
+	}
+}
+
+func TestWaitForInstances(t *testing.T) {
+	t.Parallel()
+
+	namespace := defaultDocumentDBNamespace
+	client := newFakeDynamicClient(newCNPGCluster("sample", namespace, 1, 1, cnpgHealthyPhase))
+
+	opts := &scaleOptions{
+		namespace:       namespace,
+		cnpgClusterName: "sample",
+		instances:       2,
+		waitTimeout:     time.Second,
+		pollInterval:    10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		cluster, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Get(ctx, "sample", metav1.GetOptions{})
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if err := unstructured.SetNestedField(cluster.Object, int64(2), "spec", "instances"); err != nil {
+			errCh <- err
+			return
+		}
+		if _, err := client.Resource(cnpgClusterGVR()).Namespace(namespace).Update(ctx, cluster, metav1.UpdateOptions{}); err != nil {
+			errCh <- err
+			return
+		}
+		time.Sleep(30 * time.Millisecond)
+		errCh <- setClusterState(ctx, client, namespace, "sample", 2, cnpgHealthyPhase)
+	}()
+
+	if err := opts.waitForInstances(ctx, client); err != nil {
+		t.Fatalf("waitForInstances returned error: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("failed to update cluster: %v", err)
+	}
+}
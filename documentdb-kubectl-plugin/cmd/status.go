@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"text/tabwriter"
 
@@ -15,27 +17,62 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
 )
 
 const documentdbServicePrefix = "documentdb-service-"
 
+const (
+	statusOutputTable = ""
+	statusOutputJSON  = "json"
+	statusOutputYAML  = "yaml"
+)
+
 type statusOptions struct {
 	documentDBName  string
 	namespace       string
 	kubeContext     string
 	showConnections bool
+	all             bool
+	selector        string
+	output          string
 }
 
 type clusterStatus struct {
-	Cluster     string
-	ContextName string
-	Role        string
-	Phase       string
-	PodsReady   int
-	PodsTotal   int
-	ServiceIP   string
-	Connection  string
-	Err         error
+	Cluster     string `json:"cluster"`
+	ContextName string `json:"context,omitempty"`
+	Role        string `json:"role"`
+	Phase       string `json:"phase"`
+	PodsReady   int    `json:"podsReady"`
+	PodsTotal   int    `json:"podsTotal"`
+	ServiceIP   string `json:"serviceIP"`
+	Connection  string `json:"connectionString,omitempty"`
+	Err         error  `json:"-"`
+}
+
+// MarshalJSON renders Err as an "error" string, since error values don't serialize.
+func (s clusterStatus) MarshalJSON() ([]byte, error) {
+	type plain clusterStatus
+	errorText := ""
+	if s.Err != nil {
+		errorText = s.Err.Error()
+	}
+	return json.Marshal(struct {
+		plain
+		Error string `json:"error,omitempty"`
+	}{plain(s), errorText})
+}
+
+// documentStatus is the status of one DocumentDB across its member clusters, as printed by -o json|yaml.
+type documentStatus struct {
+	Name             string          `json:"name"`
+	Namespace        string          `json:"namespace"`
+	Context          string          `json:"context"`
+	PrimaryCluster   string          `json:"primaryCluster,omitempty"`
+	Status           string          `json:"status,omitempty"`
+	ConnectionString string          `json:"connectionString,omitempty"`
+	Clusters         []clusterStatus `json:"clusters,omitempty"`
+	Error            string          `json:"error,omitempty"`
 }
 
 func newStatusCommand() *cobra.Command {
@@ -58,21 +95,35 @@ func newStatusCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
 	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
 	cmd.Flags().BoolVar(&opts.showConnections, "show-connections", false, "Include connection strings in the output")
-
-	_ = cmd.MarkFlagRequired("documentdb")
+	cmd.Flags().BoolVar(&opts.all, "all", false, "Show status for every DocumentDB resource in the namespace")
+	cmd.Flags().StringVarP(&opts.selector, "selector", "l", opts.selector, "Label selector to choose which DocumentDB resources to show")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", opts.output, "Output format: json or yaml (defaults to a table)")
 
 	return cmd
 }
 
 func (o *statusOptions) complete() error {
 	o.documentDBName = strings.TrimSpace(o.documentDBName)
-	if o.documentDBName == "" {
-		return errors.New("--documentdb is required")
+	o.selector = strings.TrimSpace(o.selector)
+	if o.all && o.selector != "" {
+		return errors.New("--all and --selector are mutually exclusive")
+	}
+	if o.documentDBName != "" && (o.all || o.selector != "") {
+		return errors.New("--documentdb cannot be combined with --all or --selector")
+	}
+	if o.documentDBName == "" && !o.all && o.selector == "" {
+		return errors.New("--documentdb is required unless --all or --selector is set")
 	}
 	o.namespace = strings.TrimSpace(o.namespace)
 	if o.namespace == "" {
 		o.namespace = defaultDocumentDBNamespace
 	}
+	o.output = strings.ToLower(strings.TrimSpace(o.output))
+	switch o.output {
+	case statusOutputTable, statusOutputJSON, statusOutputYAML:
+	default:
+		return fmt.Errorf("unsupported --output %q: must be json or yaml", o.output)
+	}
 	return nil
 }
 
@@ -92,33 +143,97 @@ func (o *statusOptions) run(ctx context.Context, cmd *cobra.Command) error {
 
 	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
 
-	document, err := dynHub.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if o.documentDBName != "" {
+		document, err := dynHub.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+		}
+		status, err := o.collectDocumentStatus(ctx, document, contextName)
+		if err != nil {
+			return err
+		}
+		if o.output != statusOutputTable {
+			return o.printStructured(cmd.OutOrStdout(), status)
+		}
+		o.renderDocumentStatus(cmd.OutOrStdout(), status)
+	} else {
+		documents, err := dynHub.Resource(gvr).Namespace(o.namespace).List(ctx, metav1.ListOptions{LabelSelector: o.selector})
+		if err != nil {
+			return fmt.Errorf("failed to list DocumentDB resources in namespace %q: %w", o.namespace, err)
+		}
+		if len(documents.Items) == 0 && o.output == statusOutputTable {
+			fmt.Fprintf(cmd.OutOrStdout(), "No DocumentDB resources found in namespace %q.\n", o.namespace)
+			return nil
+		}
+
+		statuses := make([]*documentStatus, 0, len(documents.Items))
+		for idx := range documents.Items {
+			status, err := o.collectDocumentStatus(ctx, &documents.Items[idx], contextName)
+			if err != nil {
+				status = &documentStatus{Name: documents.Items[idx].GetName(), Namespace: o.namespace, Context: contextName, Error: err.Error()}
+			}
+			statuses = append(statuses, status)
+		}
+		if o.output != statusOutputTable {
+			return o.printStructured(cmd.OutOrStdout(), statuses)
+		}
+
+		for idx, status := range statuses {
+			if idx > 0 {
+				fmt.Fprintln(cmd.OutOrStdout())
+			}
+			if status.Error != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "DocumentDB: %s/%s\nError: %s\n", status.Namespace, status.Name, status.Error)
+				continue
+			}
+			o.renderDocumentStatus(cmd.OutOrStdout(), status)
+		}
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout())
+	fmt.Fprintln(cmd.OutOrStdout(), "Tip: ensure 'kubectl config get-contexts' lists each member cluster so the plugin can query them.")
+
+	return nil
+}
+
+// printStructured writes the collected status as JSON or YAML, for scripts and CI pipelines.
+func (o *statusOptions) printStructured(out io.Writer, status any) error {
+	data, err := json.MarshalIndent(status, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+		return fmt.Errorf("failed to encode status: %w", err)
 	}
+	if o.output == statusOutputYAML {
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+	} else {
+		data = append(data, '\n')
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+// collectDocumentStatus gathers the status of the DocumentDB from every member cluster in its cluster list.
+func (o *statusOptions) collectDocumentStatus(ctx context.Context, document *unstructured.Unstructured, contextName string) (*documentStatus, error) {
+	documentName := document.GetName()
 
 	primaryCluster, _, err := unstructured.NestedString(document.Object, "spec", "clusterReplication", "primary")
 	if err != nil {
-		return fmt.Errorf("failed to read spec.clusterReplication.primary: %w", err)
+		return nil, fmt.Errorf("failed to read spec.clusterReplication.primary: %w", err)
 	}
 	clusterListRaw, found, err := unstructured.NestedSlice(document.Object, "spec", "clusterReplication", "clusterList")
 	if err != nil {
-		return fmt.Errorf("failed to read spec.clusterReplication.clusterList: %w", err)
+		return nil, fmt.Errorf("failed to read spec.clusterReplication.clusterList: %w", err)
 	}
 	if !found || len(clusterListRaw) == 0 {
-		return errors.New("DocumentDB spec.clusterReplication.clusterList is empty")
+		return nil, errors.New("DocumentDB spec.clusterReplication.clusterList is empty")
 	}
 
-	overallPhase, _, _ := unstructured.NestedString(document.Object, "status", "status")
-	overallConnection, _, _ := unstructured.NestedString(document.Object, "status", "connectionString")
-
-	fmt.Fprintf(cmd.OutOrStdout(), "DocumentDB: %s/%s\n", o.namespace, o.documentDBName)
-	fmt.Fprintf(cmd.OutOrStdout(), "Context: %s\n", contextName)
-	fmt.Fprintf(cmd.OutOrStdout(), "Primary cluster: %s\n", primaryCluster)
-	if overallPhase != "" {
-		fmt.Fprintf(cmd.OutOrStdout(), "Overall status: %s\n", overallPhase)
+	status := &documentStatus{Name: documentName, Namespace: o.namespace, Context: contextName, PrimaryCluster: primaryCluster}
+	status.Status, _, _ = unstructured.NestedString(document.Object, "status", "status")
+	if o.showConnections {
+		status.ConnectionString, _, _ = unstructured.NestedString(document.Object, "status", "connectionString")
 	}
-	fmt.Fprintln(cmd.OutOrStdout())
 
 	statuses := make([]clusterStatus, 0, len(clusterListRaw))
 	for _, clusterObj := range clusterListRaw {
@@ -146,16 +261,29 @@ func (o *statusOptions) run(ctx context.Context, cmd *cobra.Command) error {
 		}
 		st.ContextName = clusterContextName
 
-		if err := o.populateClusterStatus(ctx, &st, clusterConfig); err != nil {
+		if err := o.populateClusterStatus(ctx, &st, clusterConfig, documentName); err != nil {
 			st.Err = err
 		}
 
 		statuses = append(statuses, st)
 	}
+	status.Clusters = statuses
+
+	return status, nil
+}
+
+func (o *statusOptions) renderDocumentStatus(out io.Writer, status *documentStatus) {
+	fmt.Fprintf(out, "DocumentDB: %s/%s\n", status.Namespace, status.Name)
+	fmt.Fprintf(out, "Context: %s\n", status.Context)
+	fmt.Fprintf(out, "Primary cluster: %s\n", status.PrimaryCluster)
+	if status.Status != "" {
+		fmt.Fprintf(out, "Overall status: %s\n", status.Status)
+	}
+	fmt.Fprintln(out)
 
-	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
 	fmt.Fprintln(tw, "CLUSTER\tROLE\tPHASE\tPODS\tSERVICE IP\tCONTEXT\tERROR")
-	for _, st := range statuses {
+	for _, st := range status.Clusters {
 		errorText := "-"
 		if st.Err != nil {
 			errorText = truncateString(st.Err.Error(), 80)
@@ -173,19 +301,14 @@ func (o *statusOptions) run(ctx context.Context, cmd *cobra.Command) error {
 	}
 	_ = tw.Flush()
 
-	if o.showConnections && overallConnection != "" {
-		fmt.Fprintln(cmd.OutOrStdout())
-		fmt.Fprintln(cmd.OutOrStdout(), "Primary connection string (from hub status):")
-		fmt.Fprintln(cmd.OutOrStdout(), overallConnection)
+	if status.ConnectionString != "" {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Primary connection string (from hub status):")
+		fmt.Fprintln(out, status.ConnectionString)
 	}
-
-	fmt.Fprintln(cmd.OutOrStdout())
-	fmt.Fprintln(cmd.OutOrStdout(), "Tip: ensure 'kubectl config get-contexts' lists each member cluster so the plugin can query them.")
-
-	return nil
 }
 
-func (o *statusOptions) populateClusterStatus(ctx context.Context, st *clusterStatus, config *rest.Config) error {
+func (o *statusOptions) populateClusterStatus(ctx context.Context, st *clusterStatus, config *rest.Config, documentName string) error {
 	dynClient, err := dynamicClientForConfig(config)
 	if err != nil {
 		return fmt.Errorf("dynamic client: %w", err)
@@ -193,7 +316,7 @@ func (o *statusOptions) populateClusterStatus(ctx context.Context, st *clusterSt
 
 	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
 
-	document, err := dynClient.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	document, err := dynClient.Resource(gvr).Namespace(o.namespace).Get(ctx, documentName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("fetch documentdb: %w", err)
 	}
@@ -201,7 +324,7 @@ func (o *statusOptions) populateClusterStatus(ctx context.Context, st *clusterSt
 	if phase, _, err := unstructured.NestedString(document.Object, "status", "status"); err == nil && phase != "" {
 		st.Phase = phase
 	}
-	if conn, _, err := unstructured.NestedString(document.Object, "status", "connectionString"); err == nil {
+	if conn, _, err := unstructured.NestedString(document.Object, "status", "connectionString"); err == nil && o.showConnections {
 		st.Connection = conn
 	}
 
@@ -210,7 +333,7 @@ func (o *statusOptions) populateClusterStatus(ctx context.Context, st *clusterSt
 		return fmt.Errorf("clientset: %w", err)
 	}
 
-	pods, err := clientset.CoreV1().Pods(o.namespace).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", o.documentDBName)})
+	pods, err := clientset.CoreV1().Pods(o.namespace).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", documentName)})
 	if err != nil {
 		return fmt.Errorf("list pods: %w", err)
 	}
@@ -221,7 +344,7 @@ func (o *statusOptions) populateClusterStatus(ctx context.Context, st *clusterSt
 		}
 	}
 
-	serviceIP, err := findDocumentDBServiceEndpoint(ctx, clientset, o.namespace, st.Cluster, o.documentDBName)
+	serviceIP, err := findDocumentDBServiceEndpoint(ctx, clientset, o.namespace, st.Cluster, documentName)
 	if err == nil {
 		st.ServiceIP = serviceIP
 	}
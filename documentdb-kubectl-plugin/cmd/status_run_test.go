@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	kubefake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
 )
 
 func TestStatusRunRendersClusterTable(t *testing.T) {
@@ -179,3 +181,205 @@ func TestStatusRunRendersClusterTable(t *testing.T) {
 		}
 	}
 }
+
+func TestStatusRunRendersAllDocuments(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	clusterList := []interface{}{map[string]interface{}{"name": "cluster-a"}}
+
+	newListedDocument := func(name string, labels map[string]string) *unstructured.Unstructured {
+		doc := newDocument(name, namespace, "cluster-a", "Ready")
+		doc.SetLabels(labels)
+		if err := unstructured.SetNestedSlice(doc.Object, clusterList, "spec", "clusterReplication", "clusterList"); err != nil {
+			t.Fatalf("failed to set clusterList: %v", err)
+		}
+		return doc
+	}
+
+	docOne := newListedDocument("documentdb-one", map[string]string{"team": "payments"})
+	docTwo := newListedDocument("documentdb-two", map[string]string{"team": "search"})
+
+	dynamicClients := map[string]dynamic.Interface{
+		"hub":       newFakeDynamicClient(docOne.DeepCopy(), docTwo.DeepCopy()),
+		"cluster-a": newFakeDynamicClient(docOne.DeepCopy(), docTwo.DeepCopy()),
+	}
+
+	loadConfigFunc = func(contextName string) (*rest.Config, string, error) {
+		if contextName == "" {
+			return &rest.Config{Host: "hub"}, "hub-context", nil
+		}
+		return &rest.Config{Host: contextName}, contextName, nil
+	}
+	dynamicClientForConfig = func(cfg *rest.Config) (dynamic.Interface, error) {
+		client, ok := dynamicClients[cfg.Host]
+		if !ok {
+			return nil, fmt.Errorf("no dynamic client for host %s", cfg.Host)
+		}
+		return client, nil
+	}
+	kubernetesClientForConfig = func(cfg *rest.Config) (kubernetes.Interface, error) {
+		return kubefake.NewSimpleClientset(), nil
+	}
+
+	testCases := []struct {
+		name     string
+		opts     statusOptions
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "all",
+			opts:     statusOptions{namespace: namespace, all: true},
+			expected: []string{"DocumentDB: " + namespace + "/documentdb-one", "DocumentDB: " + namespace + "/documentdb-two"},
+		},
+		{
+			name:     "selector",
+			opts:     statusOptions{namespace: namespace, selector: "team=search"},
+			expected: []string{"DocumentDB: " + namespace + "/documentdb-two"},
+			absent:   []string{"documentdb-one"},
+		},
+	}
+
+	for _, tc := range testCases {
+		cmd := &cobra.Command{}
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+
+		if err := tc.opts.run(context.Background(), cmd); err != nil {
+			t.Fatalf("case %q: run returned error: %v", tc.name, err)
+		}
+
+		output := stdout.String()
+		for _, substring := range tc.expected {
+			if !strings.Contains(output, substring) {
+				t.Fatalf("case %q: expected output to contain %q, got: %s", tc.name, substring, output)
+			}
+		}
+		for _, substring := range tc.absent {
+			if strings.Contains(output, substring) {
+				t.Fatalf("case %q: expected output not to contain %q, got: %s", tc.name, substring, output)
+			}
+		}
+	}
+}
+
+func TestStatusRunStructuredOutput(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "documentdb-sample"
+
+	hubDoc := newDocument(docName, namespace, "cluster-a", "Cluster in healthy state")
+	clusterList := []interface{}{
+		map[string]interface{}{"name": "cluster-a"},
+		map[string]interface{}{"name": "cluster-unreachable"},
+	}
+	if err := unstructured.SetNestedSlice(hubDoc.Object, clusterList, "spec", "clusterReplication", "clusterList"); err != nil {
+		t.Fatalf("failed to set clusterList: %v", err)
+	}
+	clusterADoc := newDocument(docName, namespace, "cluster-a", "Cluster in healthy state")
+
+	dynamicClients := map[string]dynamic.Interface{
+		"hub":       newFakeDynamicClient(hubDoc.DeepCopy()),
+		"cluster-a": newFakeDynamicClient(clusterADoc.DeepCopy()),
+	}
+	loadConfigFunc = func(contextName string) (*rest.Config, string, error) {
+		if contextName == "" {
+			return &rest.Config{Host: "hub"}, "hub-context", nil
+		}
+		if _, ok := dynamicClients[contextName]; ok {
+			return &rest.Config{Host: contextName}, contextName, nil
+		}
+		return nil, "", fmt.Errorf("unknown context %q", contextName)
+	}
+	dynamicClientForConfig = func(cfg *rest.Config) (dynamic.Interface, error) {
+		return dynamicClients[cfg.Host], nil
+	}
+	kubernetesClientForConfig = func(cfg *rest.Config) (kubernetes.Interface, error) {
+		return kubefake.NewSimpleClientset(), nil
+	}
+
+	decoders := map[string]func([]byte, any) error{
+		statusOutputJSON: json.Unmarshal,
+		statusOutputYAML: func(data []byte, v any) error { return yaml.Unmarshal(data, v) },
+	}
+	for output, decode := range decoders {
+		t.Run(output, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			var stdout bytes.Buffer
+			cmd.SetOut(&stdout)
+
+			opts := &statusOptions{documentDBName: docName, namespace: namespace, output: output}
+			if err := opts.complete(); err != nil {
+				t.Fatalf("complete returned error: %v", err)
+			}
+			if err := opts.run(context.Background(), cmd); err != nil {
+				t.Fatalf("run returned error: %v", err)
+			}
+			if output == statusOutputJSON && !json.Valid(stdout.Bytes()) {
+				t.Fatalf("expected valid JSON, got: %s", stdout.String())
+			}
+
+			var status struct {
+				Name           string `json:"name"`
+				PrimaryCluster string `json:"primaryCluster"`
+				Status         string `json:"status"`
+				Clusters       []struct {
+					Cluster   string `json:"cluster"`
+					Context   string `json:"context"`
+					Role      string `json:"role"`
+					Phase     string `json:"phase"`
+					PodsReady int    `json:"podsReady"`
+					PodsTotal int    `json:"podsTotal"`
+					ServiceIP string `json:"serviceIP"`
+					Error     string `json:"error"`
+				} `json:"clusters"`
+			}
+			if err := decode(stdout.Bytes(), &status); err != nil {
+				t.Fatalf("failed to decode %s output: %v\n%s", output, err, stdout.String())
+			}
+
+			if status.Name != docName || status.PrimaryCluster != "cluster-a" || status.Status != "Cluster in healthy state" {
+				t.Errorf("unexpected document status: %+v", status)
+			}
+			if len(status.Clusters) != 2 {
+				t.Fatalf("expected 2 clusters, got %+v", status.Clusters)
+			}
+			primary, replica := status.Clusters[0], status.Clusters[1]
+			if primary.Cluster != "cluster-a" || primary.Role != "Primary" || primary.Phase != "Cluster in healthy state" || primary.Error != "" {
+				t.Errorf("unexpected primary cluster status: %+v", primary)
+			}
+			if replica.Cluster != "cluster-unreachable" || replica.Role != "Replica" || replica.Phase != "Unknown" {
+				t.Errorf("unexpected replica cluster status: %+v", replica)
+			}
+			if !strings.Contains(replica.Error, `unknown context "cluster-unreachable"`) {
+				t.Errorf("expected the error to be rendered as a string, got %q", replica.Error)
+			}
+			if strings.Contains(stdout.String(), "Tip:") {
+				t.Errorf("structured output must not contain the table tip, got: %s", stdout.String())
+			}
+		})
+	}
+}
+
+func TestStatusOptionsRejectsUnknownOutput(t *testing.T) {
+	opts := &statusOptions{documentDBName: "documentdb-sample", output: "wide"}
+	if err := opts.complete(); err == nil {
+		t.Fatal("expected an error for an unsupported output format")
+	}
+}
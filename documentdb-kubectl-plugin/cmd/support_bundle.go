@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	defaultOperatorNamespace = "documentdb-operator"
+
+	// operatorContainerName is the container of the operator Deployment installed by the Helm chart
+	operatorContainerName = "documentdb-operator"
+
+	// redactedValue replaces the values of the Secrets included in a support bundle
+	redactedValue = "REDACTED"
+
+	// lastAppliedAnnotation holds the full manifest of objects applied with kubectl, including Secret data
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+type supportBundleOptions struct {
+	documentDBName    string
+	namespace         string
+	kubeContext       string
+	operatorNamespace string
+	output            string
+	logTailLines      int64
+}
+
+// supportBundle holds the files of a support bundle, keyed by their path in the bundle.
+type supportBundle struct {
+	files map[string][]byte
+	// errors lists what could not be collected, so a partial bundle still explains its gaps
+	errors []string
+}
+
+func newSupportBundleCommand() *cobra.Command {
+	opts := &supportBundleOptions{
+		namespace:         defaultDocumentDBNamespace,
+		operatorNamespace: defaultOperatorNamespace,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "support-bundle",
+		Short: "Collect the resources, events and operator logs of a DocumentDB for troubleshooting",
+		Long: `Collect the DocumentDB resource, its CNPG Cluster, pods, Secrets (with their values redacted), events and the
+operator logs from every member cluster of the DocumentDB. The bundle is written as a .tar.gz archive, or as a
+directory when --output does not end in .tar.gz or .tgz.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.complete(time.Now()); err != nil {
+				return err
+			}
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.documentDBName, "documentdb", opts.documentDBName, "Name of the DocumentDB resource")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", opts.namespace, "Namespace containing the DocumentDB resource")
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().StringVar(&opts.operatorNamespace, "operator-namespace", opts.operatorNamespace, "Namespace the DocumentDB operator runs in")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", opts.output, "Path of the bundle (defaults to documentdb-support-bundle-<name>-<timestamp>.tar.gz)")
+	cmd.Flags().Int64Var(&opts.logTailLines, "log-tail", 5000, "Number of operator log lines to collect per container")
+
+	_ = cmd.MarkFlagRequired("documentdb")
+
+	return cmd
+}
+
+func (o *supportBundleOptions) complete(now time.Time) error {
+	o.documentDBName = strings.TrimSpace(o.documentDBName)
+	if o.documentDBName == "" {
+		return errors.New("--documentdb is required")
+	}
+	o.namespace = strings.TrimSpace(o.namespace)
+	if o.namespace == "" {
+		o.namespace = defaultDocumentDBNamespace
+	}
+	o.operatorNamespace = strings.TrimSpace(o.operatorNamespace)
+	if o.operatorNamespace == "" {
+		o.operatorNamespace = defaultOperatorNamespace
+	}
+	o.output = strings.TrimSpace(o.output)
+	if o.output == "" {
+		o.output = fmt.Sprintf("documentdb-support-bundle-%s-%s.tar.gz", o.documentDBName, now.UTC().Format("20060102-150405"))
+	}
+	if o.logTailLines <= 0 {
+		o.logTailLines = 5000
+	}
+	return nil
+}
+
+func (o *supportBundleOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	bundle, err := o.collect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if isArchivePath(o.output) {
+		err = bundle.writeArchive(o.output)
+	} else {
+		err = bundle.writeDirectory(o.output)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Support bundle for DocumentDB %s/%s written to %s\n", o.namespace, o.documentDBName, o.output)
+	if len(bundle.errors) > 0 {
+		fmt.Fprintf(cmd.OutOrStdout(), "%d item(s) could not be collected; see errors.txt in the bundle.\n", len(bundle.errors))
+	}
+	return nil
+}
+
+// collect gathers the bundle from the selected context and, for a replicated DocumentDB, from the context of every
+// member cluster. Only a failure to read the DocumentDB from the selected context aborts the collection.
+func (o *supportBundleOptions) collect(ctx context.Context) (*supportBundle, error) {
+	config, contextName, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	if contextName == "" {
+		contextName = "current"
+	}
+
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	gvr := schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}
+	document, err := dynClient.Resource(gvr).Namespace(o.namespace).Get(ctx, o.documentDBName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DocumentDB %q in namespace %q: %w", o.documentDBName, o.namespace, err)
+	}
+
+	bundle := &supportBundle{files: map[string][]byte{}}
+	bundle.addYAML("documentdb.yaml", document.Object)
+
+	members, err := memberClusterNames(document)
+	if err != nil {
+		bundle.addError("documentdb.yaml", err)
+	}
+	if len(members) == 0 {
+		// Without replication the CNPG Cluster is named after the DocumentDB and lives in the selected context
+		o.collectContext(ctx, bundle, contextName, o.kubeContext, o.documentDBName)
+	}
+	for _, member := range members {
+		// Replicated deployments name each CNPG Cluster and kubeconfig context after the member cluster
+		o.collectContext(ctx, bundle, member, member, member)
+	}
+
+	if len(bundle.errors) > 0 {
+		bundle.files["errors.txt"] = []byte(strings.Join(bundle.errors, "\n") + "\n")
+	}
+	return bundle, nil
+}
+
+// memberClusterNames returns the names in spec.clusterReplication.clusterList, or nil when the DocumentDB is not
+// replicated.
+func memberClusterNames(document *unstructured.Unstructured) ([]string, error) {
+	clusterList, _, err := unstructured.NestedSlice(document.Object, "spec", "clusterReplication", "clusterList")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec.clusterReplication.clusterList: %w", err)
+	}
+	names := make([]string, 0, len(clusterList))
+	for _, entry := range clusterList {
+		if cluster, ok := entry.(map[string]any); ok {
+			if name, ok := cluster["name"].(string); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// collectContext adds the resources of one member cluster under contexts/<dirName>/.
+func (o *supportBundleOptions) collectContext(ctx context.Context, bundle *supportBundle, dirName, kubeContext, cnpgClusterName string) {
+	dir := "contexts/" + dirName + "/"
+
+	config, _, err := loadConfigFunc(kubeContext)
+	if err != nil {
+		bundle.addError(dir, fmt.Errorf("load kubeconfig: %w", err))
+		return
+	}
+	dynClient, err := dynamicClientForConfig(config)
+	if err != nil {
+		bundle.addError(dir, fmt.Errorf("dynamic client: %w", err))
+		return
+	}
+	clientset, err := kubernetesClientForConfig(config)
+	if err != nil {
+		bundle.addError(dir, fmt.Errorf("kubernetes clientset: %w", err))
+		return
+	}
+
+	o.collectObject(ctx, bundle, dir+"documentdb.yaml", dynClient,
+		schema.GroupVersionResource{Group: documentDBGVRGroup, Version: documentDBGVRVersion, Resource: documentDBGVRResource}, o.documentDBName)
+	o.collectObject(ctx, bundle, dir+"cnpg-cluster.yaml", dynClient,
+		schema.GroupVersionResource{Group: cnpgClusterGVRGroup, Version: cnpgClusterGVRVersion, Resource: cnpgClusterGVRResource}, cnpgClusterName)
+
+	pods, err := clientset.CoreV1().Pods(o.namespace).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", cnpgClusterLabel, cnpgClusterName)})
+	if err != nil {
+		bundle.addError(dir+"pods.yaml", err)
+	} else {
+		bundle.addYAML(dir+"pods.yaml", pods)
+	}
+
+	events, err := clientset.CoreV1().Events(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		bundle.addError(dir+"events.yaml", err)
+	} else {
+		bundle.addYAML(dir+"events.yaml", events)
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(o.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		bundle.addError(dir+"secrets.yaml", err)
+	} else {
+		redactSecrets(secrets)
+		bundle.addYAML(dir+"secrets.yaml", secrets)
+	}
+
+	o.collectOperatorLogs(ctx, bundle, dir+"operator-logs/", clientset)
+}
+
+// collectObject adds the named object of the given resource in the DocumentDB's namespace.
+func (o *supportBundleOptions) collectObject(ctx context.Context, bundle *supportBundle, path string, dyn dynamic.Interface, gvr schema.GroupVersionResource, name string) {
+	obj, err := dyn.Resource(gvr).Namespace(o.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		bundle.addError(path, err)
+		return
+	}
+	bundle.addYAML(path, obj.Object)
+}
+
+// collectOperatorLogs adds the logs of the operator containers running in the operator namespace.
+func (o *supportBundleOptions) collectOperatorLogs(ctx context.Context, bundle *supportBundle, dir string, clientset kubernetes.Interface) {
+	pods, err := clientset.CoreV1().Pods(o.operatorNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		bundle.addError(dir, fmt.Errorf("list operator pods: %w", err))
+		return
+	}
+
+	found := false
+	for _, pod := range pods.Items {
+		for _, container := range pod.Spec.Containers {
+			if container.Name != operatorContainerName {
+				continue
+			}
+			found = true
+			path := dir + pod.Name + ".log"
+			logs, err := clientset.CoreV1().Pods(o.operatorNamespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container: container.Name,
+				TailLines: &o.logTailLines,
+			}).DoRaw(ctx)
+			if err != nil {
+				bundle.addError(path, err)
+				continue
+			}
+			bundle.files[path] = logs
+		}
+	}
+	if !found {
+		bundle.addError(dir, fmt.Errorf("no operator pods found in namespace %q", o.operatorNamespace))
+	}
+}
+
+// redactSecrets replaces every Secret value, keeping the keys, and drops the last applied configuration, which
+// would otherwise repeat the values.
+func redactSecrets(secrets *corev1.SecretList) {
+	for idx := range secrets.Items {
+		secret := &secrets.Items[idx]
+		for key := range secret.Data {
+			secret.Data[key] = []byte(redactedValue)
+		}
+		for key := range secret.StringData {
+			secret.StringData[key] = redactedValue
+		}
+		delete(secret.Annotations, lastAppliedAnnotation)
+	}
+}
+
+func (b *supportBundle) addYAML(path string, obj any) {
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		b.addError(path, err)
+		return
+	}
+	b.files[path] = data
+}
+
+func (b *supportBundle) addError(path string, err error) {
+	b.errors = append(b.errors, fmt.Sprintf("%s: %v", path, err))
+}
+
+// paths returns the paths of the bundle files in a stable order.
+func (b *supportBundle) paths() []string {
+	paths := make([]string, 0, len(b.files))
+	for path := range b.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (b *supportBundle) writeDirectory(dir string) error {
+	for _, path := range b.paths() {
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, b.files[path], 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeArchive writes the bundle as a gzipped tarball whose files are nested under a directory named after it.
+func (b *supportBundle) writeArchive(path string) (err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	root := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".tgz"), ".tar.gz")
+	modTime := time.Now()
+	for _, name := range b.paths() {
+		data := b.files[name]
+		if err := tw.WriteHeader(&tar.Header{Name: root + "/" + name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func isArchivePath(path string) bool {
+	return strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz")
+}
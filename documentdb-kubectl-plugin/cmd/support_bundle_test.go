@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestSupportBundleCollectsMemberClusters(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+		kubernetesClientForConfig = prevKube
+	}()
+
+	namespace := defaultDocumentDBNamespace
+	docName := "documentdb-sample"
+
+	doc := newDocument(docName, namespace, "cluster-a", "Ready")
+	clusterList := []any{
+		map[string]any{"name": "cluster-a"},
+		map[string]any{"name": "cluster-b"},
+	}
+	if err := unstructured.SetNestedSlice(doc.Object, clusterList, "spec", "clusterReplication", "clusterList"); err != nil {
+		t.Fatalf("failed to set clusterList: %v", err)
+	}
+
+	dynamicClients := map[string]dynamic.Interface{
+		"hub":       newFakeDynamicClient(doc.DeepCopy()),
+		"cluster-a": newFakeDynamicClient(doc.DeepCopy(), newCNPGCluster("cluster-a", namespace, 3, 3, cnpgHealthyPhase)),
+		"cluster-b": newFakeDynamicClient(doc.DeepCopy(), newCNPGCluster("cluster-b", namespace, 3, 3, cnpgHealthyPhase)),
+	}
+	kubeClients := map[string]kubernetes.Interface{
+		"cluster-a": newSupportBundleClientset(namespace, "cluster-a"),
+		"cluster-b": newSupportBundleClientset(namespace, "cluster-b"),
+	}
+
+	loadConfigFunc = func(contextName string) (*rest.Config, string, error) {
+		if contextName == "" {
+			return &rest.Config{Host: "hub"}, "hub", nil
+		}
+		if _, ok := dynamicClients[contextName]; ok {
+			return &rest.Config{Host: contextName}, contextName, nil
+		}
+		return nil, "", fmt.Errorf("unknown context %q", contextName)
+	}
+	dynamicClientForConfig = func(cfg *rest.Config) (dynamic.Interface, error) {
+		client, ok := dynamicClients[cfg.Host]
+		if !ok {
+			return nil, fmt.Errorf("no dynamic client for host %s", cfg.Host)
+		}
+		return client, nil
+	}
+	kubernetesClientForConfig = func(cfg *rest.Config) (kubernetes.Interface, error) {
+		client, ok := kubeClients[cfg.Host]
+		if !ok {
+			return nil, fmt.Errorf("no kubernetes client for host %s", cfg.Host)
+		}
+		return client, nil
+	}
+
+	expected := []string{"documentdb.yaml"}
+	for _, member := range []string{"cluster-a", "cluster-b"} {
+		for _, file := range []string{"documentdb.yaml", "cnpg-cluster.yaml", "pods.yaml", "events.yaml", "secrets.yaml", "operator-logs/documentdb-operator-0.log"} {
+			expected = append(expected, "contexts/"+member+"/"+file)
+		}
+	}
+	sort.Strings(expected)
+
+	for _, output := range []string{"bundle", "bundle.tar.gz"} {
+		t.Run(output, func(t *testing.T) {
+			opts := &supportBundleOptions{documentDBName: docName, output: filepath.Join(t.TempDir(), output)}
+			if err := opts.complete(time.Now()); err != nil {
+				t.Fatalf("complete returned error: %v", err)
+			}
+
+			cmd := &cobra.Command{}
+			buf := &bytes.Buffer{}
+			cmd.SetOut(buf)
+			if err := opts.run(context.Background(), cmd); err != nil {
+				t.Fatalf("run returned error: %v", err)
+			}
+			if strings.Contains(buf.String(), "could not be collected") {
+				t.Fatalf("expected a complete bundle, got output %q", buf.String())
+			}
+
+			files := readSupportBundle(t, opts.output)
+			paths := make([]string, 0, len(files))
+			for path := range files {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			if strings.Join(paths, ",") != strings.Join(expected, ",") {
+				t.Fatalf("expected bundle files %v, got %v", expected, paths)
+			}
+
+			cnpgCluster := string(files["contexts/cluster-b/cnpg-cluster.yaml"])
+			if !strings.Contains(cnpgCluster, "name: cluster-b") {
+				t.Fatalf("expected the CNPG Cluster of cluster-b, got:\n%s", cnpgCluster)
+			}
+			pods := string(files["contexts/cluster-a/pods.yaml"])
+			if !strings.Contains(pods, "name: cluster-a-1") || strings.Contains(pods, "unrelated") {
+				t.Fatalf("expected only the instance pods of cluster-a, got:\n%s", pods)
+			}
+			if !strings.Contains(string(files["contexts/cluster-a/events.yaml"]), "Switchover completed") {
+				t.Fatal("expected events of the namespace in the bundle")
+			}
+
+			secrets := string(files["contexts/cluster-a/secrets.yaml"])
+			for _, leaked := range []string{"s3cr3t", "c3Ny", "czNjcjN0"} {
+				if strings.Contains(secrets, leaked) {
+					t.Fatalf("expected secret values to be redacted, found %q in:\n%s", leaked, secrets)
+				}
+			}
+			if !strings.Contains(secrets, "password:") || strings.Contains(secrets, lastAppliedAnnotation) {
+				t.Fatalf("expected secret keys without the last applied configuration, got:\n%s", secrets)
+			}
+		})
+	}
+}
+
+func TestSupportBundleRequiresDocumentDB(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevDynamic := dynamicClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		dynamicClientForConfig = prevDynamic
+	}()
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{}, "hub", nil
+	}
+	dynamicClientForConfig = func(*rest.Config) (dynamic.Interface, error) {
+		return newFakeDynamicClient(), nil
+	}
+
+	output := filepath.Join(t.TempDir(), "bundle")
+	opts := &supportBundleOptions{documentDBName: "missing", namespace: defaultDocumentDBNamespace, output: output}
+	if err := opts.run(context.Background(), &cobra.Command{}); err == nil {
+		t.Fatal("expected error when the DocumentDB does not exist")
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Fatalf("expected no bundle to be written, stat returned %v", err)
+	}
+}
+
+func newSupportBundleClientset(namespace, cnpgClusterName string) kubernetes.Interface {
+	return kubefake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      cnpgClusterName + "-1",
+			Namespace: namespace,
+			Labels:    map[string]string{cnpgClusterLabel: cnpgClusterName},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "unrelated",
+			Namespace: namespace,
+		}},
+		&corev1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "switchover", Namespace: namespace},
+			Message:    "Switchover completed",
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "documentdb-credentials",
+				Namespace:   namespace,
+				Annotations: map[string]string{lastAppliedAnnotation: `{"stringData":{"password":"s3cr3t"}}`},
+			},
+			Data: map[string][]byte{"password": []byte("s3cr3t")},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "documentdb-operator-0", Namespace: defaultOperatorNamespace},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: operatorContainerName}}},
+		},
+	)
+}
+
+// readSupportBundle returns the files of a bundle written as a directory or archive, keyed by their bundle path.
+func readSupportBundle(t *testing.T, output string) map[string][]byte {
+	t.Helper()
+
+	files := map[string][]byte{}
+	if !isArchivePath(output) {
+		err := filepath.WalkDir(output, func(path string, entry os.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(output, path)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			files[filepath.ToSlash(rel)] = data
+			return err
+		})
+		if err != nil {
+			t.Fatalf("failed to read bundle directory: %v", err)
+		}
+		return files
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatalf("failed to open bundle: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("failed to read bundle: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	root := strings.TrimSuffix(filepath.Base(output), ".tar.gz") + "/"
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read bundle entry: %v", err)
+		}
+		if !strings.HasPrefix(header.Name, root) {
+			t.Fatalf("expected bundle entries under %q, got %q", root, header.Name)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("failed to read bundle entry %q: %v", header.Name, err)
+		}
+		files[strings.TrimPrefix(header.Name, root)] = data
+	}
+	return files
+}
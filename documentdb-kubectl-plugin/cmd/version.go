@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/discovery"
+)
+
+// version is set at build time with -ldflags "-X github.com/documentdb/documentdb-operator/documentdb-kubectl-plugin/cmd.version=<version>".
+var version = ""
+
+type versionOptions struct {
+	kubeContext string
+	clientOnly  bool
+}
+
+func newVersionCommand() *cobra.Command {
+	opts := &versionOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the plugin version and the DocumentDB API version served by the cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd.Context(), cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.kubeContext, "context", opts.kubeContext, "Kubeconfig context to use (defaults to current context)")
+	cmd.Flags().BoolVar(&opts.clientOnly, "client", false, "Only print the plugin version, without contacting the cluster")
+
+	return cmd
+}
+
+func (o *versionOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Plugin version: %s\n", pluginVersion())
+	if o.clientOnly {
+		return nil
+	}
+
+	config, _, err := loadConfigFunc(o.kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	clientset, err := kubernetesClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+
+	versions, err := documentDBAPIVersions(clientset.Discovery())
+	if err != nil {
+		return fmt.Errorf("failed to discover the DocumentDB API: %w", err)
+	}
+	if len(versions) == 0 {
+		fmt.Fprintln(out, "DocumentDB CRD: not installed")
+		return nil
+	}
+	fmt.Fprintf(out, "DocumentDB CRD: %s\n", strings.Join(versions, ", "))
+	return nil
+}
+
+// pluginVersion returns the version set at build time, falling back to the module version for `go install` builds.
+func pluginVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+// documentDBAPIVersions returns the group versions served for the DocumentDB API group, preferred version first.
+func documentDBAPIVersions(client discovery.DiscoveryInterface) ([]string, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups.Groups {
+		if group.Name != documentDBGVRGroup {
+			continue
+		}
+		versions := []string{group.PreferredVersion.GroupVersion}
+		for _, v := range group.Versions {
+			if v.GroupVersion != group.PreferredVersion.GroupVersion {
+				versions = append(versions, v.GroupVersion)
+			}
+		}
+		return versions, nil
+	}
+	return nil, nil
+}
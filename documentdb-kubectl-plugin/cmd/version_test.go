@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+func TestVersionRunPrintsPluginAndCRDVersions(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevKube := kubernetesClientForConfig
+	prevVersion := version
+	defer func() {
+		loadConfigFunc = prevLoad
+		kubernetesClientForConfig = prevKube
+		version = prevVersion
+	}()
+
+	version = "v0.1.2"
+	clientset := kubefake.NewSimpleClientset()
+	clientset.Discovery().(*discoveryfake.FakeDiscovery).Resources = []*metav1.APIResourceList{documentDBAPIResources(), certManagerAPIResources()}
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "cluster"}, "cluster", nil
+	}
+	kubernetesClientForConfig = func(*rest.Config) (kubernetes.Interface, error) {
+		return clientset, nil
+	}
+
+	cmd := &cobra.Command{}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	if err := (&versionOptions{}).run(context.Background(), cmd); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+
+	output := stdout.String()
+	for _, expected := range []string{"Plugin version: v0.1.2", "DocumentDB CRD: documentdb.io/preview"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestVersionRunReportsMissingCRD(t *testing.T) {
+	prevLoad := loadConfigFunc
+	prevKube := kubernetesClientForConfig
+	defer func() {
+		loadConfigFunc = prevLoad
+		kubernetesClientForConfig = prevKube
+	}()
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		return &rest.Config{Host: "cluster"}, "cluster", nil
+	}
+	kubernetesClientForConfig = func(*rest.Config) (kubernetes.Interface, error) {
+		return kubefake.NewSimpleClientset(), nil
+	}
+
+	cmd := &cobra.Command{}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	if err := (&versionOptions{}).run(context.Background(), cmd); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "DocumentDB CRD: not installed") {
+		t.Errorf("expected the CRD to be reported missing, got:\n%s", stdout.String())
+	}
+}
+
+func TestVersionRunClientOnlySkipsCluster(t *testing.T) {
+	prevLoad := loadConfigFunc
+	defer func() { loadConfigFunc = prevLoad }()
+
+	loadConfigFunc = func(string) (*rest.Config, string, error) {
+		t.Fatal("--client must not load the kubeconfig")
+		return nil, "", nil
+	}
+
+	cmd := &cobra.Command{}
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+
+	if err := (&versionOptions{clientOnly: true}).run(context.Background(), cmd); err != nil {
+		t.Fatalf("run returned error: %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "Plugin version: ") {
+		t.Errorf("expected the plugin version, got:\n%s", stdout.String())
+	}
+}
@@ -9,9 +9,12 @@ import (
 	"github.com/cloudnative-pg/cnpg-i/pkg/operator"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
+	"github.com/documentdb/cnpg-i-sidecar-injector/internal/healthcheck"
 	"github.com/documentdb/cnpg-i-sidecar-injector/internal/identity"
 	lifecycleImpl "github.com/documentdb/cnpg-i-sidecar-injector/internal/lifecycle"
 	operatorImpl "github.com/documentdb/cnpg-i-sidecar-injector/internal/operator"
@@ -19,10 +22,13 @@ import (
 
 // NewCmd creates the `plugin` command
 func NewCmd() *cobra.Command {
+	healthServer := health.NewServer()
+
 	cmd := http.CreateMainCmd(identity.Implementation{}, func(server *grpc.Server) error {
 		// Register the declared implementations
 		operator.RegisterOperatorServer(server, operatorImpl.Implementation{})
 		lifecycle.RegisterOperatorLifecycleServer(server, lifecycleImpl.Implementation{})
+		grpc_health_v1.RegisterHealthServer(server, healthServer)
 		return nil
 	})
 
@@ -30,7 +36,21 @@ func NewCmd() *cobra.Command {
 	// with logr.NewContext(ctx, logger) and pass it to cmd.SetContext(ctx)
 	log.SetLogger(zap.New(zap.UseDevMode(true)))
 
-	// Additional custom behaviour can be added by wrapping cmd.PersistentPreRun or cmd.Run
+	// Serve the health status over plain HTTP for Kubernetes probes alongside the gRPC server
+	cmd.Flags().String("health-address", healthcheck.DefaultAddress,
+		"The address where to serve the HTTP health endpoint (empty to disable)")
+	run := cmd.RunE
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if address, _ := cmd.Flags().GetString("health-address"); address != "" {
+			go func() {
+				if err := healthcheck.Serve(cmd.Context(), address, healthServer); err != nil {
+					log.FromContext(cmd.Context()).Error(err, "Health endpoint stopped")
+				}
+			}()
+		}
+		defer healthServer.Shutdown()
+		return run(cmd, args)
+	}
 
 	cmd.Use = "plugin"
 
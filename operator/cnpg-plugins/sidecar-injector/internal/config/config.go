@@ -5,11 +5,18 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/common"
 	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/validation"
 	"github.com/cloudnative-pg/cnpg-i/pkg/operator"
+	corev1 "k8s.io/api/core/v1"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
 )
 
 const (
@@ -17,14 +24,74 @@ const (
 	annotationParameter                 = "annotations"
 	gatewayImageParameter               = "gatewayImage"
 	documentDbCredentialSecretParameter = "documentDbCredentialSecret"
+	gatewayTLSSecretParameter           = "gatewayTLSSecret"
+	gatewayContainerNameParameter       = "gatewayContainerName"
+	gatewayRunAsUserParameter           = "gatewayRunAsUser"
+	gatewayRunAsGroupParameter          = "gatewayRunAsGroup"
+	gatewayImagePullPolicyParameter     = "gatewayImagePullPolicy"
+	gatewayImagePullSecretsParameter    = "gatewayImagePullSecrets"
+	gatewayExtraVolumesParameter        = "gatewayExtraVolumes"
+	gatewayExtraArgsParameter           = "gatewayExtraArgs"
+	gatewayLogLevelParameter            = "gatewayLogLevel"
+	pgPortParameter                     = "pgPort"
+	pgHostParameter                     = "pgHost"
+	gatewayMetricsPortParameter         = "gatewayMetricsPort"
+	otelEndpointParameter               = "otelEndpoint"
+	disableOtelParameter                = "disableOtel"
+
+	// DefaultGatewayContainerName is the name of the injected gateway container
+	DefaultGatewayContainerName = "documentdb-gateway"
+	// DefaultGatewayRunAsID is the user and group ID the gateway runs as by default
+	DefaultGatewayRunAsID int64 = 1000
+
+	// DefaultPgPort is the Postgres port the gateway connects to by default
+	DefaultPgPort = 5432
+
+	// GatewayPort is the port the gateway serves the MongoDB wire protocol on
+	GatewayPort = 10260
+
+	// DefaultOtelEndpoint is the OTLP endpoint used when otelEndpoint is not set
+	DefaultOtelEndpoint = "http://localhost:4412"
 )
 
+// GatewayLogLevelFlag is the gateway flag setting its log verbosity
+const GatewayLogLevelFlag = "--log-level"
+
+// gatewayLogLevels are the log levels accepted by the gateway
+var gatewayLogLevels = []string{"error", "warn", "info", "debug", "trace"}
+
+// managedGatewayFlags are the gateway flags set by the plugin itself, which
+// gatewayExtraArgs must not override
+var managedGatewayFlags = []string{"--create-user", "--start-pg", "--pg-port", "--pg-host", "--cert-path", "--key-file"}
+
+// ExtraVolume is a secret or config map mounted read-only into the gateway container
+type ExtraVolume struct {
+	Name          string `json:"name"`
+	MountPath     string `json:"mountPath"`
+	SecretName    string `json:"secretName,omitempty"`
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
 // Configuration represents the plugin configuration parameters
 type Configuration struct {
 	Labels                     map[string]string
 	Annotations                map[string]string
 	GatewayImage               string
 	DocumentDbCredentialSecret string
+	GatewayTLSSecret           string
+	GatewayContainerName       string
+	GatewayRunAsUser           *int64
+	GatewayRunAsGroup          *int64
+	GatewayImagePullPolicy     corev1.PullPolicy
+	GatewayImagePullSecrets    []string
+	GatewayExtraVolumes        []ExtraVolume
+	GatewayExtraArgs           []string
+	GatewayLogLevel            string
+	PgPort                     int
+	PgHost                     string
+	GatewayMetricsPort         int
+	OtelEndpoint               string
+	DisableOtel                bool
 }
 
 // FromParameters builds a plugin configuration from the configuration parameters
@@ -56,12 +123,158 @@ func FromParameters(
 	// Parse simple string parameters
 	gatewayImage := helper.Parameters[gatewayImageParameter]
 	credentialSecret := helper.Parameters[documentDbCredentialSecretParameter]
+	gatewayTLSSecret := helper.Parameters[gatewayTLSSecretParameter]
+	otelEndpoint := helper.Parameters[otelEndpointParameter]
+
+	gatewayContainerName := helper.Parameters[gatewayContainerNameParameter]
+	if gatewayContainerName != "" {
+		if errs := k8svalidation.IsDNS1123Label(gatewayContainerName); len(errs) > 0 {
+			validationErrors = append(
+				validationErrors,
+				validation.BuildErrorForParameter(helper, gatewayContainerNameParameter, strings.Join(errs, "; ")),
+			)
+		}
+	}
+
+	runAsUser, err := parseID(helper.Parameters[gatewayRunAsUserParameter])
+	if err != nil {
+		validationErrors = append(
+			validationErrors,
+			validation.BuildErrorForParameter(helper, gatewayRunAsUserParameter, err.Error()),
+		)
+	}
+	runAsGroup, err := parseID(helper.Parameters[gatewayRunAsGroupParameter])
+	if err != nil {
+		validationErrors = append(
+			validationErrors,
+			validation.BuildErrorForParameter(helper, gatewayRunAsGroupParameter, err.Error()),
+		)
+	}
+
+	var disableOtel bool
+	if helper.Parameters[disableOtelParameter] != "" {
+		parsed, err := strconv.ParseBool(helper.Parameters[disableOtelParameter])
+		if err != nil {
+			validationErrors = append(
+				validationErrors,
+				validation.BuildErrorForParameter(helper, disableOtelParameter, err.Error()),
+			)
+		}
+		disableOtel = parsed
+	}
+
+	gatewayImagePullPolicy := corev1.PullPolicy(helper.Parameters[gatewayImagePullPolicyParameter])
+	switch gatewayImagePullPolicy {
+	case "", corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		validationErrors = append(
+			validationErrors,
+			validation.BuildErrorForParameter(helper, gatewayImagePullPolicyParameter,
+				fmt.Sprintf("unsupported pull policy %q, must be one of Always, IfNotPresent or Never", gatewayImagePullPolicy)),
+		)
+	}
+
+	// Pull secrets are passed as a comma-separated list of secret names
+	var gatewayImagePullSecrets []string
+	for _, name := range strings.Split(helper.Parameters[gatewayImagePullSecretsParameter], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			gatewayImagePullSecrets = append(gatewayImagePullSecrets, name)
+		}
+	}
+
+	var gatewayExtraVolumes []ExtraVolume
+	if helper.Parameters[gatewayExtraVolumesParameter] != "" {
+		if err := json.Unmarshal([]byte(helper.Parameters[gatewayExtraVolumesParameter]), &gatewayExtraVolumes); err != nil {
+			validationErrors = append(
+				validationErrors,
+				validation.BuildErrorForParameter(helper, gatewayExtraVolumesParameter, err.Error()),
+			)
+		}
+		for _, volume := range gatewayExtraVolumes {
+			if err := volume.validate(); err != nil {
+				validationErrors = append(
+					validationErrors,
+					validation.BuildErrorForParameter(helper, gatewayExtraVolumesParameter, err.Error()),
+				)
+			}
+		}
+	}
+
+	// Extra args are separated by spaces or newlines
+	gatewayExtraArgs := strings.Fields(helper.Parameters[gatewayExtraArgsParameter])
+	for _, arg := range gatewayExtraArgs {
+		flag, _, _ := strings.Cut(arg, "=")
+		if slices.Contains(managedGatewayFlags, flag) {
+			validationErrors = append(
+				validationErrors,
+				validation.BuildErrorForParameter(helper, gatewayExtraArgsParameter,
+					fmt.Sprintf("flag %s is managed by the plugin and cannot be overridden", flag)),
+			)
+		}
+	}
+
+	gatewayLogLevel := helper.Parameters[gatewayLogLevelParameter]
+	if gatewayLogLevel != "" && !slices.Contains(gatewayLogLevels, gatewayLogLevel) {
+		validationErrors = append(
+			validationErrors,
+			validation.BuildErrorForParameter(helper, gatewayLogLevelParameter,
+				fmt.Sprintf("unsupported log level %q, must be one of %s", gatewayLogLevel, strings.Join(gatewayLogLevels, ", "))),
+		)
+	}
+
+	var pgPort int
+	if helper.Parameters[pgPortParameter] != "" {
+		parsed, err := strconv.Atoi(helper.Parameters[pgPortParameter])
+		if err == nil && (parsed < 1 || parsed > 65535) {
+			err = fmt.Errorf("port must be between 1 and 65535, got %d", parsed)
+		}
+		if err != nil {
+			validationErrors = append(
+				validationErrors,
+				validation.BuildErrorForParameter(helper, pgPortParameter, err.Error()),
+			)
+		}
+		pgPort = parsed
+	}
+
+	// The metrics port is optional, and only declared on the gateway container when set
+	var gatewayMetricsPort int
+	if helper.Parameters[gatewayMetricsPortParameter] != "" {
+		parsed, err := strconv.Atoi(helper.Parameters[gatewayMetricsPortParameter])
+		if err == nil && (parsed < 1 || parsed > 65535) {
+			err = fmt.Errorf("port must be between 1 and 65535, got %d", parsed)
+		}
+		if err == nil && parsed == GatewayPort {
+			err = fmt.Errorf("port %d is already used by the gateway", parsed)
+		}
+		if err != nil {
+			validationErrors = append(
+				validationErrors,
+				validation.BuildErrorForParameter(helper, gatewayMetricsPortParameter, err.Error()),
+			)
+		}
+		gatewayMetricsPort = parsed
+	}
 
 	configuration := &Configuration{
 		Labels:                     labels,
 		Annotations:                annotations,
 		GatewayImage:               gatewayImage,
 		DocumentDbCredentialSecret: credentialSecret,
+		GatewayTLSSecret:           gatewayTLSSecret,
+		GatewayContainerName:       gatewayContainerName,
+		GatewayRunAsUser:           runAsUser,
+		GatewayRunAsGroup:          runAsGroup,
+		GatewayImagePullPolicy:     gatewayImagePullPolicy,
+		GatewayImagePullSecrets:    gatewayImagePullSecrets,
+		GatewayExtraVolumes:        gatewayExtraVolumes,
+		GatewayExtraArgs:           gatewayExtraArgs,
+		GatewayLogLevel:            gatewayLogLevel,
+		PgPort:                     pgPort,
+		PgHost:                     helper.Parameters[pgHostParameter],
+		GatewayMetricsPort:         gatewayMetricsPort,
+		OtelEndpoint:               otelEndpoint,
+		DisableOtel:                disableOtel,
 	}
 
 	configuration.applyDefaults()
@@ -106,6 +319,24 @@ func (config *Configuration) applyDefaults() {
 	if config.DocumentDbCredentialSecret == "" {
 		config.DocumentDbCredentialSecret = "documentdb-credentials"
 	}
+	if config.GatewayContainerName == "" {
+		config.GatewayContainerName = DefaultGatewayContainerName
+	}
+	if config.GatewayRunAsUser == nil {
+		config.GatewayRunAsUser = ptr.To(DefaultGatewayRunAsID)
+	}
+	if config.GatewayRunAsGroup == nil {
+		config.GatewayRunAsGroup = ptr.To(DefaultGatewayRunAsID)
+	}
+	if config.GatewayImagePullPolicy == "" {
+		config.GatewayImagePullPolicy = corev1.PullAlways
+	}
+	if config.PgPort == 0 {
+		config.PgPort = DefaultPgPort
+	}
+	if config.OtelEndpoint == "" {
+		config.OtelEndpoint = DefaultOtelEndpoint
+	}
 }
 
 // ToParameters serialize the configuration to a map of plugin parameters
@@ -123,6 +354,71 @@ func (config *Configuration) ToParameters() (map[string]string, error) {
 	result[annotationParameter] = string(serializedAnnotations)
 	result[gatewayImageParameter] = config.GatewayImage
 	result[documentDbCredentialSecretParameter] = config.DocumentDbCredentialSecret
+	if config.GatewayTLSSecret != "" {
+		result[gatewayTLSSecretParameter] = config.GatewayTLSSecret
+	}
+	result[gatewayContainerNameParameter] = config.GatewayContainerName
+	if config.GatewayRunAsUser != nil {
+		result[gatewayRunAsUserParameter] = strconv.FormatInt(*config.GatewayRunAsUser, 10)
+	}
+	if config.GatewayRunAsGroup != nil {
+		result[gatewayRunAsGroupParameter] = strconv.FormatInt(*config.GatewayRunAsGroup, 10)
+	}
+	result[gatewayImagePullPolicyParameter] = string(config.GatewayImagePullPolicy)
+	if len(config.GatewayImagePullSecrets) > 0 {
+		result[gatewayImagePullSecretsParameter] = strings.Join(config.GatewayImagePullSecrets, ",")
+	}
+	if len(config.GatewayExtraVolumes) > 0 {
+		serializedExtraVolumes, err := json.Marshal(config.GatewayExtraVolumes)
+		if err != nil {
+			return nil, err
+		}
+		result[gatewayExtraVolumesParameter] = string(serializedExtraVolumes)
+	}
+	if len(config.GatewayExtraArgs) > 0 {
+		result[gatewayExtraArgsParameter] = strings.Join(config.GatewayExtraArgs, " ")
+	}
+	if config.GatewayLogLevel != "" {
+		result[gatewayLogLevelParameter] = config.GatewayLogLevel
+	}
+	result[pgPortParameter] = strconv.Itoa(config.PgPort)
+	if config.PgHost != "" {
+		result[pgHostParameter] = config.PgHost
+	}
+	if config.GatewayMetricsPort != 0 {
+		result[gatewayMetricsPortParameter] = strconv.Itoa(config.GatewayMetricsPort)
+	}
+	result[otelEndpointParameter] = config.OtelEndpoint
+	result[disableOtelParameter] = strconv.FormatBool(config.DisableOtel)
 
 	return result, nil
 }
+
+// validate checks that the extra volume can be mounted into the gateway
+func (volume ExtraVolume) validate() error {
+	if errs := k8svalidation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid volume name %q: %s", volume.Name, strings.Join(errs, "; "))
+	}
+	if volume.MountPath == "" {
+		return fmt.Errorf("volume %q must set mountPath", volume.Name)
+	}
+	if (volume.SecretName == "") == (volume.ConfigMapName == "") {
+		return fmt.Errorf("volume %q must set exactly one of secretName or configMapName", volume.Name)
+	}
+	return nil
+}
+
+// parseID parses an optional non-negative user or group ID
+func parseID(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if id < 0 {
+		return nil, fmt.Errorf("ID must not be negative, got %d", id)
+	}
+	return &id, nil
+}
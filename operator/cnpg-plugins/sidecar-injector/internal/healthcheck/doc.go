@@ -0,0 +1,6 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// Package healthcheck exposes the serving status of the plugin
+// over HTTP for Kubernetes probes
+package healthcheck
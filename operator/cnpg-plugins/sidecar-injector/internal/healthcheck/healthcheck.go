@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// DefaultAddress is the address the HTTP health endpoint listens on by default
+	DefaultAddress = ":8081"
+
+	// Path is the HTTP path of the health endpoint
+	Path = "/healthz"
+)
+
+// Handler returns an HTTP handler that reports whether the gRPC health server is serving.
+// It answers 200 when serving and 503 otherwise, so it can back liveness and readiness probes,
+// which cannot reach the plugin's mTLS-protected gRPC server directly.
+func Handler(healthServer grpc_health_v1.HealthServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, err := healthServer.Check(r.Context(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil || response.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+			http.Error(w, "not serving", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+}
+
+// Serve runs the HTTP health endpoint on the given address until the context is cancelled
+func Serve(ctx context.Context, address string, healthServer grpc_health_v1.HealthServer) error {
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler(healthServer))
+	server := &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to shut down health endpoint")
+		}
+	}()
+
+	log.FromContext(ctx).Info("Serving health endpoint", "address", address, "path", Path)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
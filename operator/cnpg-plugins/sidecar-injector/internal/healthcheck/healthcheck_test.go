@@ -0,0 +1,40 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHandlerReflectsServingStatus(t *testing.T) {
+	healthServer := health.NewServer()
+	handler := Handler(healthServer)
+
+	tests := []struct {
+		name     string
+		status   grpc_health_v1.HealthCheckResponse_ServingStatus
+		expected int
+	}{
+		{name: "serving", status: grpc_health_v1.HealthCheckResponse_SERVING, expected: http.StatusOK},
+		{name: "not serving", status: grpc_health_v1.HealthCheckResponse_NOT_SERVING, expected: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthServer.SetServingStatus("", tt.status)
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, Path, nil))
+
+			if recorder.Code != tt.expected {
+				t.Errorf("expected status code %d, got %d", tt.expected, recorder.Code)
+			}
+		})
+	}
+}
@@ -7,20 +7,30 @@ package lifecycle
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
+	apiv1 "github.com/cloudnative-pg/api/pkg/api/v1"
 	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/common"
 	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/decoder"
 	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/object"
 	"github.com/cloudnative-pg/cnpg-i/pkg/lifecycle"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/utils/pointer"
 
 	"github.com/documentdb/cnpg-i-sidecar-injector/internal/config"
 	"github.com/documentdb/cnpg-i-sidecar-injector/internal/utils"
 	"github.com/documentdb/cnpg-i-sidecar-injector/pkg/metadata"
 )
 
+const (
+	instanceRoleLabel           = "cnpg.io/instanceRole"
+	instanceRolePrimary         = "primary"
+	instanceRoleReplica         = "replica"
+	replicationClusterTypeLabel = "replication_cluster_type"
+)
+
 // Implementation is the implementation of the lifecycle handler
 type Implementation struct {
 	lifecycle.UnimplementedOperatorLifecycleServer
@@ -127,15 +137,34 @@ func (impl Implementation) reconcileMetadata(
 		return nil, err
 	}
 
+	mutatedPod, err := injectGateway(cluster, pod, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := object.CreatePatch(mutatedPod, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Generated patch: %s", string(patch))
+
+	return &lifecycle.OperatorLifecycleResponse{
+		JsonPatch: patch,
+	}, nil
+}
+
+// injectGateway returns a copy of the pod with the DocumentDB gateway sidecar
+// injected and the configured labels and annotations applied
+func injectGateway(
+	cluster *apiv1.Cluster,
+	pod *corev1.Pod,
+	configuration *config.Configuration,
+) (*corev1.Pod, error) {
 	mutatedPod := pod.DeepCopy()
 
 	// Initialize environment variables
-	envVars := []corev1.EnvVar{
-		{
-			Name:  "OTEL_EXPORTER_OTLP_ENDPOINT",
-			Value: "http://localhost:4412",
-		},
-	}
+	envVars := otelEnvVars(configuration)
 
 	// Add USERNAME and PASSWORD environment variables from secret defined in configuration
 	credentialSecretName := configuration.DocumentDbCredentialSecret
@@ -167,34 +196,36 @@ func (impl Implementation) reconcileMetadata(
 
 	// Initialize the sidecar container with configurable gateway image
 	sidecar := &corev1.Container{
-		Name:            "documentdb-gateway",
+		Name:            configuration.GatewayContainerName,
 		Image:           configuration.GatewayImage,
-		ImagePullPolicy: corev1.PullAlways,
+		ImagePullPolicy: configuration.GatewayImagePullPolicy,
 		Ports: []corev1.ContainerPort{
 			{
-				ContainerPort: 10260,
+				ContainerPort: config.GatewayPort,
 			},
 		},
 		Env: envVars,
 		SecurityContext: &corev1.SecurityContext{
-			RunAsUser:  pointer.Int64(1000),
-			RunAsGroup: pointer.Int64(1000),
+			RunAsUser:  configuration.GatewayRunAsUser,
+			RunAsGroup: configuration.GatewayRunAsGroup,
 		},
 	}
 
+	// Declare the metrics port so that it can be exposed and scraped
+	if configuration.GatewayMetricsPort != 0 {
+		sidecar.Ports = append(sidecar.Ports, corev1.ContainerPort{
+			Name:          "metrics",
+			ContainerPort: int32(configuration.GatewayMetricsPort),
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
 	// If TLS secret parameter provided, mount it at /tls
 	// Track whether TLS secret is configured to augment container args later
 	hasTLSSecret := false
-	if tlsSecret, ok := helper.Parameters["gatewayTLSSecret"]; ok && tlsSecret != "" {
+	if tlsSecret := configuration.GatewayTLSSecret; tlsSecret != "" {
 		// Append volume only if not already present
-		found := false
-		for _, v := range mutatedPod.Spec.Volumes {
-			if v.Name == "gateway-tls" {
-				found = true
-				break
-			}
-		}
-		if !found {
+		if !hasVolume(mutatedPod, "gateway-tls") {
 			mutatedPod.Spec.Volumes = append(mutatedPod.Spec.Volumes, corev1.Volume{
 				Name: "gateway-tls",
 				VolumeSource: corev1.VolumeSource{
@@ -216,28 +247,64 @@ func (impl Implementation) reconcileMetadata(
 		log.Printf("Injected TLS secret volume for gateway: %s", tlsSecret)
 	}
 
-	// Build base args and append TLS file args if a TLS secret is configured
-	args := []string{"--start-pg", "false", "--pg-port", "5432"}
-	// Check if the pod has the label replication_cluster_type=replica
+	// Mount any additional secrets or config maps, such as a custom CA bundle
+	for _, extraVolume := range configuration.GatewayExtraVolumes {
+		if !hasVolume(mutatedPod, extraVolume.Name) {
+			mutatedPod.Spec.Volumes = append(mutatedPod.Spec.Volumes, extraVolumeSource(extraVolume))
+		}
+		sidecar.VolumeMounts = append(sidecar.VolumeMounts, corev1.VolumeMount{
+			Name:      extraVolume.Name,
+			MountPath: extraVolume.MountPath,
+			ReadOnly:  true,
+		})
+		log.Printf("Injected extra volume %s for gateway at %s", extraVolume.Name, extraVolume.MountPath)
+	}
 
-	// Check if the pod has the label replication_cluster_type=replica or is not a local primary
-	if mutatedPod.Labels["replication_cluster_type"] == "replica" || cluster.Status.TargetPrimary != mutatedPod.Name {
-		args = append([]string{"--create-user", "false"}, args...)
-	} else {
-		args = append([]string{"--create-user", "true"}, args...)
+	// Build base args and append TLS file args if a TLS secret is configured
+	args := []string{"--start-pg", "false", "--pg-port", strconv.Itoa(configuration.PgPort)}
+	// Connect through the connection pooler instead of the local Postgres when one is configured
+	if configuration.PgHost != "" {
+		args = append(args, "--pg-host", configuration.PgHost)
 	}
+	args = append([]string{"--create-user", strconv.FormatBool(shouldCreateUser(cluster, mutatedPod))}, args...)
 	if hasTLSSecret {
 		// Pass cert and key via CLI args to align with emulator_entrypoint.sh interface
 		args = append(args, "--cert-path", "/tls/tls.crt", "--key-file", "/tls/tls.key")
 	}
+	// A log level passed in the extra args takes precedence over the one derived from the cluster
+	if configuration.GatewayLogLevel != "" && !hasFlag(configuration.GatewayExtraArgs, config.GatewayLogLevelFlag) {
+		args = append(args, config.GatewayLogLevelFlag, configuration.GatewayLogLevel)
+	}
+	args = append(args, configuration.GatewayExtraArgs...)
 	sidecar.Args = args
 
 	// Inject the sidecar container
-	err = object.InjectPluginSidecar(mutatedPod, sidecar, false)
-	if err != nil {
+	if err := object.InjectPluginSidecar(mutatedPod, sidecar, false); err != nil {
+		containerNames := make([]string, 0, len(mutatedPod.Spec.Containers))
+		for _, container := range mutatedPod.Spec.Containers {
+			containerNames = append(containerNames, container.Name)
+		}
+		err = fmt.Errorf("failed to inject gateway sidecar into pod %s/%s of cluster %s (existing containers: %v): %w",
+			mutatedPod.Namespace, mutatedPod.Name, cluster.Name, containerNames, err)
+		log.Print(err)
 		return nil, err
 	}
 
+	// Merge the gateway pull secrets with the ones already set on the pod
+	for _, secretName := range configuration.GatewayImagePullSecrets {
+		found := false
+		for _, ref := range mutatedPod.Spec.ImagePullSecrets {
+			if ref.Name == secretName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			mutatedPod.Spec.ImagePullSecrets = append(mutatedPod.Spec.ImagePullSecrets,
+				corev1.LocalObjectReference{Name: secretName})
+		}
+	}
+
 	for key, value := range configuration.Labels {
 		mutatedPod.Labels[key] = value
 	}
@@ -245,14 +312,87 @@ func (impl Implementation) reconcileMetadata(
 		mutatedPod.Annotations[key] = value
 	}
 
-	patch, err := object.CreatePatch(mutatedPod, pod)
-	if err != nil {
-		return nil, err
+	return mutatedPod, nil
+}
+
+// hasVolume reports whether the pod already defines a volume with the given name
+func hasVolume(pod *corev1.Pod, name string) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == name {
+			return true
+		}
 	}
+	return false
+}
 
-	log.Printf("Generated patch: %s", string(patch))
+// hasFlag reports whether args set the flag, either as a separate argument or as flag=value
+func hasFlag(args []string, flag string) bool {
+	for _, arg := range args {
+		if name, _, _ := strings.Cut(arg, "="); name == flag {
+			return true
+		}
+	}
+	return false
+}
 
-	return &lifecycle.OperatorLifecycleResponse{
-		JsonPatch: patch,
-	}, nil
+// extraVolumeSource builds the pod volume backing an extra gateway mount
+func extraVolumeSource(extraVolume config.ExtraVolume) corev1.Volume {
+	if extraVolume.SecretName != "" {
+		return corev1.Volume{
+			Name: extraVolume.Name,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{SecretName: extraVolume.SecretName},
+			},
+		}
+	}
+	return corev1.Volume{
+		Name: extraVolume.Name,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: extraVolume.ConfigMapName},
+			},
+		},
+	}
+}
+
+// shouldCreateUser reports whether the gateway on the pod should create the
+// DocumentDB user. Only the primary instance of a non-replica cluster does so,
+// as reported by the CNPG instance role label.
+func shouldCreateUser(cluster *apiv1.Cluster, pod *corev1.Pod) bool {
+	if pod.Labels[replicationClusterTypeLabel] == "replica" {
+		return false
+	}
+
+	switch pod.Labels[instanceRoleLabel] {
+	case instanceRolePrimary:
+		return true
+	case instanceRoleReplica:
+		return false
+	}
+
+	// The instance manager sets the role label once the instance starts, so
+	// fall back to the target primary for freshly created pods
+	return cluster.Status.TargetPrimary == pod.Name
+}
+
+// otelEnvVars returns the OpenTelemetry environment variables for the gateway
+// sidecar. When OTEL is disabled the SDK is turned off instead of pointing it
+// at a collector that may not exist.
+func otelEnvVars(configuration *config.Configuration) []corev1.EnvVar {
+	if configuration.DisableOtel {
+		log.Printf("OpenTelemetry export disabled for the gateway sidecar")
+		return []corev1.EnvVar{
+			{
+				Name:  "OTEL_SDK_DISABLED",
+				Value: "true",
+			},
+		}
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "OTEL_EXPORTER_OTLP_ENDPOINT",
+			Value: configuration.OtelEndpoint,
+		},
+	}
 }
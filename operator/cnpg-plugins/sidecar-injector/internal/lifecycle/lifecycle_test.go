@@ -0,0 +1,540 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package lifecycle
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	apiv1 "github.com/cloudnative-pg/api/pkg/api/v1"
+	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/common"
+	"github.com/cloudnative-pg/cnpg-i-machinery/pkg/pluginhelper/object"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/documentdb/cnpg-i-sidecar-injector/internal/config"
+)
+
+func TestOtelEnvVarsReflectParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		expected   corev1.EnvVar
+	}{
+		{
+			name:       "default endpoint",
+			parameters: map[string]string{},
+			expected:   corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: config.DefaultOtelEndpoint},
+		},
+		{
+			name:       "custom endpoint",
+			parameters: map[string]string{"otelEndpoint": "http://collector.monitoring.svc.cluster.local:4317"},
+			expected:   corev1.EnvVar{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://collector.monitoring.svc.cluster.local:4317"},
+		},
+		{
+			name:       "disabled",
+			parameters: map[string]string{"otelEndpoint": "http://collector:4317", "disableOtel": "true"},
+			expected:   corev1.EnvVar{Name: "OTEL_SDK_DISABLED", Value: "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper := &common.Plugin{Parameters: tt.parameters, PluginIndex: -1}
+			configuration, valErrs := config.FromParameters(helper)
+			if len(valErrs) > 0 {
+				t.Fatalf("unexpected validation errors: %v", valErrs)
+			}
+
+			envVars := otelEnvVars(configuration)
+			if len(envVars) != 1 || envVars[0] != tt.expected {
+				t.Errorf("expected env vars [%v], got %v", tt.expected, envVars)
+			}
+		})
+	}
+}
+
+func TestFromParametersRejectsInvalidDisableOtel(t *testing.T) {
+	helper := &common.Plugin{Parameters: map[string]string{"disableOtel": "maybe"}, PluginIndex: -1}
+	if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+		t.Errorf("expected one validation error, got %v", valErrs)
+	}
+}
+
+func TestInjectGatewayTLSAndCreateUserArgs(t *testing.T) {
+	tests := []struct {
+		name               string
+		podName            string
+		podLabels          map[string]string
+		tlsSecret          string
+		expectedCreateUser string
+	}{
+		{
+			name:               "primary without TLS",
+			podName:            "cluster-1",
+			expectedCreateUser: "true",
+		},
+		{
+			name:               "primary with TLS",
+			podName:            "cluster-1",
+			tlsSecret:          "gateway-cert",
+			expectedCreateUser: "true",
+		},
+		{
+			name:               "local standby with TLS",
+			podName:            "cluster-2",
+			tlsSecret:          "gateway-cert",
+			expectedCreateUser: "false",
+		},
+		{
+			name:               "replica cluster primary",
+			podName:            "cluster-1",
+			podLabels:          map[string]string{"replication_cluster_type": "replica"},
+			expectedCreateUser: "false",
+		},
+		{
+			name:               "promoted primary after failover",
+			podName:            "cluster-2",
+			podLabels:          map[string]string{"cnpg.io/instanceRole": "primary"},
+			tlsSecret:          "gateway-cert",
+			expectedCreateUser: "true",
+		},
+		{
+			name:               "demoted replica after failover",
+			podName:            "cluster-1",
+			podLabels:          map[string]string{"cnpg.io/instanceRole": "replica"},
+			expectedCreateUser: "false",
+		},
+		{
+			name:               "replica cluster primary with role label",
+			podName:            "cluster-1",
+			podLabels:          map[string]string{"cnpg.io/instanceRole": "primary", "replication_cluster_type": "replica"},
+			expectedCreateUser: "false",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &apiv1.Cluster{Status: apiv1.ClusterStatus{TargetPrimary: "cluster-1"}}
+			pod := newInstancePod(tt.podName, tt.podLabels)
+			configuration, _ := config.FromParameters(&common.Plugin{
+				Parameters:  map[string]string{"gatewayTLSSecret": tt.tlsSecret},
+				PluginIndex: -1,
+			})
+
+			mutatedPod, err := injectGateway(cluster, pod, configuration)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			gateway := findContainer(mutatedPod, "documentdb-gateway")
+			if gateway == nil {
+				t.Fatalf("expected gateway container to be injected, got %v", mutatedPod.Spec.Containers)
+			}
+
+			if got := argValue(gateway.Args, "--create-user"); got != tt.expectedCreateUser {
+				t.Errorf("expected --create-user %s, got %q (args %v)", tt.expectedCreateUser, got, gateway.Args)
+			}
+
+			hasVolume := false
+			for _, volume := range mutatedPod.Spec.Volumes {
+				if volume.Name == "gateway-tls" && volume.Secret != nil && volume.Secret.SecretName == tt.tlsSecret {
+					hasVolume = true
+				}
+			}
+			hasMount := len(gateway.VolumeMounts) == 1 && gateway.VolumeMounts[0].MountPath == "/tls"
+			hasCertArg := argValue(gateway.Args, "--cert-path") == "/tls/tls.crt"
+
+			expectTLS := tt.tlsSecret != ""
+			if hasVolume != expectTLS || hasMount != expectTLS || hasCertArg != expectTLS {
+				t.Errorf("expected TLS injection %t, got volume=%t mount=%t certArg=%t",
+					expectTLS, hasVolume, hasMount, hasCertArg)
+			}
+		})
+	}
+}
+
+func newInstancePod(name string, labels map[string]string) *corev1.Pod {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: map[string]string{},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "postgres"}},
+		},
+	}
+}
+
+func findContainer(pod *corev1.Pod, name string) *corev1.Container {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i]
+		}
+	}
+	return nil
+}
+
+// argValue returns the value following flag in args, or "" when the flag is absent
+func argValue(args []string, flag string) string {
+	for i := 0; i < len(args)-1; i++ {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func TestInjectGatewaySecurityContextOverrides(t *testing.T) {
+	tests := []struct {
+		name          string
+		parameters    map[string]string
+		expectedName  string
+		expectedUser  int64
+		expectedGroup int64
+	}{
+		{
+			name:          "defaults",
+			parameters:    map[string]string{},
+			expectedName:  "documentdb-gateway",
+			expectedUser:  1000,
+			expectedGroup: 1000,
+		},
+		{
+			name: "overrides",
+			parameters: map[string]string{
+				"gatewayContainerName": "gateway",
+				"gatewayRunAsUser":     "2000",
+				"gatewayRunAsGroup":    "3000",
+			},
+			expectedName:  "gateway",
+			expectedUser:  2000,
+			expectedGroup: 3000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configuration, valErrs := config.FromParameters(&common.Plugin{Parameters: tt.parameters, PluginIndex: -1})
+			if len(valErrs) > 0 {
+				t.Fatalf("unexpected validation errors: %v", valErrs)
+			}
+
+			mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			gateway := findContainer(mutatedPod, tt.expectedName)
+			if gateway == nil {
+				t.Fatalf("expected container %q to be injected, got %v", tt.expectedName, mutatedPod.Spec.Containers)
+			}
+			securityContext := gateway.SecurityContext
+			if *securityContext.RunAsUser != tt.expectedUser || *securityContext.RunAsGroup != tt.expectedGroup {
+				t.Errorf("expected runAsUser=%d runAsGroup=%d, got runAsUser=%d runAsGroup=%d",
+					tt.expectedUser, tt.expectedGroup, *securityContext.RunAsUser, *securityContext.RunAsGroup)
+			}
+		})
+	}
+}
+
+func TestFromParametersRejectsInvalidGatewaySecurityParameters(t *testing.T) {
+	for _, parameters := range []map[string]string{
+		{"gatewayRunAsUser": "-1"},
+		{"gatewayRunAsGroup": "root"},
+		{"gatewayContainerName": "Gateway_Container"},
+	} {
+		if _, valErrs := config.FromParameters(&common.Plugin{Parameters: parameters, PluginIndex: -1}); len(valErrs) != 1 {
+			t.Errorf("expected one validation error for %v, got %v", parameters, valErrs)
+		}
+	}
+}
+
+func TestInjectGatewayAppliesPullPolicyAndSecrets(t *testing.T) {
+	configuration, valErrs := config.FromParameters(&common.Plugin{
+		Parameters: map[string]string{
+			"gatewayImagePullPolicy":  "IfNotPresent",
+			"gatewayImagePullSecrets": "registry-creds, existing-creds",
+		},
+		PluginIndex: -1,
+	})
+	if len(valErrs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", valErrs)
+	}
+
+	pod := newInstancePod("cluster-1", nil)
+	pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "existing-creds"}}
+
+	mutatedPod, err := injectGateway(&apiv1.Cluster{}, pod, configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gateway := findContainer(mutatedPod, "documentdb-gateway")
+	if gateway.ImagePullPolicy != corev1.PullIfNotPresent {
+		t.Errorf("expected pull policy IfNotPresent, got %s", gateway.ImagePullPolicy)
+	}
+
+	expected := []corev1.LocalObjectReference{{Name: "existing-creds"}, {Name: "registry-creds"}}
+	if len(mutatedPod.Spec.ImagePullSecrets) != len(expected) {
+		t.Fatalf("expected pull secrets %v, got %v", expected, mutatedPod.Spec.ImagePullSecrets)
+	}
+	for i := range expected {
+		if mutatedPod.Spec.ImagePullSecrets[i] != expected[i] {
+			t.Errorf("expected pull secrets %v, got %v", expected, mutatedPod.Spec.ImagePullSecrets)
+		}
+	}
+}
+
+func TestFromParametersRejectsInvalidPullPolicy(t *testing.T) {
+	helper := &common.Plugin{Parameters: map[string]string{"gatewayImagePullPolicy": "Sometimes"}, PluginIndex: -1}
+	if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+		t.Errorf("expected one validation error, got %v", valErrs)
+	}
+}
+
+func TestInjectGatewayMountsExtraVolumes(t *testing.T) {
+	configuration, valErrs := config.FromParameters(&common.Plugin{
+		Parameters: map[string]string{
+			"gatewayExtraVolumes": `[{"name":"ca-bundle","mountPath":"/etc/ssl/custom","configMapName":"corporate-ca"}]`,
+		},
+		PluginIndex: -1,
+	})
+	if len(valErrs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", valErrs)
+	}
+
+	mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gateway := findContainer(mutatedPod, "documentdb-gateway")
+	expectedMount := corev1.VolumeMount{Name: "ca-bundle", MountPath: "/etc/ssl/custom", ReadOnly: true}
+	if len(gateway.VolumeMounts) != 1 || gateway.VolumeMounts[0] != expectedMount {
+		t.Errorf("expected volume mounts [%v], got %v", expectedMount, gateway.VolumeMounts)
+	}
+
+	found := false
+	for _, volume := range mutatedPod.Spec.Volumes {
+		if volume.Name == "ca-bundle" && volume.ConfigMap != nil && volume.ConfigMap.Name == "corporate-ca" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected config map volume corporate-ca, got %v", mutatedPod.Spec.Volumes)
+	}
+}
+
+func TestFromParametersRejectsInvalidExtraVolumes(t *testing.T) {
+	for _, extraVolumes := range []string{
+		`[{"name":"ca-bundle","mountPath":"/etc/ssl/custom"}]`,
+		`[{"name":"ca-bundle","mountPath":"/etc/ssl/custom","secretName":"a","configMapName":"b"}]`,
+		`[{"name":"ca-bundle","secretName":"a"}]`,
+		`not-json`,
+	} {
+		helper := &common.Plugin{Parameters: map[string]string{"gatewayExtraVolumes": extraVolumes}, PluginIndex: -1}
+		if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+			t.Errorf("expected one validation error for %s, got %v", extraVolumes, valErrs)
+		}
+	}
+}
+
+func TestInjectGatewayReportsMissingPostgresContainer(t *testing.T) {
+	configuration, _ := config.FromParameters(&common.Plugin{Parameters: map[string]string{}, PluginIndex: -1})
+
+	cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster"}}
+	pod := newInstancePod("my-cluster-1", nil)
+	pod.Namespace = "documentdb-ns"
+	pod.Spec.Containers = []corev1.Container{{Name: "bootstrap-controller"}}
+
+	_, err := injectGateway(cluster, pod, configuration)
+	if !errors.Is(err, object.ErrNoPostgresContainerFound) {
+		t.Fatalf("expected ErrNoPostgresContainerFound, got %v", err)
+	}
+
+	for _, expected := range []string{"documentdb-ns/my-cluster-1", "cluster my-cluster", "[bootstrap-controller]"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %q", expected, err.Error())
+		}
+	}
+}
+
+func TestInjectGatewayAppendsExtraArgs(t *testing.T) {
+	configuration, valErrs := config.FromParameters(&common.Plugin{
+		Parameters:  map[string]string{"gatewayExtraArgs": "--log-level debug\n--max-connections=200"},
+		PluginIndex: -1,
+	})
+	if len(valErrs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", valErrs)
+	}
+
+	cluster := &apiv1.Cluster{Status: apiv1.ClusterStatus{TargetPrimary: "cluster-1"}}
+	mutatedPod, err := injectGateway(cluster, newInstancePod("cluster-1", nil), configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"--create-user", "true", "--start-pg", "false", "--pg-port", "5432",
+		"--log-level", "debug", "--max-connections=200",
+	}
+	gateway := findContainer(mutatedPod, "documentdb-gateway")
+	if strings.Join(gateway.Args, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected args %v, got %v", expected, gateway.Args)
+	}
+}
+
+func TestInjectGatewayPassesLogLevel(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		expected   string
+	}{
+		{name: "unset", parameters: map[string]string{}, expected: ""},
+		{name: "log level", parameters: map[string]string{"gatewayLogLevel": "debug"}, expected: "debug"},
+		{
+			name:       "extra args take precedence",
+			parameters: map[string]string{"gatewayLogLevel": "debug", "gatewayExtraArgs": "--log-level=trace"},
+			expected:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configuration, valErrs := config.FromParameters(&common.Plugin{Parameters: tt.parameters, PluginIndex: -1})
+			if len(valErrs) > 0 {
+				t.Fatalf("unexpected validation errors: %v", valErrs)
+			}
+
+			mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			gateway := findContainer(mutatedPod, "documentdb-gateway")
+			if got := argValue(gateway.Args, "--log-level"); got != tt.expected {
+				t.Errorf("expected --log-level %q, got %q (args %v)", tt.expected, got, gateway.Args)
+			}
+		})
+	}
+}
+
+func TestFromParametersRejectsInvalidGatewayLogLevel(t *testing.T) {
+	for _, level := range []string{"warning", "verbose", "DEBUG"} {
+		helper := &common.Plugin{Parameters: map[string]string{"gatewayLogLevel": level}, PluginIndex: -1}
+		if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+			t.Errorf("expected one validation error for %q, got %v", level, valErrs)
+		}
+	}
+}
+
+func TestFromParametersRejectsManagedExtraArgs(t *testing.T) {
+	for _, extraArgs := range []string{"--pg-port 6432", "--create-user=false", "--verbose --cert-path /tmp/cert"} {
+		helper := &common.Plugin{Parameters: map[string]string{"gatewayExtraArgs": extraArgs}, PluginIndex: -1}
+		if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+			t.Errorf("expected one validation error for %q, got %v", extraArgs, valErrs)
+		}
+	}
+}
+
+func TestInjectGatewayUsesConfiguredPgPort(t *testing.T) {
+	configuration, valErrs := config.FromParameters(&common.Plugin{
+		Parameters:  map[string]string{"pgPort": "6432"},
+		PluginIndex: -1,
+	})
+	if len(valErrs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", valErrs)
+	}
+
+	mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gateway := findContainer(mutatedPod, "documentdb-gateway")
+	if got := argValue(gateway.Args, "--pg-port"); got != "6432" {
+		t.Errorf("expected --pg-port 6432, got %q (args %v)", got, gateway.Args)
+	}
+}
+
+func TestInjectGatewayConnectsThroughPgHost(t *testing.T) {
+	configuration, valErrs := config.FromParameters(&common.Plugin{
+		Parameters:  map[string]string{"pgHost": "my-cluster-pooler"},
+		PluginIndex: -1,
+	})
+	if len(valErrs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", valErrs)
+	}
+
+	mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gateway := findContainer(mutatedPod, "documentdb-gateway")
+	if got := argValue(gateway.Args, "--pg-host"); got != "my-cluster-pooler" {
+		t.Errorf("expected --pg-host my-cluster-pooler, got %q (args %v)", got, gateway.Args)
+	}
+}
+
+func TestFromParametersRejectsInvalidPgPort(t *testing.T) {
+	for _, port := range []string{"0", "70000", "postgres"} {
+		helper := &common.Plugin{Parameters: map[string]string{"pgPort": port}, PluginIndex: -1}
+		if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+			t.Errorf("expected one validation error for %q, got %v", port, valErrs)
+		}
+	}
+}
+
+func TestInjectGatewayDeclaresMetricsPort(t *testing.T) {
+	configuration, valErrs := config.FromParameters(&common.Plugin{
+		Parameters:  map[string]string{"gatewayMetricsPort": "9187"},
+		PluginIndex: -1,
+	})
+	if len(valErrs) > 0 {
+		t.Fatalf("unexpected validation errors: %v", valErrs)
+	}
+
+	mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gateway := findContainer(mutatedPod, "documentdb-gateway")
+	expected := []corev1.ContainerPort{
+		{ContainerPort: config.GatewayPort},
+		{Name: "metrics", ContainerPort: 9187, Protocol: corev1.ProtocolTCP},
+	}
+	if !reflect.DeepEqual(gateway.Ports, expected) {
+		t.Errorf("expected ports %v, got %v", expected, gateway.Ports)
+	}
+}
+
+func TestInjectGatewayOmitsMetricsPortByDefault(t *testing.T) {
+	configuration, _ := config.FromParameters(&common.Plugin{Parameters: map[string]string{}, PluginIndex: -1})
+
+	mutatedPod, err := injectGateway(&apiv1.Cluster{}, newInstancePod("cluster-1", nil), configuration)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ports := findContainer(mutatedPod, "documentdb-gateway").Ports; len(ports) != 1 {
+		t.Errorf("expected only the gateway port, got %v", ports)
+	}
+}
+
+func TestFromParametersRejectsInvalidGatewayMetricsPort(t *testing.T) {
+	for _, port := range []string{"0", "70000", "metrics", "10260"} {
+		helper := &common.Plugin{Parameters: map[string]string{"gatewayMetricsPort": port}, PluginIndex: -1}
+		if _, valErrs := config.FromParameters(helper); len(valErrs) != 1 {
+			t.Errorf("expected one validation error for %q, got %v", port, valErrs)
+		}
+	}
+}
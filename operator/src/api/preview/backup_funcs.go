@@ -4,6 +4,8 @@
 package preview
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
@@ -13,14 +15,24 @@ import (
 )
 
 // CreateCNPGBackup creates a CNPG Backup resource based on the DocumentDB Backup spec.
-func (backup *Backup) CreateCNPGBackup(scheme *runtime.Scheme, clusterName string) (*cnpgv1.Backup, error) {
+// Object store backups need the WAL archive of the cluster's backup configuration.
+func (backup *Backup) CreateCNPGBackup(scheme *runtime.Scheme, clusterName string, backupConfiguration *BackupConfiguration) (*cnpgv1.Backup, error) {
+	method := cnpgv1.BackupMethodVolumeSnapshot
+	if backup.Spec.Method == BackupMethodObjectStore {
+		if backupConfiguration == nil || backupConfiguration.WalArchive == nil {
+			return nil, fmt.Errorf("backup method %s requires spec.backup.walArchive to be configured on DocumentDB %s",
+				BackupMethodObjectStore, backup.Spec.Cluster.Name)
+		}
+		method = cnpgv1.BackupMethodBarmanObjectStore
+	}
+
 	cnpgBackup := &cnpgv1.Backup{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      backup.Name,
 			Namespace: backup.Namespace,
 		},
 		Spec: cnpgv1.BackupSpec{
-			Method: cnpgv1.BackupMethodVolumeSnapshot,
+			Method: method,
 			Cluster: cnpgv1.LocalObjectReference{
 				Name: clusterName,
 			},
@@ -57,6 +69,21 @@ func (backup *Backup) UpdateStatus(cnpgBackup *cnpgv1.Backup, backupConfiguratio
 		needsUpdate = true
 	}
 
+	if backup.Status.BackupID != cnpgBackup.Status.BackupID {
+		backup.Status.BackupID = cnpgBackup.Status.BackupID
+		needsUpdate = true
+	}
+
+	snapshots := cnpgBackup.Status.BackupSnapshotStatus.Elements
+	snapshotNames := make([]string, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		snapshotNames = append(snapshotNames, snapshot.Name)
+	}
+	if !slices.Equal(backup.Status.Snapshots, snapshotNames) {
+		backup.Status.Snapshots = snapshotNames
+		needsUpdate = true
+	}
+
 	expirationTime := backup.CalculateExpirationTime(backupConfiguration)
 	if !areTimesEqual(backup.Status.ExpiredAt, expirationTime) {
 		backup.Status.ExpiredAt = expirationTime
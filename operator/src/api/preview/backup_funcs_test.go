@@ -35,7 +35,7 @@ var _ = Describe("Backup", func() {
 				},
 			}
 
-			cnpg, err := backup.CreateCNPGBackup(scheme, "my-cluster-x")
+			cnpg, err := backup.CreateCNPGBackup(scheme, "my-cluster-x", nil)
 			Expect(err).To(BeNil())
 			Expect(cnpg).ToNot(BeNil())
 
@@ -52,6 +52,38 @@ var _ = Describe("Backup", func() {
 			Expect(owner.Kind).To(Equal("Backup"))
 			Expect(owner.APIVersion).To(Equal(gv.String()))
 		})
+
+		DescribeTable("sets the CNPG method for each backup method",
+			func(method string, backupConfiguration *BackupConfiguration, expected cnpgv1.BackupMethod) {
+				scheme := runtime.NewScheme()
+				Expect(cnpgv1.AddToScheme(scheme)).To(Succeed())
+				scheme.AddKnownTypes(schema.GroupVersion{Group: "preview.test", Version: "preview"}, &Backup{}, &BackupList{})
+
+				backup := &Backup{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-backup", Namespace: "my-ns"},
+					Spec:       BackupSpec{Cluster: cnpgv1.LocalObjectReference{Name: "my-cluster"}, Method: method},
+				}
+
+				cnpg, err := backup.CreateCNPGBackup(scheme, "my-cluster", backupConfiguration)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cnpg.Spec.Method).To(Equal(expected))
+			},
+			Entry("defaults to volume snapshots", "", nil, cnpgv1.BackupMethodVolumeSnapshot),
+			Entry("volume snapshot", BackupMethodVolumeSnapshot, nil, cnpgv1.BackupMethodVolumeSnapshot),
+			Entry("object store", BackupMethodObjectStore,
+				&BackupConfiguration{WalArchive: &WalArchiveConfiguration{DestinationPath: "s3://backups/"}},
+				cnpgv1.BackupMethodBarmanObjectStore),
+		)
+
+		It("rejects object store backups of a cluster without a WAL archive", func() {
+			backup := &Backup{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-backup", Namespace: "my-ns"},
+				Spec:       BackupSpec{Cluster: cnpgv1.LocalObjectReference{Name: "my-cluster"}, Method: BackupMethodObjectStore},
+			}
+
+			_, err := backup.CreateCNPGBackup(runtime.NewScheme(), "my-cluster", &BackupConfiguration{RetentionDays: 7})
+			Expect(err).To(MatchError(ContainSubstring("requires spec.backup.walArchive")))
+		})
 	})
 
 	Describe("UpdateStatus", func() {
@@ -94,6 +126,30 @@ var _ = Describe("Backup", func() {
 			Expect(backup.Status.ExpiredAt.Time.Equal(stoppedAt.Time.Add(30 * 24 * time.Hour))).To(BeTrue())
 		})
 
+		It("maps the backup ID and volume snapshot names from cnpg backup", func() {
+			cnpg := &cnpgv1.Backup{
+				Status: cnpgv1.BackupStatus{
+					Phase:    cnpgv1.BackupPhaseCompleted,
+					BackupID: "20250401T010000",
+					BackupSnapshotStatus: cnpgv1.BackupSnapshotStatus{
+						Elements: []cnpgv1.BackupSnapshotElementStatus{
+							{Name: "my-backup-1", Type: "PG_DATA"},
+							{Name: "my-backup-1-wal", Type: "PG_WAL"},
+						},
+					},
+				},
+			}
+
+			backup := &Backup{}
+
+			Expect(backup.UpdateStatus(cnpg, nil)).To(BeTrue())
+			Expect(backup.Status.BackupID).To(Equal("20250401T010000"))
+			Expect(backup.Status.Snapshots).To(Equal([]string{"my-backup-1", "my-backup-1-wal"}))
+
+			// A second pass with the same status is a no-op
+			Expect(backup.UpdateStatus(cnpg, nil)).To(BeFalse())
+		})
+
 		It("does not update when there are no changes", func() {
 			startedAt := metav1.NewTime(time.Date(2025, 5, 1, 1, 0, 0, 0, time.UTC))
 			stoppedAt := metav1.NewTime(time.Date(2025, 5, 1, 2, 0, 0, 0, time.UTC))
@@ -5,6 +5,7 @@ package preview
 
 import (
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -20,8 +21,22 @@ type BackupSpec struct {
 	// If not specified, the default retention period from the cluster's backup retention policy will be used.
 	// +optional
 	RetentionDays *int `json:"retentionDays,omitempty"`
+
+	// Method is how the backup is taken. VolumeSnapshot snapshots the volumes of the primary, which needs storage
+	// with snapshot support. ObjectStore takes a hot base backup to the object store configured in the cluster's
+	// spec.backup.walArchive.
+	// +kubebuilder:validation:Enum=VolumeSnapshot;ObjectStore
+	// +kubebuilder:default=VolumeSnapshot
+	// +optional
+	Method string `json:"method,omitempty"`
 }
 
+// Methods accepted in BackupSpec.Method.
+const (
+	BackupMethodVolumeSnapshot = "VolumeSnapshot"
+	BackupMethodObjectStore    = "ObjectStore"
+)
+
 // BackupPhaseSkipped indicates that the backup was skipped,
 // for example backup won't run for a standby cluster in multi-region setup.
 const BackupPhaseSkipped cnpgv1.BackupPhase = "skipped"
@@ -48,6 +63,19 @@ type BackupStatus struct {
 	// For skipped backups, this explains why the backup was skipped.
 	// +optional
 	Message string `json:"message,omitempty"`
+
+	// BackupID is the ID that CNPG assigned to the backup.
+	// +optional
+	BackupID string `json:"backupId,omitempty"`
+
+	// Snapshots are the names of the volume snapshots taken by the backup.
+	// +optional
+	Snapshots []string `json:"snapshots,omitempty"`
+
+	// Size is the total restore size of the volume snapshots, as reported by the snapshot driver.
+	// It is not set for object store backups.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
 }
 
 // +kubebuilder:object:root=true
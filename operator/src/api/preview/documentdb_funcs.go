@@ -0,0 +1,368 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MinimumPvcSize is the smallest storage size accepted for PvcSize.
+const MinimumPvcSize = "1Gi"
+
+// ConditionSchedulable reports whether all requested instances could be scheduled onto nodes.
+const ConditionSchedulable = "Schedulable"
+
+// ConditionReplicationRoleGranted reports that documentdb_admin_role was granted to streaming_replica.
+const ConditionReplicationRoleGranted = "ReplicationRoleGranted"
+
+// ConditionMaintenanceDeferred reports disruptive changes held back until the maintenance window opens.
+const ConditionMaintenanceDeferred = "MaintenanceDeferred"
+
+// ConditionStorageClassReady reports whether the StorageClass named in the storage configuration exists.
+const ConditionStorageClassReady = "StorageClassReady"
+
+// ConditionReplicationConfigured reports whether the cross-cluster replication resources could be set up.
+const ConditionReplicationConfigured = "ReplicationConfigured"
+
+// ConditionServiceReady reports whether the DocumentDB Service has a gateway endpoint accepting connections.
+const ConditionServiceReady = "ServiceReady"
+
+// Promotion modes accepted in ClusterReplication.PromotionMode.
+const (
+	PromotionModeSwitchover = "Switchover"
+	PromotionModeFailover   = "Failover"
+)
+
+// Upgrade phases reported in DocumentDBStatus.Upgrade.
+const (
+	UpgradePhaseInProgress = "InProgress"
+	UpgradePhaseCompleted  = "Completed"
+	UpgradePhaseBlocked    = "Blocked"
+)
+
+// Clone phases reported in DocumentDBStatus.Clone.
+const (
+	ClonePhaseBackingUp = "BackingUp"
+	ClonePhaseRestoring = "Restoring"
+	ClonePhaseCompleted = "Completed"
+	ClonePhaseFailed    = "Failed"
+)
+
+// GracefulSwitchover reports whether promotions wait for the new primary to catch up, see ClusterReplication.PromotionMode.
+func (documentdb *DocumentDB) GracefulSwitchover() bool {
+	return documentdb.Spec.ClusterReplication != nil && documentdb.Spec.ClusterReplication.PromotionMode == PromotionModeSwitchover
+}
+
+// UpdateInstanceStatus updates the instance counts and current primary based on the CNPG Cluster status.
+// Returns true if any field changed.
+func (documentdb *DocumentDB) UpdateInstanceStatus(cluster *cnpgv1.Cluster) bool {
+	needsUpdate := false
+
+	readyInstances := len(cluster.Status.InstancesStatus[cnpgv1.PodHealthy])
+	totalInstances := 0
+	for _, instances := range cluster.Status.InstancesStatus {
+		totalInstances += len(instances)
+	}
+
+	if documentdb.Status.ReadyInstances != readyInstances {
+		documentdb.Status.ReadyInstances = readyInstances
+		needsUpdate = true
+	}
+
+	if documentdb.Status.TotalInstances != totalInstances {
+		documentdb.Status.TotalInstances = totalInstances
+		needsUpdate = true
+	}
+
+	if documentdb.Status.CurrentPrimary != cluster.Status.CurrentPrimary {
+		documentdb.Status.CurrentPrimary = cluster.Status.CurrentPrimary
+		needsUpdate = true
+	}
+
+	return needsUpdate
+}
+
+// ValidatePvcSize checks that PvcSize parses as a quantity of at least MinimumPvcSize and,
+// when currentSize is not empty, that it does not shrink below the current size.
+func (storage *StorageConfiguration) ValidatePvcSize(currentSize string) error {
+	size, err := resource.ParseQuantity(storage.PvcSize)
+	if err != nil {
+		return fmt.Errorf("invalid pvcSize %q: %w", storage.PvcSize, err)
+	}
+
+	if size.Cmp(resource.MustParse(MinimumPvcSize)) < 0 {
+		return fmt.Errorf("pvcSize %s is below the minimum of %s", storage.PvcSize, MinimumPvcSize)
+	}
+
+	if currentSize == "" {
+		return nil
+	}
+	current, err := resource.ParseQuantity(currentSize)
+	if err != nil {
+		return fmt.Errorf("invalid current storage size %q: %w", currentSize, err)
+	}
+	if size.Cmp(current) < 0 {
+		return fmt.Errorf("pvcSize %s cannot be smaller than the current size %s", storage.PvcSize, currentSize)
+	}
+
+	return nil
+}
+
+// Validate checks that the sub-config required by the selected mode is set, mirroring the CRD validation rules:
+// CertManager requires certManager.issuerRef.name and Provided requires provided.secretName. It also checks
+// the extra IP SANs of SelfSigned, which the CRD doesn't validate.
+func (gateway *GatewayTLS) Validate() error {
+	switch gateway.Mode {
+	case "SelfSigned":
+		if gateway.SelfSigned != nil {
+			for _, ip := range gateway.SelfSigned.IPAddresses {
+				if net.ParseIP(ip) == nil {
+					return fmt.Errorf("selfSigned.ipAddresses contains an invalid IP address %q", ip)
+				}
+			}
+		}
+	case "CertManager":
+		if gateway.CertManager == nil || gateway.CertManager.IssuerRef.Name == "" {
+			return errors.New("certManager.issuerRef.name is required when mode is CertManager")
+		}
+	case "Provided":
+		if gateway.Provided == nil || gateway.Provided.SecretName == "" {
+			return errors.New("provided.secretName is required when mode is Provided")
+		}
+	}
+	return nil
+}
+
+// UpdateUpgradeStatus marks an in-progress upgrade as completed once the CNPG Cluster runs the target image
+// and is healthy. Returns true if the status changed.
+func (documentdb *DocumentDB) UpdateUpgradeStatus(cluster *cnpgv1.Cluster) bool {
+	upgrade := documentdb.Status.Upgrade
+	if upgrade == nil || upgrade.Phase != UpgradePhaseInProgress {
+		return false
+	}
+
+	if cluster.Status.Image != upgrade.DocumentDBImage || cluster.Status.Phase != cnpgv1.PhaseHealthy {
+		return false
+	}
+
+	upgrade.Phase = UpgradePhaseCompleted
+	upgrade.Message = fmt.Sprintf("Cluster is running %s", upgrade.DocumentDBImage)
+	return true
+}
+
+// UpdateCloneStatus marks a clone as completed once the cluster restored from the backup of its source is
+// healthy. Returns true if the status changed.
+func (documentdb *DocumentDB) UpdateCloneStatus(cluster *cnpgv1.Cluster) bool {
+	clone := documentdb.Status.Clone
+	if clone == nil || clone.Phase != ClonePhaseRestoring || cluster.Status.Phase != cnpgv1.PhaseHealthy {
+		return false
+	}
+
+	clone.Phase = ClonePhaseCompleted
+	clone.Message = fmt.Sprintf("Cluster restored from backup %s", clone.BackupName)
+	return true
+}
+
+// UpdateSchedulableCondition sets the Schedulable condition from the scheduling state of the cluster's pods,
+// reporting the pods the scheduler could not place. Returns true if the condition changed.
+func (documentdb *DocumentDB) UpdateSchedulableCondition(pods []corev1.Pod) bool {
+	condition := metav1.Condition{
+		Type:               ConditionSchedulable,
+		Status:             metav1.ConditionTrue,
+		Reason:             "AllInstancesScheduled",
+		Message:            "All instances have been scheduled",
+		ObservedGeneration: documentdb.Generation,
+	}
+
+	var unschedulable []string
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		for _, podCondition := range pod.Status.Conditions {
+			if podCondition.Type == corev1.PodScheduled && podCondition.Status == corev1.ConditionFalse && podCondition.Reason == corev1.PodReasonUnschedulable {
+				unschedulable = append(unschedulable, fmt.Sprintf("%s: %s", pod.Name, podCondition.Message))
+				break
+			}
+		}
+	}
+
+	if len(unschedulable) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = corev1.PodReasonUnschedulable
+		condition.Message = fmt.Sprintf("%d instance(s) cannot be scheduled: %s", len(unschedulable), strings.Join(unschedulable, "; "))
+	}
+
+	return meta.SetStatusCondition(&documentdb.Status.Conditions, condition)
+}
+
+// UpdateDatabaseStatus reports, for each entry of spec.databases, whether the CNPG Database controlled by this
+// DocumentDB has been applied. Returns true if the status changed.
+func (documentdb *DocumentDB) UpdateDatabaseStatus(databases []cnpgv1.Database) bool {
+	var statuses []DatabaseStatus
+	for _, database := range documentdb.Spec.Databases {
+		status := DatabaseStatus{Name: database.Name, Message: "Waiting for the database to be created"}
+		for i := range databases {
+			if databases[i].Spec.Name != database.Name || !metav1.IsControlledBy(&databases[i], documentdb) {
+				continue
+			}
+			status.Applied = databases[i].Status.Applied != nil && *databases[i].Status.Applied
+			status.Message = databases[i].Status.Message
+			break
+		}
+		statuses = append(statuses, status)
+	}
+
+	if equality.Semantic.DeepEqual(documentdb.Status.Databases, statuses) {
+		return false
+	}
+	documentdb.Status.Databases = statuses
+	return true
+}
+
+// UpdateMaintenanceDeferredCondition sets the MaintenanceDeferred condition listing the disruptive changes held back
+// until nextOpen, or removes it when nothing is deferred. Returns true if the condition changed.
+func (documentdb *DocumentDB) UpdateMaintenanceDeferredCondition(deferred []string, nextOpen time.Time) bool {
+	if len(deferred) == 0 {
+		return meta.RemoveStatusCondition(&documentdb.Status.Conditions, ConditionMaintenanceDeferred)
+	}
+
+	return meta.SetStatusCondition(&documentdb.Status.Conditions, metav1.Condition{
+		Type:               ConditionMaintenanceDeferred,
+		Status:             metav1.ConditionTrue,
+		Reason:             "OutsideMaintenanceWindow",
+		Message:            fmt.Sprintf("Deferred until %s: %s", nextOpen.UTC().Format(time.RFC3339), strings.Join(deferred, "; ")),
+		ObservedGeneration: documentdb.Generation,
+	})
+}
+
+// UpdateStorageClassCondition sets the StorageClassReady condition for the StorageClass of the given name, nil when
+// it does not exist, or removes the condition when no StorageClass is named. Returns true if the condition changed.
+func (documentdb *DocumentDB) UpdateStorageClassCondition(name string, storageClass *storagev1.StorageClass) bool {
+	if name == "" {
+		return meta.RemoveStatusCondition(&documentdb.Status.Conditions, ConditionStorageClassReady)
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionStorageClassReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "StorageClassFound",
+		Message:            fmt.Sprintf("StorageClass %s exists", name),
+		ObservedGeneration: documentdb.Generation,
+	}
+	switch {
+	case storageClass == nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "StorageClassNotFound"
+		condition.Message = fmt.Sprintf("StorageClass %s does not exist, so the volumes can't be provisioned", name)
+	case storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion:
+		condition.Reason = "VolumeExpansionNotAllowed"
+		condition.Message = fmt.Sprintf("StorageClass %s exists but does not allow volume expansion, so pvcSize can't be increased", name)
+	}
+
+	return meta.SetStatusCondition(&documentdb.Status.Conditions, condition)
+}
+
+// UpdateReplicationConfiguredCondition sets the ReplicationConfigured condition from the error of setting up the
+// cross-cluster replication, nil on success. Returns true if the condition changed.
+func (documentdb *DocumentDB) UpdateReplicationConfiguredCondition(err error) bool {
+	condition := metav1.Condition{
+		Type:               ConditionReplicationConfigured,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Configured",
+		Message:            "Cross-cluster replication is configured",
+		ObservedGeneration: documentdb.Generation,
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ConfigurationFailed"
+		condition.Message = err.Error()
+	}
+
+	return meta.SetStatusCondition(&documentdb.Status.Conditions, condition)
+}
+
+// UpdateServiceReadyCondition sets the ServiceReady condition from whether the named Service has a ready gateway
+// endpoint, or removes the condition when the DocumentDB is not exposed via a Service. Returns true if the condition
+// changed.
+func (documentdb *DocumentDB) UpdateServiceReadyCondition(serviceName string, ready bool) bool {
+	if serviceName == "" {
+		return meta.RemoveStatusCondition(&documentdb.Status.Conditions, ConditionServiceReady)
+	}
+
+	condition := metav1.Condition{
+		Type:               ConditionServiceReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "EndpointsReady",
+		Message:            fmt.Sprintf("Service %s has a ready gateway endpoint", serviceName),
+		ObservedGeneration: documentdb.Generation,
+	}
+	if !ready {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoReadyEndpoints"
+		condition.Message = fmt.Sprintf("Service %s has no ready gateway endpoint yet", serviceName)
+	}
+
+	return meta.SetStatusCondition(&documentdb.Status.Conditions, condition)
+}
+
+// kmsKeyIDPattern matches the KMS key references S3 accepts, like the pattern of ObjectStoreEncryption.KMSKeyID
+var kmsKeyIDPattern = regexp.MustCompile(`^(arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:(key|alias)/[A-Za-z0-9/_-]+|alias/[A-Za-z0-9/_-]+|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32})$`)
+
+// ValidateEncryption checks the encryption settings against the object store credentials and the KMS key
+// reference, mirroring the CRD validation rules for objects stored before they existed.
+func (walArchive *WalArchiveConfiguration) ValidateEncryption() error {
+	encryption := walArchive.Encryption
+	if encryption == nil {
+		return nil
+	}
+	if walArchive.Credentials.AWS == nil {
+		return errors.New("walArchive.encryption is only supported with s3Credentials")
+	}
+	if encryption.KMSKeyID == "" {
+		return nil
+	}
+	if encryption.Mode != "aws:kms" {
+		return fmt.Errorf("walArchive.encryption.kmsKeyId requires mode aws:kms, not %q", encryption.Mode)
+	}
+	if !kmsKeyIDPattern.MatchString(encryption.KMSKeyID) {
+		return fmt.Errorf("walArchive.encryption.kmsKeyId %q is not a KMS key ID, key ARN, alias name or alias ARN", encryption.KMSKeyID)
+	}
+	return nil
+}
+
+// CredentialSecretKeys returns the Secret keys referenced by the object store credentials.
+func (walArchive *WalArchiveConfiguration) CredentialSecretKeys() []cnpgv1.SecretKeySelector {
+	var selectors []*cnpgv1.SecretKeySelector
+	if s3 := walArchive.Credentials.AWS; s3 != nil {
+		selectors = append(selectors, s3.AccessKeyIDReference, s3.SecretAccessKeyReference, s3.RegionReference, s3.SessionToken)
+	}
+	if azure := walArchive.Credentials.Azure; azure != nil {
+		selectors = append(selectors, azure.ConnectionString, azure.StorageAccount, azure.StorageKey, azure.StorageSasToken)
+	}
+	if google := walArchive.Credentials.Google; google != nil {
+		selectors = append(selectors, google.ApplicationCredentials)
+	}
+
+	var keys []cnpgv1.SecretKeySelector
+	for _, selector := range selectors {
+		if selector != nil {
+			keys = append(keys, *selector)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,248 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package preview
+
+import (
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("DocumentDB", func() {
+	Describe("UpdateInstanceStatus", func() {
+		It("maps CNPG instance status to ready/total instances and current primary", func() {
+			cluster := &cnpgv1.Cluster{
+				Status: cnpgv1.ClusterStatus{
+					CurrentPrimary: "my-cluster-1",
+					InstancesStatus: map[cnpgv1.PodStatus][]string{
+						cnpgv1.PodHealthy:     {"my-cluster-1", "my-cluster-2"},
+						cnpgv1.PodReplicating: {"my-cluster-3"},
+					},
+				},
+			}
+
+			documentdb := &DocumentDB{}
+			Expect(documentdb.UpdateInstanceStatus(cluster)).To(BeTrue())
+			Expect(documentdb.Status.ReadyInstances).To(Equal(2))
+			Expect(documentdb.Status.TotalInstances).To(Equal(3))
+			Expect(documentdb.Status.CurrentPrimary).To(Equal("my-cluster-1"))
+
+			// Same CNPG status again should report no change
+			Expect(documentdb.UpdateInstanceStatus(cluster)).To(BeFalse())
+		})
+
+		It("reports zero instances for a cluster without instance status", func() {
+			documentdb := &DocumentDB{
+				Status: DocumentDBStatus{ReadyInstances: 1, TotalInstances: 1, CurrentPrimary: "old-primary"},
+			}
+
+			Expect(documentdb.UpdateInstanceStatus(&cnpgv1.Cluster{})).To(BeTrue())
+			Expect(documentdb.Status.ReadyInstances).To(BeZero())
+			Expect(documentdb.Status.TotalInstances).To(BeZero())
+			Expect(documentdb.Status.CurrentPrimary).To(BeEmpty())
+		})
+	})
+
+	Describe("ValidatePvcSize", func() {
+		DescribeTable("validates the storage size format and minimum",
+			func(pvcSize string, valid bool) {
+				storage := &StorageConfiguration{PvcSize: pvcSize}
+				if valid {
+					Expect(storage.ValidatePvcSize("")).To(Succeed())
+				} else {
+					Expect(storage.ValidatePvcSize("")).NotTo(Succeed())
+				}
+			},
+			Entry("gibibytes", "10Gi", true),
+			Entry("minimum size", "1Gi", true),
+			Entry("decimal gigabytes", "20G", true),
+			Entry("tebibytes", "1Ti", true),
+			Entry("typo in unit", "10G1", false),
+			Entry("not a number", "large", false),
+			Entry("empty", "", false),
+			Entry("below minimum", "512Mi", false),
+		)
+
+		It("rejects shrinking below the current size", func() {
+			storage := &StorageConfiguration{PvcSize: "5Gi"}
+			Expect(storage.ValidatePvcSize("10Gi")).NotTo(Succeed())
+		})
+
+		It("allows keeping or growing the current size", func() {
+			Expect((&StorageConfiguration{PvcSize: "10Gi"}).ValidatePvcSize("10Gi")).To(Succeed())
+			Expect((&StorageConfiguration{PvcSize: "20Gi"}).ValidatePvcSize("10Gi")).To(Succeed())
+			// Equivalent quantities in different units are not a shrink
+			Expect((&StorageConfiguration{PvcSize: "10240Mi"}).ValidatePvcSize("10Gi")).To(Succeed())
+		})
+	})
+
+	Describe("UpdateUpgradeStatus", func() {
+		It("completes an in-progress upgrade once the cluster runs the target image", func() {
+			documentdb := &DocumentDB{Status: DocumentDBStatus{Upgrade: &UpgradeStatus{
+				Phase:           UpgradePhaseInProgress,
+				DocumentDBImage: "documentdb:0.107.0",
+			}}}
+			cluster := &cnpgv1.Cluster{Status: cnpgv1.ClusterStatus{Image: "documentdb:0.106.0", Phase: cnpgv1.PhaseHealthy}}
+
+			Expect(documentdb.UpdateUpgradeStatus(cluster)).To(BeFalse())
+			Expect(documentdb.Status.Upgrade.Phase).To(Equal(UpgradePhaseInProgress))
+
+			cluster.Status.Image = "documentdb:0.107.0"
+			cluster.Status.Phase = cnpgv1.PhaseUpgrade
+			Expect(documentdb.UpdateUpgradeStatus(cluster)).To(BeFalse())
+
+			cluster.Status.Phase = cnpgv1.PhaseHealthy
+			Expect(documentdb.UpdateUpgradeStatus(cluster)).To(BeTrue())
+			Expect(documentdb.Status.Upgrade.Phase).To(Equal(UpgradePhaseCompleted))
+		})
+
+		It("leaves blocked upgrades untouched", func() {
+			documentdb := &DocumentDB{Status: DocumentDBStatus{Upgrade: &UpgradeStatus{Phase: UpgradePhaseBlocked}}}
+			cluster := &cnpgv1.Cluster{Status: cnpgv1.ClusterStatus{Phase: cnpgv1.PhaseHealthy}}
+
+			Expect(documentdb.UpdateUpgradeStatus(cluster)).To(BeFalse())
+			Expect(documentdb.Status.Upgrade.Phase).To(Equal(UpgradePhaseBlocked))
+		})
+	})
+
+	Describe("UpdateSchedulableCondition", func() {
+		unschedulablePod := func(name string) corev1.Pod {
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					Conditions: []corev1.PodCondition{{
+						Type:    corev1.PodScheduled,
+						Status:  corev1.ConditionFalse,
+						Reason:  corev1.PodReasonUnschedulable,
+						Message: "0/1 nodes are available: 1 node(s) didn't match pod anti-affinity rules.",
+					}},
+				},
+			}
+		}
+		runningPod := func(name string) corev1.Pod {
+			return corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Status: corev1.PodStatus{
+					Phase:      corev1.PodRunning,
+					Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}},
+				},
+			}
+		}
+
+		It("reports pods stuck pending because they cannot be scheduled", func() {
+			documentdb := &DocumentDB{}
+			pods := []corev1.Pod{runningPod("my-cluster-1"), unschedulablePod("my-cluster-2"), unschedulablePod("my-cluster-3")}
+
+			Expect(documentdb.UpdateSchedulableCondition(pods)).To(BeTrue())
+			condition := meta.FindStatusCondition(documentdb.Status.Conditions, ConditionSchedulable)
+			Expect(condition).NotTo(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(corev1.PodReasonUnschedulable))
+			Expect(condition.Message).To(ContainSubstring("2 instance(s) cannot be scheduled"))
+			Expect(condition.Message).To(ContainSubstring("my-cluster-2"))
+			Expect(condition.Message).To(ContainSubstring("anti-affinity"))
+
+			// Same pods again should report no change
+			Expect(documentdb.UpdateSchedulableCondition(pods)).To(BeFalse())
+		})
+
+		It("reports schedulable once all pods are placed", func() {
+			documentdb := &DocumentDB{}
+			Expect(documentdb.UpdateSchedulableCondition([]corev1.Pod{unschedulablePod("my-cluster-1")})).To(BeTrue())
+
+			Expect(documentdb.UpdateSchedulableCondition([]corev1.Pod{runningPod("my-cluster-1")})).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(documentdb.Status.Conditions, ConditionSchedulable)).To(BeTrue())
+		})
+	})
+
+	Describe("UpdateDatabaseStatus", func() {
+		It("reports the applied state of the CNPG Databases it controls", func() {
+			documentdb := &DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-documentdb", UID: "documentdb-uid"},
+				Spec:       DocumentDBSpec{Databases: []DatabaseSpec{{Name: "orders"}, {Name: "inventory"}, {Name: "billing"}}},
+			}
+			controlled := func(name, database string, applied *bool, message string) cnpgv1.Database {
+				return cnpgv1.Database{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            name,
+						OwnerReferences: []metav1.OwnerReference{{Name: "my-documentdb", UID: "documentdb-uid", Controller: ptr.To(true)}},
+					},
+					Spec:   cnpgv1.DatabaseSpec{Name: database},
+					Status: cnpgv1.DatabaseStatus{Applied: applied, Message: message},
+				}
+			}
+			foreign := cnpgv1.Database{Spec: cnpgv1.DatabaseSpec{Name: "billing"}, Status: cnpgv1.DatabaseStatus{Applied: ptr.To(true)}}
+			databases := []cnpgv1.Database{
+				controlled("my-cluster-orders", "orders", ptr.To(true), ""),
+				controlled("my-cluster-inventory", "inventory", ptr.To(false), "role \"shop\" does not exist"),
+				foreign,
+			}
+
+			Expect(documentdb.UpdateDatabaseStatus(databases)).To(BeTrue())
+			Expect(documentdb.Status.Databases).To(Equal([]DatabaseStatus{
+				{Name: "orders", Applied: true},
+				{Name: "inventory", Message: "role \"shop\" does not exist"},
+				{Name: "billing", Message: "Waiting for the database to be created"},
+			}))
+
+			// Same databases again should report no change
+			Expect(documentdb.UpdateDatabaseStatus(databases)).To(BeFalse())
+		})
+	})
+
+	Describe("WalArchiveConfiguration.ValidateEncryption", func() {
+		s3 := cnpgv1.BarmanCredentials{AWS: &cnpgv1.S3Credentials{InheritFromIAMRole: true}}
+		azure := cnpgv1.BarmanCredentials{Azure: &cnpgv1.AzureCredentials{InheritFromAzureAD: true}}
+
+		DescribeTable("checks the encryption mode and KMS key reference",
+			func(credentials cnpgv1.BarmanCredentials, encryption *ObjectStoreEncryption, valid bool) {
+				walArchive := WalArchiveConfiguration{DestinationPath: "s3://documentdb-wal", Credentials: credentials, Encryption: encryption}
+				if valid {
+					Expect(walArchive.ValidateEncryption()).To(Succeed())
+				} else {
+					Expect(walArchive.ValidateEncryption()).NotTo(Succeed())
+				}
+			},
+			Entry("no encryption", azure, nil, true),
+			Entry("S3 managed keys", s3, &ObjectStoreEncryption{Mode: "AES256"}, true),
+			Entry("AWS managed KMS key", s3, &ObjectStoreEncryption{Mode: "aws:kms"}, true),
+			Entry("KMS key ID", s3, &ObjectStoreEncryption{Mode: "aws:kms", KMSKeyID: "1234abcd-12ab-34cd-56ef-1234567890ab"}, true),
+			Entry("KMS key ARN", s3, &ObjectStoreEncryption{Mode: "aws:kms", KMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}, true),
+			Entry("KMS alias", s3, &ObjectStoreEncryption{Mode: "aws:kms", KMSKeyID: "alias/documentdb-backups"}, true),
+			Entry("KMS key with AES256", s3, &ObjectStoreEncryption{Mode: "AES256", KMSKeyID: "alias/documentdb-backups"}, false),
+			Entry("malformed KMS key", s3, &ObjectStoreEncryption{Mode: "aws:kms", KMSKeyID: "documentdb-backups"}, false),
+			Entry("Azure object store", azure, &ObjectStoreEncryption{Mode: "AES256"}, false),
+		)
+	})
+
+	Describe("GatewayTLS.Validate", func() {
+		DescribeTable("checks the sub-config required by the mode",
+			func(gateway GatewayTLS, valid bool) {
+				if valid {
+					Expect(gateway.Validate()).To(Succeed())
+				} else {
+					Expect(gateway.Validate()).NotTo(Succeed())
+				}
+			},
+			Entry("mode not set", GatewayTLS{}, true),
+			Entry("disabled", GatewayTLS{Mode: "Disabled"}, true),
+			Entry("self-signed", GatewayTLS{Mode: "SelfSigned"}, true),
+			Entry("self-signed with extra SANs", GatewayTLS{Mode: "SelfSigned", SelfSigned: &SelfSignedTLS{DNSNames: []string{"db.example.com"}, IPAddresses: []string{"20.1.2.3", "2001:db8::1"}}}, true),
+			Entry("self-signed with an invalid IP SAN", GatewayTLS{Mode: "SelfSigned", SelfSigned: &SelfSignedTLS{IPAddresses: []string{"db.example.com"}}}, false),
+			Entry("cert-manager with issuer", GatewayTLS{Mode: "CertManager", CertManager: &CertManagerTLS{IssuerRef: IssuerRef{Name: "ca-issuer"}}}, true),
+			Entry("cert-manager without certManager", GatewayTLS{Mode: "CertManager"}, false),
+			Entry("cert-manager without issuer name", GatewayTLS{Mode: "CertManager", CertManager: &CertManagerTLS{SecretName: "gw-tls"}}, false),
+			Entry("cert-manager with only a provided secret", GatewayTLS{Mode: "CertManager", Provided: &ProvidedTLS{SecretName: "gw-tls"}}, false),
+			Entry("provided with secret", GatewayTLS{Mode: "Provided", Provided: &ProvidedTLS{SecretName: "gw-tls"}}, true),
+			Entry("provided without provided", GatewayTLS{Mode: "Provided"}, false),
+			Entry("provided with empty secret name", GatewayTLS{Mode: "Provided", Provided: &ProvidedTLS{}}, false),
+			Entry("provided with only a cert-manager issuer", GatewayTLS{Mode: "Provided", CertManager: &CertManagerTLS{IssuerRef: IssuerRef{Name: "ca-issuer"}}}, false),
+		)
+	})
+})
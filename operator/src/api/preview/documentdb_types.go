@@ -5,10 +5,14 @@ package preview
 
 import (
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // DocumentDBSpec defines the desired state of DocumentDB.
+// +kubebuilder:validation:XValidation:rule="!has(self.networkPolicy) || !self.networkPolicy.enabled || !has(self.clusterReplication)",message="networkPolicy cannot be enabled with clusterReplication, whose replicas connect to Postgres from other clusters"
+// +kubebuilder:validation:XValidation:rule="!has(self.networkPolicy) || !self.networkPolicy.enabled || !has(self.exposeViaService) || self.exposeViaService.serviceType != 'LoadBalancer' || (has(self.networkPolicy.ipBlocks) && size(self.networkPolicy.ipBlocks) > 0)",message="networkPolicy with a LoadBalancer service requires networkPolicy.ipBlocks, as clients outside the cluster don't match pod or namespace selectors"
 type DocumentDBSpec struct {
 	// NodeCount is the number of nodes in the DocumentDB cluster. Must be 1.
 	// +kubebuilder:validation:Minimum=1
@@ -38,11 +42,49 @@ type DocumentDBSpec struct {
 	// If not specified, defaults to a version that matches the DocumentDB operator version.
 	GatewayImage string `json:"gatewayImage,omitempty"`
 
+	// ImagePullSecrets are the names of Secrets in the DocumentDB namespace used to pull the DocumentDB and
+	// gateway images, for images hosted in a private or air-gapped registry.
+	// +optional
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
+	// GatewayMetricsPort is the port the gateway serves metrics on. When set, it is declared on the gateway
+	// container and exposed as the "metrics" port of the DocumentDB services for scraping.
+	// Changing it restarts the instances.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:validation:XValidation:rule="self != 10260",message="gatewayMetricsPort must differ from the gateway port 10260"
+	// +optional
+	GatewayMetricsPort int32 `json:"gatewayMetricsPort,omitempty"`
+
+	// DisableGateway runs the cluster without the gateway sidecar, for clients that connect with the Postgres
+	// protocol instead of the MongoDB protocol. The services then expose the Postgres port and the connection
+	// string is a postgres:// URI. Changing it restarts the instances.
+	// +optional
+	DisableGateway bool `json:"disableGateway,omitempty"`
+
+	// AllowDowngrade permits changing the DocumentDB or gateway image to an older version, or to a version that
+	// can't be compared with the current one (e.g. from "16" to "0.1.3" or "latest").
+	// Without it, such changes are blocked and reported in status.upgrade.
+	// +optional
+	AllowDowngrade bool `json:"allowDowngrade,omitempty"`
+
 	// DocumentDbCredentialSecret is the name of the Kubernetes Secret containing credentials
 	// for the DocumentDB gateway (expects keys `username` and `password`). If omitted,
 	// a default secret name `documentdb-credentials` is used.
 	DocumentDbCredentialSecret string `json:"documentDbCredentialSecret,omitempty"`
 
+	// DocumentDbCredentialSecretNamespace is the namespace of DocumentDbCredentialSecret, defaulting to the
+	// DocumentDB's namespace. Pods cannot mount Secrets from other namespaces, so the operator keeps a copy
+	// of the Secret with the same name in the DocumentDB's namespace.
+	// +optional
+	DocumentDbCredentialSecretNamespace string `json:"documentDbCredentialSecretNamespace,omitempty"`
+
+	// SuperuserSecret is the name of a basic-auth Secret (keys `username` and `password`) for the
+	// Postgres superuser. When set, superuser access is enabled in CNPG with these credentials and the
+	// operator authenticates with them when it runs SQL, so pg_hba does not need to trust local connections.
+	// +optional
+	SuperuserSecret string `json:"superuserSecret,omitempty"`
+
 	// ClusterReplication configures cross-cluster replication for DocumentDB.
 	ClusterReplication *ClusterReplication `json:"clusterReplication,omitempty"`
 
@@ -52,21 +94,85 @@ type DocumentDBSpec struct {
 	// WalReplicaPluginName is the name of the wal replica plugin to use.
 	WalReplicaPluginName string `json:"walReplicaPluginName,omitempty"`
 
+	// ReplicaSetName is the replica set name advertised in the connection string.
+	// It must match the replica set name the gateway reports to clients.
+	// +kubebuilder:default=rs0
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	ReplicaSetName string `json:"replicaSetName,omitempty"`
+
+	// AuthMechanism is the SASL mechanism clients are told to use in the connection string.
+	// SCRAM-SHA-1 is offered for legacy drivers that do not support SCRAM-SHA-256.
+	// +kubebuilder:validation:Enum=SCRAM-SHA-256;SCRAM-SHA-1
+	// +kubebuilder:default=SCRAM-SHA-256
+	// +optional
+	AuthMechanism string `json:"authMechanism,omitempty"`
+
+	// DirectConnection makes clients connect only to the service endpoint instead of discovering
+	// the replica set topology. Disable it so multi-node clients can discover and route to replicas.
+	// +kubebuilder:default=true
+	// +optional
+	DirectConnection *bool `json:"directConnection,omitempty"`
+
+	// TLSInsecureSkipVerify makes the connection string skip verification of the gateway certificate.
+	// When false, certificates are verified whenever the gateway serves a certificate from spec.tls.
+	// +kubebuilder:default=false
+	// +optional
+	TLSInsecureSkipVerify bool `json:"tlsInsecureSkipVerify,omitempty"`
+
 	// ExposeViaService configures how to expose DocumentDB via a Kubernetes service.
 	// This can be a LoadBalancer or ClusterIP service.
 	ExposeViaService ExposeViaService `json:"exposeViaService,omitempty"`
 
+	// NetworkPolicy restricts the traffic allowed to reach the DocumentDB pods.
+	// +optional
+	NetworkPolicy *NetworkPolicyConfiguration `json:"networkPolicy,omitempty"`
+
 	// Environment specifies the cloud environment for deployment
 	// This determines cloud-specific service annotations for LoadBalancer services
+	// If not specified, it is detected from the provider IDs of the cluster nodes when they all agree. The detected
+	// environment is applied on every reconcile without being written back to this field.
 	// +kubebuilder:validation:Enum=eks;aks;gke
 	Environment string `json:"environment,omitempty"`
 
+	// PriorityClassName is the name of the PriorityClass of the DocumentDB pods, protecting them from
+	// preemption by lower priority workloads. Changing it restarts the instances.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// PrimaryUpdateStrategy controls whether CNPG updates the primary automatically after the replicas
+	// (unsupervised), or waits for a manual switchover or restart (supervised).
+	// If not specified, CNPG defaults to unsupervised.
+	// +kubebuilder:validation:Enum=unsupervised;supervised
+	// +optional
+	PrimaryUpdateStrategy string `json:"primaryUpdateStrategy,omitempty"`
+
+	// PrimaryUpdateMethod controls how CNPG updates the primary, by switching over to an updated replica
+	// (switchover) or by restarting it in place (restart).
+	// If not specified, CNPG defaults to restart.
+	// +kubebuilder:validation:Enum=switchover;restart
+	// +optional
+	PrimaryUpdateMethod string `json:"primaryUpdateMethod,omitempty"`
+
+	// PodLabels are additional labels for the DocumentDB pods, e.g. for cost allocation or network policies.
+	// Labels set by the operator take precedence.
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are additional annotations for the DocumentDB pods, e.g. for service mesh injection.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
 	Timeouts Timeouts `json:"timeouts,omitempty"`
 
 	// TLS configures certificate management for DocumentDB components.
 	TLS *TLSConfiguration `json:"tls,omitempty"`
 
-	// Overrides default log level for the DocumentDB cluster.
+	// Overrides default log level for the DocumentDB cluster. It applies to Postgres and to the gateway.
+	// Postgres picks up changes while running, the gateway when the instances restart.
+	// +kubebuilder:validation:Enum=error;warning;info;debug;trace
 	LogLevel string `json:"logLevel,omitempty"`
 
 	// Bootstrap configures the initialization of the DocumentDB cluster.
@@ -76,13 +182,146 @@ type DocumentDBSpec struct {
 	// Backup configures backup settings for DocumentDB.
 	// +optional
 	Backup *BackupConfiguration `json:"backup,omitempty"`
+
+	// Pooler configures a PgBouncer connection pooler between the gateway and the primary.
+	// +optional
+	Pooler *PoolerConfiguration `json:"pooler,omitempty"`
+
+	// Databases lists additional logical databases to create in the cluster, each through a CNPG Database.
+	// Removing an entry stops managing the database but keeps its data.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Databases []DatabaseSpec `json:"databases,omitempty"`
+}
+
+// DatabaseSpec declares a logical database and, optionally, the login role that owns it.
+// +kubebuilder:validation:XValidation:rule="!(self.name in ['postgres', 'template0', 'template1'])",message="name must not be a reserved Postgres database"
+// +kubebuilder:validation:XValidation:rule="!has(self.credentialsSecret) || (has(self.owner) && !(self.owner in ['documentdb', 'postgres', 'streaming_replica']))",message="credentialsSecret requires an owner other than the roles managed by the operator"
+type DatabaseSpec struct {
+	// Name is the name of the Postgres database.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Owner is the role that owns the database. Defaults to the documentdb role.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// CredentialsSecret is the name of a basic-auth Secret (keys `username` and `password`) in the DocumentDB
+	// namespace. When set, the owner is created as a login role with this password; the username must match the owner.
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// PoolerConfiguration defines the PgBouncer connection pooler settings.
+type PoolerConfiguration struct {
+	// Enabled creates a CNPG Pooler in front of the primary and routes the gateway through it.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Instances is the number of PgBouncer pods.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	Instances int32 `json:"instances,omitempty"`
+
+	// PoolMode is the PgBouncer pooling mode.
+	// +kubebuilder:validation:Enum=session;transaction
+	// +kubebuilder:default=session
+	// +optional
+	PoolMode string `json:"poolMode,omitempty"`
+
+	// DefaultPoolSize is the number of server connections allowed per user and database pair.
+	// If not specified, the PgBouncer default is used.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DefaultPoolSize int32 `json:"defaultPoolSize,omitempty"`
+
+	// MaxClientConnections is the maximum number of client connections PgBouncer accepts.
+	// If not specified, the PgBouncer default is used.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxClientConnections int32 `json:"maxClientConnections,omitempty"`
 }
 
 // BootstrapConfiguration defines how to bootstrap a DocumentDB cluster.
+// +kubebuilder:validation:XValidation:rule="!has(self.recovery) || !has(self.externalCluster)",message="recovery and externalCluster are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!has(self.clone) || (!has(self.recovery) && !has(self.externalCluster))",message="clone can't be combined with recovery or externalCluster"
 type BootstrapConfiguration struct {
 	// Recovery configures recovery from a backup.
 	// +optional
 	Recovery *RecoveryConfiguration `json:"recovery,omitempty"`
+
+	// ExternalCluster bootstraps the cluster from an existing Postgres or DocumentDB server outside the operator,
+	// e.g. to migrate it.
+	// +optional
+	ExternalCluster *ExternalClusterConfiguration `json:"externalCluster,omitempty"`
+
+	// Clone bootstraps the cluster with a copy of another DocumentDB: the operator takes a backup of the source
+	// and restores the cluster from it. The progress is reported in status.clone.
+	// +optional
+	Clone *CloneConfiguration `json:"clone,omitempty"`
+}
+
+// CloneConfiguration defines the DocumentDB a cluster is cloned from.
+type CloneConfiguration struct {
+	// Source is the DocumentDB to clone. It must be in the same namespace, since the backup is restored from
+	// volume snapshots.
+	Source cnpgv1.LocalObjectReference `json:"source"`
+}
+
+// Methods accepted in ExternalClusterConfiguration.Method.
+const (
+	ExternalClusterMethodPgBaseBackup = "PgBaseBackup"
+	ExternalClusterMethodImport       = "Import"
+)
+
+// ExternalClusterConfiguration defines the external server a DocumentDB cluster is bootstrapped from.
+// +kubebuilder:validation:XValidation:rule="!has(self.method) || self.method != 'Import' || (has(self.databases) && size(self.databases) > 0)",message="databases is required when method is Import"
+type ExternalClusterConfiguration struct {
+	// Host is the hostname or IP address of the external server.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// Port is the Postgres port of the external server.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=5432
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// User is the role to connect as. PgBaseBackup requires a role with the REPLICATION attribute.
+	// +kubebuilder:default=postgres
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// PasswordSecret references the key of the Secret holding the password of User.
+	PasswordSecret corev1.SecretKeySelector `json:"passwordSecret"`
+
+	// SSLMode is the libpq sslmode used to connect. If not specified, the libpq default (prefer) is used.
+	// +kubebuilder:validation:Enum=disable;allow;prefer;require;verify-ca;verify-full
+	// +optional
+	SSLMode string `json:"sslMode,omitempty"`
+
+	// SSLRootCert references the key of the Secret holding the CA certificate of the external server,
+	// required to verify it with sslMode verify-ca or verify-full.
+	// +optional
+	SSLRootCert *corev1.SecretKeySelector `json:"sslRootCert,omitempty"`
+
+	// Method selects how the data is copied. PgBaseBackup takes a physical copy of the whole server, which must
+	// run the same Postgres major version and DocumentDB extension. Import copies the listed databases with
+	// pg_dump and pg_restore.
+	// +kubebuilder:validation:Enum=PgBaseBackup;Import
+	// +kubebuilder:default=PgBaseBackup
+	// +optional
+	Method string `json:"method,omitempty"`
+
+	// Databases lists the databases to copy with the Import method.
+	// +optional
+	Databases []string `json:"databases,omitempty"`
 }
 
 // RecoveryConfiguration defines backup recovery settings.
@@ -101,6 +340,51 @@ type BackupConfiguration struct {
 	// +kubebuilder:default=30
 	// +optional
 	RetentionDays int `json:"retentionDays,omitempty"`
+
+	// WalArchive continuously archives WAL to an object store, independently of the volume snapshot backups,
+	// which enables point-in-time and cross-region recovery.
+	// +optional
+	WalArchive *WalArchiveConfiguration `json:"walArchive,omitempty"`
+}
+
+// WalArchiveConfiguration defines the object store the CNPG cluster archives WAL to with Barman Cloud.
+// +kubebuilder:validation:XValidation:rule="!has(self.encryption) || has(self.credentials.s3Credentials)",message="encryption is only supported with s3Credentials"
+type WalArchiveConfiguration struct {
+	// DestinationPath is the object store path to archive WAL to, e.g. s3://bucket/path.
+	// Each member of a replicated DocumentDB archives into its own folder, named after its CNPG cluster.
+	// +kubebuilder:validation:MinLength=1
+	DestinationPath string `json:"destinationPath"`
+
+	// EndpointURL overrides the object store endpoint, e.g. for S3-compatible storage such as MinIO.
+	// +optional
+	EndpointURL string `json:"endpointURL,omitempty"`
+
+	// Credentials reference the keys of the Secrets holding the object store credentials.
+	// +kubebuilder:validation:XValidation:rule="has(self.s3Credentials) || has(self.azureCredentials) || has(self.googleCredentials)",message="one of s3Credentials, azureCredentials or googleCredentials is required"
+	Credentials cnpgv1.BarmanCredentials `json:"credentials"`
+
+	// Wal tunes the archiving, e.g. its compression, encryption and parallelism.
+	// +optional
+	Wal *cnpgv1.WalBackupConfiguration `json:"wal,omitempty"`
+
+	// Encryption requests S3 server-side encryption of the archived WAL and of object store base backups,
+	// for buckets that don't enforce it themselves. It takes precedence over wal.encryption.
+	// +optional
+	Encryption *ObjectStoreEncryption `json:"encryption,omitempty"`
+}
+
+// ObjectStoreEncryption selects the S3 server-side encryption of the files Barman Cloud uploads.
+// +kubebuilder:validation:XValidation:rule="!has(self.kmsKeyId) || self.mode == 'aws:kms'",message="kmsKeyId requires mode aws:kms"
+type ObjectStoreEncryption struct {
+	// Mode is the server-side encryption to request: AES256 for keys managed by S3, or aws:kms for KMS keys.
+	// +kubebuilder:validation:Enum=AES256;"aws:kms"
+	Mode string `json:"mode"`
+
+	// KMSKeyID is the KMS key to encrypt with when Mode is aws:kms: a key ID, key ARN, alias name or alias ARN.
+	// If not specified, the AWS managed key of S3 is used.
+	// +kubebuilder:validation:Pattern=`^(arn:aws[a-z-]*:kms:[a-z0-9-]+:[0-9]{12}:(key|alias)/[A-Za-z0-9/_-]+|alias/[A-Za-z0-9/_-]+|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|mrk-[0-9a-f]{32})$`
+	// +optional
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
 }
 
 type Resource struct {
@@ -110,7 +394,13 @@ type Resource struct {
 
 type StorageConfiguration struct {
 	// PvcSize is the size of the persistent volume claim for DocumentDB storage (e.g., "10Gi").
-	PvcSize string `json:"pvcSize"`
+	// Must be at least 1Gi and cannot be decreased once set.
+	// +kubebuilder:default="10Gi"
+	// +kubebuilder:validation:XValidation:rule="isQuantity(self)",message="pvcSize must be a valid quantity, e.g. 10Gi"
+	// +kubebuilder:validation:XValidation:rule="!isQuantity(self) || quantity(self).compareTo(quantity('1Gi')) >= 0",message="pvcSize must be at least 1Gi"
+	// +kubebuilder:validation:XValidation:rule="!isQuantity(self) || !isQuantity(oldSelf) || quantity(self).compareTo(quantity(oldSelf)) >= 0",message="pvcSize cannot be decreased"
+	// +optional
+	PvcSize string `json:"pvcSize,omitempty"`
 
 	// StorageClass specifies the storage class for DocumentDB persistent volumes.
 	// If not specified, the cluster's default storage class will be used.
@@ -123,14 +413,59 @@ type ClusterReplication struct {
 	CrossCloudNetworkingStrategy string `json:"crossCloudNetworkingStrategy,omitempty"`
 	// Primary is the name of the primary cluster for replication.
 	Primary string `json:"primary"`
-	// ClusterList is the list of clusters participating in replication.
+	// ClusterList is the list of clusters participating in replication. Names must be unique.
+	// +listType=map
+	// +listMapKey=name
 	ClusterList []MemberCluster `json:"clusterList"`
 	// Whether or not to have replicas on the primary cluster.
 	HighAvailability bool `json:"highAvailability,omitempty"`
+	// IncludeWalReceiverInQuorum adds the WAL receiver (pg_receivewal) to the synchronous standby names
+	// used for quorum writes when HighAvailability is enabled. Only enable this when a WAL replica is deployed.
+	IncludeWalReceiverInQuorum bool `json:"includeWalReceiverInQuorum,omitempty"`
+	// SynchronousQuorumPercent is the percentage of available synchronous standbys (local replicas, remote
+	// clusters and, if included, the WAL receiver) that must acknowledge a write when HighAvailability is enabled.
+	// Defaults to a simple majority of the available standbys.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	SynchronousQuorumPercent int `json:"synchronousQuorumPercent,omitempty"`
+	// PromotionMode controls how the primary moves when Primary or status.targetPrimary changes.
+	// Switchover waits until the new primary has replayed the current primary's WAL before cutting over,
+	// while Failover cuts over immediately. Use Failover when the current primary is unavailable.
+	// Defaults to Failover.
+	// +kubebuilder:validation:Enum=Switchover;Failover
+	// +optional
+	PromotionMode string `json:"promotionMode,omitempty"`
+	// ReplicationDatabase is the database the member clusters connect to when replicating from each other.
+	// Defaults to postgres.
+	// +kubebuilder:default=postgres
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	ReplicationDatabase string `json:"replicationDatabase,omitempty"`
+	// ReplicationOwner is the owner of ReplicationDatabase on replica clusters bootstrapped from the primary.
+	// Defaults to postgres.
+	// +kubebuilder:default=postgres
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	ReplicationOwner string `json:"replicationOwner,omitempty"`
+	// ReplicationUser is the role the member clusters connect as when replicating from each other. It must have the
+	// REPLICATION attribute. Defaults to postgres.
+	// +kubebuilder:default=postgres
+	// +kubebuilder:validation:MinLength=1
+	// +optional
+	ReplicationUser string `json:"replicationUser,omitempty"`
+	// ReplicationTLSSecret names a secret holding a CA certificate (ca.crt) shared by all member clusters and a
+	// client certificate (tls.crt, tls.key) for ReplicationUser issued by that CA. When set, the member clusters
+	// verify each other's server certificates against the CA and authenticate with the client certificate. When
+	// unset, the connections between member clusters are encrypted without verifying the peer.
+	// +optional
+	ReplicationTLSSecret string `json:"replicationTLSSecret,omitempty"`
 }
 
 type MemberCluster struct {
-	// Name is the name of the member cluster.
+	// Name is the name of the member cluster, which is also the name of its CNPG Cluster.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=50
 	Name string `json:"name"`
 	// EnvironmentOverride is the cloud environment of the member cluster.
 	// Will default to the global setting
@@ -140,16 +475,118 @@ type MemberCluster struct {
 	StorageClassOverride string `json:"storageClass,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!has(self.healthCheckNodePort) || (has(self.externalTrafficPolicy) && self.externalTrafficPolicy == 'Local')",message="healthCheckNodePort requires externalTrafficPolicy Local"
 type ExposeViaService struct {
 	// ServiceType determines the type of service to expose for DocumentDB.
 	// +kubebuilder:validation:Enum=LoadBalancer;ClusterIP
 	ServiceType string `json:"serviceType"`
+
+	// EnableReaderEndpoint additionally creates a read-only service that forwards traffic to replica instances.
+	// +optional
+	EnableReaderEndpoint bool `json:"enableReaderEndpoint,omitempty"`
+
+	// Ingress additionally exposes the gateway service through an Ingress resource.
+	// The gateway speaks the MongoDB wire protocol over TLS rather than HTTP, so only ingress
+	// controllers that support TLS passthrough (routing on SNI without terminating TLS) are supported.
+	// +optional
+	Ingress *IngressConfiguration `json:"ingress,omitempty"`
+
+	// IPFamilyPolicy sets the IP family policy of the DocumentDB services for dual-stack clusters.
+	// If not specified, the cluster default (SingleStack) is used.
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy string `json:"ipFamilyPolicy,omitempty"`
+
+	// ExternalTrafficPolicy sets the external traffic policy of LoadBalancer services.
+	// Local preserves the client source IP but only routes to nodes running a ready pod.
+	// If not specified, the Kubernetes default (Cluster) is used.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy string `json:"externalTrafficPolicy,omitempty"`
+
+	// HealthCheckNodePort is the node port the load balancer uses to check which nodes run a ready pod.
+	// It requires ExternalTrafficPolicy Local and is allocated by Kubernetes if not specified.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	HealthCheckNodePort int32 `json:"healthCheckNodePort,omitempty"`
+
+	// Annotations are added to the DocumentDB services. They take precedence over the default annotations.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// DisableDefaultAnnotations stops the operator from adding the cloud-specific LoadBalancer annotations
+	// for the detected environment, so that only Annotations are applied.
+	// +optional
+	DisableDefaultAnnotations bool `json:"disableDefaultAnnotations,omitempty"`
+}
+
+// IngressConfiguration defines the Ingress created in front of the gateway service.
+type IngressConfiguration struct {
+	// IngressClassName selects the ingress controller. If empty, the cluster's default class is used.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Host is the hostname clients connect to. TLS passthrough routes on SNI, so a host is required.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// TLSSecretName references a secret holding the certificate for Host, for ingress controllers that require one.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Annotations are added to the Ingress. They default to enabling ingress-nginx SSL passthrough.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NetworkPolicyConfiguration defines the NetworkPolicy created for the DocumentDB pods. When enabled, the gateway
+// only accepts connections from the selected pods and Postgres only from the pods of the DocumentDB cluster.
+type NetworkPolicyConfiguration struct {
+	// Enabled creates the NetworkPolicy.
+	Enabled bool `json:"enabled"`
+
+	// NamespaceSelector selects the namespaces whose pods may connect to the gateway.
+	// If neither selector is set, only pods in the DocumentDB's namespace may connect, besides IPBlocks.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// PodSelector selects the pods that may connect to the gateway, within the namespaces selected by
+	// NamespaceSelector, or the DocumentDB's namespace if it is not set.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// IPBlocks lists the CIDRs outside the cluster that may connect to the gateway, such as the clients of a
+	// LoadBalancer service, which is required with one. Unless the service's externalTrafficPolicy is Local, the load
+	// balancer may replace the client address with a node address, so the node CIDRs must be allowed too.
+	// +optional
+	IPBlocks []networkingv1.IPBlock `json:"ipBlocks,omitempty"`
 }
 
 type Timeouts struct {
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=1800
 	StopDelay int32 `json:"stopDelay,omitempty"`
+
+	// StartDelay is the time in seconds allowed for an instance to start up.
+	// If not specified, the CNPG default of 3600 seconds is used.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	StartDelay int32 `json:"startDelay,omitempty"`
+
+	// SwitchoverDelay is the time in seconds allowed for the primary to shut down during a switchover.
+	// If not specified, the CNPG default of 3600 seconds is used.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=86400
+	SwitchoverDelay int32 `json:"switchoverDelay,omitempty"`
+
+	// SwitchoverTimeout is the time in seconds allowed for the instance in status.targetPrimary to become primary.
+	// When it runs out, status.targetPrimary is reverted to the current primary and a SwitchoverFailed event is
+	// emitted. If not specified, 900 seconds are allowed.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=86400
+	// +optional
+	SwitchoverTimeout int32 `json:"switchoverTimeout,omitempty"`
 }
 
 // TLSConfiguration aggregates TLS settings across DocumentDB components.
@@ -160,16 +597,21 @@ type TLSConfiguration struct {
 	// Postgres configures TLS for the Postgres server (placeholder for future phases).
 	Postgres *PostgresTLS `json:"postgres,omitempty"`
 
-	// GlobalEndpoints configures TLS for global endpoints (placeholder for future phases).
+	// GlobalEndpoints provisions a cert-manager certificate for the global DNS names of a multi-region deployment.
 	GlobalEndpoints *GlobalEndpointsTLS `json:"globalEndpoints,omitempty"`
 }
 
 // GatewayTLS defines TLS configuration for the gateway sidecar (Phase 1: certificate provisioning only)
+// +kubebuilder:validation:XValidation:rule="!has(self.mode) || self.mode != 'CertManager' || (has(self.certManager) && size(self.certManager.issuerRef.name) > 0)",message="certManager.issuerRef.name is required when mode is CertManager"
+// +kubebuilder:validation:XValidation:rule="!has(self.mode) || self.mode != 'Provided' || (has(self.provided) && size(self.provided.secretName) > 0)",message="provided.secretName is required when mode is Provided"
 type GatewayTLS struct {
 	// Mode selects the TLS management strategy.
 	// +kubebuilder:validation:Enum=Disabled;SelfSigned;CertManager;Provided
 	Mode string `json:"mode,omitempty"`
 
+	// SelfSigned config when Mode=SelfSigned.
+	SelfSigned *SelfSignedTLS `json:"selfSigned,omitempty"`
+
 	// CertManager config when Mode=CertManager.
 	CertManager *CertManagerTLS `json:"certManager,omitempty"`
 
@@ -180,8 +622,31 @@ type GatewayTLS struct {
 // PostgresTLS acts as a placeholder for future Postgres TLS settings.
 type PostgresTLS struct{}
 
-// GlobalEndpointsTLS acts as a placeholder for future global endpoint TLS settings.
-type GlobalEndpointsTLS struct{}
+// GlobalEndpointsTLS holds the global DNS names that front the member clusters of a multi-region deployment, such
+// as an Azure Traffic Manager or Route 53 record, and the cert-manager issuer of their certificate.
+// +kubebuilder:validation:XValidation:rule="size(self.issuerRef.name) > 0",message="issuerRef.name is required"
+type GlobalEndpointsTLS struct {
+	// DNSNames are the global DNS names clients connect to. The gateway Service DNS names are added as SANs too.
+	// +kubebuilder:validation:MinItems=1
+	DNSNames []string `json:"dnsNames"`
+	// IssuerRef is the cert-manager Issuer or ClusterIssuer that signs the certificate. Use issuers of the same CA
+	// in every member cluster so clients trust whichever region the global endpoint routes them to.
+	IssuerRef IssuerRef `json:"issuerRef"`
+	// SecretName optional explicit name for the target secret. If empty a default is chosen.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// SelfSignedTLS holds additional SANs for the operator generated self-signed certificate. The Service DNS
+// names and the LoadBalancer ingress address are always included.
+type SelfSignedTLS struct {
+	// DNSNames are extra DNS SANs, e.g. an external DNS name pointing at the gateway.
+	// +optional
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// IPAddresses are extra IP SANs.
+	// +optional
+	IPAddresses []string `json:"ipAddresses,omitempty"`
+}
 
 // CertManagerTLS holds parameters for cert-manager driven certificates.
 type CertManagerTLS struct {
@@ -195,6 +660,10 @@ type CertManagerTLS struct {
 // ProvidedTLS references an existing secret that contains tls.crt/tls.key (and optional ca.crt).
 type ProvidedTLS struct {
 	SecretName string `json:"secretName"`
+	// Namespace of the secret, defaulting to the DocumentDB's namespace. A secret in another namespace is
+	// copied into the DocumentDB's namespace under the same name.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // IssuerRef references a cert-manager Issuer or ClusterIssuer.
@@ -214,22 +683,104 @@ type DocumentDBStatus struct {
 	TargetPrimary    string `json:"targetPrimary,omitempty"`
 	LocalPrimary     string `json:"localPrimary,omitempty"`
 
+	// ObservedGeneration is the generation of the DocumentDB spec last applied to the CNPG Cluster.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ReaderConnectionString is the connection string for the read-only service, when the reader endpoint is enabled.
+	ReaderConnectionString string `json:"readerConnectionString,omitempty"`
+
+	// MultiHostConnectionString lists the gateway endpoints of all member clusters of a replicated DocumentDB,
+	// so clients can fail over between them. Member clusters not yet reachable through the cross-cloud networking are left out,
+	// and it is empty while fewer than two endpoints are known.
+	MultiHostConnectionString string `json:"multiHostConnectionString,omitempty"`
+
+	// ReadyInstances is the number of healthy instances in the underlying CNPG Cluster.
+	ReadyInstances int `json:"readyInstances,omitempty"`
+
+	// TotalInstances is the number of instances reported by the underlying CNPG Cluster.
+	TotalInstances int `json:"totalInstances,omitempty"`
+
+	// CurrentPrimary is the name of the instance currently acting as primary in the CNPG Cluster.
+	CurrentPrimary string `json:"currentPrimary,omitempty"`
+
+	// CredentialsSecretVersion is the resourceVersion of the credentials secret last observed by the operator.
+	// A change is reported in a CredentialsRotated event.
+	CredentialsSecretVersion string `json:"credentialsSecretVersion,omitempty"`
+
 	// TLS reports gateway TLS provisioning status (Phase 1).
 	TLS *TLSStatus `json:"tls,omitempty"`
+
+	// GlobalEndpointsTLS reports the provisioning status of the global endpoints certificate.
+	// +optional
+	GlobalEndpointsTLS *TLSStatus `json:"globalEndpointsTLS,omitempty"`
+
+	// Upgrade reports the progress of the latest DocumentDB or gateway image change.
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// Clone reports the progress of the clone from spec.bootstrap.clone.
+	// +optional
+	Clone *CloneStatus `json:"clone,omitempty"`
+
+	// Databases reports the provisioning state of each database in spec.databases.
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	Databases []DatabaseStatus `json:"databases,omitempty"`
+
+	// Conditions reports the latest observations of the DocumentDB cluster's state.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DatabaseStatus reports whether a database from spec.databases has been created.
+type DatabaseStatus struct {
+	// Name is the name of the Postgres database.
+	Name string `json:"name"`
+	// Applied is true once CNPG has created the database with the requested owner.
+	Applied bool `json:"applied"`
+	// Message explains why the database isn't applied yet.
+	Message string `json:"message,omitempty"`
+}
+
+// UpgradeStatus captures the progress of an image upgrade.
+type UpgradeStatus struct {
+	// Phase is one of InProgress, Completed or Blocked.
+	Phase string `json:"phase,omitempty"`
+	// DocumentDBImage is the engine image being upgraded to.
+	DocumentDBImage string `json:"documentDBImage,omitempty"`
+	// GatewayImage is the gateway image being upgraded to.
+	GatewayImage string `json:"gatewayImage,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+// CloneStatus captures the progress of a clone from spec.bootstrap.clone.
+type CloneStatus struct {
+	// Phase is one of BackingUp, Restoring, Completed or Failed.
+	Phase string `json:"phase,omitempty"`
+	// BackupName is the Backup of the source that the cluster is restored from.
+	BackupName string `json:"backupName,omitempty"`
+	Message    string `json:"message,omitempty"`
 }
 
 // TLSStatus captures readiness and secret information.
 type TLSStatus struct {
 	Ready      bool   `json:"ready,omitempty"`
 	SecretName string `json:"secretName,omitempty"`
-	Message    string `json:"message,omitempty"`
+	// CABundle is the PEM encoded CA from ca.crt of the TLS secret, used by clients to verify the gateway certificate.
+	CABundle string `json:"caBundle,omitempty"`
+	Message  string `json:"message,omitempty"`
 }
 
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=".status.status",description="CNPG Cluster Status"
+// +kubebuilder:printcolumn:name="Ready",type=integer,JSONPath=".status.readyInstances",description="Number of ready instances"
 // +kubebuilder:printcolumn:name="Connection String",type=string,JSONPath=".status.connectionString",description="DocumentDB Connection String"
 // +kubebuilder:resource:path=dbs,scope=Namespaced,singular=documentdb,shortName=documentdb
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:validation:XValidation:rule="size(self.metadata.name) <= 50",message="name must be at most 50 characters, the limit for CNPG Cluster names"
 
 // DocumentDB is the Schema for the dbs API.
 type DocumentDB struct {
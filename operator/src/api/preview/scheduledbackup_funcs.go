@@ -28,6 +28,7 @@ func (scheduledBackup *ScheduledBackup) CreateBackup(now time.Time) *Backup {
 		Spec: BackupSpec{
 			Cluster:       scheduledBackup.Spec.Cluster,
 			RetentionDays: scheduledBackup.Spec.RetentionDays,
+			Method:        scheduledBackup.Spec.Method,
 		},
 	}
 }
@@ -29,6 +29,7 @@ var _ = Describe("ScheduledBackup", func() {
 						Name: "test-cluster",
 					},
 					RetentionDays: &retentionDays,
+					Method:        BackupMethodObjectStore,
 				},
 			}
 
@@ -41,6 +42,7 @@ var _ = Describe("ScheduledBackup", func() {
 			Expect(backup.Spec.Cluster.Name).To(Equal("test-cluster"))
 			Expect(backup.Spec.RetentionDays).ToNot(BeNil())
 			Expect(*backup.Spec.RetentionDays).To(Equal(7))
+			Expect(backup.Spec.Method).To(Equal(BackupMethodObjectStore))
 		})
 
 		It("creates a Backup without RetentionDays when not specified", func() {
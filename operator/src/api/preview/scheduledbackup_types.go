@@ -24,6 +24,12 @@ type ScheduledBackupSpec struct {
 	// If not specified, the default retention period from the cluster's backup retention policy will be used.
 	// +optional
 	RetentionDays *int `json:"retentionDays,omitempty"`
+
+	// Method is how the backups are taken, see BackupSpec.Method.
+	// +kubebuilder:validation:Enum=VolumeSnapshot;ObjectStore
+	// +kubebuilder:default=VolumeSnapshot
+	// +optional
+	Method string `json:"method,omitempty"`
 }
 
 // ScheduledBackupStatus defines the observed state of ScheduledBackup
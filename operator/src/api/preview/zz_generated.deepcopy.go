@@ -8,6 +8,10 @@
 package preview
 
 import (
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -41,6 +45,11 @@ func (in *Backup) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupConfiguration) DeepCopyInto(out *BackupConfiguration) {
 	*out = *in
+	if in.WalArchive != nil {
+		in, out := &in.WalArchive, &out.WalArchive
+		*out = new(WalArchiveConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupConfiguration.
@@ -121,6 +130,16 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 		in, out := &in.ExpiredAt, &out.ExpiredAt
 		*out = (*in).DeepCopy()
 	}
+	if in.Snapshots != nil {
+		in, out := &in.Snapshots, &out.Snapshots
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
@@ -141,6 +160,16 @@ func (in *BootstrapConfiguration) DeepCopyInto(out *BootstrapConfiguration) {
 		*out = new(RecoveryConfiguration)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ExternalCluster != nil {
+		in, out := &in.ExternalCluster, &out.ExternalCluster
+		*out = new(ExternalClusterConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(CloneConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapConfiguration.
@@ -174,6 +203,37 @@ func (in *CertManagerTLS) DeepCopy() *CertManagerTLS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneConfiguration) DeepCopyInto(out *CloneConfiguration) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneConfiguration.
+func (in *CloneConfiguration) DeepCopy() *CloneConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneStatus) DeepCopyInto(out *CloneStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneStatus.
+func (in *CloneStatus) DeepCopy() *CloneStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterReplication) DeepCopyInto(out *ClusterReplication) {
 	*out = *in
@@ -194,6 +254,36 @@ func (in *ClusterReplication) DeepCopy() *ClusterReplication {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseSpec) DeepCopyInto(out *DatabaseSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseSpec.
+func (in *DatabaseSpec) DeepCopy() *DatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseStatus) DeepCopyInto(out *DatabaseStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseStatus.
+func (in *DatabaseStatus) DeepCopy() *DatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DocumentDB) DeepCopyInto(out *DocumentDB) {
 	*out = *in
@@ -257,12 +347,41 @@ func (in *DocumentDBList) DeepCopyObject() runtime.Object {
 func (in *DocumentDBSpec) DeepCopyInto(out *DocumentDBSpec) {
 	*out = *in
 	out.Resource = in.Resource
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.ClusterReplication != nil {
 		in, out := &in.ClusterReplication, &out.ClusterReplication
 		*out = new(ClusterReplication)
 		(*in).DeepCopyInto(*out)
 	}
-	out.ExposeViaService = in.ExposeViaService
+	if in.DirectConnection != nil {
+		in, out := &in.DirectConnection, &out.DirectConnection
+		*out = new(bool)
+		**out = **in
+	}
+	in.ExposeViaService.DeepCopyInto(&out.ExposeViaService)
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicyConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodLabels != nil {
+		in, out := &in.PodLabels, &out.PodLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	out.Timeouts = in.Timeouts
 	if in.TLS != nil {
 		in, out := &in.TLS, &out.TLS
@@ -277,8 +396,18 @@ func (in *DocumentDBSpec) DeepCopyInto(out *DocumentDBSpec) {
 	if in.Backup != nil {
 		in, out := &in.Backup, &out.Backup
 		*out = new(BackupConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pooler != nil {
+		in, out := &in.Pooler, &out.Pooler
+		*out = new(PoolerConfiguration)
 		**out = **in
 	}
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]DatabaseSpec, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DocumentDBSpec.
@@ -299,6 +428,33 @@ func (in *DocumentDBStatus) DeepCopyInto(out *DocumentDBStatus) {
 		*out = new(TLSStatus)
 		**out = **in
 	}
+	if in.GlobalEndpointsTLS != nil {
+		in, out := &in.GlobalEndpointsTLS, &out.GlobalEndpointsTLS
+		*out = new(TLSStatus)
+		**out = **in
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		**out = **in
+	}
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(CloneStatus)
+		**out = **in
+	}
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]DatabaseStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DocumentDBStatus.
@@ -314,6 +470,18 @@ func (in *DocumentDBStatus) DeepCopy() *DocumentDBStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExposeViaService) DeepCopyInto(out *ExposeViaService) {
 	*out = *in
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(IngressConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExposeViaService.
@@ -326,9 +494,40 @@ func (in *ExposeViaService) DeepCopy() *ExposeViaService {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalClusterConfiguration) DeepCopyInto(out *ExternalClusterConfiguration) {
+	*out = *in
+	in.PasswordSecret.DeepCopyInto(&out.PasswordSecret)
+	if in.SSLRootCert != nil {
+		in, out := &in.SSLRootCert, &out.SSLRootCert
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalClusterConfiguration.
+func (in *ExternalClusterConfiguration) DeepCopy() *ExternalClusterConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalClusterConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayTLS) DeepCopyInto(out *GatewayTLS) {
 	*out = *in
+	if in.SelfSigned != nil {
+		in, out := &in.SelfSigned, &out.SelfSigned
+		*out = new(SelfSignedTLS)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.CertManager != nil {
 		in, out := &in.CertManager, &out.CertManager
 		*out = new(CertManagerTLS)
@@ -354,6 +553,12 @@ func (in *GatewayTLS) DeepCopy() *GatewayTLS {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GlobalEndpointsTLS) DeepCopyInto(out *GlobalEndpointsTLS) {
 	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.IssuerRef = in.IssuerRef
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GlobalEndpointsTLS.
@@ -366,6 +571,28 @@ func (in *GlobalEndpointsTLS) DeepCopy() *GlobalEndpointsTLS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressConfiguration) DeepCopyInto(out *IngressConfiguration) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressConfiguration.
+func (in *IngressConfiguration) DeepCopy() *IngressConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IssuerRef) DeepCopyInto(out *IssuerRef) {
 	*out = *in
@@ -396,6 +623,68 @@ func (in *MemberCluster) DeepCopy() *MemberCluster {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyConfiguration) DeepCopyInto(out *NetworkPolicyConfiguration) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IPBlocks != nil {
+		in, out := &in.IPBlocks, &out.IPBlocks
+		*out = make([]networkingv1.IPBlock, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyConfiguration.
+func (in *NetworkPolicyConfiguration) DeepCopy() *NetworkPolicyConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStoreEncryption) DeepCopyInto(out *ObjectStoreEncryption) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStoreEncryption.
+func (in *ObjectStoreEncryption) DeepCopy() *ObjectStoreEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStoreEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolerConfiguration) DeepCopyInto(out *PoolerConfiguration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolerConfiguration.
+func (in *PoolerConfiguration) DeepCopy() *PoolerConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolerConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PostgresTLS) DeepCopyInto(out *PostgresTLS) {
 	*out = *in
@@ -561,6 +850,31 @@ func (in *ScheduledBackupStatus) DeepCopy() *ScheduledBackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SelfSignedTLS) DeepCopyInto(out *SelfSignedTLS) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPAddresses != nil {
+		in, out := &in.IPAddresses, &out.IPAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SelfSignedTLS.
+func (in *SelfSignedTLS) DeepCopy() *SelfSignedTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(SelfSignedTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageConfiguration) DeepCopyInto(out *StorageConfiguration) {
 	*out = *in
@@ -592,7 +906,7 @@ func (in *TLSConfiguration) DeepCopyInto(out *TLSConfiguration) {
 	if in.GlobalEndpoints != nil {
 		in, out := &in.GlobalEndpoints, &out.GlobalEndpoints
 		*out = new(GlobalEndpointsTLS)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -635,3 +949,44 @@ func (in *Timeouts) DeepCopy() *Timeouts {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WalArchiveConfiguration) DeepCopyInto(out *WalArchiveConfiguration) {
+	*out = *in
+	in.Credentials.DeepCopyInto(&out.Credentials)
+	if in.Wal != nil {
+		in, out := &in.Wal, &out.Wal
+		*out = new(apiv1.WalBackupConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(ObjectStoreEncryption)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WalArchiveConfiguration.
+func (in *WalArchiveConfiguration) DeepCopy() *WalArchiveConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(WalArchiveConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -6,8 +6,10 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -57,13 +59,16 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var requeue controller.RequeueConfig
+	var reconcileTimeout time.Duration
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
-			"Enabling this will ensure there is only one active controller manager.")
+			"Enabling this will ensure there is only one active controller manager, "+
+			"and that leader-only background tasks run on a single replica.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true,
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
@@ -75,6 +80,18 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&requeue.Short, "requeue-after-short",
+		durationFromEnv("REQUEUE_AFTER_SHORT", controller.DefaultRequeueAfterShort),
+		"The base interval to requeue after while waiting for resources expected to be ready soon. "+
+			"Defaults to the REQUEUE_AFTER_SHORT environment variable, if set.")
+	flag.DurationVar(&requeue.Long, "requeue-after-long",
+		durationFromEnv("REQUEUE_AFTER_LONG", controller.DefaultRequeueAfterLong),
+		"The base interval to requeue after while waiting for slower operations, such as a new cluster starting up. "+
+			"Defaults to the REQUEUE_AFTER_LONG environment variable, if set.")
+	flag.DurationVar(&reconcileTimeout, "reconcile-timeout",
+		durationFromEnv("RECONCILE_TIMEOUT", controller.DefaultReconcileTimeout),
+		"The deadline of a single DocumentDB reconcile, after which it is requeued. "+
+			"Defaults to the RECONCILE_TIMEOUT environment variable, if set.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -83,6 +100,17 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if requeue.Short <= 0 || requeue.Long <= 0 {
+		setupLog.Error(fmt.Errorf("requeue intervals must be positive"), "invalid requeue configuration",
+			"requeueAfterShort", requeue.Short, "requeueAfterLong", requeue.Long)
+		os.Exit(1)
+	}
+	if reconcileTimeout <= 0 {
+		setupLog.Error(fmt.Errorf("reconcile timeout must be positive"), "invalid reconcile timeout",
+			"reconcileTimeout", reconcileTimeout)
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -197,8 +225,9 @@ func main() {
 	}
 
 	if err = (&controller.CertificateReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:  mgr.GetClient(),
+		Scheme:  mgr.GetScheme(),
+		Requeue: requeue,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Certificate")
 		os.Exit(1)
@@ -212,10 +241,13 @@ func main() {
 	}
 
 	if err = (&controller.DocumentDBReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Config:    mgr.GetConfig(),
-		Clientset: clientset,
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Config:           mgr.GetConfig(),
+		Clientset:        clientset,
+		Recorder:         mgr.GetEventRecorderFor("documentdb-controller"),
+		Requeue:          requeue,
+		ReconcileTimeout: reconcileTimeout,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DocumentDB")
 		os.Exit(1)
@@ -239,6 +271,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controller.OrphanedClusterReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("orphaned-cluster-controller"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "OrphanedCluster")
+		os.Exit(1)
+	}
+
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -272,3 +313,18 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// durationFromEnv returns the duration in the environment variable of the given name, or defaultValue when it is
+// not set. The operator exits on an invalid duration rather than silently falling back to the default.
+func durationFromEnv(name string, defaultValue time.Duration) time.Duration {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid duration %q in environment variable %s\n", value, name)
+		os.Exit(1)
+	}
+	return duration
+}
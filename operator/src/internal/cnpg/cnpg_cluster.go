@@ -5,7 +5,9 @@ package cnpg
 
 import (
 	"cmp"
+	"strconv"
 
+	barmanApi "github.com/cloudnative-pg/barman-cloud/pkg/api"
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/go-logr/logr"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -16,20 +18,20 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// GetSidecarPluginName returns the name of the sidecar injector plugin configured on the CNPG Cluster
+func GetSidecarPluginName(documentdb *dbpreview.DocumentDB) string {
+	return cmp.Or(documentdb.Spec.SidecarInjectorPluginName, util.DEFAULT_SIDECAR_INJECTOR_PLUGIN)
+}
+
 func GetCnpgClusterSpec(req ctrl.Request, documentdb *dbpreview.DocumentDB, documentdb_image, serviceAccountName, storageClass string, isPrimaryRegion bool, log logr.Logger) *cnpgv1.Cluster {
-	sidecarPluginName := documentdb.Spec.SidecarInjectorPluginName
-	if sidecarPluginName == "" {
-		sidecarPluginName = util.DEFAULT_SIDECAR_INJECTOR_PLUGIN
-	}
+	sidecarPluginName := GetSidecarPluginName(documentdb)
 
 	// Get the gateway image for this DocumentDB instance
 	gatewayImage := util.GetGatewayImageForDocumentDB(documentdb)
 	log.Info("Creating CNPG cluster with gateway image", "gatewayImage", gatewayImage, "documentdbName", documentdb.Name, "specGatewayImage", documentdb.Spec.GatewayImage)
 
-	credentialSecretName := documentdb.Spec.DocumentDbCredentialSecret
-	if credentialSecretName == "" {
-		credentialSecretName = util.DEFAULT_DOCUMENTDB_CREDENTIALS_SECRET
-	}
+	// A credential secret in another namespace is copied into this namespace under the same name
+	credentialSecretName := util.CredentialSecretSource(documentdb).Name
 
 	// Configure storage class - use specified storage class or nil for default
 	var storageClassPointer *string
@@ -41,6 +43,10 @@ func GetCnpgClusterSpec(req ctrl.Request, documentdb *dbpreview.DocumentDB, docu
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      req.Name,
 			Namespace: req.Namespace,
+			Labels: map[string]string{
+				util.LABEL_APP:        documentdb.Name,
+				util.LABEL_MANAGED_BY: util.MANAGED_BY_DOCUMENTDB_OPERATOR,
+			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion:         documentdb.APIVersion,
@@ -60,9 +66,27 @@ func GetCnpgClusterSpec(req ctrl.Request, documentdb *dbpreview.DocumentDB, docu
 					StorageClass: storageClassPointer, // Use configured storage class or default
 					Size:         documentdb.Spec.Resource.Storage.PvcSize,
 				},
-				InheritedMetadata: getInheritedMetadataLabels(documentdb.Name),
+				InheritedMetadata: getInheritedMetadata(documentdb),
 				Plugins: func() []cnpgv1.PluginConfiguration {
-					params := map[string]string{"gatewayImage": gatewayImage}
+					// Without the gateway, clients connect to Postgres directly and no sidecar is injected
+					if documentdb.Spec.DisableGateway {
+						return nil
+					}
+					params := map[string]string{
+						util.GATEWAY_IMAGE_PLUGIN_PARAMETER:     gatewayImage,
+						util.PG_PORT_PLUGIN_PARAMETER:           strconv.Itoa(int(util.GetPortFor(util.POSTGRES_PORT))),
+						util.CREDENTIAL_SECRET_PLUGIN_PARAMETER: credentialSecretName,
+					}
+					if documentdb.Spec.GatewayMetricsPort != 0 {
+						params[util.GATEWAY_METRICS_PORT_PLUGIN_PARAMETER] = strconv.Itoa(int(documentdb.Spec.GatewayMetricsPort))
+					}
+					if gatewayLogLevel := getGatewayLogLevel(documentdb.Spec.LogLevel); gatewayLogLevel != "" {
+						params[util.GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER] = gatewayLogLevel
+					}
+					// Route the gateway through the PgBouncer pooler when it is enabled
+					if documentdb.Spec.Pooler != nil && documentdb.Spec.Pooler.Enabled {
+						params[util.PG_HOST_PLUGIN_PARAMETER] = util.GetDocumentDBPoolerName(req.Name)
+					}
 					// If TLS is ready, surface secret name to plugin so it can mount certs.
 					if documentdb.Status.TLS != nil && documentdb.Status.TLS.Ready && documentdb.Status.TLS.SecretName != "" {
 						params["gatewayTLSSecret"] = documentdb.Status.TLS.SecretName
@@ -88,8 +112,9 @@ func GetCnpgClusterSpec(req ctrl.Request, documentdb *dbpreview.DocumentDB, docu
 						"host replication all all trust",
 					},
 				},
-				Bootstrap: getBootstrapConfiguration(documentdb, isPrimaryRegion, log),
-				LogLevel:  cmp.Or(documentdb.Spec.LogLevel, "info"),
+				Bootstrap:        getBootstrapConfiguration(documentdb, isPrimaryRegion, log),
+				ExternalClusters: getExternalClusters(documentdb, isPrimaryRegion),
+				LogLevel:         cmp.Or(documentdb.Spec.LogLevel, "info"),
 				Backup: &cnpgv1.BackupConfiguration{
 					VolumeSnapshot: &cnpgv1.VolumeSnapshotConfiguration{
 						SnapshotOwnerReference: "backup", // Set owner reference to 'backup' so that snapshots are deleted when Backup resource is deleted
@@ -97,24 +122,99 @@ func GetCnpgClusterSpec(req ctrl.Request, documentdb *dbpreview.DocumentDB, docu
 					Target: cnpgv1.BackupTarget("primary"),
 				},
 			}
+			if backup := documentdb.Spec.Backup; backup != nil && backup.WalArchive != nil {
+				spec.Backup.BarmanObjectStore = getBarmanObjectStore(backup.WalArchive, req.Name)
+			}
+			// Enable superuser access only with explicit credentials, so SQL run by the operator can authenticate
+			if documentdb.Spec.SuperuserSecret != "" {
+				spec.EnableSuperuserAccess = pointer.Bool(true)
+				spec.SuperuserSecret = &cnpgv1.LocalObjectReference{Name: documentdb.Spec.SuperuserSecret}
+			}
+			// Owners of databases with credentials are created as login roles
+			if roles := getManagedRoles(documentdb); len(roles) > 0 {
+				spec.Managed = &cnpgv1.ManagedConfiguration{Roles: roles}
+			}
+			spec.PriorityClassName = documentdb.Spec.PriorityClassName
+			spec.PrimaryUpdateStrategy = cnpgv1.PrimaryUpdateStrategy(documentdb.Spec.PrimaryUpdateStrategy)
+			spec.PrimaryUpdateMethod = cnpgv1.PrimaryUpdateMethod(documentdb.Spec.PrimaryUpdateMethod)
+			for _, name := range documentdb.Spec.ImagePullSecrets {
+				spec.ImagePullSecrets = append(spec.ImagePullSecrets, cnpgv1.LocalObjectReference{Name: name})
+			}
 			spec.MaxStopDelay = getMaxStopDelayOrDefault(documentdb)
+			// Leave the start and switchover delays unset so CNPG applies its defaults
+			spec.MaxStartDelay = documentdb.Spec.Timeouts.StartDelay
+			spec.MaxSwitchoverDelay = documentdb.Spec.Timeouts.SwitchoverDelay
 			return spec
 		}(),
 	}
 }
 
-func getInheritedMetadataLabels(appName string) *cnpgv1.EmbeddedObjectMetadata {
+// getBarmanObjectStore returns the Barman Cloud configuration archiving WAL to the object store. Base backups keep
+// using volume snapshots by default, so the data settings are only set to encrypt object store backups. Each CNPG
+// cluster archives into its own folder, since the members of a replicated DocumentDB would otherwise write to the
+// same WAL archive.
+func getBarmanObjectStore(walArchive *dbpreview.WalArchiveConfiguration, clusterName string) *cnpgv1.BarmanObjectStoreConfiguration {
+	wal := &cnpgv1.WalBackupConfiguration{}
+	if walArchive.Wal != nil {
+		wal = walArchive.Wal.DeepCopy()
+	}
+
+	var data *cnpgv1.DataBackupConfiguration
+	if encryption := walArchive.Encryption; encryption != nil {
+		wal.Encryption = barmanApi.EncryptionType(encryption.Mode)
+		data = &cnpgv1.DataBackupConfiguration{Encryption: barmanApi.EncryptionType(encryption.Mode)}
+		// Barman Cloud has no CNPG setting for the KMS key, only a command-line option
+		if encryption.KMSKeyID != "" {
+			kmsKeyArg := "--sse-kms-key-id=" + encryption.KMSKeyID
+			wal.ArchiveAdditionalCommandArgs = append(wal.ArchiveAdditionalCommandArgs, kmsKeyArg)
+			data.AdditionalCommandArgs = []string{kmsKeyArg}
+		}
+	}
+
+	return &cnpgv1.BarmanObjectStoreConfiguration{
+		BarmanCredentials: *walArchive.Credentials.DeepCopy(),
+		EndpointURL:       walArchive.EndpointURL,
+		DestinationPath:   walArchive.DestinationPath,
+		ServerName:        clusterName,
+		Wal:               wal,
+		Data:              data,
+	}
+}
+
+// getInheritedMetadata returns the labels and annotations of the pods, merging the ones from the spec under the
+// labels the operator relies on
+func getInheritedMetadata(documentdb *dbpreview.DocumentDB) *cnpgv1.EmbeddedObjectMetadata {
+	labels := make(map[string]string, len(documentdb.Spec.PodLabels)+1)
+	for key, value := range documentdb.Spec.PodLabels {
+		labels[key] = value
+	}
+	labels[util.LABEL_APP] = documentdb.Name
+
+	var annotations map[string]string
+	if len(documentdb.Spec.PodAnnotations) > 0 {
+		annotations = make(map[string]string, len(documentdb.Spec.PodAnnotations))
+		for key, value := range documentdb.Spec.PodAnnotations {
+			annotations[key] = value
+		}
+	}
+
 	return &cnpgv1.EmbeddedObjectMetadata{
-		Labels: map[string]string{
-			util.LABEL_APP:          appName,
-			util.LABEL_REPLICA_TYPE: "primary", // TODO: Replace with CNPG default setup
-		},
+		Labels:      labels,
+		Annotations: annotations,
 	}
 }
 
 func getBootstrapConfiguration(documentdb *dbpreview.DocumentDB, isPrimaryRegion bool, log logr.Logger) *cnpgv1.BootstrapConfiguration {
-	if isPrimaryRegion && documentdb.Spec.Bootstrap != nil && documentdb.Spec.Bootstrap.Recovery != nil && documentdb.Spec.Bootstrap.Recovery.Backup.Name != "" {
-		backupName := documentdb.Spec.Bootstrap.Recovery.Backup.Name
+	backupName := ""
+	if isPrimaryRegion && documentdb.Spec.Bootstrap != nil {
+		if recovery := documentdb.Spec.Bootstrap.Recovery; recovery != nil {
+			backupName = recovery.Backup.Name
+		} else if documentdb.Spec.Bootstrap.Clone != nil {
+			// The controller takes this backup of the source before it creates the cluster
+			backupName = util.GetCloneBackupName(documentdb.Name)
+		}
+	}
+	if backupName != "" {
 		log.Info("DocumentDB cluster will be bootstrapped from backup", "backupName", backupName)
 		return &cnpgv1.BootstrapConfiguration{
 			Recovery: &cnpgv1.BootstrapRecovery{
@@ -125,15 +225,70 @@ func getBootstrapConfiguration(documentdb *dbpreview.DocumentDB, isPrimaryRegion
 		}
 	}
 
-	return &cnpgv1.BootstrapConfiguration{
-		InitDB: &cnpgv1.BootstrapInitDB{
-			PostInitSQL: []string{
-				"CREATE EXTENSION documentdb CASCADE",
-				"CREATE ROLE documentdb WITH LOGIN PASSWORD 'Admin100'",
-				"ALTER ROLE documentdb WITH SUPERUSER CREATEDB CREATEROLE REPLICATION BYPASSRLS",
-			},
+	initDB := &cnpgv1.BootstrapInitDB{
+		PostInitSQL: []string{
+			"CREATE EXTENSION documentdb CASCADE",
+			"CREATE ROLE documentdb WITH LOGIN PASSWORD 'Admin100'",
+			"ALTER ROLE documentdb WITH SUPERUSER CREATEDB CREATEROLE REPLICATION BYPASSRLS",
 		},
 	}
+
+	if isPrimaryRegion && documentdb.Spec.Bootstrap != nil && documentdb.Spec.Bootstrap.ExternalCluster != nil {
+		externalCluster := documentdb.Spec.Bootstrap.ExternalCluster
+		log.Info("DocumentDB cluster will be bootstrapped from an external cluster", "host", externalCluster.Host, "method", externalCluster.Method)
+		if externalCluster.Method == dbpreview.ExternalClusterMethodImport {
+			initDB.Import = &cnpgv1.Import{
+				Source:    cnpgv1.ImportSource{ExternalCluster: util.EXTERNAL_SOURCE_CLUSTER_NAME},
+				Type:      cnpgv1.MonolithSnapshotType,
+				Databases: externalCluster.Databases,
+			}
+			return &cnpgv1.BootstrapConfiguration{InitDB: initDB}
+		}
+		// The physical copy already contains the DocumentDB extension and roles of the external server
+		return &cnpgv1.BootstrapConfiguration{
+			PgBaseBackup: &cnpgv1.BootstrapPgBaseBackup{
+				Source:   util.EXTERNAL_SOURCE_CLUSTER_NAME,
+				Database: "postgres",
+				Owner:    "postgres",
+			},
+		}
+	}
+
+	return &cnpgv1.BootstrapConfiguration{InitDB: initDB}
+}
+
+// getExternalClusters returns the external server the cluster is bootstrapped from, if any
+func getExternalClusters(documentdb *dbpreview.DocumentDB, isPrimaryRegion bool) []cnpgv1.ExternalCluster {
+	if !isPrimaryRegion || documentdb.Spec.Bootstrap == nil || documentdb.Spec.Bootstrap.ExternalCluster == nil {
+		return nil
+	}
+	externalCluster := documentdb.Spec.Bootstrap.ExternalCluster
+
+	connectionParameters := map[string]string{
+		"host":   externalCluster.Host,
+		"port":   strconv.Itoa(int(cmp.Or(externalCluster.Port, 5432))),
+		"user":   cmp.Or(externalCluster.User, "postgres"),
+		"dbname": "postgres",
+	}
+	if externalCluster.SSLMode != "" {
+		connectionParameters["sslmode"] = externalCluster.SSLMode
+	}
+
+	return []cnpgv1.ExternalCluster{{
+		Name:                 util.EXTERNAL_SOURCE_CLUSTER_NAME,
+		ConnectionParameters: connectionParameters,
+		Password:             externalCluster.PasswordSecret.DeepCopy(),
+		SSLRootCert:          externalCluster.SSLRootCert.DeepCopy(),
+	}}
+}
+
+// getGatewayLogLevel maps the cluster log level to the gateway's, which calls warnings "warn".
+// Without a cluster log level the gateway keeps its default.
+func getGatewayLogLevel(logLevel string) string {
+	if logLevel == "warning" {
+		return "warn"
+	}
+	return logLevel
 }
 
 // getMaxStopDelayOrDefault returns StopDelay if set, otherwise util.CNPG_DEFAULT_STOP_DELAY
@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package cnpg
+
+import (
+	"cmp"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+// GetCnpgDatabaseSpec returns the CNPG Database that creates a database from spec.databases in the given CNPG cluster
+func GetCnpgDatabaseSpec(documentdb *dbpreview.DocumentDB, database dbpreview.DatabaseSpec, clusterName, namespace string) *cnpgv1.Database {
+	return &cnpgv1.Database{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.GetDocumentDBDatabaseName(clusterName, database.Name),
+			Namespace: namespace,
+			Labels: map[string]string{
+				util.LABEL_APP: documentdb.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         documentdb.APIVersion,
+					Kind:               documentdb.Kind,
+					Name:               documentdb.Name,
+					UID:                documentdb.UID,
+					Controller:         pointer.Bool(true),
+					BlockOwnerDeletion: pointer.Bool(true),
+				},
+			},
+		},
+		Spec: cnpgv1.DatabaseSpec{
+			ClusterRef: corev1.LocalObjectReference{Name: clusterName},
+			Ensure:     cnpgv1.EnsurePresent,
+			Name:       database.Name,
+			Owner:      cmp.Or(database.Owner, util.DEFAULT_DATABASE_OWNER),
+		},
+	}
+}
+
+// getManagedRoles returns the login roles for the owners of the databases that have credentials, in spec order.
+// An owner listed for several databases gets the credentials of its first database.
+func getManagedRoles(documentdb *dbpreview.DocumentDB) []cnpgv1.RoleConfiguration {
+	var roles []cnpgv1.RoleConfiguration
+	seen := map[string]bool{}
+	for _, database := range documentdb.Spec.Databases {
+		if database.CredentialsSecret == "" || database.Owner == "" || database.Owner == util.DEFAULT_DATABASE_OWNER || seen[database.Owner] {
+			continue
+		}
+		seen[database.Owner] = true
+		roles = append(roles, cnpgv1.RoleConfiguration{
+			Name:           database.Owner,
+			Ensure:         cnpgv1.EnsurePresent,
+			Login:          true,
+			PasswordSecret: &cnpgv1.LocalObjectReference{Name: database.CredentialsSecret},
+		})
+	}
+	return roles
+}
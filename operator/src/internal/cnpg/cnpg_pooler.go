@@ -0,0 +1,64 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package cnpg
+
+import (
+	"strconv"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+// GetCnpgPoolerSpec returns the PgBouncer Pooler placed in front of the primary of the given CNPG cluster
+func GetCnpgPoolerSpec(documentdb *dbpreview.DocumentDB, clusterName, namespace string) *cnpgv1.Pooler {
+	poolerConfig := documentdb.Spec.Pooler
+
+	instances := poolerConfig.Instances
+	if instances < 1 {
+		instances = 1
+	}
+
+	poolMode := cnpgv1.PgBouncerPoolModeSession
+	if poolerConfig.PoolMode != "" {
+		poolMode = cnpgv1.PgBouncerPoolMode(poolerConfig.PoolMode)
+	}
+
+	parameters := map[string]string{}
+	if poolerConfig.DefaultPoolSize > 0 {
+		parameters["default_pool_size"] = strconv.Itoa(int(poolerConfig.DefaultPoolSize))
+	}
+	if poolerConfig.MaxClientConnections > 0 {
+		parameters["max_client_conn"] = strconv.Itoa(int(poolerConfig.MaxClientConnections))
+	}
+
+	return &cnpgv1.Pooler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.GetDocumentDBPoolerName(clusterName),
+			Namespace: namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         documentdb.APIVersion,
+					Kind:               documentdb.Kind,
+					Name:               documentdb.Name,
+					UID:                documentdb.UID,
+					Controller:         pointer.Bool(true),
+					BlockOwnerDeletion: pointer.Bool(true),
+				},
+			},
+		},
+		Spec: cnpgv1.PoolerSpec{
+			Cluster:   cnpgv1.LocalObjectReference{Name: clusterName},
+			Type:      cnpgv1.PoolerTypeRW,
+			Instances: pointer.Int32(instances),
+			PgBouncer: &cnpgv1.PgBouncerSpec{
+				PoolMode:   poolMode,
+				Parameters: parameters,
+			},
+		},
+	}
+}
@@ -11,6 +11,7 @@ import (
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -74,9 +75,20 @@ func (r *BackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return r.SetBackupPhaseFailed(ctx, backup, "Failed to get associated DocumentDB cluster: "+err.Error(), nil)
 	}
 
-	// Ensure VolumeSnapshotClass exists
-	if err := r.ensureVolumeSnapshotClass(ctx, cluster.Spec.Environment); err != nil {
-		return r.SetBackupPhaseFailed(ctx, backup, "Failed to ensure VolumeSnapshotClass: "+err.Error(), cluster.Spec.Backup)
+	environment := cluster.Spec.Environment
+	if environment == "" {
+		detected, err := util.DetectEnvironment(ctx, r.Client)
+		if err != nil {
+			logger.Error(err, "Failed to detect the cloud environment")
+		}
+		environment = detected
+	}
+
+	// Ensure VolumeSnapshotClass exists, object store backups don't take snapshots
+	if backup.Spec.Method != dbpreview.BackupMethodObjectStore {
+		if err := r.ensureVolumeSnapshotClass(ctx, environment); err != nil {
+			return r.SetBackupPhaseFailed(ctx, backup, "Failed to ensure VolumeSnapshotClass: "+err.Error(), cluster.Spec.Backup)
+		}
 	}
 
 	// Get or create the CNPG Backup
@@ -181,7 +193,7 @@ func (r *BackupReconciler) createCNPGBackup(ctx context.Context, backup *dbprevi
 		cnpgClusterName = cluster.Spec.ClusterReplication.Primary
 	}
 
-	cnpgBackup, err := backup.CreateCNPGBackup(r.Scheme, cnpgClusterName)
+	cnpgBackup, err := backup.CreateCNPGBackup(r.Scheme, cnpgClusterName, cluster.Spec.Backup)
 	if err != nil {
 		return r.SetBackupPhaseFailed(ctx, backup, "Failed to initialize backup: "+err.Error(), cluster.Spec.Backup)
 	}
@@ -201,6 +213,13 @@ func (r *BackupReconciler) updateBackupStatus(ctx context.Context, backup *dbpre
 	original := backup.DeepCopy()
 	needsUpdate := backup.UpdateStatus(cnpgBackup, backupConfiguration)
 
+	if backup.Status.Phase == cnpgv1.BackupPhaseCompleted && backup.Status.Size == nil {
+		if size := r.getSnapshotsSize(ctx, backup.Namespace, backup.Status.Snapshots); size != nil {
+			backup.Status.Size = size
+			needsUpdate = true
+		}
+	}
+
 	if needsUpdate {
 		if err := r.Status().Patch(ctx, backup, client.MergeFrom(original)); err != nil {
 			logger := log.FromContext(ctx)
@@ -221,6 +240,29 @@ func (r *BackupReconciler) updateBackupStatus(ctx context.Context, backup *dbpre
 	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 }
 
+// getSnapshotsSize sums the restore size of the given volume snapshots.
+// It returns nil if a snapshot cannot be read or its driver does not report a size.
+func (r *BackupReconciler) getSnapshotsSize(ctx context.Context, namespace string, snapshotNames []string) *resource.Quantity {
+	if len(snapshotNames) == 0 {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	total := resource.NewQuantity(0, resource.BinarySI)
+	for _, name := range snapshotNames {
+		snapshot := &snapshotv1.VolumeSnapshot{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, snapshot); err != nil {
+			logger.Error(err, "Failed to get VolumeSnapshot", "name", name)
+			return nil
+		}
+		if snapshot.Status == nil || snapshot.Status.RestoreSize == nil {
+			return nil
+		}
+		total.Add(*snapshot.Status.RestoreSize)
+	}
+	return total
+}
+
 func (r *BackupReconciler) SetBackupPhaseFailed(ctx context.Context, backup *dbpreview.Backup, errMessage string, backupConfiguration *dbpreview.BackupConfiguration) (ctrl.Result, error) {
 	original := backup.DeepCopy()
 
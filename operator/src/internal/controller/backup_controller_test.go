@@ -8,8 +8,10 @@ import (
 	"time"
 
 	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	snapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -259,5 +261,70 @@ var _ = Describe("Backup Controller", func() {
 			Expect(fakeClient.Get(ctx, client.ObjectKey{Name: backupName, Namespace: backupNamespace}, updated)).To(Succeed())
 			Expect(string(updated.Status.Phase)).To(Equal(string(cnpgv1.BackupPhaseRunning)))
 		})
+
+		It("records the total size of the volume snapshots once completed", func() {
+			Expect(snapshotv1.AddToScheme(scheme)).To(Succeed())
+
+			backup := &dbpreview.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      backupName,
+					Namespace: backupNamespace,
+				},
+				Spec: dbpreview.BackupSpec{
+					Cluster: cnpgv1.LocalObjectReference{Name: clusterName},
+				},
+				Status: dbpreview.BackupStatus{
+					Phase: cnpgv1.BackupPhaseRunning,
+				},
+			}
+			snapshot := func(name, size string) *snapshotv1.VolumeSnapshot {
+				restoreSize := resource.MustParse(size)
+				return &snapshotv1.VolumeSnapshot{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: backupNamespace},
+					Status:     &snapshotv1.VolumeSnapshotStatus{RestoreSize: &restoreSize},
+				}
+			}
+
+			fakeClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(backup, snapshot("test-backup-data", "10Gi"), snapshot("test-backup-wal", "2Gi")).
+				WithStatusSubresource(&dbpreview.Backup{}).
+				Build()
+
+			reconciler := &BackupReconciler{
+				Client:   fakeClient,
+				Scheme:   scheme,
+				Recorder: recorder,
+			}
+
+			now := time.Now().UTC()
+			cnpgBackup := &cnpgv1.Backup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      backupName,
+					Namespace: backupNamespace,
+				},
+				Status: cnpgv1.BackupStatus{
+					Phase:     cnpgv1.BackupPhaseCompleted,
+					BackupID:  "20250401T010000",
+					StoppedAt: &metav1.Time{Time: now},
+					BackupSnapshotStatus: cnpgv1.BackupSnapshotStatus{
+						Elements: []cnpgv1.BackupSnapshotElementStatus{
+							{Name: "test-backup-data", Type: "PG_DATA"},
+							{Name: "test-backup-wal", Type: "PG_WAL"},
+						},
+					},
+				},
+			}
+
+			_, err := reconciler.updateBackupStatus(ctx, backup, cnpgBackup, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			updated := &dbpreview.Backup{}
+			Expect(fakeClient.Get(ctx, client.ObjectKey{Name: backupName, Namespace: backupNamespace}, updated)).To(Succeed())
+			Expect(updated.Status.BackupID).To(Equal("20250401T010000"))
+			Expect(updated.Status.Snapshots).To(Equal([]string{"test-backup-data", "test-backup-wal"}))
+			Expect(updated.Status.Size).ToNot(BeNil())
+			Expect(updated.Status.Size.Cmp(resource.MustParse("12Gi"))).To(Equal(0))
+		})
 	})
 })
@@ -4,7 +4,10 @@
 package controller
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"slices"
 	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
@@ -18,6 +21,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
@@ -25,17 +29,21 @@ import (
 )
 
 // CertificateReconciler manages certificate lifecycle for DocumentDB components.
-// Today it provisions gateway TLS assets; future work can layer in additional surfaces.
+// It provisions the gateway TLS assets and the certificate of the global endpoints.
 type CertificateReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme  *runtime.Scheme
+	Requeue RequeueConfig
+
+	backoff *requeueBackoff
 }
 
 // +kubebuilder:rbac:groups=documentdb.io,resources=dbs,verbs=get;list;watch
 // +kubebuilder:rbac:groups=documentdb.io,resources=dbs/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates;issuers,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates;issuers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificates/status;issuers/status,verbs=get
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
 
 func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -52,16 +60,35 @@ func (r *CertificateReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	if err != nil {
 		logger.Error(err, "failed to reconcile certificate resources")
 	}
-	return res, err
+	return r.backoff.apply(req.NamespacedName, res), err
 }
 
 func (r *CertificateReconciler) reconcileCertificates(ctx context.Context, ddb *dbpreview.DocumentDB) (ctrl.Result, error) {
+	gatewayResult, err := r.reconcileGatewayCertificate(ctx, ddb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	globalResult, err := r.reconcileGlobalEndpointsCertificate(ctx, ddb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Requeue as soon as either certificate needs it
+	if gatewayResult.RequeueAfter == 0 || (globalResult.RequeueAfter > 0 && globalResult.RequeueAfter < gatewayResult.RequeueAfter) {
+		return globalResult, nil
+	}
+	return gatewayResult, nil
+}
+
+func (r *CertificateReconciler) reconcileGatewayCertificate(ctx context.Context, ddb *dbpreview.DocumentDB) (ctrl.Result, error) {
 	if ddb.Spec.TLS == nil || ddb.Spec.TLS.Gateway == nil {
 		return ctrl.Result{}, nil
 	}
 
 	gatewayCfg := ddb.Spec.TLS.Gateway
 	if gatewayCfg.Mode == "" || gatewayCfg.Mode == "Disabled" {
+		if _, err := r.cleanupPreviousTLSMode(ctx, ddb); err != nil {
+			return ctrl.Result{}, err
+		}
 		if ddb.Status.TLS != nil && ddb.Status.TLS.Ready {
 			if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
 				status.Ready = false
@@ -81,6 +108,31 @@ func (r *CertificateReconciler) reconcileCertificates(ctx context.Context, ddb *
 		}
 	}
 
+	// Objects stored before the CRD validation rules existed can still be inconsistent
+	if err := gatewayCfg.Validate(); err != nil {
+		if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+			status.Ready = false
+			status.Message = err.Error()
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	// Wait for the stale Certificate to be gone before creating the one of the current mode
+	if deleted, err := r.cleanupPreviousTLSMode(ctx, ddb); err != nil {
+		return ctrl.Result{}, err
+	} else if deleted {
+		if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+			status.Ready = false
+			status.CABundle = ""
+			status.Message = "Removing the certificate of the previous gateway TLS configuration"
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+
 	switch gatewayCfg.Mode {
 	case "SelfSigned":
 		return r.ensureSelfSignedCert(ctx, ddb)
@@ -105,8 +157,13 @@ func (r *CertificateReconciler) ensureProvidedSecret(ctx context.Context, ddb *d
 		return ctrl.Result{}, nil
 	}
 
+	// The gateway can only mount a secret from its own namespace, so copy one from another namespace first
 	secret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: gatewayCfg.Provided.SecretName, Namespace: ddb.Namespace}, secret); err != nil {
+	err := r.copyProvidedSecret(ctx, ddb)
+	if err == nil {
+		err = r.Get(ctx, types.NamespacedName{Name: gatewayCfg.Provided.SecretName, Namespace: ddb.Namespace}, secret)
+	}
+	if err != nil {
 		if errors.IsNotFound(err) {
 			if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
 				status.Ready = false
@@ -115,7 +172,7 @@ func (r *CertificateReconciler) ensureProvidedSecret(ctx context.Context, ddb *d
 			}); err != nil {
 				return ctrl.Result{}, err
 			}
-			return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 		}
 		return ctrl.Result{}, err
 	}
@@ -128,7 +185,7 @@ func (r *CertificateReconciler) ensureProvidedSecret(ctx context.Context, ddb *d
 		}); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 	}
 	if _, keyOk := secret.Data["tls.key"]; !keyOk {
 		if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
@@ -138,12 +195,13 @@ func (r *CertificateReconciler) ensureProvidedSecret(ctx context.Context, ddb *d
 		}); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 	}
 
 	if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
 		status.Ready = true
 		status.SecretName = gatewayCfg.Provided.SecretName
+		status.CABundle = string(secret.Data[cmmeta.TLSCAKey])
 		status.Message = "Using provided TLS secret"
 	}); err != nil {
 		return ctrl.Result{}, err
@@ -151,6 +209,92 @@ func (r *CertificateReconciler) ensureProvidedSecret(ctx context.Context, ddb *d
 	return ctrl.Result{}, nil
 }
 
+// copyProvidedSecret copies the provided TLS secret into the DocumentDB's namespace when it lives elsewhere
+func (r *CertificateReconciler) copyProvidedSecret(ctx context.Context, ddb *dbpreview.DocumentDB) error {
+	source, ok := util.ProvidedTLSSecretSource(ddb)
+	if !ok || source.Namespace == ddb.Namespace {
+		return nil
+	}
+	return util.CopySecret(ctx, r.Client, ddb, source)
+}
+
+// gatewayCertificateName returns the name of the Certificate created by the SelfSigned and CertManager modes
+func gatewayCertificateName(ddb *dbpreview.DocumentDB) string {
+	return ddb.Name + "-gateway-cert"
+}
+
+// selfSignedIssuerName returns the name of the Issuer created by the SelfSigned mode
+func selfSignedIssuerName(ddb *dbpreview.DocumentDB) string {
+	return ddb.Name + "-gateway-selfsigned"
+}
+
+func selfSignedIssuerRef(ddb *dbpreview.DocumentDB) cmmeta.ObjectReference {
+	return cmmeta.ObjectReference{Name: selfSignedIssuerName(ddb), Kind: "Issuer", Group: "cert-manager.io"}
+}
+
+// certManagerIssuerRef returns the cert-manager reference of an issuer, defaulting to a cert-manager.io Issuer
+func certManagerIssuerRef(ref dbpreview.IssuerRef) cmmeta.ObjectReference {
+	return cmmeta.ObjectReference{
+		Name:  ref.Name,
+		Kind:  cmp.Or(ref.Kind, "Issuer"),
+		Group: cmp.Or(ref.Group, "cert-manager.io"),
+	}
+}
+
+func certManagerSecretName(ddb *dbpreview.DocumentDB) string {
+	return cmp.Or(ddb.Spec.TLS.Gateway.CertManager.SecretName, gatewayCertificateName(ddb)+"-tls")
+}
+
+// cleanupPreviousTLSMode deletes the Issuer and Certificate created for another gateway TLS mode, or for
+// another issuer or secret of the current mode. Both modes use the same Certificate name, so a stale one
+// would otherwise be reported ready with the old secret. Returns true if anything was deleted.
+func (r *CertificateReconciler) cleanupPreviousTLSMode(ctx context.Context, ddb *dbpreview.DocumentDB) (bool, error) {
+	logger := log.FromContext(ctx)
+	gatewayCfg := ddb.Spec.TLS.Gateway
+	deleted := false
+
+	if gatewayCfg.Mode != "SelfSigned" {
+		issuer := &cmapi.Issuer{}
+		err := r.Get(ctx, types.NamespacedName{Name: selfSignedIssuerName(ddb), Namespace: ddb.Namespace}, issuer)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		if err == nil && metav1.IsControlledBy(issuer, ddb) {
+			logger.Info("Deleting self-signed issuer of the previous gateway TLS mode", "issuer", issuer.Name)
+			if err := r.Delete(ctx, issuer); err != nil && !errors.IsNotFound(err) {
+				return false, err
+			}
+			deleted = true
+		}
+	}
+
+	cert := &cmapi.Certificate{}
+	if err := r.Get(ctx, types.NamespacedName{Name: gatewayCertificateName(ddb), Namespace: ddb.Namespace}, cert); err != nil {
+		if errors.IsNotFound(err) {
+			return deleted, nil
+		}
+		return false, err
+	}
+	if !metav1.IsControlledBy(cert, ddb) {
+		return deleted, nil
+	}
+	switch gatewayCfg.Mode {
+	case "SelfSigned":
+		if cert.Spec.IssuerRef == selfSignedIssuerRef(ddb) && cert.Spec.SecretName == cert.Name+"-tls" {
+			return deleted, nil
+		}
+	case "CertManager":
+		if cert.Spec.IssuerRef == certManagerIssuerRef(gatewayCfg.CertManager.IssuerRef) && cert.Spec.SecretName == certManagerSecretName(ddb) {
+			return deleted, nil
+		}
+	}
+	logger.Info("Deleting gateway certificate of the previous TLS configuration", "certificate", cert.Name, "issuer", cert.Spec.IssuerRef.Name)
+	if err := r.Delete(ctx, cert); err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
+
 func (r *CertificateReconciler) ensureCertManagerManagedCert(ctx context.Context, ddb *dbpreview.DocumentDB) (ctrl.Result, error) {
 	gatewayCfg := ddb.Spec.TLS.Gateway
 	if gatewayCfg == nil || gatewayCfg.CertManager == nil {
@@ -165,41 +309,12 @@ func (r *CertificateReconciler) ensureCertManagerManagedCert(ctx context.Context
 
 	cmCfg := gatewayCfg.CertManager
 
-	issuerRef := cmmeta.ObjectReference{Name: cmCfg.IssuerRef.Name}
-	if cmCfg.IssuerRef.Kind != "" {
-		issuerRef.Kind = cmCfg.IssuerRef.Kind
-	} else {
-		issuerRef.Kind = "Issuer"
-	}
-	if cmCfg.IssuerRef.Group != "" {
-		issuerRef.Group = cmCfg.IssuerRef.Group
-	} else {
-		issuerRef.Group = "cert-manager.io"
-	}
+	issuerRef := certManagerIssuerRef(cmCfg.IssuerRef)
+	secretName := certManagerSecretName(ddb)
 
-	secretName := cmCfg.SecretName
-	if secretName == "" {
-		secretName = ddb.Name + "-gateway-cert-tls"
-	}
+	finalDNS := appendUniqueSANs(appendUniqueSANs([]string{}, cmCfg.DNSNames...), serviceDNSNames(ddb)...)
 
-	serviceBase := util.DOCUMENTDB_SERVICE_PREFIX + ddb.Name
-	baseDNS := []string{serviceBase, serviceBase + "." + ddb.Namespace, serviceBase + "." + ddb.Namespace + ".svc"}
-	dnsSet := map[string]struct{}{}
-	finalDNS := []string{}
-	for _, n := range cmCfg.DNSNames {
-		if _, ok := dnsSet[n]; !ok && n != "" {
-			dnsSet[n] = struct{}{}
-			finalDNS = append(finalDNS, n)
-		}
-	}
-	for _, n := range baseDNS {
-		if _, ok := dnsSet[n]; !ok {
-			dnsSet[n] = struct{}{}
-			finalDNS = append(finalDNS, n)
-		}
-	}
-
-	certName := ddb.Name + "-gateway-cert"
+	certName := gatewayCertificateName(ddb)
 	cert := &cmapi.Certificate{}
 	if err := r.Get(ctx, types.NamespacedName{Name: certName, Namespace: ddb.Namespace}, cert); err != nil {
 		if !errors.IsNotFound(err) {
@@ -230,15 +345,20 @@ func (r *CertificateReconciler) ensureCertManagerManagedCert(ctx context.Context
 		}); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 	}
 
 	for _, cond := range cert.Status.Conditions {
 		if cond.Type == cmapi.CertificateConditionReady && cond.Status == cmmeta.ConditionTrue {
-			if !ddb.Status.TLS.Ready {
+			caBundle, err := r.caBundleFromSecret(ctx, ddb.Namespace, cert.Spec.SecretName)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !ddb.Status.TLS.Ready || ddb.Status.TLS.CABundle != caBundle {
 				if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
 					status.Ready = true
 					status.SecretName = cert.Spec.SecretName
+					status.CABundle = caBundle
 					status.Message = "Gateway TLS certificate ready (cert-manager)"
 				}); err != nil {
 					return ctrl.Result{}, err
@@ -251,17 +371,210 @@ func (r *CertificateReconciler) ensureCertManagerManagedCert(ctx context.Context
 	if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
 		status.Ready = false
 		status.SecretName = cert.Spec.SecretName
-		status.Message = "Waiting for cert-manager certificate to become ready"
+		status.Message = certManagerNotReadyMessage(cert)
 	}); err != nil {
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+	return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+}
+
+// globalEndpointsCertificateName returns the name of the Certificate of the global endpoints
+func globalEndpointsCertificateName(ddb *dbpreview.DocumentDB) string {
+	return ddb.Name + "-global-cert"
+}
+
+// reconcileGlobalEndpointsCertificate provisions the cert-manager Certificate of the global DNS names, keeping its
+// spec in line with spec.tls.globalEndpoints, and reports its readiness in status.globalEndpointsTLS. The
+// Certificate is deleted once the global endpoints are no longer configured.
+func (r *CertificateReconciler) reconcileGlobalEndpointsCertificate(ctx context.Context, ddb *dbpreview.DocumentDB) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	certName := globalEndpointsCertificateName(ddb)
+
+	// The status is only set once the Certificate was provisioned, so DocumentDBs that never configured global
+	// endpoints don't need cert-manager to be installed
+	if ddb.Spec.TLS == nil || ddb.Spec.TLS.GlobalEndpoints == nil {
+		if ddb.Status.GlobalEndpointsTLS == nil {
+			return ctrl.Result{}, nil
+		}
+		cert := &cmapi.Certificate{}
+		err := r.Get(ctx, types.NamespacedName{Name: certName, Namespace: ddb.Namespace}, cert)
+		if err != nil && !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		if err == nil && metav1.IsControlledBy(cert, ddb) {
+			logger.Info("Deleting certificate of the removed global endpoints", "certificate", cert.Name)
+			if err := r.Delete(ctx, cert); err != nil && !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		if err := r.updateStatus(ctx, ddb, func(status *dbpreview.DocumentDBStatus) {
+			status.GlobalEndpointsTLS = nil
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	cert := &cmapi.Certificate{}
+	err := r.Get(ctx, types.NamespacedName{Name: certName, Namespace: ddb.Namespace}, cert)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	found := err == nil
+
+	globalCfg := ddb.Spec.TLS.GlobalEndpoints
+	desiredSpec := cmapi.CertificateSpec{
+		SecretName:  cmp.Or(globalCfg.SecretName, certName+"-tls"),
+		DNSNames:    appendUniqueSANs(appendUniqueSANs([]string{}, globalCfg.DNSNames...), serviceDNSNames(ddb)...),
+		IssuerRef:   certManagerIssuerRef(globalCfg.IssuerRef),
+		Duration:    &metav1.Duration{Duration: 90 * 24 * time.Hour},
+		RenewBefore: &metav1.Duration{Duration: 15 * 24 * time.Hour},
+		Usages:      []cmapi.KeyUsage{cmapi.UsageServerAuth},
+	}
+
+	if !found {
+		cert = &cmapi.Certificate{
+			ObjectMeta: metav1.ObjectMeta{Name: certName, Namespace: ddb.Namespace},
+			Spec:       desiredSpec,
+		}
+		if err := controllerutil.SetControllerReference(ddb, cert, r.Scheme); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.Create(ctx, cert); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.updateGlobalEndpointsTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+			status.Ready = false
+			status.SecretName = desiredSpec.SecretName
+			status.Message = "Creating global endpoints certificate"
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+
+	if !slices.Equal(cert.Spec.DNSNames, desiredSpec.DNSNames) || cert.Spec.IssuerRef != desiredSpec.IssuerRef || cert.Spec.SecretName != desiredSpec.SecretName {
+		logger.Info("Updating global endpoints certificate", "certificate", cert.Name, "dnsNames", desiredSpec.DNSNames)
+		cert.Spec.DNSNames = desiredSpec.DNSNames
+		cert.Spec.IssuerRef = desiredSpec.IssuerRef
+		cert.Spec.SecretName = desiredSpec.SecretName
+		if err := r.Update(ctx, cert); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.updateGlobalEndpointsTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+			status.Ready = false
+			status.SecretName = desiredSpec.SecretName
+			status.CABundle = ""
+			status.Message = "Updating global endpoints certificate"
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+
+	for _, cond := range cert.Status.Conditions {
+		if cond.Type == cmapi.CertificateConditionReady && cond.Status == cmmeta.ConditionTrue {
+			caBundle, err := r.caBundleFromSecret(ctx, ddb.Namespace, cert.Spec.SecretName)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if status := ddb.Status.GlobalEndpointsTLS; status == nil || !status.Ready || status.CABundle != caBundle {
+				if err := r.updateGlobalEndpointsTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+					status.Ready = true
+					status.SecretName = cert.Spec.SecretName
+					status.CABundle = caBundle
+					status.Message = "Global endpoints TLS certificate ready (cert-manager)"
+				}); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			return ctrl.Result{}, nil
+		}
+	}
+
+	if err := r.updateGlobalEndpointsTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+		status.Ready = false
+		status.SecretName = cert.Spec.SecretName
+		status.Message = certManagerNotReadyMessage(cert)
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+}
+
+// certManagerNotReadyMessage explains why a cert-manager Certificate is not ready yet. A failed issuance
+// is reported ahead of the Ready condition, which cert-manager leaves at the reason issuance started.
+func certManagerNotReadyMessage(cert *cmapi.Certificate) string {
+	const waiting = "Waiting for cert-manager certificate to become ready"
+	var ready, issuing *cmapi.CertificateCondition
+	for i := range cert.Status.Conditions {
+		switch cert.Status.Conditions[i].Type {
+		case cmapi.CertificateConditionReady:
+			ready = &cert.Status.Conditions[i]
+		case cmapi.CertificateConditionIssuing:
+			issuing = &cert.Status.Conditions[i]
+		}
+	}
+
+	switch {
+	case issuing != nil && issuing.Status == cmmeta.ConditionFalse && cert.Status.LastFailureTime != nil:
+		return fmt.Sprintf("cert-manager failed to issue the certificate (%s): %s", issuing.Reason, issuing.Message)
+	case ready != nil && ready.Status == cmmeta.ConditionFalse && ready.Message != "":
+		return fmt.Sprintf("%s (%s): %s", waiting, ready.Reason, ready.Message)
+	}
+	return waiting
+}
+
+// serviceDNSNames returns the in-cluster DNS names of the gateway Service
+func serviceDNSNames(ddb *dbpreview.DocumentDB) []string {
+	serviceBase := util.DOCUMENTDB_SERVICE_PREFIX + ddb.Name
+	return []string{serviceBase, serviceBase + "." + ddb.Namespace, serviceBase + "." + ddb.Namespace + ".svc"}
+}
+
+// appendUniqueSANs appends the non-empty names that aren't in sans yet
+func appendUniqueSANs(sans []string, names ...string) []string {
+	for _, name := range names {
+		if name != "" && !slices.Contains(sans, name) {
+			sans = append(sans, name)
+		}
+	}
+	return sans
+}
+
+// selfSignedSANs returns the DNS and IP SANs of the self-signed certificate: the Service DNS names, the extra
+// SANs of the spec and the ingress address of the gateway LoadBalancer once it has one.
+func (r *CertificateReconciler) selfSignedSANs(ctx context.Context, ddb *dbpreview.DocumentDB) ([]string, []string, error) {
+	dnsNames := serviceDNSNames(ddb)
+	var ipAddresses []string
+	if cfg := ddb.Spec.TLS.Gateway.SelfSigned; cfg != nil {
+		dnsNames = appendUniqueSANs(dnsNames, cfg.DNSNames...)
+		ipAddresses = appendUniqueSANs(ipAddresses, cfg.IPAddresses...)
+	}
+
+	replicationContext, err := util.GetReplicationContext(ctx, r.Client, *ddb)
+	if err != nil {
+		return nil, nil, err
+	}
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: util.GetDocumentDBServiceName(replicationContext.Self), Namespace: ddb.Namespace}, service); err != nil {
+		if errors.IsNotFound(err) {
+			return dnsNames, ipAddresses, nil
+		}
+		return nil, nil, err
+	}
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			dnsNames = appendUniqueSANs(dnsNames, ingress.Hostname)
+			ipAddresses = appendUniqueSANs(ipAddresses, ingress.IP)
+		}
+	}
+	return dnsNames, ipAddresses, nil
 }
 
 func (r *CertificateReconciler) ensureSelfSignedCert(ctx context.Context, ddb *dbpreview.DocumentDB) (ctrl.Result, error) {
 	namespace := ddb.Namespace
-	issuerName := ddb.Name + "-gateway-selfsigned"
-	certName := ddb.Name + "-gateway-cert"
+	issuerName := selfSignedIssuerName(ddb)
+	certName := gatewayCertificateName(ddb)
 	secretName := certName + "-tls"
 
 	issuer := &cmapi.Issuer{}
@@ -282,11 +595,9 @@ func (r *CertificateReconciler) ensureSelfSignedCert(ctx context.Context, ddb *d
 		}
 	}
 
-	serviceBase := util.DOCUMENTDB_SERVICE_PREFIX + ddb.Name
-	dnsNames := []string{
-		serviceBase,
-		serviceBase + "." + namespace,
-		serviceBase + "." + namespace + ".svc",
+	dnsNames, ipAddresses, err := r.selfSignedSANs(ctx, ddb)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
 	cert := &cmapi.Certificate{}
@@ -302,7 +613,8 @@ func (r *CertificateReconciler) ensureSelfSignedCert(ctx context.Context, ddb *d
 				Duration:    &metav1.Duration{Duration: 90 * 24 * time.Hour},
 				RenewBefore: &metav1.Duration{Duration: 15 * 24 * time.Hour},
 				DNSNames:    dnsNames,
-				IssuerRef:   cmmeta.ObjectReference{Name: issuerName, Kind: "Issuer", Group: "cert-manager.io"},
+				IPAddresses: ipAddresses,
+				IssuerRef:   selfSignedIssuerRef(ddb),
 				Usages:      []cmapi.KeyUsage{cmapi.UsageServerAuth},
 			},
 		}
@@ -319,15 +631,38 @@ func (r *CertificateReconciler) ensureSelfSignedCert(ctx context.Context, ddb *d
 		}); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+
+	// The LoadBalancer address is only known after the certificate was first created, and cert-manager
+	// reissues the certificate when its SANs change
+	if !slices.Equal(cert.Spec.DNSNames, dnsNames) || !slices.Equal(cert.Spec.IPAddresses, ipAddresses) {
+		cert.Spec.DNSNames = dnsNames
+		cert.Spec.IPAddresses = ipAddresses
+		if err := r.Update(ctx, cert); err != nil {
+			return ctrl.Result{}, err
+		}
+		if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
+			status.Ready = false
+			status.SecretName = cert.Spec.SecretName
+			status.Message = "Updating self-signed certificate SANs"
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 	}
 
 	for _, cond := range cert.Status.Conditions {
 		if cond.Type == cmapi.CertificateConditionReady && cond.Status == cmmeta.ConditionTrue {
-			if !ddb.Status.TLS.Ready {
+			caBundle, err := r.caBundleFromSecret(ctx, ddb.Namespace, cert.Spec.SecretName)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !ddb.Status.TLS.Ready || ddb.Status.TLS.CABundle != caBundle {
 				if err := r.updateTLSStatus(ctx, ddb, func(status *dbpreview.TLSStatus) {
 					status.Ready = true
 					status.SecretName = cert.Spec.SecretName
+					status.CABundle = caBundle
 					status.Message = "Gateway TLS certificate ready"
 				}); err != nil {
 					return ctrl.Result{}, err
@@ -344,20 +679,48 @@ func (r *CertificateReconciler) ensureSelfSignedCert(ctx context.Context, ddb *d
 	}); err != nil {
 		return ctrl.Result{}, err
 	}
-	return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+	return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+}
+
+// caBundleFromSecret returns the PEM encoded CA stored in ca.crt of the TLS secret, or "" when the secret or key is missing.
+func (r *CertificateReconciler) caBundleFromSecret(ctx context.Context, namespace, secretName string) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return string(secret.Data[cmmeta.TLSCAKey]), nil
 }
 
 func (r *CertificateReconciler) updateTLSStatus(ctx context.Context, ddb *dbpreview.DocumentDB, mutate func(*dbpreview.TLSStatus)) error {
+	return r.updateStatus(ctx, ddb, func(status *dbpreview.DocumentDBStatus) {
+		if status.TLS == nil {
+			status.TLS = &dbpreview.TLSStatus{}
+		}
+		mutate(status.TLS)
+	})
+}
+
+func (r *CertificateReconciler) updateGlobalEndpointsTLSStatus(ctx context.Context, ddb *dbpreview.DocumentDB, mutate func(*dbpreview.TLSStatus)) error {
+	return r.updateStatus(ctx, ddb, func(status *dbpreview.DocumentDBStatus) {
+		if status.GlobalEndpointsTLS == nil {
+			status.GlobalEndpointsTLS = &dbpreview.TLSStatus{}
+		}
+		mutate(status.GlobalEndpointsTLS)
+	})
+}
+
+// updateStatus applies mutate to the latest status of the DocumentDB, retrying on conflicts
+func (r *CertificateReconciler) updateStatus(ctx context.Context, ddb *dbpreview.DocumentDB, mutate func(*dbpreview.DocumentDBStatus)) error {
 	key := types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}
 	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		current := &dbpreview.DocumentDB{}
 		if err := r.Get(ctx, key, current); err != nil {
 			return err
 		}
-		if current.Status.TLS == nil {
-			current.Status.TLS = &dbpreview.TLSStatus{}
-		}
-		mutate(current.Status.TLS)
+		mutate(&current.Status)
 		if err := r.Status().Update(ctx, current); err != nil {
 			return err
 		}
@@ -367,10 +730,17 @@ func (r *CertificateReconciler) updateTLSStatus(ctx context.Context, ddb *dbprev
 }
 
 func (r *CertificateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.backoff = newRequeueBackoff()
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&dbpreview.DocumentDB{}).
 		Owns(&cmapi.Certificate{}).
 		Owns(&cmapi.Issuer{}).
+		// The self-signed certificate includes the LoadBalancer address of the gateway Service
+		Owns(&corev1.Service{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(documentDBsReferencingSecret(r.Client, func(ddb *dbpreview.DocumentDB) (types.NamespacedName, bool) {
+			source, ok := util.ProvidedTLSSecretSource(ddb)
+			return source, ok && source.Namespace != ddb.Namespace
+		}))).
 		Named("certificate-controller").
 		Complete(r)
 }
@@ -5,9 +5,12 @@ package controller
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,20 +19,30 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/resources/status"
 	pgTime "github.com/cloudnative-pg/machinery/pkg/postgres/time"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/tools/remotecommand"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
 	cnpg "github.com/documentdb/documentdb-operator/internal/cnpg"
@@ -37,8 +50,17 @@ import (
 )
 
 const (
-	RequeueAfterShort = 10 * time.Second
-	RequeueAfterLong  = 30 * time.Second
+	// DefaultRequeueAfterShort and DefaultRequeueAfterLong are the requeue intervals used when RequeueConfig leaves them unset
+	DefaultRequeueAfterShort = 10 * time.Second
+	DefaultRequeueAfterLong  = 30 * time.Second
+
+	// DefaultReconcileTimeout bounds a reconcile when ReconcileTimeout is unset, so a hung exec or HTTP call
+	// can't hold up the reconciliation of every DocumentDB
+	DefaultReconcileTimeout = 5 * time.Minute
+
+	// switchoverMaxLagBytes is the replay lag up to which a standby counts as caught up for a switchover.
+	// CNPG still waits for the demotion LSN before promoting, this only keeps the cutover short.
+	switchoverMaxLagBytes int64 = 1 << 20
 )
 
 // DocumentDBReconciler reconciles a DocumentDB object
@@ -47,6 +69,37 @@ type DocumentDBReconciler struct {
 	Scheme    *runtime.Scheme
 	Config    *rest.Config
 	Clientset kubernetes.Interface
+	Recorder  record.EventRecorder
+	Requeue   RequeueConfig
+	// ReconcileTimeout is the deadline of a single reconcile, after which it is requeued. Defaults to
+	// DefaultReconcileTimeout.
+	ReconcileTimeout time.Duration
+
+	backoff     *requeueBackoff
+	environment detectedEnvironment
+}
+
+// detectedEnvironment caches the environment detected from the node provider IDs. Providers don't change over the
+// life of a cluster, so the nodes are listed once instead of on every reconcile.
+type detectedEnvironment struct {
+	mu    sync.Mutex
+	value string
+}
+
+// detectEnvironment returns the environment detected from the node provider IDs, listing the nodes until a detection
+// finds an environment. An empty result isn't cached, as nodes that just joined may not have a provider ID yet.
+func (r *DocumentDBReconciler) detectEnvironment(ctx context.Context) (string, error) {
+	r.environment.mu.Lock()
+	defer r.environment.mu.Unlock()
+
+	if r.environment.value == "" {
+		environment, err := util.DetectEnvironment(ctx, r.Client)
+		if err != nil {
+			return "", err
+		}
+		r.environment.value = environment
+	}
+	return r.environment.value, nil
 }
 
 var reconcileMutex sync.Mutex
@@ -54,10 +107,35 @@ var reconcileMutex sync.Mutex
 // +kubebuilder:rbac:groups=documentdb.io,resources=dbs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=documentdb.io,resources=dbs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=documentdb.io,resources=dbs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=documentdb.io,resources=backups,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=poolers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=databases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
 func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	reconcileMutex.Lock()
 	defer reconcileMutex.Unlock()
 
+	timeout := cmp.Or(r.ReconcileTimeout, DefaultReconcileTimeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := r.reconcile(ctx, req)
+	if ctx.Err() == context.DeadlineExceeded {
+		// Whatever step was cut short is retried from the start
+		log.FromContext(ctx).Info("Reconcile did not complete in time, requeueing", "timeout", timeout, "error", err)
+		result, err = ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+	return r.backoff.apply(req.NamespacedName, result), err
+}
+
+// reconcile brings the resources of a DocumentDB in line with its spec. The requeue intervals it returns for
+// transient conditions are base delays, which Reconcile backs off per DocumentDB.
+func (r *DocumentDBReconciler) reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	// Fetch the DocumentDB instance
@@ -76,13 +154,34 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Default the environment from the node provider IDs so cloud-specific settings apply without configuration. It is
+	// only resolved for this reconcile and not written back to the spec, so a detection that is empty while nodes
+	// join isn't persisted, and the spec stays as the user or their GitOps tooling applied it.
+	if documentdb.Spec.Environment == "" {
+		environment, err := r.detectEnvironment(ctx)
+		if err != nil {
+			logger.Error(err, "Failed to detect the cloud environment")
+		} else if environment != "" {
+			logger.V(1).Info("Detected cloud environment from node provider IDs", "environment", environment)
+			documentdb.Spec.Environment = environment
+		}
+	}
+
 	replicationContext, err := util.GetReplicationContext(ctx, r.Client, *documentdb)
 	if err != nil {
 		logger.Error(err, "Failed to determine replication context")
 		return ctrl.Result{}, err
 	}
 
+	// The rest of the reconcile, including the helpers it calls, logs the member cluster, its replication role and
+	// the phase of the DocumentDB alongside the controller, DocumentDB and reconcile ID set by controller-runtime
+	logger = logger.WithValues("cluster", replicationContext.Self, "replicationRole", replicationContext.Role(), "phase", documentdb.Status.Status)
+	ctx = log.IntoContext(ctx, logger)
+
 	var documentDbServiceIp string
+	var documentDbReaderServiceIp string
+	var documentDbServiceName string
+	documentDbServiceReady := false
 
 	// Only create/manage the service if ExposeViaService is configured
 	if documentdb.Spec.ExposeViaService.ServiceType != "" {
@@ -98,7 +197,7 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		foundService, err := util.UpsertService(ctx, r.Client, ddbService)
 		if err != nil {
 			logger.Info("Failed to create DocumentDB Service; Requeuing.")
-			return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 		}
 
 		// Ensure DocumentDB Service has an IP assigned
@@ -107,58 +206,195 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			logger.Info("DocumentDB Service IP not assigned, pausing until update posted.")
 			return ctrl.Result{}, nil
 		}
+
+		// The Service gets its IP before any gateway accepts connections, so the connection string waits for a ready endpoint
+		documentDbServiceName = foundService.Name
+		documentDbServiceReady, err = util.ServiceHasReadyEndpoints(ctx, r.Client, foundService)
+		if err != nil {
+			logger.Error(err, "Failed to list the endpoints of the DocumentDB Service")
+		}
+
+		if documentdb.Spec.ExposeViaService.EnableReaderEndpoint {
+			readerService := util.GetDocumentDBReaderServiceDefinition(documentdb, replicationContext, req.Namespace, serviceType)
+			foundReaderService, err := util.UpsertService(ctx, r.Client, readerService)
+			if err != nil {
+				logger.Info("Failed to create DocumentDB reader Service; Requeuing.")
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+			}
+
+			// The reader endpoint is optional, so don't block reconciliation on its IP
+			documentDbReaderServiceIp, err = util.EnsureServiceIP(ctx, foundReaderService)
+			if err != nil {
+				logger.Info("DocumentDB reader Service IP not assigned yet.")
+			}
+		} else if err := util.DeleteService(ctx, r.Client, util.GetDocumentDBReaderServiceName(replicationContext.Self), req.Namespace); err != nil {
+			logger.Error(err, "Failed to delete DocumentDB reader Service")
+		}
+
+		if documentdb.Spec.ExposeViaService.Ingress != nil {
+			ingress := util.GetDocumentDBIngressDefinition(documentdb, replicationContext, req.Namespace)
+			if err := util.UpsertIngress(ctx, r.Client, ingress); err != nil {
+				logger.Error(err, "Failed to create DocumentDB Ingress; Requeuing.")
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+			}
+		} else if err := util.DeleteIngress(ctx, r.Client, util.GetDocumentDBServiceName(replicationContext.Self), req.Namespace); err != nil {
+			logger.Error(err, "Failed to delete DocumentDB Ingress")
+		}
+	}
+
+	if documentdb.Spec.NetworkPolicy != nil && documentdb.Spec.NetworkPolicy.Enabled {
+		networkPolicy := util.GetDocumentDBNetworkPolicyDefinition(documentdb, replicationContext.Self, req.Namespace)
+		if err := util.UpsertNetworkPolicy(ctx, r.Client, networkPolicy); err != nil {
+			logger.Error(err, "Failed to create DocumentDB NetworkPolicy; Requeuing.")
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+		}
+	} else if err := util.DeleteNetworkPolicy(ctx, r.Client, util.GetDocumentDBNetworkPolicyName(replicationContext.Self), req.Namespace); err != nil {
+		logger.Error(err, "Failed to delete DocumentDB NetworkPolicy")
 	}
 
 	// Ensure App ServiceAccount, Role and RoleBindings are created
 	if err := r.EnsureServiceAccountRoleAndRoleBinding(ctx, documentdb, req.Namespace); err != nil {
 		logger.Info("Failed to create ServiceAccount, Role and RoleBinding; Requeuing.")
-		return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 	}
 
-	// create the CNPG Cluster
-	documentdbImage := util.GetDocumentDBImageForInstance(documentdb)
+	// Reject malformed storage sizes before they reach the CNPG Cluster; a spec change will trigger a new reconcile
+	if err := documentdb.Spec.Resource.Storage.ValidatePvcSize(""); err != nil {
+		logger.Error(err, "Invalid DocumentDB storage configuration")
+		return ctrl.Result{}, nil
+	}
+
+	// Pods cannot mount Secrets from other namespaces, so keep a local copy of a cross-namespace credential secret
+	if source := util.CredentialSecretSource(documentdb); source.Namespace != documentdb.Namespace {
+		if err := util.CopySecret(ctx, r.Client, documentdb, source); err != nil {
+			logger.Error(err, "Failed to copy the credential secret", "source", source)
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+		}
+	}
+
+	// Barman Cloud can't archive without its credentials, so wait for them before configuring the archive
+	if backup := documentdb.Spec.Backup; backup != nil && backup.WalArchive != nil {
+		if err := backup.WalArchive.ValidateEncryption(); err != nil {
+			r.Recorder.Event(documentdb, corev1.EventTypeWarning, "InvalidWalArchiveEncryption", err.Error())
+			logger.Error(err, "Invalid WAL archive encryption")
+			return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
+		}
+	}
+
+	if err := r.validateWalArchiveCredentials(ctx, documentdb); err != nil {
+		r.Recorder.Event(documentdb, corev1.EventTypeWarning, "WalArchiveCredentialsMissing", err.Error())
+		logger.Error(err, "Invalid WAL archive configuration")
+		return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
+	}
+
+	if err := r.validateImagePullSecrets(ctx, documentdb); err != nil {
+		r.Recorder.Event(documentdb, corev1.EventTypeWarning, "ImagePullSecretMissing", err.Error())
+		logger.Error(err, "Invalid image pull secrets")
+		return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
+	}
+
+	// A missing StorageClass leaves the volumes of a new cluster pending, so it is reported and holds back creation
+	storageClassFound, err := r.checkStorageClass(ctx, documentdb, replicationContext.StorageClass)
+	if err != nil {
+		logger.Error(err, "Failed to check the StorageClass", "storageClass", replicationContext.StorageClass)
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
 
 	currentCnpgCluster := &cnpgv1.Cluster{}
+	if err := r.Client.Get(ctx, req.NamespacedName, currentCnpgCluster); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Failed to get CNPG Cluster")
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	} else if err != nil {
+		currentCnpgCluster = nil
+	}
+
+	// create the CNPG Cluster
+	documentdbImage := util.GetDocumentDBImageForInstance(documentdb)
 	desiredCnpgCluster := cnpg.GetCnpgClusterSpec(req, documentdb, documentdbImage, documentdb.Name, replicationContext.StorageClass, replicationContext.IsPrimary(), logger)
 
 	if replicationContext.IsReplicating() {
 		err = r.AddClusterReplicationToClusterSpec(ctx, documentdb, replicationContext, desiredCnpgCluster)
+		if statusErr := r.reportReplicationConfigured(ctx, documentdb, err); statusErr != nil {
+			logger.Error(statusErr, "Failed to update DocumentDB status")
+		}
 		if err != nil {
+			// Creating the cluster without its replication settings would make it an independent primary,
+			// so the cluster is held back until the replication resources can be created
 			logger.Error(err, "Failed to add physical replication features cnpg Cluster spec")
-			return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+			return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
 		}
 	}
 
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: desiredCnpgCluster.Name, Namespace: req.Namespace}, currentCnpgCluster); err != nil {
-		if errors.IsNotFound(err) {
-			if err := r.Client.Create(ctx, desiredCnpgCluster); err != nil {
-				logger.Error(err, "Failed to create CNPG Cluster")
-				return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+	if currentCnpgCluster == nil {
+		if !storageClassFound {
+			logger.Info("Waiting for the StorageClass to be created", "storageClass", replicationContext.StorageClass)
+			return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
+		}
+		// A clone is restored from a backup of its source, which has to complete first
+		if replicationContext.IsPrimary() && documentdb.Spec.Bootstrap != nil && documentdb.Spec.Bootstrap.Clone != nil {
+			backupCompleted, err := r.reconcileCloneBackup(ctx, documentdb)
+			if err != nil {
+				logger.Error(err, "Failed to back up the clone source")
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+			}
+			if !backupCompleted {
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 			}
-			logger.Info("CNPG Cluster created successfully", "Cluster.Name", desiredCnpgCluster.Name, "Namespace", desiredCnpgCluster.Namespace)
-			return ctrl.Result{RequeueAfter: RequeueAfterLong}, nil
 		}
-		logger.Error(err, "Failed to get CNPG Cluster")
-		return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+		if err := r.Client.Create(ctx, desiredCnpgCluster); err != nil {
+			logger.Error(err, "Failed to create CNPG Cluster")
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+		}
+		logger.Info("CNPG Cluster created successfully", "Cluster.Name", desiredCnpgCluster.Name, "Namespace", desiredCnpgCluster.Namespace)
+		// A new cluster starts without the grant, so it has to be applied again
+		if meta.RemoveStatusCondition(&documentdb.Status.Conditions, dbpreview.ConditionReplicationRoleGranted) {
+			if err := r.Status().Update(ctx, documentdb); err != nil {
+				logger.Error(err, "Failed to update DocumentDB status")
+			}
+		}
+		return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
 	}
 
-	// Check if anything has changed in the generated cnpg spec
-	err, requeueTime := r.TryUpdateCluster(ctx, currentCnpgCluster, desiredCnpgCluster, documentdb, replicationContext)
+	// A healthy cluster that already reflects this generation of the spec doesn't need its spec diffed, but the
+	// replica cluster transitions still run, as they follow the promotion token rather than the spec
+	var requeueTime time.Duration
+	if clusterSettled(documentdb, currentCnpgCluster, desiredCnpgCluster) {
+		logger.V(1).Info("DocumentDB spec already applied to a healthy CNPG Cluster", "generation", documentdb.Generation)
+		err, requeueTime = r.updateReplicaCluster(ctx, currentCnpgCluster, desiredCnpgCluster, documentdb, replicationContext, false)
+	} else {
+		// Check if anything has changed in the generated cnpg spec
+		err, requeueTime = r.TryUpdateCluster(ctx, currentCnpgCluster, desiredCnpgCluster, documentdb, replicationContext)
+	}
 	if err != nil {
 		logger.Error(err, "Failed to update CNPG Cluster")
 	}
 	if requeueTime > 0 {
 		return ctrl.Result{RequeueAfter: requeueTime}, nil
 	}
+	specApplied := err == nil
+
+	// The Pooler and Databases are reconciled even when the spec is unchanged, so that deleted ones are recreated
+	if err := r.reconcilePooler(ctx, documentdb, desiredCnpgCluster.Name, req.Namespace); err != nil {
+		logger.Error(err, "Failed to reconcile CNPG Pooler")
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+
+	// Databases are created on the primary and reach the replicas through physical replication
+	if replicationContext.IsPrimary() {
+		if err := r.reconcileDatabases(ctx, documentdb, desiredCnpgCluster.Name, req.Namespace); err != nil {
+			logger.Error(err, "Failed to reconcile CNPG Databases")
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+		}
+	}
 
 	// Sync TLS secret parameter into CNPG Cluster plugin if ready
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: desiredCnpgCluster.Name, Namespace: req.Namespace}, currentCnpgCluster); err == nil {
+	if err := r.Client.Get(ctx, req.NamespacedName, currentCnpgCluster); err == nil {
 		if documentdb.Status.TLS != nil && documentdb.Status.TLS.Ready && documentdb.Status.TLS.SecretName != "" {
 			logger.Info("Syncing TLS secret into CNPG Cluster plugin parameters", "secret", documentdb.Status.TLS.SecretName)
 			updated := false
 			for i := range currentCnpgCluster.Spec.Plugins {
 				p := &currentCnpgCluster.Spec.Plugins[i]
-				if p.Name == desiredCnpgCluster.Spec.Plugins[0].Name { // target our sidecar plugin
+				if p.Name == cnpg.GetSidecarPluginName(documentdb) { // target our sidecar plugin
 					if p.Enabled == nil || !*p.Enabled {
 						trueVal := true
 						p.Enabled = &trueVal
@@ -183,7 +419,7 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				currentCnpgCluster.Annotations["documentdb.io/gateway-tls-rev"] = time.Now().Format(time.RFC3339Nano)
 				if err := r.Client.Update(ctx, currentCnpgCluster); err == nil {
 					logger.Info("Patched CNPG Cluster with TLS settings; requeueing for pod update")
-					return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+					return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 				} else {
 					logger.Error(err, "Failed to update CNPG Cluster with TLS settings")
 				}
@@ -192,31 +428,35 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	if slices.Contains(currentCnpgCluster.Status.InstancesStatus[cnpgv1.PodHealthy], currentCnpgCluster.Status.CurrentPrimary) && replicationContext.IsPrimary() {
-		// Check if permissions have already been granted
-		checkCommand := "SELECT 1 FROM pg_roles WHERE rolname = 'streaming_replica' AND pg_has_role('streaming_replica', 'documentdb_admin_role', 'USAGE');"
-		output, err := r.executeSQLCommand(ctx, currentCnpgCluster, replicationContext, checkCommand, "check-permissions")
-		if err != nil {
-			logger.Error(err, "Failed to check if permissions already granted")
-			return ctrl.Result{RequeueAfter: RequeueAfterLong}, nil
-		}
-
-		if !strings.Contains(output, "(1 row)") {
-			grantCommand := "GRANT documentdb_admin_role TO streaming_replica;"
-
-			if _, err := r.executeSQLCommand(ctx, currentCnpgCluster, replicationContext, grantCommand, "grant-permissions"); err != nil {
-				logger.Error(err, "Failed to grant permissions to streaming_replica")
-				return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
-			}
+		if err := r.grantReplicationRole(ctx, documentdb, currentCnpgCluster, replicationContext); err != nil {
+			logger.Error(err, "Failed to grant permissions to streaming_replica")
+			return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 		}
 	}
 
+	switchoverInProgress := false
 	if replicationContext.IsPrimary() && documentdb.Status.TargetPrimary != "" {
 		// If these are different, we need to initiate a failover
 		if documentdb.Status.TargetPrimary != currentCnpgCluster.Status.TargetPrimary {
+			if documentdb.GracefulSwitchover() && meta.IsStatusConditionTrue(documentdb.Status.Conditions, dbpreview.ConditionMaintenanceDeferred) {
+				logger.Info("Deferring switchover until the maintenance window opens", "targetPrimary", documentdb.Status.TargetPrimary)
+				return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
+			}
+			if documentdb.GracefulSwitchover() {
+				caughtUp, err := r.replicaCaughtUp(ctx, currentCnpgCluster, documentdb.Status.TargetPrimary, replicationContext)
+				if err != nil {
+					logger.Error(err, "Failed to check replication lag of the new primary")
+					return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+				}
+				if !caughtUp {
+					logger.Info("Waiting for the new primary to catch up before switching over", "targetPrimary", documentdb.Status.TargetPrimary)
+					return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+				}
+			}
 
 			if err = Promote(ctx, r.Client, currentCnpgCluster.Namespace, currentCnpgCluster.Name, documentdb.Status.TargetPrimary); err != nil {
 				logger.Error(err, "Failed to promote standby cluster to primary")
-				return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 			}
 		} else if documentdb.Status.TargetPrimary != documentdb.Status.LocalPrimary &&
 			documentdb.Status.TargetPrimary == currentCnpgCluster.Status.CurrentPrimary {
@@ -225,23 +465,80 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			documentdb.Status.LocalPrimary = currentCnpgCluster.Status.CurrentPrimary
 			if err := r.Status().Update(ctx, documentdb); err != nil {
 				logger.Error(err, "Failed to update DocumentDB status")
-				return ctrl.Result{RequeueAfter: RequeueAfterShort}, nil
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
 			}
+		} else if documentdb.Status.TargetPrimary != currentCnpgCluster.Status.CurrentPrimary {
+			// A target that never becomes primary, e.g. because it is unhealthy, would leave the switchover pending forever
+			if switchoverTimedOut(documentdb, currentCnpgCluster) {
+				if err := r.revertSwitchover(ctx, documentdb, currentCnpgCluster); err != nil {
+					logger.Error(err, "Failed to revert the switchover", "targetPrimary", documentdb.Status.TargetPrimary)
+				}
+				return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+			}
+			switchoverInProgress = true
 		}
 	}
 
-	// Update DocumentDB status with CNPG Cluster phase and connection string
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: desiredCnpgCluster.Name, Namespace: req.Namespace}, currentCnpgCluster); err == nil {
+	// Update DocumentDB status with CNPG Cluster phase, instance health and connection string
+	if err := r.Client.Get(ctx, req.NamespacedName, currentCnpgCluster); err == nil {
 		statusChanged := false
 
+		if specApplied && documentdb.Status.ObservedGeneration != documentdb.Generation {
+			documentdb.Status.ObservedGeneration = documentdb.Generation
+			statusChanged = true
+		}
+
 		// Update phase status from CNPG Cluster
 		if currentCnpgCluster.Status.Phase != "" && documentdb.Status.Status != currentCnpgCluster.Status.Phase {
 			documentdb.Status.Status = currentCnpgCluster.Status.Phase
 			statusChanged = true
 		}
 
-		// Update connection string if primary and service IP available
-		if replicationContext.IsPrimary() && documentDbServiceIp != "" {
+		// Update instance counts and current primary from CNPG Cluster
+		if documentdb.UpdateInstanceStatus(currentCnpgCluster) {
+			statusChanged = true
+		}
+
+		// Complete an in-progress upgrade once the cluster runs the new image
+		if documentdb.UpdateUpgradeStatus(currentCnpgCluster) {
+			statusChanged = true
+		}
+
+		// Complete a clone once the cluster restored from the source's backup is healthy
+		if documentdb.UpdateCloneStatus(currentCnpgCluster) {
+			statusChanged = true
+		}
+
+		// Report instances the scheduler cannot place, e.g. with too few nodes for the anti-affinity rules
+		pods := &corev1.PodList{}
+		if err := r.Client.List(ctx, pods, client.InNamespace(req.Namespace), client.MatchingLabels{util.CNPG_CLUSTER_LABEL: currentCnpgCluster.Name}); err != nil {
+			logger.Error(err, "Failed to list CNPG Cluster pods")
+		} else if documentdb.UpdateSchedulableCondition(pods.Items) {
+			statusChanged = true
+		}
+
+		// Report whether CNPG has applied each database from spec.databases
+		if replicationContext.IsPrimary() {
+			databases := &cnpgv1.DatabaseList{}
+			if err := r.Client.List(ctx, databases, client.InNamespace(req.Namespace)); err != nil {
+				logger.Error(err, "Failed to list CNPG Databases")
+			} else if documentdb.UpdateDatabaseStatus(databases.Items) {
+				statusChanged = true
+			}
+		}
+
+		// Signal credential rotations, which break clients still using the old credentials
+		if r.trackCredentialsRotation(ctx, documentdb) {
+			statusChanged = true
+		}
+
+		// Report whether the gateway behind the Service accepts connections
+		if documentdb.UpdateServiceReadyCondition(documentDbServiceName, documentDbServiceReady) {
+			statusChanged = true
+		}
+
+		// Update connection string if primary and the service has a ready gateway endpoint
+		if replicationContext.IsPrimary() && documentDbServiceIp != "" && documentDbServiceReady {
 			trustTLS := documentdb.Status.TLS != nil && documentdb.Status.TLS.Ready
 			newConnStr := util.GenerateConnectionString(documentdb, documentDbServiceIp, trustTLS)
 			if documentdb.Status.ConnectionString != newConnStr {
@@ -250,6 +547,30 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}
 		}
 
+		// List every member cluster's gateway for clients that fail over between them
+		newMultiHostConnStr := ""
+		if replicationContext.IsReplicating() && replicationContext.IsPrimary() && documentDbServiceIp != "" && documentDbServiceReady {
+			if hosts := r.memberClusterHosts(ctx, documentdb, replicationContext, req.Namespace, documentDbServiceIp); len(hosts) > 1 {
+				trustTLS := documentdb.Status.TLS != nil && documentdb.Status.TLS.Ready
+				newMultiHostConnStr = util.GenerateMultiHostConnectionString(documentdb, hosts, trustTLS)
+			}
+		}
+		if documentdb.Status.MultiHostConnectionString != newMultiHostConnStr {
+			documentdb.Status.MultiHostConnectionString = newMultiHostConnStr
+			statusChanged = true
+		}
+
+		// Update reader connection string, clearing it when the reader endpoint is disabled
+		newReaderConnStr := ""
+		if replicationContext.IsPrimary() && documentDbReaderServiceIp != "" {
+			trustTLS := documentdb.Status.TLS != nil && documentdb.Status.TLS.Ready
+			newReaderConnStr = util.GenerateConnectionString(documentdb, documentDbReaderServiceIp, trustTLS)
+		}
+		if documentdb.Status.ReaderConnectionString != newReaderConnStr {
+			documentdb.Status.ReaderConnectionString = newReaderConnStr
+			statusChanged = true
+		}
+
 		if statusChanged {
 			if err := r.Status().Update(ctx, documentdb); err != nil {
 				logger.Error(err, "Failed to update DocumentDB status")
@@ -257,10 +578,889 @@ func (r *DocumentDBReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Check again later for a gateway endpoint, or for a switchover to complete or time out, as neither triggers a reconcile
+	if switchoverInProgress || documentDbServiceName != "" && !documentDbServiceReady {
+		return ctrl.Result{RequeueAfter: r.Requeue.short()}, nil
+	}
+
+	// Check again later for the maintenance window to open
+	if meta.IsStatusConditionTrue(documentdb.Status.Conditions, dbpreview.ConditionMaintenanceDeferred) {
+		return ctrl.Result{RequeueAfter: r.Requeue.long()}, nil
+	}
+
 	// Don't reque again unless there is a change
 	return ctrl.Result{}, nil
 }
 
+// clusterSettled reports whether the CNPG Cluster has the current generation of the DocumentDB spec applied and is
+// healthy, with no switchover, deferred maintenance or clone restore pending. Changes to the DocumentDB spec bump
+// its generation, so the cluster spec only needs to be diffed when it is not settled. The images are compared as
+// well, since the default images change with the operator version rather than the spec.
+func clusterSettled(documentdb *dbpreview.DocumentDB, cluster, desired *cnpgv1.Cluster) bool {
+	if len(desired.Spec.Plugins) > 0 {
+		pluginName := desired.Spec.Plugins[0].Name
+		_, currentGatewayImage := gatewayImageParameter(cluster, pluginName)
+		_, desiredGatewayImage := gatewayImageParameter(desired, pluginName)
+		if currentGatewayImage != desiredGatewayImage {
+			return false
+		}
+	}
+	return cluster.Spec.ImageName == desired.Spec.ImageName &&
+		documentdb.Generation != 0 &&
+		documentdb.Status.ObservedGeneration == documentdb.Generation &&
+		cluster.Status.Phase == cnpgv1.PhaseHealthy &&
+		documentdb.Status.TargetPrimary == documentdb.Status.LocalPrimary &&
+		!meta.IsStatusConditionTrue(documentdb.Status.Conditions, dbpreview.ConditionMaintenanceDeferred) &&
+		(documentdb.Status.Clone == nil || documentdb.Status.Clone.Phase != dbpreview.ClonePhaseRestoring)
+}
+
+// switchoverTimeout returns the time allowed for the instance in status.targetPrimary to become primary
+func switchoverTimeout(documentdb *dbpreview.DocumentDB) time.Duration {
+	return time.Duration(cmp.Or(documentdb.Spec.Timeouts.SwitchoverTimeout, util.DEFAULT_SWITCHOVER_TIMEOUT)) * time.Second
+}
+
+// switchoverTimedOut reports whether the switchover CNPG started to the target primary has been pending for longer
+// than the switchover timeout.
+func switchoverTimedOut(documentdb *dbpreview.DocumentDB, cluster *cnpgv1.Cluster) bool {
+	if cluster.Status.TargetPrimaryTimestamp == "" {
+		return false
+	}
+	elapsed, err := pgTime.DifferenceBetweenTimestamps(pgTime.GetCurrentTimestamp(), cluster.Status.TargetPrimaryTimestamp)
+	return err == nil && elapsed > switchoverTimeout(documentdb)
+}
+
+// revertSwitchover gives up on a switchover that did not complete in time: CNPG is pointed back at the current
+// primary, status.targetPrimary is reverted and a SwitchoverFailed event is emitted.
+func (r *DocumentDBReconciler) revertSwitchover(ctx context.Context, documentdb *dbpreview.DocumentDB, cluster *cnpgv1.Cluster) error {
+	failedTarget := documentdb.Status.TargetPrimary
+	currentPrimary := cluster.Status.CurrentPrimary
+	if currentPrimary == "" {
+		return fmt.Errorf("CNPG Cluster %s reports no current primary to revert to", cluster.Name)
+	}
+
+	if err := Promote(ctx, r.Client, cluster.Namespace, cluster.Name, currentPrimary); err != nil {
+		return err
+	}
+
+	documentdb.Status.TargetPrimary = currentPrimary
+	if err := r.Status().Update(ctx, documentdb); err != nil {
+		return fmt.Errorf("failed to update DocumentDB status: %w", err)
+	}
+
+	message := fmt.Sprintf("Instance %s did not become primary within %s, reverted to %s", failedTarget, switchoverTimeout(documentdb), currentPrimary)
+	r.Recorder.Event(documentdb, corev1.EventTypeWarning, "SwitchoverFailed", message)
+	log.FromContext(ctx).Info("Switchover timed out", "targetPrimary", failedTarget, "currentPrimary", currentPrimary)
+	return nil
+}
+
+// updateStorageSize propagates a storage size increase to the CNPG Cluster, which expands the PVCs
+// when the storage class allows volume expansion. Decreases are rejected and the current size is kept.
+func (r *DocumentDBReconciler) updateStorageSize(ctx context.Context, current, desired *cnpgv1.Cluster, documentdb *dbpreview.DocumentDB) error {
+	currentSize := current.Spec.StorageConfiguration.Size
+	desiredSize := desired.Spec.StorageConfiguration.Size
+	if desiredSize == "" || currentSize == desiredSize {
+		return nil
+	}
+
+	if err := documentdb.Spec.Resource.Storage.ValidatePvcSize(currentSize); err != nil {
+		r.Recorder.Event(documentdb, corev1.EventTypeWarning, "StorageResizeRejected", err.Error())
+		log.FromContext(ctx).Info("Ignoring storage size change", "reason", err.Error())
+		return nil
+	}
+
+	// Equivalent quantities in different units don't need a resize
+	desiredQuantity := resource.MustParse(desiredSize)
+	if desiredQuantity.Cmp(resource.MustParse(currentSize)) == 0 {
+		return nil
+	}
+
+	// Without volume expansion the PVCs would be left at their size, so keep the cluster consistent with them
+	if storageClassName := current.Spec.StorageConfiguration.StorageClass; storageClassName != nil && *storageClassName != "" {
+		storageClass := &storagev1.StorageClass{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: *storageClassName}, storageClass); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get StorageClass %s: %w", *storageClassName, err)
+		} else if err != nil || storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+			message := fmt.Sprintf("StorageClass %s does not allow volume expansion", *storageClassName)
+			r.Recorder.Event(documentdb, corev1.EventTypeWarning, "StorageResizeRejected", message)
+			log.FromContext(ctx).Info("Ignoring storage size change", "reason", message)
+			return nil
+		}
+	}
+
+	patch, err := json.Marshal([]util.JSONPatch{{
+		Op:    util.JSON_PATCH_OP_REPLACE,
+		Path:  util.JSON_PATCH_PATH_STORAGE_SIZE,
+		Value: desiredSize,
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal storage patch: %w", err)
+	}
+
+	if err := r.Client.Patch(ctx, current, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return fmt.Errorf("failed to expand storage of CNPG Cluster %s: %w", current.Name, err)
+	}
+
+	r.Recorder.Eventf(documentdb, corev1.EventTypeNormal, "StorageExpansion", "Expanding storage from %s to %s", currentSize, desiredSize)
+	log.FromContext(ctx).Info("Expanded CNPG Cluster storage", "cluster", current.Name, "from", currentSize, "to", desiredSize)
+	return nil
+}
+
+// desiredInheritedMetadata returns the desired pod metadata of the cluster, keeping its current replication type
+// label, which is left to the replication transitions
+func desiredInheritedMetadata(current, desired *cnpgv1.Cluster) *cnpgv1.EmbeddedObjectMetadata {
+	if desired.Spec.InheritedMetadata == nil {
+		return nil
+	}
+	inheritedMetadata := desired.Spec.InheritedMetadata.DeepCopy()
+	delete(inheritedMetadata.Labels, util.LABEL_REPLICATION_CLUSTER_TYPE)
+	if current.Spec.InheritedMetadata != nil {
+		if clusterType, ok := current.Spec.InheritedMetadata.Labels[util.LABEL_REPLICATION_CLUSTER_TYPE]; ok {
+			if inheritedMetadata.Labels == nil {
+				inheritedMetadata.Labels = map[string]string{}
+			}
+			inheritedMetadata.Labels[util.LABEL_REPLICATION_CLUSTER_TYPE] = clusterType
+		}
+	}
+	return inheritedMetadata
+}
+
+// updateMutableClusterFields patches the fields of the CNPG Cluster that can change after creation
+// (labels, pod labels and annotations, image, log level, stop, start and switchover delays, superuser access,
+// priority class, WAL archive, Postgres parameters, database owner roles and, without replication, the instance count)
+// when they differ from the desired spec. Returns true if a patch was applied.
+func (r *DocumentDBReconciler) updateMutableClusterFields(ctx context.Context, current, desired *cnpgv1.Cluster) (bool, error) {
+	var patchOps []util.JSONPatch
+
+	// Label clusters created before the operator labelled them, leaving other labels alone
+	if current.Labels == nil {
+		if len(desired.Labels) > 0 {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_LABELS,
+				Value: desired.Labels,
+			})
+		}
+	} else {
+		labelKeys := make([]string, 0, len(desired.Labels))
+		for key := range desired.Labels {
+			labelKeys = append(labelKeys, key)
+		}
+		slices.Sort(labelKeys)
+		for _, key := range labelKeys {
+			if current.Labels[key] != desired.Labels[key] {
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:    util.JSON_PATCH_OP_ADD,
+					Path:  util.JSON_PATCH_PATH_LABELS + "/" + jsonPointerEscaper.Replace(key),
+					Value: desired.Labels[key],
+				})
+			}
+		}
+	}
+
+	if desired.Spec.ImageName != "" && current.Spec.ImageName != desired.Spec.ImageName {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_REPLACE,
+			Path:  util.JSON_PATCH_PATH_IMAGE_NAME,
+			Value: desired.Spec.ImageName,
+		})
+	}
+
+	if desired.Spec.LogLevel != "" && current.Spec.LogLevel != desired.Spec.LogLevel {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_LOG_LEVEL,
+			Value: desired.Spec.LogLevel,
+		})
+	}
+
+	if current.Spec.MaxStopDelay != desired.Spec.MaxStopDelay {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_MAX_STOP_DELAY,
+			Value: desired.Spec.MaxStopDelay,
+		})
+	}
+
+	// Unset start and switchover delays are defaulted by CNPG and left as they are
+	if desired.Spec.MaxStartDelay != 0 && current.Spec.MaxStartDelay != desired.Spec.MaxStartDelay {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_MAX_START_DELAY,
+			Value: desired.Spec.MaxStartDelay,
+		})
+	}
+
+	if desired.Spec.MaxSwitchoverDelay != 0 && current.Spec.MaxSwitchoverDelay != desired.Spec.MaxSwitchoverDelay {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_MAX_SWITCHOVER_DELAY,
+			Value: desired.Spec.MaxSwitchoverDelay,
+		})
+	}
+
+	// Superuser access is only switched on here; clearing spec.superuserSecret leaves the CNPG setting as it is
+	if desired.Spec.SuperuserSecret != nil && !current.GetEnableSuperuserAccess() {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_SUPERUSER_ACCESS,
+			Value: true,
+		})
+	}
+	if desired.Spec.SuperuserSecret != nil && (current.Spec.SuperuserSecret == nil || current.Spec.SuperuserSecret.Name != desired.Spec.SuperuserSecret.Name) {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_SUPERUSER_SECRET,
+			Value: desired.Spec.SuperuserSecret,
+		})
+	}
+
+	// CNPG applies the inherited labels and annotations to the running pods without restarting them
+	if inheritedMetadata := desiredInheritedMetadata(current, desired); inheritedMetadata != nil && !equality.Semantic.DeepEqual(current.Spec.InheritedMetadata, inheritedMetadata) {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_INHERITED_METADATA,
+			Value: inheritedMetadata,
+		})
+	}
+
+	if current.Spec.PriorityClassName != desired.Spec.PriorityClassName {
+		if desired.Spec.PriorityClassName == "" {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:   util.JSON_PATCH_OP_REMOVE,
+				Path: util.JSON_PATCH_PATH_PRIORITY_CLASS_NAME,
+			})
+		} else {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_PRIORITY_CLASS_NAME,
+				Value: desired.Spec.PriorityClassName,
+			})
+		}
+	}
+
+	// Unset primary update settings are defaulted by CNPG, and may be changed by hand (e.g. by the kubectl plugin)
+	if desired.Spec.PrimaryUpdateStrategy != "" && current.Spec.PrimaryUpdateStrategy != desired.Spec.PrimaryUpdateStrategy {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_PRIMARY_UPDATE_STRATEGY,
+			Value: desired.Spec.PrimaryUpdateStrategy,
+		})
+	}
+	if desired.Spec.PrimaryUpdateMethod != "" && current.Spec.PrimaryUpdateMethod != desired.Spec.PrimaryUpdateMethod {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_ADD,
+			Path:  util.JSON_PATCH_PATH_PRIMARY_UPDATE_METHOD,
+			Value: desired.Spec.PrimaryUpdateMethod,
+		})
+	}
+
+	// Pull secrets are used the next time the instances pull their images
+	if !equality.Semantic.DeepEqual(current.Spec.ImagePullSecrets, desired.Spec.ImagePullSecrets) {
+		if len(desired.Spec.ImagePullSecrets) == 0 {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:   util.JSON_PATCH_OP_REMOVE,
+				Path: util.JSON_PATCH_PATH_IMAGE_PULL_SECRETS,
+			})
+		} else {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_IMAGE_PULL_SECRETS,
+				Value: desired.Spec.ImagePullSecrets,
+			})
+		}
+	}
+
+	// CNPG reloads the archive command when the WAL archive changes, without restarting the instances
+	var currentObjectStore, desiredObjectStore *cnpgv1.BarmanObjectStoreConfiguration
+	if current.Spec.Backup != nil {
+		currentObjectStore = current.Spec.Backup.BarmanObjectStore
+	}
+	if desired.Spec.Backup != nil {
+		desiredObjectStore = desired.Spec.Backup.BarmanObjectStore
+	}
+	if !equality.Semantic.DeepEqual(currentObjectStore, desiredObjectStore) {
+		switch {
+		case current.Spec.Backup == nil:
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_BACKUP,
+				Value: desired.Spec.Backup,
+			})
+		case desiredObjectStore == nil:
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:   util.JSON_PATCH_OP_REMOVE,
+				Path: util.JSON_PATCH_PATH_BARMAN_OBJECT_STORE,
+			})
+		default:
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_BARMAN_OBJECT_STORE,
+				Value: desiredObjectStore,
+			})
+		}
+	}
+
+	// Keep the login roles of database owners in sync, leaving the managed services of fleet networking alone
+	var currentRoles, desiredRoles []cnpgv1.RoleConfiguration
+	if current.Spec.Managed != nil {
+		currentRoles = current.Spec.Managed.Roles
+	}
+	if desired.Spec.Managed != nil {
+		desiredRoles = desired.Spec.Managed.Roles
+	}
+	if !equality.Semantic.DeepEqual(currentRoles, desiredRoles) {
+		switch {
+		case current.Spec.Managed == nil:
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_MANAGED,
+				Value: cnpgv1.ManagedConfiguration{Roles: desiredRoles},
+			})
+		case len(desiredRoles) == 0:
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:   util.JSON_PATCH_OP_REMOVE,
+				Path: util.JSON_PATCH_PATH_MANAGED_ROLES,
+			})
+		default:
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_MANAGED_ROLES,
+				Value: desiredRoles,
+			})
+		}
+	}
+
+	// Instance count is managed by the replication transitions when replication is configured
+	if desired.Spec.ReplicaCluster == nil && current.Spec.ReplicaCluster == nil && current.Spec.Instances != desired.Spec.Instances {
+		patchOps = append(patchOps, util.JSONPatch{
+			Op:    util.JSON_PATCH_OP_REPLACE,
+			Path:  util.JSON_PATCH_PATH_INSTANCES,
+			Value: desired.Spec.Instances,
+		})
+	}
+
+	// Adding or removing the gateway sidecar with spec.disableGateway replaces the plugins
+	restartInstances := false
+	if sidecarPluginConfigured(current) != sidecarPluginConfigured(desired) {
+		if len(desired.Spec.Plugins) == 0 {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:   util.JSON_PATCH_OP_REMOVE,
+				Path: util.JSON_PATCH_PATH_PLUGINS,
+			})
+		} else {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_PLUGINS,
+				Value: desired.Spec.Plugins,
+			})
+		}
+		restartInstances = true
+	} else if len(desired.Spec.Plugins) > 0 {
+		// Keep the gateway image of the sidecar plugin in sync
+		index, currentGatewayImage := gatewayImageParameter(current, desired.Spec.Plugins[0].Name)
+		_, desiredGatewayImage := gatewayImageParameter(desired, desired.Spec.Plugins[0].Name)
+		if index >= 0 && desiredGatewayImage != "" && currentGatewayImage != desiredGatewayImage {
+			pluginParametersPath := fmt.Sprintf("%s/%d/parameters", util.JSON_PATCH_PATH_PLUGINS, index)
+			if current.Spec.Plugins[index].Parameters == nil {
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:    util.JSON_PATCH_OP_ADD,
+					Path:  pluginParametersPath,
+					Value: map[string]string{util.GATEWAY_IMAGE_PLUGIN_PARAMETER: desiredGatewayImage},
+				})
+			} else {
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:    util.JSON_PATCH_OP_ADD,
+					Path:  pluginParametersPath + "/" + util.GATEWAY_IMAGE_PLUGIN_PARAMETER,
+					Value: desiredGatewayImage,
+				})
+			}
+
+			restartInstances = true
+		}
+
+		// Apply the gateway settings that only take effect on restart, e.g. pointing the gateway at the pooler
+		for _, parameter := range restartingPluginParameters {
+			currentValue := pluginParameter(current, desired.Spec.Plugins[0].Name, parameter.name)
+			desiredValue := pluginParameter(desired, desired.Spec.Plugins[0].Name, parameter.name)
+			if index < 0 || currentValue == desiredValue {
+				continue
+			}
+			pluginParametersPath := fmt.Sprintf("%s/%d/parameters", util.JSON_PATCH_PATH_PLUGINS, index)
+			switch {
+			case desiredValue == "":
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:   util.JSON_PATCH_OP_REMOVE,
+					Path: pluginParametersPath + "/" + parameter.name,
+				})
+			case current.Spec.Plugins[index].Parameters == nil && !restartInstances:
+				// The parameters map doesn't exist yet, and wasn't added by an earlier patch
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:    util.JSON_PATCH_OP_ADD,
+					Path:  pluginParametersPath,
+					Value: map[string]string{parameter.name: desiredValue},
+				})
+			default:
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:    util.JSON_PATCH_OP_ADD,
+					Path:  pluginParametersPath + "/" + parameter.name,
+					Value: desiredValue,
+				})
+			}
+			restartInstances = true
+		}
+	}
+
+	// Restart the instances for the plugin changes, unless the engine image change restarts them anyway
+	if restartInstances && current.Spec.ImageName == desired.Spec.ImageName {
+		restartedAt := time.Now().Format(time.RFC3339)
+		if current.Annotations == nil {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_ANNOTATIONS,
+				Value: map[string]string{util.CNPG_RESTART_ANNOTATION: restartedAt},
+			})
+		} else {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_ANNOTATIONS + "/" + jsonPointerEscaper.Replace(util.CNPG_RESTART_ANNOTATION),
+				Value: restartedAt,
+			})
+		}
+	}
+
+	// CNPG adds its own default parameters, so only reconcile the parameters the operator sets
+	if current.Spec.PostgresConfiguration.Parameters == nil {
+		if len(desired.Spec.PostgresConfiguration.Parameters) > 0 {
+			patchOps = append(patchOps, util.JSONPatch{
+				Op:    util.JSON_PATCH_OP_ADD,
+				Path:  util.JSON_PATCH_PATH_POSTGRES_PARAMETERS,
+				Value: desired.Spec.PostgresConfiguration.Parameters,
+			})
+		}
+	} else {
+		parameterNames := make([]string, 0, len(desired.Spec.PostgresConfiguration.Parameters))
+		for name := range desired.Spec.PostgresConfiguration.Parameters {
+			parameterNames = append(parameterNames, name)
+		}
+		slices.Sort(parameterNames)
+		for _, name := range parameterNames {
+			value := desired.Spec.PostgresConfiguration.Parameters[name]
+			if currentValue, ok := current.Spec.PostgresConfiguration.Parameters[name]; !ok || currentValue != value {
+				patchOps = append(patchOps, util.JSONPatch{
+					Op:    util.JSON_PATCH_OP_ADD,
+					Path:  util.JSON_PATCH_PATH_POSTGRES_PARAMETERS + "/" + jsonPointerEscaper.Replace(name),
+					Value: value,
+				})
+			}
+		}
+	}
+
+	if len(patchOps) == 0 {
+		return false, nil
+	}
+
+	patch, err := json.Marshal(patchOps)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal patch operations: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Applying patch for CNPG Cluster spec changes", "patch", string(patch), "cluster", current.Name)
+	if err := r.Client.Patch(ctx, current, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return false, fmt.Errorf("failed to update CNPG Cluster %s: %w", current.Name, err)
+	}
+
+	return true, nil
+}
+
+// reconcilePooler creates or updates the PgBouncer Pooler of the CNPG cluster when the pooler is enabled,
+// and deletes it otherwise
+func (r *DocumentDBReconciler) reconcilePooler(ctx context.Context, documentdb *dbpreview.DocumentDB, clusterName, namespace string) error {
+	poolerName := util.GetDocumentDBPoolerName(clusterName)
+	foundPooler := &cnpgv1.Pooler{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: poolerName, Namespace: namespace}, foundPooler)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if documentdb.Spec.Pooler == nil || !documentdb.Spec.Pooler.Enabled {
+		if found {
+			if err := r.Client.Delete(ctx, foundPooler); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			log.FromContext(ctx).Info("Deleted CNPG Pooler", "Pooler.Name", poolerName)
+		}
+		return nil
+	}
+
+	desiredPooler := cnpg.GetCnpgPoolerSpec(documentdb, clusterName, namespace)
+	if !found {
+		if err := r.Client.Create(ctx, desiredPooler); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		log.FromContext(ctx).Info("CNPG Pooler created successfully", "Pooler.Name", poolerName)
+		return nil
+	}
+
+	if equality.Semantic.DeepDerivative(desiredPooler.Spec, foundPooler.Spec) {
+		return nil
+	}
+	foundPooler.Spec = desiredPooler.Spec
+	return r.Client.Update(ctx, foundPooler)
+}
+
+// trackCredentialsRotation records the resourceVersion of the credentials secret in status and emits a
+// CredentialsRotated event when it changes. The connection string reads the credentials from the secret,
+// so it stays valid, but clients must reconnect with the new credentials. Returns true if the status changed.
+func (r *DocumentDBReconciler) trackCredentialsRotation(ctx context.Context, documentdb *dbpreview.DocumentDB) bool {
+	source := util.CredentialSecretSource(documentdb)
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, source, secret); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to get the credentials secret", "secret", source)
+		return false
+	}
+
+	previousVersion := documentdb.Status.CredentialsSecretVersion
+	if previousVersion == secret.ResourceVersion {
+		return false
+	}
+	// The first observed version is recorded without an event
+	if previousVersion != "" {
+		r.Recorder.Eventf(documentdb, corev1.EventTypeNormal, "CredentialsRotated", "Credentials secret %s changed; clients must reconnect with the new credentials", source)
+		log.FromContext(ctx).Info("Credentials secret changed", "secret", source, "resourceVersion", secret.ResourceVersion)
+	}
+	documentdb.Status.CredentialsSecretVersion = secret.ResourceVersion
+	return true
+}
+
+// checkStorageClass reports in the StorageClassReady condition whether the named StorageClass exists, and returns
+// whether it does. Without a name the cluster's default StorageClass is used, which is not checked.
+func (r *DocumentDBReconciler) checkStorageClass(ctx context.Context, documentdb *dbpreview.DocumentDB, name string) (bool, error) {
+	var storageClass *storagev1.StorageClass
+	if name != "" {
+		storageClass = &storagev1.StorageClass{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, storageClass); err != nil {
+			if !errors.IsNotFound(err) {
+				return false, err
+			}
+			storageClass = nil
+		}
+	}
+
+	if documentdb.UpdateStorageClassCondition(name, storageClass) {
+		if name != "" && storageClass == nil {
+			r.Recorder.Eventf(documentdb, corev1.EventTypeWarning, "StorageClassNotFound", "StorageClass %s does not exist", name)
+		}
+		if err := r.Status().Update(ctx, documentdb); err != nil {
+			return false, fmt.Errorf("failed to update DocumentDB storage class status: %w", err)
+		}
+	}
+	return name == "" || storageClass != nil, nil
+}
+
+// memberClusterHosts returns the gateway endpoint of this member cluster followed by the endpoints the other member
+// clusters are reached at through the cross-cloud networking: the service fleet networking derives from the
+// MultiClusterService importing their services, or the Istio service mirroring their gateway Service. Member
+// clusters whose endpoint is not resolved yet are left out, as are all of them without cross-cloud networking.
+func (r *DocumentDBReconciler) memberClusterHosts(ctx context.Context, documentdb *dbpreview.DocumentDB, replicationContext *util.ReplicationContext, namespace, selfHost string) []string {
+	logger := log.FromContext(ctx)
+	hosts := []string{selfHost}
+	for _, other := range replicationContext.Others {
+		var host string
+		var err error
+		switch {
+		case replicationContext.IsAzureFleetNetworking():
+			host, err = r.fleetMemberClusterHost(ctx, replicationContext, other, namespace)
+		case replicationContext.IsIstioNetworking():
+			host, err = r.istioMemberClusterHost(ctx, documentdb, other, namespace)
+		}
+		if err != nil {
+			logger.Error(err, "Failed to resolve the endpoint of member cluster", "cluster", other)
+			continue
+		}
+		if host == "" {
+			logger.V(1).Info("Endpoint of member cluster is not resolved yet", "cluster", other)
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// reportReplicationConfigured records the outcome of setting up the cross-cluster replication in the
+// ReplicationConfigured condition, raising a warning event when it starts failing.
+func (r *DocumentDBReconciler) reportReplicationConfigured(ctx context.Context, documentdb *dbpreview.DocumentDB, replicationErr error) error {
+	if !documentdb.UpdateReplicationConfiguredCondition(replicationErr) {
+		return nil
+	}
+	if replicationErr != nil {
+		r.Recorder.Event(documentdb, corev1.EventTypeWarning, "ReplicationConfigurationFailed", replicationErr.Error())
+	}
+	if err := r.Status().Update(ctx, documentdb); err != nil {
+		return fmt.Errorf("failed to update DocumentDB replication status: %w", err)
+	}
+	return nil
+}
+
+// reconcileCloneBackup takes the Backup of the clone source that the cluster is restored from and reports its
+// progress in status.clone. Returns true once the backup has completed.
+func (r *DocumentDBReconciler) reconcileCloneBackup(ctx context.Context, documentdb *dbpreview.DocumentDB) (bool, error) {
+	sourceName := documentdb.Spec.Bootstrap.Clone.Source.Name
+	backupName := util.GetCloneBackupName(documentdb.Name)
+
+	backup := &dbpreview.Backup{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: backupName, Namespace: documentdb.Namespace}, backup)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	if errors.IsNotFound(err) {
+		source := &dbpreview.DocumentDB{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: sourceName, Namespace: documentdb.Namespace}, source); err != nil {
+			if errors.IsNotFound(err) {
+				return false, r.updateCloneStatus(ctx, documentdb, dbpreview.ClonePhaseFailed, backupName,
+					fmt.Sprintf("Source DocumentDB %s not found in namespace %s", sourceName, documentdb.Namespace))
+			}
+			return false, err
+		}
+
+		// The backup belongs to the clone, so it is removed with it rather than with the source
+		backup = &dbpreview.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: backupName, Namespace: documentdb.Namespace},
+			Spec:       dbpreview.BackupSpec{Cluster: cnpgv1.LocalObjectReference{Name: sourceName}},
+		}
+		if err := controllerutil.SetControllerReference(documentdb, backup, r.Scheme); err != nil {
+			return false, err
+		}
+		if err := r.Client.Create(ctx, backup); err != nil {
+			return false, fmt.Errorf("failed to create Backup %s of clone source %s: %w", backupName, sourceName, err)
+		}
+		r.Recorder.Event(documentdb, corev1.EventTypeNormal, "CloneBackupCreated", fmt.Sprintf("Backing up %s to clone it", sourceName))
+	}
+
+	switch backup.Status.Phase {
+	case cnpgv1.BackupPhaseCompleted:
+		return true, r.updateCloneStatus(ctx, documentdb, dbpreview.ClonePhaseRestoring, backupName,
+			fmt.Sprintf("Restoring from backup %s of %s", backupName, sourceName))
+	case cnpgv1.BackupPhaseFailed, dbpreview.BackupPhaseSkipped:
+		message := fmt.Sprintf("Backup %s of %s did not complete: %s", backupName, sourceName, backup.Status.Message)
+		if documentdb.Status.Clone == nil || documentdb.Status.Clone.Phase != dbpreview.ClonePhaseFailed {
+			r.Recorder.Event(documentdb, corev1.EventTypeWarning, "CloneFailed", message+". Delete the Backup to retry.")
+		}
+		return false, r.updateCloneStatus(ctx, documentdb, dbpreview.ClonePhaseFailed, backupName, message)
+	default:
+		return false, r.updateCloneStatus(ctx, documentdb, dbpreview.ClonePhaseBackingUp, backupName,
+			fmt.Sprintf("Waiting for backup %s of %s to complete", backupName, sourceName))
+	}
+}
+
+// updateCloneStatus sets status.clone, updating the DocumentDB status only when it changed
+func (r *DocumentDBReconciler) updateCloneStatus(ctx context.Context, documentdb *dbpreview.DocumentDB, phase, backupName, message string) error {
+	clone := &dbpreview.CloneStatus{Phase: phase, BackupName: backupName, Message: message}
+	if documentdb.Status.Clone != nil && *documentdb.Status.Clone == *clone {
+		return nil
+	}
+	documentdb.Status.Clone = clone
+	if err := r.Status().Update(ctx, documentdb); err != nil {
+		return fmt.Errorf("failed to update DocumentDB clone status: %w", err)
+	}
+	return nil
+}
+
+// validateWalArchiveCredentials checks that the Secret keys referenced by the WAL archive credentials exist
+func (r *DocumentDBReconciler) validateWalArchiveCredentials(ctx context.Context, documentdb *dbpreview.DocumentDB) error {
+	if documentdb.Spec.Backup == nil || documentdb.Spec.Backup.WalArchive == nil {
+		return nil
+	}
+	for _, key := range documentdb.Spec.Backup.WalArchive.CredentialSecretKeys() {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: key.Name, Namespace: documentdb.Namespace}, secret); err != nil {
+			return fmt.Errorf("failed to get WAL archive credentials secret %s: %w", key.Name, err)
+		}
+		if _, ok := secret.Data[key.Key]; !ok {
+			return fmt.Errorf("WAL archive credentials secret %s has no key %s", key.Name, key.Key)
+		}
+	}
+	return nil
+}
+
+// validateImagePullSecrets checks that the Secrets listed in spec.imagePullSecrets exist
+func (r *DocumentDBReconciler) validateImagePullSecrets(ctx context.Context, documentdb *dbpreview.DocumentDB) error {
+	for _, name := range documentdb.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: documentdb.Namespace}, secret); err != nil {
+			return fmt.Errorf("failed to get image pull secret %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// reconcileDatabases creates or updates a CNPG Database for each entry of spec.databases and deletes the ones
+// this DocumentDB controls that are no longer listed. CNPG retains the database itself when its Database is deleted.
+func (r *DocumentDBReconciler) reconcileDatabases(ctx context.Context, documentdb *dbpreview.DocumentDB, clusterName, namespace string) error {
+	desired := map[string]bool{}
+	for _, database := range documentdb.Spec.Databases {
+		desiredDatabase := cnpg.GetCnpgDatabaseSpec(documentdb, database, clusterName, namespace)
+		desired[desiredDatabase.Name] = true
+
+		foundDatabase := &cnpgv1.Database{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: desiredDatabase.Name, Namespace: namespace}, foundDatabase)
+		if errors.IsNotFound(err) {
+			if err := r.Client.Create(ctx, desiredDatabase); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			log.FromContext(ctx).Info("CNPG Database created successfully", "Database.Name", desiredDatabase.Name, "database", database.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if equality.Semantic.DeepDerivative(desiredDatabase.Spec, foundDatabase.Spec) {
+			continue
+		}
+		foundDatabase.Spec = desiredDatabase.Spec
+		if err := r.Client.Update(ctx, foundDatabase); err != nil {
+			return err
+		}
+	}
+
+	databases := &cnpgv1.DatabaseList{}
+	if err := r.Client.List(ctx, databases, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	for i := range databases.Items {
+		database := &databases.Items[i]
+		if desired[database.Name] || !metav1.IsControlledBy(database, documentdb) {
+			continue
+		}
+		if err := r.Client.Delete(ctx, database); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		log.FromContext(ctx).Info("Deleted CNPG Database", "Database.Name", database.Name, "database", database.Spec.Name)
+	}
+	return nil
+}
+
+// reconcileImageUpgrade validates a change of the DocumentDB or gateway image and records its progress in status.
+// Downgrades, and changes between versions that can't be compared, are blocked unless spec.allowDowngrade is set. While
+// blocked, the desired spec keeps the current images.
+func (r *DocumentDBReconciler) reconcileImageUpgrade(ctx context.Context, current, desired *cnpgv1.Cluster, documentdb *dbpreview.DocumentDB) error {
+	if len(desired.Spec.Plugins) == 0 {
+		return nil
+	}
+	pluginName := desired.Spec.Plugins[0].Name
+	_, currentGatewayImage := gatewayImageParameter(current, pluginName)
+	desiredIndex, desiredGatewayImage := gatewayImageParameter(desired, pluginName)
+
+	engineChanged := current.Spec.ImageName != "" && current.Spec.ImageName != desired.Spec.ImageName
+	gatewayChanged := currentGatewayImage != "" && currentGatewayImage != desiredGatewayImage
+	if !engineChanged && !gatewayChanged {
+		return nil
+	}
+
+	var blocked []string
+	if engineChanged {
+		if reason := imageChangeBlockedReason(current.Spec.ImageName, desired.Spec.ImageName); reason != "" {
+			blocked = append(blocked, fmt.Sprintf("DocumentDB image from %s to %s (%s)", current.Spec.ImageName, desired.Spec.ImageName, reason))
+		}
+	}
+	if gatewayChanged {
+		if reason := imageChangeBlockedReason(currentGatewayImage, desiredGatewayImage); reason != "" {
+			blocked = append(blocked, fmt.Sprintf("gateway image from %s to %s (%s)", currentGatewayImage, desiredGatewayImage, reason))
+		}
+	}
+
+	upgrade := &dbpreview.UpgradeStatus{
+		Phase:           dbpreview.UpgradePhaseInProgress,
+		DocumentDBImage: desired.Spec.ImageName,
+		GatewayImage:    desiredGatewayImage,
+		Message:         fmt.Sprintf("Upgrading from %s to %s", current.Spec.ImageName, desired.Spec.ImageName),
+	}
+	eventType, reason := corev1.EventTypeNormal, "Upgrade"
+	if len(blocked) > 0 && !documentdb.Spec.AllowDowngrade {
+		// Keep running the current images until the downgrade is explicitly allowed
+		desired.Spec.ImageName = current.Spec.ImageName
+		if desiredIndex >= 0 && currentGatewayImage != "" {
+			desired.Spec.Plugins[desiredIndex].Parameters[util.GATEWAY_IMAGE_PLUGIN_PARAMETER] = currentGatewayImage
+		}
+		upgrade.Phase = dbpreview.UpgradePhaseBlocked
+		upgrade.Message = fmt.Sprintf("Change of %s requires spec.allowDowngrade", strings.Join(blocked, " and "))
+		eventType, reason = corev1.EventTypeWarning, "DowngradeBlocked"
+	}
+
+	if equality.Semantic.DeepEqual(documentdb.Status.Upgrade, upgrade) {
+		return nil
+	}
+
+	r.Recorder.Event(documentdb, eventType, reason, upgrade.Message)
+	log.FromContext(ctx).Info("DocumentDB image change", "phase", upgrade.Phase, "message", upgrade.Message)
+
+	documentdb.Status.Upgrade = upgrade
+	if err := r.Status().Update(ctx, documentdb); err != nil {
+		return fmt.Errorf("failed to update DocumentDB upgrade status: %w", err)
+	}
+	return nil
+}
+
+// imageChangeBlockedReason returns why changing from the current to the desired image needs spec.allowDowngrade, or ""
+// if it doesn't. Besides downgrades, this covers images whose versions can't be compared, such as a change from the
+// Postgres major version tag "16" to "0.1.3" or "latest", since it can't be told whether they are downgrades.
+func imageChangeBlockedReason(current, desired string) string {
+	comparison, err := util.CompareImageVersions(current, desired)
+	if err != nil {
+		return "versions can't be compared: " + err.Error()
+	}
+	if comparison < 0 {
+		return "downgrade"
+	}
+	return ""
+}
+
+// gatewayImageParameter returns the index of the named plugin in the cluster spec and its gateway image parameter,
+// or -1 if the plugin isn't configured
+func gatewayImageParameter(cluster *cnpgv1.Cluster, pluginName string) (int, string) {
+	for i, plugin := range cluster.Spec.Plugins {
+		if plugin.Name == pluginName {
+			return i, plugin.Parameters[util.GATEWAY_IMAGE_PLUGIN_PARAMETER]
+		}
+	}
+	return -1, ""
+}
+
+// sidecarPluginConfigured reports whether the cluster has the gateway sidecar plugin, the plugin carrying the gateway image
+func sidecarPluginConfigured(cluster *cnpgv1.Cluster) bool {
+	for _, plugin := range cluster.Spec.Plugins {
+		if _, ok := plugin.Parameters[util.GATEWAY_IMAGE_PLUGIN_PARAMETER]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginParameter returns the value of a parameter of the named plugin, or "" if it isn't set
+func pluginParameter(cluster *cnpgv1.Cluster, pluginName, parameter string) string {
+	for _, plugin := range cluster.Spec.Plugins {
+		if plugin.Name == pluginName {
+			return plugin.Parameters[parameter]
+		}
+	}
+	return ""
+}
+
+// jsonPointerEscaper escapes a map key for use as a JSON pointer reference token (RFC 6901)
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// restartingPluginParameters are the sidecar plugin parameters that the gateway only picks up when the
+// instances restart, with a description of the setting for events
+var restartingPluginParameters = []struct {
+	name        string
+	description string
+}{
+	{name: util.PG_HOST_PLUGIN_PARAMETER, description: "the Postgres host"},
+	{name: util.GATEWAY_METRICS_PORT_PLUGIN_PARAMETER, description: "the gateway metrics port"},
+	{name: util.GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER, description: "the gateway log level"},
+}
+
 // cleanupResources handles the cleanup of associated resources when a DocumentDB resource is not found
 func (r *DocumentDBReconciler) cleanupResources(ctx context.Context, req ctrl.Request, documentdb *dbpreview.DocumentDB) error {
 	log := log.FromContext(ctx)
@@ -288,28 +1488,29 @@ func (r *DocumentDBReconciler) cleanupResources(ctx context.Context, req ctrl.Re
 func (r *DocumentDBReconciler) EnsureServiceAccountRoleAndRoleBinding(ctx context.Context, documentdb *dbpreview.DocumentDB, namespace string) error {
 	log := log.FromContext(ctx)
 
+	// The instance pods only read cluster topology, so the Role is limited to read access
 	rules := []rbacv1.PolicyRule{
 		{
 			APIGroups: []string{""},
 			Resources: []string{"pods", "services", "endpoints"},
-			Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			Verbs:     []string{"get", "list", "watch"},
 		},
 	}
 
 	// Create Role
-	if err := util.CreateRole(ctx, r.Client, documentdb.Name, namespace, rules); err != nil {
+	if err := util.CreateRole(ctx, r.Client, documentdb, namespace, rules); err != nil {
 		log.Error(err, "Failed to create Role for DocumentDB", "DocumentDB.Name", documentdb.Name, "Namespace", namespace)
 		return err
 	}
 
 	// Create ServiceAccount
-	if err := util.CreateServiceAccount(ctx, r.Client, documentdb.Name, namespace); err != nil {
+	if err := util.CreateServiceAccount(ctx, r.Client, documentdb, namespace); err != nil {
 		log.Error(err, "Failed to create ServiceAccount for DocumentDB", "DocumentDB.Name", documentdb.Name, "Namespace", namespace)
 		return err
 	}
 
 	// Create RoleBinding
-	if err := util.CreateRoleBinding(ctx, r.Client, documentdb.Name, namespace); err != nil {
+	if err := util.CreateRoleBinding(ctx, r.Client, documentdb, namespace); err != nil {
 		log.Error(err, "Failed to create RoleBinding for DocumentDB", "DocumentDB.Name", documentdb.Name, "Namespace", namespace)
 		return err
 	}
@@ -357,16 +1558,49 @@ func documentDBServicePredicate() predicate.Predicate {
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *DocumentDBReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.backoff = newRequeueBackoff()
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&dbpreview.DocumentDB{}).
 		Owns(&corev1.Service{}, builder.WithPredicates(documentDBServicePredicate())).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&networkingv1.NetworkPolicy{}).
 		Owns(&cnpgv1.Cluster{}, builder.WithPredicates(clusterInstanceStatusChangedPredicate())).
+		Owns(&cnpgv1.Pooler{}).
+		Owns(&cnpgv1.Database{}).
 		Owns(&cnpgv1.Publication{}).
 		Owns(&cnpgv1.Subscription{}).
+		Owns(&corev1.Secret{}).
+		// A clone waits for the Backup of its source
+		Owns(&dbpreview.Backup{}).
+		// Credential rotations are tracked in status wherever the secret lives
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(documentDBsReferencingSecret(r.Client, func(documentdb *dbpreview.DocumentDB) (types.NamespacedName, bool) {
+			return util.CredentialSecretSource(documentdb), true
+		}))).
 		Named("documentdb-controller").
 		Complete(r)
 }
 
+// documentDBsReferencingSecret maps a Secret to the DocumentDB instances whose secret reference, as returned by ref,
+// points at it, so their copies and status are kept in sync with the source.
+func documentDBsReferencingSecret(c client.Client, ref func(*dbpreview.DocumentDB) (types.NamespacedName, bool)) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		documentdbs := &dbpreview.DocumentDBList{}
+		if err := c.List(ctx, documentdbs); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to list DocumentDB instances for secret", "secret", client.ObjectKeyFromObject(obj))
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for i := range documentdbs.Items {
+			source, ok := ref(&documentdbs.Items[i])
+			if ok && source == client.ObjectKeyFromObject(obj) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&documentdbs.Items[i])})
+			}
+		}
+		return requests
+	}
+}
+
 // COPIED FROM https://github.com/cloudnative-pg/cloudnative-pg/blob/release-1.25/internal/cmd/plugin/promote/promote.go
 func Promote(ctx context.Context, cli client.Client,
 	namespace, clusterName, serverName string,
@@ -415,7 +1649,7 @@ func Promote(ctx context.Context, cli client.Client,
 }
 
 // executeSQLCommand executes SQL commands directly in the postgres container of a running pod
-func (r *DocumentDBReconciler) executeSQLCommand(ctx context.Context, cluster *cnpgv1.Cluster, replicationContext *util.ReplicationContext, sqlCommand, uniqueName string) (string, error) {
+func (r *DocumentDBReconciler) executeSQLCommand(ctx context.Context, cluster *cnpgv1.Cluster, containerName string, replicationContext *util.ReplicationContext, sqlCommand, uniqueName string) (string, error) {
 	logger := log.FromContext(ctx)
 
 	var targetPod corev1.Pod
@@ -423,23 +1657,23 @@ func (r *DocumentDBReconciler) executeSQLCommand(ctx context.Context, cluster *c
 		return "", fmt.Errorf("failed to get primary pod: %w", err)
 	}
 
-	// Execute psql command in the postgres container
-	cmd := []string{
-		"psql",
-		"-U", "postgres",
-		"-d", "postgres",
-		"-c", sqlCommand,
+	credentials, err := r.superuserCredentials(ctx, cluster)
+	if err != nil {
+		return "", err
 	}
 
+	// Execute psql command in the given container of the primary pod
+	cmd, stdin := psqlCommand(sqlCommand, credentials)
+
 	req := r.Clientset.CoreV1().RESTClient().Post().
 		Resource("pods").
 		Name(targetPod.Name).
 		Namespace(cluster.Namespace).
 		SubResource("exec").
 		VersionedParams(&corev1.PodExecOptions{
-			Container: "postgres",
+			Container: containerName,
 			Command:   cmd,
-			Stdin:     false,
+			Stdin:     stdin != "",
 			Stdout:    true,
 			Stderr:    true,
 			TTY:       false,
@@ -451,10 +1685,14 @@ func (r *DocumentDBReconciler) executeSQLCommand(ctx context.Context, cluster *c
 	}
 
 	var stdout, stderr bytes.Buffer
-	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+	streamOptions := remotecommand.StreamOptions{
 		Stdout: &stdout,
 		Stderr: &stderr,
-	})
+	}
+	if stdin != "" {
+		streamOptions.Stdin = strings.NewReader(stdin)
+	}
+	err = exec.StreamWithContext(ctx, streamOptions)
 
 	if err != nil {
 		logger.Error(err, "Failed to execute SQL command",
@@ -469,3 +1707,134 @@ func (r *DocumentDBReconciler) executeSQLCommand(ctx context.Context, cluster *c
 
 	return stdout.String(), nil
 }
+
+// grantReplicationRole grants documentdb_admin_role to streaming_replica on the primary and records it in the
+// ReplicationRoleGranted condition, so later reconciles skip exec-ing into the primary until the condition is lost
+func (r *DocumentDBReconciler) grantReplicationRole(ctx context.Context, documentdb *dbpreview.DocumentDB, cluster *cnpgv1.Cluster, replicationContext *util.ReplicationContext) error {
+	if meta.IsStatusConditionTrue(documentdb.Status.Conditions, dbpreview.ConditionReplicationRoleGranted) {
+		return nil
+	}
+
+	// Check if permissions have already been granted
+	checkCommand := "SELECT 1 FROM pg_roles WHERE rolname = 'streaming_replica' AND pg_has_role('streaming_replica', 'documentdb_admin_role', 'USAGE');"
+	output, err := r.executeSQLCommand(ctx, cluster, util.POSTGRES_CONTAINER_NAME, replicationContext, checkCommand, "check-permissions")
+	if err != nil {
+		return fmt.Errorf("failed to check if permissions already granted: %w", err)
+	}
+
+	if !slices.Equal(parseSQLRows(output), []string{"1"}) {
+		grantCommand := "GRANT documentdb_admin_role TO streaming_replica;"
+		if _, err := r.executeSQLCommand(ctx, cluster, util.POSTGRES_CONTAINER_NAME, replicationContext, grantCommand, "grant-permissions"); err != nil {
+			return err
+		}
+	}
+
+	meta.SetStatusCondition(&documentdb.Status.Conditions, metav1.Condition{
+		Type:    dbpreview.ConditionReplicationRoleGranted,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Granted",
+		Message: "documentdb_admin_role is granted to streaming_replica",
+	})
+	return r.Status().Update(ctx, documentdb)
+}
+
+// replicaCaughtUp reports whether the standby streaming from the cluster's primary as applicationName has replayed
+// the primary's WAL to within switchoverMaxLagBytes. Local standbys stream as their pod name and remote clusters as
+// their cluster name. A standby that is not streaming is not caught up.
+func (r *DocumentDBReconciler) replicaCaughtUp(ctx context.Context, cluster *cnpgv1.Cluster, applicationName string, replicationContext *util.ReplicationContext) (bool, error) {
+	output, err := r.executeSQLCommand(ctx, cluster, util.POSTGRES_CONTAINER_NAME, replicationContext, replicationLagQuery(applicationName), "replication-lag")
+	if err != nil {
+		return false, fmt.Errorf("failed to read replication lag of %s: %w", applicationName, err)
+	}
+	return replicationCaughtUp(output, switchoverMaxLagBytes)
+}
+
+// replicationLagQuery returns the replay lag in bytes of each pg_stat_replication entry for applicationName,
+// or -1 for an entry that has not replayed anything yet.
+func replicationLagQuery(applicationName string) string {
+	return fmt.Sprintf("SELECT COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), replay_lsn), -1)::bigint FROM pg_stat_replication WHERE application_name = '%s';",
+		strings.ReplaceAll(applicationName, "'", "''"))
+}
+
+// replicationCaughtUp parses the output of replicationLagQuery and reports whether there is a standby and every
+// entry lags by at most maxLagBytes.
+func replicationCaughtUp(output string, maxLagBytes int64) (bool, error) {
+	rows := parseSQLRows(output)
+	if len(rows) == 0 {
+		return false, nil
+	}
+	for _, row := range rows {
+		lag, err := strconv.ParseInt(strings.TrimSpace(row), 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("unexpected replication lag %q: %w", row, err)
+		}
+		if lag < 0 || lag > maxLagBytes {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// superuserCredentials holds the Postgres superuser login read from the CNPG superuser secret
+type superuserCredentials struct {
+	username string
+	password string
+}
+
+// superuserCredentials returns the superuser login when superuser access is enabled on the cluster, or nil to
+// connect as postgres over the local socket
+func (r *DocumentDBReconciler) superuserCredentials(ctx context.Context, cluster *cnpgv1.Cluster) (*superuserCredentials, error) {
+	if !cluster.GetEnableSuperuserAccess() {
+		return nil, nil
+	}
+
+	secretName := cluster.GetSuperuserSecretName()
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: cluster.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get superuser secret %s: %w", secretName, err)
+	}
+	username := string(secret.Data[corev1.BasicAuthUsernameKey])
+	password := string(secret.Data[corev1.BasicAuthPasswordKey])
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("superuser secret %s must contain %q and %q", secretName, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+	return &superuserCredentials{username: username, password: password}, nil
+}
+
+// psqlCommand returns the command running sqlCommand with psql and the data to send on its stdin.
+// Output is tuples only and unaligned (-tA) without reading psqlrc (-X), to be parsed with parseSQLRows.
+// With credentials psql authenticates over TCP and reads the password from stdin so it never appears in the command line.
+func psqlCommand(sqlCommand string, credentials *superuserCredentials) ([]string, string) {
+	if credentials == nil {
+		return []string{
+			"psql",
+			"-U", "postgres",
+			"-d", "postgres",
+			"-X", "-tA",
+			"-c", sqlCommand,
+		}, ""
+	}
+
+	return []string{
+		"sh", "-c", `PGPASSWORD="$(cat)" exec psql "$@"`, "psql",
+		"-h", "localhost",
+		"-p", strconv.Itoa(int(util.GetPortFor(util.POSTGRES_PORT))),
+		"-U", credentials.username,
+		"-d", "postgres",
+		"-X", "-tA",
+		"-c", sqlCommand,
+	}, credentials.password
+}
+
+// parseSQLRows splits the tuples-only, unaligned output of psql into its rows, dropping empty lines.
+// Columns within a row are separated by "|".
+func parseSQLRows(output string) []string {
+	var rows []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line != "" {
+			rows = append(rows, line)
+		}
+	}
+	return rows
+}
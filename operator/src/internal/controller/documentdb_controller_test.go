@@ -2,20 +2,37 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
 	cmapi "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
 	cmmeta "github.com/cert-manager/cert-manager/pkg/apis/meta/v1"
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/require"
+	fleetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	cnpg "github.com/documentdb/documentdb-operator/internal/cnpg"
 	util "github.com/documentdb/documentdb-operator/internal/utils"
 )
 
@@ -42,6 +59,27 @@ func buildCertificateReconciler(t *testing.T, objs ...runtime.Object) *Certifica
 	return &CertificateReconciler{Client: c, Scheme: scheme}
 }
 
+// helper to build DocumentDB reconciler with CNPG and DocumentDB objects
+func buildDocumentDBReconciler(t *testing.T, funcs interceptor.Funcs, objs ...client.Object) *DocumentDBReconciler {
+	scheme := runtime.NewScheme()
+	require.NoError(t, dbpreview.AddToScheme(scheme))
+	require.NoError(t, cnpgv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, storagev1.AddToScheme(scheme))
+	require.NoError(t, rbacv1.AddToScheme(scheme))
+	require.NoError(t, networkingv1.AddToScheme(scheme))
+	require.NoError(t, discoveryv1.AddToScheme(scheme))
+	require.NoError(t, fleetv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).
+		WithObjects(objs...).
+		WithStatusSubresource(&dbpreview.DocumentDB{}).
+		WithInterceptorFuncs(funcs).
+		Build()
+	return &DocumentDBReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+}
+
 func baseDocumentDB(name, ns string) *dbpreview.DocumentDB {
 	return &dbpreview.DocumentDB{
 		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
@@ -63,7 +101,7 @@ func TestEnsureProvidedSecret(t *testing.T) {
 	r := buildCertificateReconciler(t, ddb)
 	res, err := r.reconcileCertificates(ctx, ddb)
 	require.NoError(t, err)
-	require.Equal(t, RequeueAfterShort, res.RequeueAfter)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
 	require.False(t, ddb.Status.TLS.Ready, "Should not be ready until secret exists")
 
 	// Create secret with required keys then reconcile again
@@ -74,6 +112,248 @@ func TestEnsureProvidedSecret(t *testing.T) {
 	require.Zero(t, res.RequeueAfter)
 	require.True(t, ddb.Status.TLS.Ready, "Provided secret should mark TLS ready")
 	require.Equal(t, "mycert", ddb.Status.TLS.SecretName)
+	require.Empty(t, ddb.Status.TLS.CABundle, "Secret without ca.crt should not report a CA bundle")
+}
+
+func TestReconcilersUseConfiguredRequeueIntervals(t *testing.T) {
+	ctx := context.Background()
+	requeue := RequeueConfig{Short: 2 * time.Second, Long: 2 * time.Minute}
+
+	// The certificate reconciler waits for a missing provided secret
+	ddb := baseDocumentDB("ddb-requeue", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "Provided", Provided: &dbpreview.ProvidedTLS{SecretName: "missing"}}}
+	certificateReconciler := buildCertificateReconciler(t, ddb)
+	certificateReconciler.Requeue = requeue
+	res, err := certificateReconciler.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, res.RequeueAfter)
+
+	// The DocumentDB reconciler waits for CNPG to pick up a cluster update
+	ddb = baseDocumentDB("ddb-requeue", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	r.Requeue = requeue
+	ddb.Spec.LogLevel = "debug"
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, existing))
+	err, requeueAfter := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, requeueAfter)
+
+	// Unset intervals fall back to the defaults
+	require.Equal(t, 2*time.Minute, requeue.long())
+	require.Equal(t, DefaultRequeueAfterShort, RequeueConfig{Long: time.Minute}.short())
+	require.Equal(t, DefaultRequeueAfterLong, RequeueConfig{}.long())
+}
+
+func TestReconcileRequeuesWhenDeadlineExceeded(t *testing.T) {
+	ddb := baseDocumentDB("ddb-slow", "default")
+	// A hung API call only returns once the reconcile deadline cancels it
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}, ddb)
+	r.ReconcileTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}})
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, result.RequeueAfter)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestDetectEnvironmentListsNodesOnce(t *testing.T) {
+	ctx := context.Background()
+	// The node has just joined, so the cloud controller hasn't set its provider ID yet
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+	var nodeLists int
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if _, ok := list.(*corev1.NodeList); ok {
+				nodeLists++
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}, node)
+
+	environment, err := r.detectEnvironment(ctx)
+	require.NoError(t, err)
+	require.Empty(t, environment)
+
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(node), node))
+	node.Spec.ProviderID = "azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/node-1"
+	require.NoError(t, r.Client.Update(ctx, node))
+
+	for range 3 {
+		environment, err := r.detectEnvironment(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "aks", environment)
+	}
+	// Once after the empty detection, then the detected environment is reused
+	require.Equal(t, 2, nodeLists)
+}
+
+func TestReconcileSkipsClusterUpdateForObservedGeneration(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-observed", "default")
+	ddb.Generation = 1
+	ddb.Spec.Environment = "kind"
+	ddb.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, util.GetDocumentDBImageForInstance(ddb), ddb.Name, "", true, logr.Discard())
+	cluster.Status.Phase = cnpgv1.PhaseHealthy
+
+	var clusterWrites, statusWrites, poolerReads int
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*cnpgv1.Pooler); ok {
+				poolerReads++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*cnpgv1.Cluster); ok {
+				clusterWrites++
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*cnpgv1.Cluster); ok {
+				clusterWrites++
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			statusWrites++
+			return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+		},
+	}, ddb, cluster)
+
+	// The first pass applies the spec and records its generation
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, int64(1), updated.Status.ObservedGeneration)
+	require.Equal(t, string(cnpgv1.PhaseHealthy), updated.Status.Status)
+
+	require.Equal(t, 1, poolerReads)
+
+	// Further events for the same generation skip the cluster update and leave the status alone, while the
+	// dependent resources are still checked
+	clusterWrites, statusWrites, poolerReads = 0, 0, 0
+	for range 3 {
+		_, err = r.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 3, poolerReads)
+	require.Zero(t, clusterWrites)
+	require.Zero(t, statusWrites)
+
+	// A spec change bumps the generation and is applied to the cluster
+	updated.Spec.LogLevel = "debug"
+	updated.Generation = 2
+	require.NoError(t, r.Client.Update(ctx, updated))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, clusterWrites)
+
+	patched := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, patched))
+	require.Equal(t, "debug", patched.Spec.LogLevel)
+}
+
+func TestReconcileRecreatesPoolerOfSettledCluster(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-settled-pooler", "default")
+	ddb.Generation = 1
+	ddb.Spec.Environment = "kind"
+	ddb.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	ddb.Spec.Pooler = &dbpreview.PoolerConfiguration{Enabled: true, Instances: 1}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, util.GetDocumentDBImageForInstance(ddb), ddb.Name, "", true, logr.Discard())
+	cluster.Status.Phase = cnpgv1.PhaseHealthy
+
+	var clusterWrites int
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if _, ok := obj.(*cnpgv1.Cluster); ok {
+				clusterWrites++
+			}
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*cnpgv1.Cluster); ok {
+				clusterWrites++
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, ddb, cluster)
+
+	_, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, int64(1), updated.Status.ObservedGeneration)
+
+	poolerKey := types.NamespacedName{Name: util.GetDocumentDBPoolerName(ddb.Name), Namespace: ddb.Namespace}
+	pooler := &cnpgv1.Pooler{}
+	require.NoError(t, r.Client.Get(ctx, poolerKey, pooler))
+
+	// A Pooler deleted by hand is recreated although the spec, and so the generation, is unchanged
+	require.NoError(t, r.Client.Delete(ctx, pooler))
+	clusterWrites = 0
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, r.Client.Get(ctx, poolerKey, &cnpgv1.Pooler{}))
+	require.Zero(t, clusterWrites)
+}
+
+func TestClusterSettledComparesImages(t *testing.T) {
+	ddb := baseDocumentDB("ddb-images", "default")
+	ddb.Generation = 1
+	ddb.Status.ObservedGeneration = 1
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "documentdb:2", ddb.Name, "", true, logr.Discard())
+	current := desired.DeepCopy()
+	current.Status.Phase = cnpgv1.PhaseHealthy
+	require.True(t, clusterSettled(ddb, current, desired))
+
+	// A new default image shipped with the operator changes the desired cluster without a new generation
+	current.Spec.ImageName = "documentdb:1"
+	require.False(t, clusterSettled(ddb, current, desired))
+}
+
+func TestEnsureProvidedSecretFromOtherNamespace(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-prov-xns", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "Provided", Provided: &dbpreview.ProvidedTLS{SecretName: "shared-cert", Namespace: "certs"}}}
+	r := buildCertificateReconciler(t, ddb)
+
+	// Source secret missing first
+	res, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
+	require.False(t, ddb.Status.TLS.Ready)
+	require.Equal(t, "Waiting for provided TLS secret", ddb.Status.TLS.Message)
+
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "shared-cert", Namespace: "certs"}, Data: map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key")}}
+	require.NoError(t, r.Client.Create(ctx, source))
+	res, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Zero(t, res.RequeueAfter)
+	require.True(t, ddb.Status.TLS.Ready)
+	require.Equal(t, "shared-cert", ddb.Status.TLS.SecretName)
+
+	// The gateway mounts the copy in the DocumentDB's namespace
+	copied := &corev1.Secret{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "shared-cert", Namespace: "default"}, copied))
+	require.Equal(t, source.Data, copied.Data)
+	require.Equal(t, "certs/shared-cert", copied.Annotations[util.SOURCE_SECRET_ANNOTATION])
 }
 
 func TestEnsureCertManagerManagedCert(t *testing.T) {
@@ -87,10 +367,10 @@ func TestEnsureCertManagerManagedCert(t *testing.T) {
 	// Call certificate ensure twice to mimic reconcile loops
 	res, err := r.reconcileCertificates(ctx, ddb)
 	require.NoError(t, err)
-	require.Equal(t, RequeueAfterShort, res.RequeueAfter)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
 	res, err = r.reconcileCertificates(ctx, ddb)
 	require.NoError(t, err)
-	require.Equal(t, RequeueAfterShort, res.RequeueAfter)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
 
 	cert := &cmapi.Certificate{}
 	// fetch certificate (self-created by reconcile). If not found, run reconcile again once.
@@ -116,6 +396,91 @@ func TestEnsureCertManagerManagedCert(t *testing.T) {
 	require.NotEmpty(t, ddb.Status.TLS.SecretName)
 }
 
+func TestEnsureCertManagerManagedCertReportsFailure(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-cm-fail", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "CertManager", CertManager: &dbpreview.CertManagerTLS{IssuerRef: dbpreview.IssuerRef{Name: "missing-issuer", Kind: "Issuer"}}}}
+	ddb.Status.TLS = &dbpreview.TLSStatus{}
+	r := buildCertificateReconciler(t, ddb)
+
+	_, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	cert := &cmapi.Certificate{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "ddb-cm-fail-gateway-cert", Namespace: "default"}, cert))
+
+	// cert-manager is still issuing, so the Ready condition explains why
+	cert.Status.Conditions = []cmapi.CertificateCondition{
+		{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionFalse, Reason: "DoesNotExist", Message: "Issuing certificate as Secret does not exist"},
+		{Type: cmapi.CertificateConditionIssuing, Status: cmmeta.ConditionTrue, Reason: "DoesNotExist", Message: "Issuing certificate as Secret does not exist"},
+	}
+	require.NoError(t, r.Client.Update(ctx, cert))
+	res, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
+	require.False(t, ddb.Status.TLS.Ready)
+	require.Equal(t, "Waiting for cert-manager certificate to become ready (DoesNotExist): Issuing certificate as Secret does not exist", ddb.Status.TLS.Message)
+
+	// The issuance failed, which takes precedence over the Ready condition
+	cert.Status.Conditions[1] = cmapi.CertificateCondition{Type: cmapi.CertificateConditionIssuing, Status: cmmeta.ConditionFalse, Reason: "Failed", Message: `The certificate request has failed to complete and will be retried: issuer "missing-issuer" not found`}
+	cert.Status.LastFailureTime = &metav1.Time{Time: time.Now()}
+	require.NoError(t, r.Client.Update(ctx, cert))
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.False(t, ddb.Status.TLS.Ready)
+	require.Contains(t, ddb.Status.TLS.Message, "cert-manager failed to issue the certificate (Failed)")
+	require.Contains(t, ddb.Status.TLS.Message, `issuer "missing-issuer" not found`)
+}
+
+func TestReconcileGlobalEndpointsCertificate(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-global", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{GlobalEndpoints: &dbpreview.GlobalEndpointsTLS{
+		DNSNames:  []string{"documentdb.trafficmanager.net"},
+		IssuerRef: dbpreview.IssuerRef{Name: "global-issuer", Kind: "ClusterIssuer"},
+	}}
+	r := buildCertificateReconciler(t, ddb)
+	key := types.NamespacedName{Name: "ddb-global-global-cert", Namespace: "default"}
+
+	res, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
+
+	cert := &cmapi.Certificate{}
+	require.NoError(t, r.Client.Get(ctx, key, cert))
+	require.Equal(t, "documentdb.trafficmanager.net", cert.Spec.DNSNames[0])
+	require.Contains(t, cert.Spec.DNSNames, util.DOCUMENTDB_SERVICE_PREFIX+ddb.Name+".default.svc")
+	require.Equal(t, cmmeta.ObjectReference{Name: "global-issuer", Kind: "ClusterIssuer", Group: "cert-manager.io"}, cert.Spec.IssuerRef)
+	require.Equal(t, "ddb-global-global-cert-tls", cert.Spec.SecretName)
+	require.False(t, ddb.Status.GlobalEndpointsTLS.Ready)
+	// The gateway TLS status is left alone
+	require.Nil(t, ddb.Status.TLS)
+
+	// cert-manager issues the certificate
+	cert.Status.Conditions = []cmapi.CertificateCondition{{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue}}
+	require.NoError(t, r.Client.Update(ctx, cert))
+	res, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Zero(t, res.RequeueAfter)
+	require.True(t, ddb.Status.GlobalEndpointsTLS.Ready)
+	require.Equal(t, "ddb-global-global-cert-tls", ddb.Status.GlobalEndpointsTLS.SecretName)
+
+	// A new global DNS name is added to the SANs
+	ddb.Spec.TLS.GlobalEndpoints.DNSNames = append(ddb.Spec.TLS.GlobalEndpoints.DNSNames, "documentdb.example.com")
+	res, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
+	require.NoError(t, r.Client.Get(ctx, key, cert))
+	require.Contains(t, cert.Spec.DNSNames, "documentdb.example.com")
+	require.False(t, ddb.Status.GlobalEndpointsTLS.Ready)
+
+	// Removing the global endpoints deletes the certificate and clears the status
+	ddb.Spec.TLS = nil
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.True(t, errors.IsNotFound(r.Client.Get(ctx, key, cert)))
+	require.Nil(t, ddb.Status.GlobalEndpointsTLS)
+}
+
 func TestEnsureSelfSignedCert(t *testing.T) {
 	ctx := context.Background()
 	ddb := baseDocumentDB("ddb-ss", "default")
@@ -126,7 +491,7 @@ func TestEnsureSelfSignedCert(t *testing.T) {
 	// First call should create issuer and certificate
 	res, err := r.reconcileCertificates(ctx, ddb)
 	require.NoError(t, err)
-	require.Equal(t, RequeueAfterShort, res.RequeueAfter)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
 
 	// Certificate should exist
 	cert := &cmapi.Certificate{}
@@ -141,3 +506,1470 @@ func TestEnsureSelfSignedCert(t *testing.T) {
 	require.True(t, ddb.Status.TLS.Ready)
 	require.NotEmpty(t, ddb.Status.TLS.SecretName)
 }
+
+func TestSwitchingGatewayTLSModeCleansUpPreviousMode(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-switch", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "SelfSigned"}}
+	ddb.Status.TLS = &dbpreview.TLSStatus{}
+	r := buildCertificateReconciler(t, ddb)
+	certKey := types.NamespacedName{Name: "ddb-switch-gateway-cert", Namespace: "default"}
+	issuerKey := types.NamespacedName{Name: "ddb-switch-gateway-selfsigned", Namespace: "default"}
+
+	// Bring the self-signed certificate to ready
+	_, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	cert := &cmapi.Certificate{}
+	require.NoError(t, r.Client.Get(ctx, certKey, cert))
+	cert.Status.Conditions = []cmapi.CertificateCondition{{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue}}
+	require.NoError(t, r.Client.Update(ctx, cert))
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.True(t, ddb.Status.TLS.Ready)
+
+	// Switch to cert-manager with its own secret
+	ddb.Spec.TLS.Gateway = &dbpreview.GatewayTLS{Mode: "CertManager", CertManager: &dbpreview.CertManagerTLS{IssuerRef: dbpreview.IssuerRef{Name: "ca-issuer", Kind: "ClusterIssuer"}, SecretName: "gateway-ca-tls"}}
+	res, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
+	require.False(t, ddb.Status.TLS.Ready, "the self-signed certificate must not be reported for the new mode")
+	require.True(t, errors.IsNotFound(r.Client.Get(ctx, issuerKey, &cmapi.Issuer{})))
+	require.True(t, errors.IsNotFound(r.Client.Get(ctx, certKey, &cmapi.Certificate{})))
+
+	// The next reconcile creates the cert-manager certificate for the new secret
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	cert = &cmapi.Certificate{}
+	require.NoError(t, r.Client.Get(ctx, certKey, cert))
+	require.Equal(t, "ca-issuer", cert.Spec.IssuerRef.Name)
+	require.Equal(t, "ClusterIssuer", cert.Spec.IssuerRef.Kind)
+	require.Equal(t, "gateway-ca-tls", cert.Spec.SecretName)
+	require.Equal(t, "gateway-ca-tls", ddb.Status.TLS.SecretName)
+	require.False(t, ddb.Status.TLS.Ready)
+
+	// Reconciling the same mode again leaves the certificate in place
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.NoError(t, r.Client.Get(ctx, certKey, &cmapi.Certificate{}))
+
+	// Switching to a provided secret removes the cert-manager certificate
+	ddb.Spec.TLS.Gateway = &dbpreview.GatewayTLS{Mode: "Provided", Provided: &dbpreview.ProvidedTLS{SecretName: "my-tls"}}
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.True(t, errors.IsNotFound(r.Client.Get(ctx, certKey, &cmapi.Certificate{})))
+}
+
+func TestEnsureSelfSignedCertExtraSANs(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-san", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{
+		Mode:       "SelfSigned",
+		SelfSigned: &dbpreview.SelfSignedTLS{DNSNames: []string{"db.example.com", "documentdb-service-ddb-san"}, IPAddresses: []string{"10.20.30.40"}},
+	}}
+	ddb.Status.TLS = &dbpreview.TLSStatus{}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: util.GetDocumentDBServiceName(ddb.Name), Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+	}
+	r := buildCertificateReconciler(t, ddb, service)
+
+	// The LoadBalancer has no address yet, so only the Service names and the extra SANs are included
+	_, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	cert := &cmapi.Certificate{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "ddb-san-gateway-cert", Namespace: "default"}, cert))
+	serviceBase := util.DOCUMENTDB_SERVICE_PREFIX + ddb.Name
+	require.Equal(t, []string{serviceBase, serviceBase + ".default", serviceBase + ".default.svc", "db.example.com"}, cert.Spec.DNSNames)
+	require.Equal(t, []string{"10.20.30.40"}, cert.Spec.IPAddresses)
+
+	// Once the LoadBalancer is assigned an address the certificate is updated with it
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "20.1.2.3"}, {Hostname: "gateway.example.com"}}
+	require.NoError(t, r.Client.Status().Update(ctx, service))
+	res, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, res.RequeueAfter)
+	require.False(t, ddb.Status.TLS.Ready)
+	require.Equal(t, "Updating self-signed certificate SANs", ddb.Status.TLS.Message)
+
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "ddb-san-gateway-cert", Namespace: "default"}, cert))
+	require.Contains(t, cert.Spec.DNSNames, "gateway.example.com")
+	require.Equal(t, []string{"10.20.30.40", "20.1.2.3"}, cert.Spec.IPAddresses)
+}
+
+func TestEnsureSelfSignedCertReportsCABundle(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-ca", "default")
+	ddb.Spec.TLS = &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "SelfSigned"}}
+	ddb.Status.TLS = &dbpreview.TLSStatus{}
+	r := buildCertificateReconciler(t, ddb)
+
+	_, err := r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	cert := &cmapi.Certificate{}
+	require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "ddb-ca-gateway-cert", Namespace: "default"}, cert))
+	cert.Status.Conditions = append(cert.Status.Conditions, cmapi.CertificateCondition{Type: cmapi.CertificateConditionReady, Status: cmmeta.ConditionTrue, LastTransitionTime: &metav1.Time{Time: time.Now()}})
+	require.NoError(t, r.Client.Update(ctx, cert))
+
+	// Secret written by cert-manager with the issuing CA
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: cert.Spec.SecretName, Namespace: "default"},
+		Data:       map[string][]byte{"tls.crt": []byte("crt"), "tls.key": []byte("key"), "ca.crt": []byte("ca-pem")},
+	}
+	require.NoError(t, r.Client.Create(ctx, secret))
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.True(t, ddb.Status.TLS.Ready)
+	require.Equal(t, "ca-pem", ddb.Status.TLS.CABundle)
+
+	// A rotated CA is picked up on the next reconcile
+	secret.Data["ca.crt"] = []byte("rotated-ca-pem")
+	require.NoError(t, r.Client.Update(ctx, secret))
+	_, err = r.reconcileCertificates(ctx, ddb)
+	require.NoError(t, err)
+	require.Equal(t, "rotated-ca-pem", ddb.Status.TLS.CABundle)
+}
+
+func TestTryUpdateClusterStorageSize(t *testing.T) {
+	tests := []struct {
+		name          string
+		currentSize   string
+		requestedSize string
+		storageClass  string
+		expectedSize  string
+		expectedEvent string
+	}{
+		{name: "grows cluster storage", currentSize: "1Gi", requestedSize: "5Gi", expectedSize: "5Gi", expectedEvent: "StorageExpansion"},
+		{name: "rejects shrinking", currentSize: "5Gi", requestedSize: "2Gi", expectedSize: "5Gi", expectedEvent: "StorageResizeRejected"},
+		{name: "ignores equivalent quantity", currentSize: "1Gi", requestedSize: "1024Mi", expectedSize: "1Gi"},
+		{name: "grows storage of an expandable class", currentSize: "1Gi", requestedSize: "5Gi", storageClass: "expandable", expectedSize: "5Gi", expectedEvent: "StorageExpansion"},
+		{name: "rejects growth without volume expansion", currentSize: "1Gi", requestedSize: "5Gi", storageClass: "fixed", expectedSize: "1Gi", expectedEvent: "does not allow volume expansion"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("ddb-storage", "default")
+			ddb.Spec.Resource.Storage.PvcSize = tt.currentSize
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+			current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, tt.storageClass, true, logr.Discard())
+			ddb.Spec.Resource.Storage.PvcSize = tt.requestedSize
+
+			r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current.DeepCopy(),
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, AllowVolumeExpansion: ptr.To(true)},
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}})
+			c := r.Client
+			recorder := r.Recorder.(*record.FakeRecorder)
+
+			desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, tt.storageClass, true, logr.Discard())
+
+			existing := &cnpgv1.Cluster{}
+			require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+			err, requeue := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+			require.NoError(t, err)
+			require.LessOrEqual(t, requeue, time.Duration(0))
+
+			updated := &cnpgv1.Cluster{}
+			require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+			require.Equal(t, tt.expectedSize, updated.Spec.StorageConfiguration.Size)
+
+			if tt.expectedEvent == "" {
+				require.Empty(t, recorder.Events)
+			} else {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, tt.expectedEvent)
+			}
+		})
+	}
+}
+
+func TestCheckStorageClass(t *testing.T) {
+	tests := []struct {
+		name            string
+		storageClass    string
+		expectedFound   bool
+		expectedReason  string
+		expectedWarning bool
+	}{
+		{name: "default storage class is not checked", expectedFound: true},
+		{name: "existing storage class", storageClass: "expandable", expectedFound: true, expectedReason: "StorageClassFound"},
+		{name: "storage class without volume expansion", storageClass: "fixed", expectedFound: true, expectedReason: "VolumeExpansionNotAllowed"},
+		{name: "missing storage class", storageClass: "missing", expectedReason: "StorageClassNotFound", expectedWarning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("ddb-storage-class", "default")
+			ddb.Spec.Resource.Storage.StorageClass = tt.storageClass
+			r := buildDocumentDBReconciler(t, interceptor.Funcs{}, ddb,
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, AllowVolumeExpansion: ptr.To(true)},
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}})
+			recorder := r.Recorder.(*record.FakeRecorder)
+
+			found, err := r.checkStorageClass(ctx, ddb, tt.storageClass)
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedFound, found)
+
+			updated := &dbpreview.DocumentDB{}
+			require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(ddb), updated))
+			condition := meta.FindStatusCondition(updated.Status.Conditions, dbpreview.ConditionStorageClassReady)
+			if tt.expectedReason == "" {
+				require.Nil(t, condition)
+			} else {
+				require.NotNil(t, condition)
+				require.Equal(t, tt.expectedReason, condition.Reason)
+				require.Equal(t, tt.expectedFound, condition.Status == metav1.ConditionTrue)
+			}
+
+			if tt.expectedWarning {
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, "StorageClassNotFound")
+			} else {
+				require.Empty(t, recorder.Events)
+			}
+		})
+	}
+}
+
+func TestTryUpdateClusterAppliesSpecChanges(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-update", "default")
+	ddb.Spec.LogLevel = "info"
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "documentdb:0.106.0", ddb.Name, "", true, logr.Discard())
+	// Simulate a parameter defaulted by CNPG, which must be left untouched
+	current.Spec.PostgresConfiguration.Parameters["log_destination"] = "csvlog"
+
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current, ddb.DeepCopy())
+	c := r.Client
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(ddb), ddb))
+
+	ddb.Spec.LogLevel = "debug"
+	ddb.Spec.InstancesPerNode = 3
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "documentdb:0.107.0", ddb.Name, "", true, logr.Discard())
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, requeue := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, requeue)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "documentdb:0.107.0", updated.Spec.ImageName)
+	require.Equal(t, "debug", updated.Spec.LogLevel)
+	require.Equal(t, 3, updated.Spec.Instances)
+	require.Equal(t, "csvlog", updated.Spec.PostgresConfiguration.Parameters["log_destination"])
+
+	// A second pass with no further changes should not requeue
+	err, requeue = r.TryUpdateCluster(ctx, updated, desired, ddb, nil)
+	require.NoError(t, err)
+	require.LessOrEqual(t, requeue, time.Duration(0))
+}
+
+func TestTryUpdateClusterPatchesLogLevel(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-loglevel", "default")
+	ddb.Spec.LogLevel = "info"
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+	var patches []string
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			data, err := patch.Data(obj)
+			require.NoError(t, err)
+			patches = append(patches, string(data))
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, current)
+	c := r.Client
+
+	ddb.Spec.LogLevel = "debug"
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+
+	// Postgres picks up the log level while running, the gateway when the instances restart
+	require.Len(t, patches, 1)
+	var ops []util.JSONPatch
+	require.NoError(t, json.Unmarshal([]byte(patches[0]), &ops))
+	require.Len(t, ops, 3)
+	require.Equal(t, util.JSONPatch{Op: util.JSON_PATCH_OP_ADD, Path: util.JSON_PATCH_PATH_LOG_LEVEL, Value: "debug"}, ops[0])
+	require.Equal(t, util.JSONPatch{Op: util.JSON_PATCH_OP_ADD, Path: "/spec/plugins/0/parameters/" + util.GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER, Value: "debug"}, ops[1])
+	require.Equal(t, util.JSON_PATCH_PATH_ANNOTATIONS, ops[2].Path)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "debug", updated.Spec.LogLevel)
+	require.Equal(t, "debug", updated.Spec.Plugins[0].Parameters[util.GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER])
+	require.Contains(t, updated.Annotations, util.CNPG_RESTART_ANNOTATION)
+}
+
+func TestTryUpdateClusterImageUpgrade(t *testing.T) {
+	const (
+		oldEngineImage  = "ghcr.io/microsoft/documentdb/documentdb-local:0.106.0"
+		newEngineImage  = "ghcr.io/microsoft/documentdb/documentdb-local:0.107.0"
+		oldGatewayImage = "ghcr.io/microsoft/documentdb/gateway:0.106.0"
+		newGatewayImage = "ghcr.io/microsoft/documentdb/gateway:0.107.0"
+	)
+
+	tests := []struct {
+		name            string
+		fromEngine      string
+		toEngine        string
+		fromGateway     string
+		toGateway       string
+		allowDowngrade  bool
+		expectedEngine  string
+		expectedGateway string
+		expectedPhase   string
+		expectedEvent   string
+		expectedMessage string
+		expectedRestart bool
+	}{
+		{
+			name:       "upgrade applies engine and gateway images",
+			fromEngine: oldEngineImage, toEngine: newEngineImage,
+			fromGateway: oldGatewayImage, toGateway: newGatewayImage,
+			expectedEngine: newEngineImage, expectedGateway: newGatewayImage,
+			expectedPhase: dbpreview.UpgradePhaseInProgress, expectedEvent: "Upgrade",
+		},
+		{
+			name:       "gateway only upgrade restarts instances",
+			fromEngine: oldEngineImage, toEngine: oldEngineImage,
+			fromGateway: oldGatewayImage, toGateway: newGatewayImage,
+			expectedEngine: oldEngineImage, expectedGateway: newGatewayImage,
+			expectedPhase: dbpreview.UpgradePhaseInProgress, expectedEvent: "Upgrade",
+			expectedRestart: true,
+		},
+		{
+			name:       "downgrade is blocked",
+			fromEngine: newEngineImage, toEngine: oldEngineImage,
+			fromGateway: newGatewayImage, toGateway: oldGatewayImage,
+			expectedEngine: newEngineImage, expectedGateway: newGatewayImage,
+			expectedPhase: dbpreview.UpgradePhaseBlocked, expectedEvent: "DowngradeBlocked",
+			expectedMessage: "(downgrade)",
+		},
+		{
+			name:       "change between incomparable versions is blocked",
+			fromEngine: "ghcr.io/microsoft/documentdb/documentdb-local:16", toEngine: "ghcr.io/microsoft/documentdb/documentdb-local:0.1.3",
+			fromGateway: oldGatewayImage, toGateway: oldGatewayImage,
+			expectedEngine: "ghcr.io/microsoft/documentdb/documentdb-local:16", expectedGateway: oldGatewayImage,
+			expectedPhase: dbpreview.UpgradePhaseBlocked, expectedEvent: "DowngradeBlocked",
+			expectedMessage: "versions can't be compared",
+		},
+		{
+			name:       "change between incomparable versions is applied when allowed",
+			fromEngine: "ghcr.io/microsoft/documentdb/documentdb-local:16", toEngine: "ghcr.io/microsoft/documentdb/documentdb-local:0.1.3",
+			fromGateway: oldGatewayImage, toGateway: oldGatewayImage,
+			allowDowngrade: true,
+			expectedEngine: "ghcr.io/microsoft/documentdb/documentdb-local:0.1.3", expectedGateway: oldGatewayImage,
+			expectedPhase: dbpreview.UpgradePhaseInProgress, expectedEvent: "Upgrade",
+		},
+		{
+			name:       "downgrade is applied when allowed",
+			fromEngine: newEngineImage, toEngine: oldEngineImage,
+			fromGateway: newGatewayImage, toGateway: oldGatewayImage,
+			allowDowngrade: true,
+			expectedEngine: oldEngineImage, expectedGateway: oldGatewayImage,
+			expectedPhase: dbpreview.UpgradePhaseInProgress, expectedEvent: "Upgrade",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("ddb-upgrade", "default")
+			ddb.Spec.GatewayImage = tt.fromGateway
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+			current := cnpg.GetCnpgClusterSpec(req, ddb, tt.fromEngine, ddb.Name, "", true, logr.Discard())
+
+			r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current, ddb.DeepCopy())
+			require.NoError(t, r.Get(ctx, req.NamespacedName, ddb))
+
+			ddb.Spec.GatewayImage = tt.toGateway
+			ddb.Spec.AllowDowngrade = tt.allowDowngrade
+			desired := cnpg.GetCnpgClusterSpec(req, ddb, tt.toEngine, ddb.Name, "", true, logr.Discard())
+
+			existing := &cnpgv1.Cluster{}
+			require.NoError(t, r.Get(ctx, req.NamespacedName, existing))
+			err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+			require.NoError(t, err)
+
+			updated := &cnpgv1.Cluster{}
+			require.NoError(t, r.Get(ctx, req.NamespacedName, updated))
+			require.Equal(t, tt.expectedEngine, updated.Spec.ImageName)
+			_, gatewayImage := gatewayImageParameter(updated, desired.Spec.Plugins[0].Name)
+			require.Equal(t, tt.expectedGateway, gatewayImage)
+			_, restarted := updated.Annotations[util.CNPG_RESTART_ANNOTATION]
+			require.Equal(t, tt.expectedRestart, restarted)
+
+			stored := &dbpreview.DocumentDB{}
+			require.NoError(t, r.Get(ctx, req.NamespacedName, stored))
+			require.NotNil(t, stored.Status.Upgrade)
+			require.Equal(t, tt.expectedPhase, stored.Status.Upgrade.Phase)
+			require.Contains(t, stored.Status.Upgrade.Message, tt.expectedMessage)
+
+			recorder := r.Recorder.(*record.FakeRecorder)
+			require.Len(t, recorder.Events, 1)
+			require.Contains(t, <-recorder.Events, tt.expectedEvent)
+		})
+	}
+}
+
+func TestTryUpdateClusterDefersDisruptiveChangesOutsideMaintenanceWindow(t *testing.T) {
+	const (
+		oldEngineImage = "ghcr.io/microsoft/documentdb/documentdb-local:0.106.0"
+		newEngineImage = "ghcr.io/microsoft/documentdb/documentdb-local:0.107.0"
+	)
+
+	now := time.Now().UTC()
+	tests := []struct {
+		name           string
+		window         string
+		expectedEngine string
+		expectDeferred bool
+	}{
+		{
+			name:           "outside the window",
+			window:         now.Add(2*time.Hour).Format("15:04") + "-" + now.Add(3*time.Hour).Format("15:04"),
+			expectedEngine: oldEngineImage,
+			expectDeferred: true,
+		},
+		{
+			name:           "inside the window",
+			window:         now.Add(-time.Hour).Format("15:04") + "-" + now.Add(time.Hour).Format("15:04"),
+			expectedEngine: newEngineImage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("ddb-maintenance", "default")
+			ddb.Annotations = map[string]string{util.MAINTENANCE_WINDOW_ANNOTATION: tt.window}
+			ddb.Spec.LogLevel = "info"
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+			current := cnpg.GetCnpgClusterSpec(req, ddb, oldEngineImage, ddb.Name, "", true, logr.Discard())
+
+			r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current, ddb.DeepCopy())
+			require.NoError(t, r.Get(ctx, req.NamespacedName, ddb))
+
+			// The image change is disruptive, the log level change isn't
+			ddb.Spec.LogLevel = "debug"
+			desired := cnpg.GetCnpgClusterSpec(req, ddb, newEngineImage, ddb.Name, "", true, logr.Discard())
+
+			existing := &cnpgv1.Cluster{}
+			require.NoError(t, r.Get(ctx, req.NamespacedName, existing))
+			err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+			require.NoError(t, err)
+
+			updated := &cnpgv1.Cluster{}
+			require.NoError(t, r.Get(ctx, req.NamespacedName, updated))
+			require.Equal(t, tt.expectedEngine, updated.Spec.ImageName)
+			require.Equal(t, "debug", updated.Spec.LogLevel)
+
+			stored := &dbpreview.DocumentDB{}
+			require.NoError(t, r.Get(ctx, req.NamespacedName, stored))
+			condition := meta.FindStatusCondition(stored.Status.Conditions, dbpreview.ConditionMaintenanceDeferred)
+			recorder := r.Recorder.(*record.FakeRecorder)
+			if tt.expectDeferred {
+				require.NotNil(t, condition)
+				require.Equal(t, metav1.ConditionTrue, condition.Status)
+				require.Contains(t, condition.Message, newEngineImage)
+				require.Nil(t, stored.Status.Upgrade, "The upgrade must not start outside the window")
+				require.Len(t, recorder.Events, 1)
+				require.Contains(t, <-recorder.Events, "MaintenanceDeferred")
+			} else {
+				require.Nil(t, condition)
+				require.NotNil(t, stored.Status.Upgrade)
+				require.Equal(t, dbpreview.UpgradePhaseInProgress, stored.Status.Upgrade.Phase)
+			}
+		})
+	}
+}
+
+func TestReconcilePooler(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-pooler", "default")
+	ddb.Spec.Pooler = &dbpreview.PoolerConfiguration{
+		Enabled:              true,
+		Instances:            2,
+		PoolMode:             "transaction",
+		DefaultPoolSize:      20,
+		MaxClientConnections: 500,
+	}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{})
+	c := r.Client
+	poolerKey := types.NamespacedName{Name: "ddb-pooler-pooler", Namespace: ddb.Namespace}
+
+	require.NoError(t, r.reconcilePooler(ctx, ddb, ddb.Name, ddb.Namespace))
+
+	pooler := &cnpgv1.Pooler{}
+	require.NoError(t, c.Get(ctx, poolerKey, pooler))
+	require.Equal(t, ddb.Name, pooler.Spec.Cluster.Name)
+	require.Equal(t, cnpgv1.PoolerTypeRW, pooler.Spec.Type)
+	require.Equal(t, int32(2), *pooler.Spec.Instances)
+	require.Equal(t, cnpgv1.PgBouncerPoolModeTransaction, pooler.Spec.PgBouncer.PoolMode)
+	require.Equal(t, map[string]string{"default_pool_size": "20", "max_client_conn": "500"}, pooler.Spec.PgBouncer.Parameters)
+	require.Len(t, pooler.OwnerReferences, 1)
+	require.Equal(t, ddb.Name, pooler.OwnerReferences[0].Name)
+
+	ddb.Spec.Pooler.Instances = 3
+	require.NoError(t, r.reconcilePooler(ctx, ddb, ddb.Name, ddb.Namespace))
+	require.NoError(t, c.Get(ctx, poolerKey, pooler))
+	require.Equal(t, int32(3), *pooler.Spec.Instances)
+
+	ddb.Spec.Pooler.Enabled = false
+	require.NoError(t, r.reconcilePooler(ctx, ddb, ddb.Name, ddb.Namespace))
+	require.True(t, errors.IsNotFound(c.Get(ctx, poolerKey, pooler)))
+}
+
+func TestReconcileDatabases(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-databases", "default")
+	ddb.UID = "ddb-databases-uid"
+	ddb.Spec.Databases = []dbpreview.DatabaseSpec{
+		{Name: "orders"},
+		{Name: "Inventory_DB", Owner: "shop", CredentialsSecret: "shop-credentials"},
+	}
+	// A Database created by someone else must be left alone
+	foreign := &cnpgv1.Database{
+		ObjectMeta: metav1.ObjectMeta{Name: "reporting", Namespace: ddb.Namespace},
+		Spec:       cnpgv1.DatabaseSpec{ClusterRef: corev1.LocalObjectReference{Name: ddb.Name}, Name: "reporting", Owner: "app"},
+	}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, foreign)
+	c := r.Client
+
+	require.NoError(t, r.reconcileDatabases(ctx, ddb, ddb.Name, ddb.Namespace))
+
+	orders := &cnpgv1.Database{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "ddb-databases-orders", Namespace: ddb.Namespace}, orders))
+	require.Equal(t, ddb.Name, orders.Spec.ClusterRef.Name)
+	require.Equal(t, "orders", orders.Spec.Name)
+	require.Equal(t, util.DEFAULT_DATABASE_OWNER, orders.Spec.Owner)
+	require.Equal(t, cnpgv1.EnsurePresent, orders.Spec.Ensure)
+	require.True(t, metav1.IsControlledBy(orders, ddb))
+
+	inventory := &cnpgv1.Database{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: util.GetDocumentDBDatabaseName(ddb.Name, "Inventory_DB"), Namespace: ddb.Namespace}, inventory))
+	require.Equal(t, "Inventory_DB", inventory.Spec.Name)
+	require.Equal(t, "shop", inventory.Spec.Owner)
+
+	// Changing the owner updates the Database and removing an entry deletes it
+	ddb.Spec.Databases = []dbpreview.DatabaseSpec{{Name: "orders", Owner: "sales"}}
+	require.NoError(t, r.reconcileDatabases(ctx, ddb, ddb.Name, ddb.Namespace))
+
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "ddb-databases-orders", Namespace: ddb.Namespace}, orders))
+	require.Equal(t, "sales", orders.Spec.Owner)
+	require.True(t, errors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: util.GetDocumentDBDatabaseName(ddb.Name, "Inventory_DB"), Namespace: ddb.Namespace}, inventory)))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(foreign), foreign))
+}
+
+func TestTrackCredentialsRotation(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-rotation", "default")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: util.DEFAULT_DOCUMENTDB_CREDENTIALS_SECRET, Namespace: ddb.Namespace},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("old")},
+	}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, secret)
+	recorder := r.Recorder.(*record.FakeRecorder)
+
+	// The first version is recorded silently
+	require.True(t, r.trackCredentialsRotation(ctx, ddb))
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(secret), secret))
+	require.Equal(t, secret.ResourceVersion, ddb.Status.CredentialsSecretVersion)
+	require.Empty(t, recorder.Events)
+	require.False(t, r.trackCredentialsRotation(ctx, ddb))
+
+	secret.Data["password"] = []byte("new")
+	require.NoError(t, r.Client.Update(ctx, secret))
+
+	require.True(t, r.trackCredentialsRotation(ctx, ddb))
+	require.Equal(t, secret.ResourceVersion, ddb.Status.CredentialsSecretVersion)
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "CredentialsRotated")
+}
+
+func TestGetCnpgClusterSpecManagesDatabaseOwners(t *testing.T) {
+	ddb := baseDocumentDB("ddb-owners", "default")
+	ddb.Spec.Databases = []dbpreview.DatabaseSpec{
+		{Name: "orders", Owner: "shop", CredentialsSecret: "shop-credentials"},
+		{Name: "inventory", Owner: "shop", CredentialsSecret: "other-credentials"},
+		{Name: "reporting"},
+		{Name: "audit", Owner: "auditor"},
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+	require.NotNil(t, cluster.Spec.Managed)
+	require.Equal(t, []cnpgv1.RoleConfiguration{{
+		Name:           "shop",
+		Ensure:         cnpgv1.EnsurePresent,
+		Login:          true,
+		PasswordSecret: &cnpgv1.LocalObjectReference{Name: "shop-credentials"},
+	}}, cluster.Spec.Managed.Roles)
+
+	ddb.Spec.Databases = nil
+	cluster = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Nil(t, cluster.Spec.Managed)
+}
+
+func TestGetCnpgClusterSpecBootstrapsFromExternalCluster(t *testing.T) {
+	passwordSecret := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "source-credentials"}, Key: "password"}
+	caSecret := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "source-ca"}, Key: "ca.crt"}
+
+	tests := []struct {
+		name              string
+		externalCluster   dbpreview.ExternalClusterConfiguration
+		isPrimaryRegion   bool
+		expectedBootstrap *cnpgv1.BootstrapConfiguration
+		expectedExternal  []cnpgv1.ExternalCluster
+	}{
+		{
+			name:            "pg_basebackup with defaults",
+			externalCluster: dbpreview.ExternalClusterConfiguration{Host: "legacy.example.com", PasswordSecret: passwordSecret},
+			isPrimaryRegion: true,
+			expectedBootstrap: &cnpgv1.BootstrapConfiguration{
+				PgBaseBackup: &cnpgv1.BootstrapPgBaseBackup{Source: util.EXTERNAL_SOURCE_CLUSTER_NAME, Database: "postgres", Owner: "postgres"},
+			},
+			expectedExternal: []cnpgv1.ExternalCluster{{
+				Name:                 util.EXTERNAL_SOURCE_CLUSTER_NAME,
+				ConnectionParameters: map[string]string{"host": "legacy.example.com", "port": "5432", "user": "postgres", "dbname": "postgres"},
+				Password:             &passwordSecret,
+			}},
+		},
+		{
+			name: "import over verified TLS",
+			externalCluster: dbpreview.ExternalClusterConfiguration{
+				Host:           "10.0.0.5",
+				Port:           6432,
+				User:           "migrator",
+				PasswordSecret: passwordSecret,
+				SSLMode:        "verify-full",
+				SSLRootCert:    caSecret,
+				Method:         dbpreview.ExternalClusterMethodImport,
+				Databases:      []string{"postgres"},
+			},
+			isPrimaryRegion: true,
+			expectedBootstrap: &cnpgv1.BootstrapConfiguration{
+				InitDB: &cnpgv1.BootstrapInitDB{
+					PostInitSQL: []string{
+						"CREATE EXTENSION documentdb CASCADE",
+						"CREATE ROLE documentdb WITH LOGIN PASSWORD 'Admin100'",
+						"ALTER ROLE documentdb WITH SUPERUSER CREATEDB CREATEROLE REPLICATION BYPASSRLS",
+					},
+					Import: &cnpgv1.Import{
+						Source:    cnpgv1.ImportSource{ExternalCluster: util.EXTERNAL_SOURCE_CLUSTER_NAME},
+						Type:      cnpgv1.MonolithSnapshotType,
+						Databases: []string{"postgres"},
+					},
+				},
+			},
+			expectedExternal: []cnpgv1.ExternalCluster{{
+				Name:                 util.EXTERNAL_SOURCE_CLUSTER_NAME,
+				ConnectionParameters: map[string]string{"host": "10.0.0.5", "port": "6432", "user": "migrator", "dbname": "postgres", "sslmode": "verify-full"},
+				Password:             &passwordSecret,
+				SSLRootCert:          caSecret,
+			}},
+		},
+		{
+			name:            "replica regions ignore the external cluster",
+			externalCluster: dbpreview.ExternalClusterConfiguration{Host: "legacy.example.com", PasswordSecret: passwordSecret},
+			isPrimaryRegion: false,
+			expectedBootstrap: &cnpgv1.BootstrapConfiguration{
+				InitDB: &cnpgv1.BootstrapInitDB{
+					PostInitSQL: []string{
+						"CREATE EXTENSION documentdb CASCADE",
+						"CREATE ROLE documentdb WITH LOGIN PASSWORD 'Admin100'",
+						"ALTER ROLE documentdb WITH SUPERUSER CREATEDB CREATEROLE REPLICATION BYPASSRLS",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			externalCluster := tt.externalCluster
+			ddb := baseDocumentDB("ddb-external", "default")
+			ddb.Spec.Bootstrap = &dbpreview.BootstrapConfiguration{ExternalCluster: &externalCluster}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+			cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", tt.isPrimaryRegion, logr.Discard())
+
+			require.Equal(t, tt.expectedBootstrap, cluster.Spec.Bootstrap)
+			require.Equal(t, tt.expectedExternal, cluster.Spec.ExternalClusters)
+		})
+	}
+}
+
+func TestReconcileCloneBacksUpSourceBeforeRestoring(t *testing.T) {
+	ctx := context.Background()
+	source := baseDocumentDB("source", "default")
+	clone := baseDocumentDB("staging", "default")
+	clone.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	clone.Spec.Bootstrap = &dbpreview.BootstrapConfiguration{Clone: &dbpreview.CloneConfiguration{Source: cnpgv1.LocalObjectReference{Name: "source"}}}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, source, clone)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: clone.Name, Namespace: clone.Namespace}}
+	backupKey := types.NamespacedName{Name: "staging-clone", Namespace: "default"}
+
+	cloneStatus := func() *dbpreview.CloneStatus {
+		updated := &dbpreview.DocumentDB{}
+		require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+		require.NotNil(t, updated.Status.Clone)
+		return updated.Status.Clone
+	}
+
+	// The source is backed up first, and the cluster waits for the backup
+	result, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, result.RequeueAfter)
+	backup := &dbpreview.Backup{}
+	require.NoError(t, r.Client.Get(ctx, backupKey, backup))
+	require.Equal(t, "source", backup.Spec.Cluster.Name)
+	require.Len(t, backup.OwnerReferences, 1)
+	require.Equal(t, "staging", backup.OwnerReferences[0].Name)
+	require.True(t, errors.IsNotFound(r.Client.Get(ctx, req.NamespacedName, &cnpgv1.Cluster{})))
+	require.Equal(t, dbpreview.ClonePhaseBackingUp, cloneStatus().Phase)
+
+	// Once the backup completes, the cluster is restored from it
+	backup.Status.Phase = cnpgv1.BackupPhaseCompleted
+	require.NoError(t, r.Client.Update(ctx, backup))
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	cluster := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, cluster))
+	require.NotNil(t, cluster.Spec.Bootstrap.Recovery)
+	require.Equal(t, "staging-clone", cluster.Spec.Bootstrap.Recovery.Backup.Name)
+	require.Nil(t, cluster.Spec.Bootstrap.InitDB)
+	require.Equal(t, &dbpreview.CloneStatus{Phase: dbpreview.ClonePhaseRestoring, BackupName: "staging-clone", Message: "Restoring from backup staging-clone of source"}, cloneStatus())
+
+	// The clone completes when the restored cluster is healthy
+	cluster.Status.Phase = cnpgv1.PhaseHealthy
+	require.NoError(t, r.Client.Update(ctx, cluster))
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, dbpreview.ClonePhaseCompleted, cloneStatus().Phase)
+}
+
+func TestReconcileCloneReportsFailedBackup(t *testing.T) {
+	ctx := context.Background()
+	clone := baseDocumentDB("staging", "default")
+	clone.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	clone.Spec.Bootstrap = &dbpreview.BootstrapConfiguration{Clone: &dbpreview.CloneConfiguration{Source: cnpgv1.LocalObjectReference{Name: "source"}}}
+	backup := &dbpreview.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "staging-clone", Namespace: "default"},
+		Spec:       dbpreview.BackupSpec{Cluster: cnpgv1.LocalObjectReference{Name: "source"}},
+		Status:     dbpreview.BackupStatus{Phase: cnpgv1.BackupPhaseFailed, Message: "no VolumeSnapshotClass"},
+	}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, clone, backup)
+	recorder := r.Recorder.(*record.FakeRecorder)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: clone.Name, Namespace: clone.Namespace}}
+
+	for range 2 {
+		_, err := r.reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, dbpreview.ClonePhaseFailed, updated.Status.Clone.Phase)
+	require.Contains(t, updated.Status.Clone.Message, "no VolumeSnapshotClass")
+	require.True(t, errors.IsNotFound(r.Client.Get(ctx, req.NamespacedName, &cnpgv1.Cluster{})))
+	// The failure is reported once
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "CloneFailed")
+}
+
+func TestReconcilePublishesConnectionStringOnceGatewayServes(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ready", "default")
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: util.GetDocumentDBServiceName("ready"), Namespace: "default"},
+		Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP, ClusterIP: "10.0.0.5"},
+	}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, ddb, service)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	// Create the cluster and mark it healthy so the status is reported
+	_, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	cluster := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, cluster))
+	cluster.Status.Phase = cnpgv1.PhaseHealthy
+	require.NoError(t, r.Client.Update(ctx, cluster))
+
+	// The Service has an IP but no gateway serves behind it yet
+	result, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, result.RequeueAfter)
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Empty(t, updated.Status.ConnectionString)
+	require.True(t, meta.IsStatusConditionFalse(updated.Status.Conditions, dbpreview.ConditionServiceReady))
+
+	// An endpoint that isn't ready yet doesn't publish it either
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      service.Name + "-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: service.Name},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.1.0.7"}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)}}},
+	}
+	require.NoError(t, r.Client.Create(ctx, endpointSlice))
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Empty(t, updated.Status.ConnectionString)
+
+	// Once a gateway endpoint is ready the connection string is published
+	endpointSlice.Endpoints[0].Conditions.Ready = ptr.To(true)
+	require.NoError(t, r.Client.Update(ctx, endpointSlice))
+	result, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Zero(t, result.RequeueAfter)
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Contains(t, updated.Status.ConnectionString, "10.0.0.5")
+	require.True(t, meta.IsStatusConditionTrue(updated.Status.Conditions, dbpreview.ConditionServiceReady))
+}
+
+func s3WalArchive() *dbpreview.WalArchiveConfiguration {
+	return &dbpreview.WalArchiveConfiguration{
+		DestinationPath: "s3://documentdb-wal/archive",
+		EndpointURL:     "https://minio.example.com",
+		Credentials: cnpgv1.BarmanCredentials{
+			AWS: &cnpgv1.S3Credentials{
+				AccessKeyIDReference:     &cnpgv1.SecretKeySelector{LocalObjectReference: cnpgv1.LocalObjectReference{Name: "wal-credentials"}, Key: "ACCESS_KEY_ID"},
+				SecretAccessKeyReference: &cnpgv1.SecretKeySelector{LocalObjectReference: cnpgv1.LocalObjectReference{Name: "wal-credentials"}, Key: "ACCESS_SECRET_KEY"},
+			},
+		},
+		Wal: &cnpgv1.WalBackupConfiguration{Compression: "gzip", MaxParallel: 4},
+	}
+}
+
+func TestGetCnpgClusterSpecArchivesWal(t *testing.T) {
+	ddb := baseDocumentDB("ddb-wal", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Nil(t, cluster.Spec.Backup.BarmanObjectStore)
+
+	walArchive := s3WalArchive()
+	ddb.Spec.Backup = &dbpreview.BackupConfiguration{RetentionDays: 7, WalArchive: walArchive}
+	cluster = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+	objectStore := cluster.Spec.Backup.BarmanObjectStore
+	require.NotNil(t, objectStore)
+	require.Equal(t, "s3://documentdb-wal/archive", objectStore.DestinationPath)
+	require.Equal(t, "https://minio.example.com", objectStore.EndpointURL)
+	require.Equal(t, ddb.Name, objectStore.ServerName)
+	require.Equal(t, walArchive.Credentials, objectStore.BarmanCredentials)
+	require.Equal(t, walArchive.Wal, objectStore.Wal)
+	require.Nil(t, objectStore.Data)
+	// Base backups keep using volume snapshots
+	require.NotNil(t, cluster.Spec.Backup.VolumeSnapshot)
+}
+
+func TestGetCnpgClusterSpecEncryptsObjectStore(t *testing.T) {
+	ddb := baseDocumentDB("ddb-wal-encrypted", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	walArchive := s3WalArchive()
+	walArchive.Encryption = &dbpreview.ObjectStoreEncryption{Mode: "aws:kms", KMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"}
+	ddb.Spec.Backup = &dbpreview.BackupConfiguration{WalArchive: walArchive}
+
+	objectStore := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard()).Spec.Backup.BarmanObjectStore
+	require.NotNil(t, objectStore)
+	kmsKeyArg := "--sse-kms-key-id=arn:aws:kms:us-east-1:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	require.Equal(t, &cnpgv1.WalBackupConfiguration{Compression: "gzip", MaxParallel: 4, Encryption: "aws:kms", ArchiveAdditionalCommandArgs: []string{kmsKeyArg}}, objectStore.Wal)
+	require.Equal(t, &cnpgv1.DataBackupConfiguration{Encryption: "aws:kms", AdditionalCommandArgs: []string{kmsKeyArg}}, objectStore.Data)
+	// The spec itself is left untouched
+	require.Empty(t, walArchive.Wal.Encryption)
+
+	// S3 managed keys need no key reference
+	walArchive.Encryption = &dbpreview.ObjectStoreEncryption{Mode: "AES256"}
+	objectStore = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard()).Spec.Backup.BarmanObjectStore
+	require.Equal(t, &cnpgv1.WalBackupConfiguration{Compression: "gzip", MaxParallel: 4, Encryption: "AES256"}, objectStore.Wal)
+	require.Equal(t, &cnpgv1.DataBackupConfiguration{Encryption: "AES256"}, objectStore.Data)
+}
+
+func TestValidateWalArchiveCredentials(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-wal-credentials", "default")
+	ddb.Spec.Backup = &dbpreview.BackupConfiguration{WalArchive: s3WalArchive()}
+
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{})
+	require.ErrorContains(t, r.validateWalArchiveCredentials(ctx, ddb), "wal-credentials")
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wal-credentials", Namespace: ddb.Namespace},
+		Data:       map[string][]byte{"ACCESS_KEY_ID": []byte("id")},
+	}
+	r = buildDocumentDBReconciler(t, interceptor.Funcs{}, secret)
+	require.ErrorContains(t, r.validateWalArchiveCredentials(ctx, ddb), "has no key ACCESS_SECRET_KEY")
+
+	secret.Data["ACCESS_SECRET_KEY"] = []byte("key")
+	r = buildDocumentDBReconciler(t, interceptor.Funcs{}, secret)
+	require.NoError(t, r.validateWalArchiveCredentials(ctx, ddb))
+}
+
+func TestTryUpdateClusterPatchesWalArchive(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-wal-patch", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	// Enabling the archive adds it to the cluster, and disabling it removes it
+	for _, walArchive := range []*dbpreview.WalArchiveConfiguration{s3WalArchive(), nil} {
+		ddb.Spec.Backup = &dbpreview.BackupConfiguration{WalArchive: walArchive}
+		desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+		existing := &cnpgv1.Cluster{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+		err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+		require.NoError(t, err)
+
+		updated := &cnpgv1.Cluster{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+		require.Equal(t, desired.Spec.Backup.BarmanObjectStore, updated.Spec.Backup.BarmanObjectStore)
+		require.NotNil(t, updated.Spec.Backup.VolumeSnapshot)
+	}
+}
+
+func TestImagePullSecretsPropagateToCluster(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-pull-secrets", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Empty(t, current.Spec.ImagePullSecrets)
+
+	ddb.Spec.ImagePullSecrets = []string{"registry-credentials", "mirror-credentials"}
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Equal(t, []cnpgv1.LocalObjectReference{{Name: "registry-credentials"}, {Name: "mirror-credentials"}}, desired.Spec.ImagePullSecrets)
+
+	// Missing secrets are reported before the cluster is created
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	require.ErrorContains(t, r.validateImagePullSecrets(ctx, ddb), "registry-credentials")
+
+	// Adding the secrets to an existing cluster patches them in, and removing them patches them out
+	for _, names := range [][]string{ddb.Spec.ImagePullSecrets, nil} {
+		ddb.Spec.ImagePullSecrets = names
+		desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+		existing := &cnpgv1.Cluster{}
+		require.NoError(t, r.Client.Get(ctx, req.NamespacedName, existing))
+		err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+		require.NoError(t, err)
+
+		updated := &cnpgv1.Cluster{}
+		require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+		require.Equal(t, desired.Spec.ImagePullSecrets, updated.Spec.ImagePullSecrets)
+	}
+
+	secrets := []client.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: ddb.Namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "mirror-credentials", Namespace: ddb.Namespace}},
+	}
+	ddb.Spec.ImagePullSecrets = []string{"registry-credentials", "mirror-credentials"}
+	r = buildDocumentDBReconciler(t, interceptor.Funcs{}, secrets...)
+	require.NoError(t, r.validateImagePullSecrets(ctx, ddb))
+}
+
+func TestTryUpdateClusterPatchesDatabaseOwnerRoles(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-roles", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	ddb.Spec.Databases = []dbpreview.DatabaseSpec{{Name: "orders", Owner: "shop", CredentialsSecret: "shop-credentials"}}
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.NotNil(t, updated.Spec.Managed)
+	require.Equal(t, desired.Spec.Managed.Roles, updated.Spec.Managed.Roles)
+
+	// Dropping the credentials removes the role from the cluster, keeping the managed configuration
+	ddb.Spec.Databases[0].CredentialsSecret = ""
+	desired = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	err, _ = r.TryUpdateCluster(ctx, updated, desired, ddb, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.NotNil(t, updated.Spec.Managed)
+	require.Empty(t, updated.Spec.Managed.Roles)
+}
+
+func TestTryUpdateClusterRoutesGatewayThroughPooler(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-pooler-route", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	ddb.Spec.Pooler = &dbpreview.PoolerConfiguration{Enabled: true}
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Equal(t, "ddb-pooler-route-pooler", desired.Spec.Plugins[0].Parameters[util.PG_HOST_PLUGIN_PARAMETER])
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, requeue := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, requeue)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "ddb-pooler-route-pooler", updated.Spec.Plugins[0].Parameters[util.PG_HOST_PLUGIN_PARAMETER])
+	require.Contains(t, updated.Annotations, util.CNPG_RESTART_ANNOTATION)
+
+	// Disabling the pooler points the gateway back at the local Postgres
+	ddb.Spec.Pooler.Enabled = false
+	desired = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	err, _ = r.TryUpdateCluster(ctx, updated, desired, ddb, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.NotContains(t, updated.Spec.Plugins[0].Parameters, util.PG_HOST_PLUGIN_PARAMETER)
+}
+
+func TestPriorityClassNamePropagatesToCluster(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-priority", "default")
+	ddb.Spec.PriorityClassName = "documentdb-critical"
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Equal(t, "documentdb-critical", current.Spec.PriorityClassName)
+
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	// Changing the priority class patches the cluster, and clearing it removes it
+	for _, priorityClassName := range []string{"documentdb-high", ""} {
+		ddb.Spec.PriorityClassName = priorityClassName
+		desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+		existing := &cnpgv1.Cluster{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+		err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+		require.NoError(t, err)
+
+		updated := &cnpgv1.Cluster{}
+		require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+		require.Equal(t, priorityClassName, updated.Spec.PriorityClassName)
+	}
+}
+
+func TestPodLabelsAndAnnotationsMergeIntoInheritedMetadata(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-pod-metadata", "default")
+	ddb.Spec.PodLabels = map[string]string{"team": "payments", util.LABEL_APP: "overridden"}
+	ddb.Spec.PodAnnotations = map[string]string{"sidecar.istio.io/inject": "false"}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	// Labels required by the operator take precedence over the ones from the spec
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Equal(t, map[string]string{"team": "payments", util.LABEL_APP: ddb.Name}, current.Spec.InheritedMetadata.Labels)
+	require.Equal(t, map[string]string{"sidecar.istio.io/inject": "false"}, current.Spec.InheritedMetadata.Annotations)
+
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	ddb.Spec.PodLabels = map[string]string{"team": "billing"}
+	ddb.Spec.PodAnnotations = nil
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, map[string]string{"team": "billing", util.LABEL_APP: ddb.Name}, updated.Spec.InheritedMetadata.Labels)
+	require.Empty(t, updated.Spec.InheritedMetadata.Annotations)
+}
+
+func TestTryUpdateClusterRestartsGatewayForMetricsPort(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-metrics", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	ddb.Spec.GatewayMetricsPort = 9187
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Equal(t, "9187", desired.Spec.Plugins[0].Parameters[util.GATEWAY_METRICS_PORT_PLUGIN_PARAMETER])
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, requeue := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, requeue)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "9187", updated.Spec.Plugins[0].Parameters[util.GATEWAY_METRICS_PORT_PLUGIN_PARAMETER])
+	require.Contains(t, updated.Annotations, util.CNPG_RESTART_ANNOTATION)
+}
+
+func TestTryUpdateClusterTogglesGatewaySidecar(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-raw-postgres", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, current)
+	c := r.Client
+
+	// Disabling the gateway drops the sidecar injector plugin
+	ddb.Spec.DisableGateway = true
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Empty(t, desired.Spec.Plugins)
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, existing))
+	err, requeue := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, requeue)
+
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Empty(t, updated.Spec.Plugins)
+	require.Contains(t, updated.Annotations, util.CNPG_RESTART_ANNOTATION)
+
+	// Enabling it again restores the plugin
+	ddb.Spec.DisableGateway = false
+	desired = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	err, _ = r.TryUpdateCluster(ctx, updated, desired, ddb, nil)
+	require.NoError(t, err)
+	require.NoError(t, c.Get(ctx, req.NamespacedName, updated))
+	require.Len(t, updated.Spec.Plugins, 1)
+	require.Equal(t, cnpg.GetSidecarPluginName(ddb), updated.Spec.Plugins[0].Name)
+}
+
+func TestReconcileRevertsStuckSwitchover(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-switchover", "default")
+	ddb.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	ddb.Spec.Timeouts.SwitchoverTimeout = 60
+	ddb.Status.LocalPrimary = "ddb-switchover-1"
+	ddb.Status.TargetPrimary = "ddb-switchover-2"
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	// CNPG started the switchover, but the unhealthy target never becomes primary
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	cluster.Status.Phase = cnpgv1.PhaseSwitchover
+	cluster.Status.CurrentPrimary = "ddb-switchover-1"
+	cluster.Status.TargetPrimary = "ddb-switchover-2"
+	cluster.Status.TargetPrimaryTimestamp = time.Now().Add(-30 * time.Second).Format(metav1.RFC3339Micro)
+	primaryPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "ddb-switchover-1", Namespace: "default"}}
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{}, ddb, cluster, primaryPod)
+	// Promote patches the status of the CNPG Cluster, so it needs the status subresource
+	r.Client = fake.NewClientBuilder().
+		WithScheme(r.Scheme).
+		WithObjects(ddb, cluster, primaryPod).
+		WithStatusSubresource(&dbpreview.DocumentDB{}, &cnpgv1.Cluster{}).
+		Build()
+	recorder := r.Recorder.(*record.FakeRecorder)
+
+	// Within the timeout the switchover is left alone and checked again
+	result, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterShort, result.RequeueAfter)
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "ddb-switchover-2", updated.Status.TargetPrimary)
+	require.Empty(t, recorder.Events)
+
+	// Once it runs out, CNPG and the DocumentDB are pointed back at the current primary
+	current := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, current))
+	current.Status.TargetPrimaryTimestamp = time.Now().Add(-2 * time.Minute).Format(metav1.RFC3339Micro)
+	require.NoError(t, r.Client.Status().Update(ctx, current))
+	_, err = r.reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "ddb-switchover-1", updated.Status.TargetPrimary)
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, current))
+	require.Equal(t, "ddb-switchover-1", current.Status.TargetPrimary)
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "SwitchoverFailed")
+}
+
+func TestGetCnpgClusterSpecPassesLogLevelToGateway(t *testing.T) {
+	ddb := baseDocumentDB("ddb-gateway-log-level", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.NotContains(t, current.Spec.Plugins[0].Parameters, util.GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER)
+
+	for logLevel, gatewayLogLevel := range map[string]string{"debug": "debug", "warning": "warn", "trace": "trace"} {
+		ddb.Spec.LogLevel = logLevel
+		desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+		require.Equal(t, gatewayLogLevel, desired.Spec.Plugins[0].Parameters[util.GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER])
+	}
+}
+
+func TestTryUpdateClusterPatchesDelays(t *testing.T) {
+	tests := []struct {
+		name          string
+		timeouts      dbpreview.Timeouts
+		expectedPatch string
+	}{
+		{
+			name:          "stop delay",
+			timeouts:      dbpreview.Timeouts{StopDelay: 120},
+			expectedPatch: `[{"op":"add","path":"/spec/stopDelay","value":120}]`,
+		},
+		{
+			name:          "start delay",
+			timeouts:      dbpreview.Timeouts{StartDelay: 7200},
+			expectedPatch: `[{"op":"add","path":"/spec/startDelay","value":7200}]`,
+		},
+		{
+			name:          "switchover delay",
+			timeouts:      dbpreview.Timeouts{SwitchoverDelay: 600},
+			expectedPatch: `[{"op":"add","path":"/spec/switchoverDelay","value":600}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("ddb-delays", "default")
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+			current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+			var patches []string
+			r := buildDocumentDBReconciler(t, interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					data, err := patch.Data(obj)
+					require.NoError(t, err)
+					patches = append(patches, string(data))
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}, current)
+
+			ddb.Spec.Timeouts = tt.timeouts
+			desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+			if tt.timeouts.StopDelay != 0 {
+				require.Equal(t, tt.timeouts.StopDelay, desired.Spec.MaxStopDelay)
+			}
+			require.Equal(t, tt.timeouts.StartDelay, desired.Spec.MaxStartDelay)
+			require.Equal(t, tt.timeouts.SwitchoverDelay, desired.Spec.MaxSwitchoverDelay)
+
+			existing := &cnpgv1.Cluster{}
+			require.NoError(t, r.Client.Get(ctx, req.NamespacedName, existing))
+			err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+			require.NoError(t, err)
+
+			require.Len(t, patches, 1)
+			require.JSONEq(t, tt.expectedPatch, patches[0])
+		})
+	}
+}
+
+func TestPrimaryUpdateSettingsPropagateToCluster(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-primary-update", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Empty(t, current.Spec.PrimaryUpdateStrategy)
+	require.Empty(t, current.Spec.PrimaryUpdateMethod)
+
+	var patches []string
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			data, err := patch.Data(obj)
+			require.NoError(t, err)
+			patches = append(patches, string(data))
+			return c.Patch(ctx, obj, patch, opts...)
+		},
+	}, current)
+
+	ddb.Spec.PrimaryUpdateStrategy = "supervised"
+	ddb.Spec.PrimaryUpdateMethod = "switchover"
+	desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.Equal(t, cnpgv1.PrimaryUpdateStrategySupervised, desired.Spec.PrimaryUpdateStrategy)
+	require.Equal(t, cnpgv1.PrimaryUpdateMethodSwitchover, desired.Spec.PrimaryUpdateMethod)
+
+	existing := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, existing))
+	err, _ := r.TryUpdateCluster(ctx, existing, desired, ddb, nil)
+	require.NoError(t, err)
+
+	require.Len(t, patches, 1)
+	require.JSONEq(t, `[{"op":"add","path":"/spec/primaryUpdateStrategy","value":"supervised"},{"op":"add","path":"/spec/primaryUpdateMethod","value":"switchover"}]`, patches[0])
+
+	// Unset settings leave the values on the cluster alone
+	updated := &cnpgv1.Cluster{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	patches = nil
+	ddb.Spec.PrimaryUpdateStrategy = ""
+	ddb.Spec.PrimaryUpdateMethod = ""
+	desired = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	err, _ = r.TryUpdateCluster(ctx, updated, desired, ddb, nil)
+	require.NoError(t, err)
+	require.Empty(t, patches)
+}
+
+func TestPsqlCommand(t *testing.T) {
+	cmd, stdin := psqlCommand("SELECT 1", nil)
+	require.Equal(t, []string{"psql", "-U", "postgres", "-d", "postgres", "-X", "-tA", "-c", "SELECT 1"}, cmd)
+	require.Empty(t, stdin, "Local socket connections should not send a password")
+
+	cmd, stdin = psqlCommand("SELECT 1", &superuserCredentials{username: "postgres", password: "s3cret"})
+	require.Equal(t, []string{
+		"sh", "-c", `PGPASSWORD="$(cat)" exec psql "$@"`, "psql",
+		"-h", "localhost",
+		"-p", "5432",
+		"-U", "postgres",
+		"-d", "postgres",
+		"-X", "-tA",
+		"-c", "SELECT 1",
+	}, cmd)
+	require.Equal(t, "s3cret", stdin)
+	require.NotContains(t, strings.Join(cmd, " "), "s3cret", "The password must not appear in the command line")
+}
+
+func TestSuperuserCredentials(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-su", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	// Without a superuser secret psql connects as postgres over the local socket
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{})
+	credentials, err := r.superuserCredentials(ctx, cluster)
+	require.NoError(t, err)
+	require.Nil(t, credentials)
+
+	ddb.Spec.SuperuserSecret = "pg-superuser"
+	cluster = cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	require.True(t, cluster.GetEnableSuperuserAccess())
+	require.Equal(t, "pg-superuser", cluster.GetSuperuserSecretName())
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "pg-superuser", Namespace: "default"},
+		Type:       corev1.SecretTypeBasicAuth,
+		Data:       map[string][]byte{"username": []byte("postgres"), "password": []byte("s3cret")},
+	}
+	r = buildDocumentDBReconciler(t, interceptor.Funcs{}, secret)
+	credentials, err = r.superuserCredentials(ctx, cluster)
+	require.NoError(t, err)
+	require.Equal(t, &superuserCredentials{username: "postgres", password: "s3cret"}, credentials)
+
+	// A secret without a password is rejected rather than falling back to trust authentication
+	delete(secret.Data, "password")
+	r = buildDocumentDBReconciler(t, interceptor.Funcs{}, secret)
+	_, err = r.superuserCredentials(ctx, cluster)
+	require.Error(t, err)
+}
+
+func TestParseSQLRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected []string
+	}{
+		{name: "no rows", output: "", expected: nil},
+		{name: "single value", output: "1\n", expected: []string{"1"}},
+		{name: "several rows with columns", output: "a|1\nb|2\n", expected: []string{"a|1", "b|2"}},
+		{name: "carriage returns and blank lines", output: "1\r\n\r\n", expected: []string{"1"}},
+		{name: "command tag only", output: "GRANT ROLE\n", expected: []string{"GRANT ROLE"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, parseSQLRows(tt.output))
+		})
+	}
+}
+
+func TestReplicationCaughtUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		expected  bool
+		expectErr bool
+	}{
+		{name: "not streaming", output: "", expected: false},
+		{name: "fully replayed", output: "0\n", expected: true},
+		{name: "within tolerance", output: "1024\n", expected: true},
+		{name: "lagging", output: "2048\n", expected: false},
+		{name: "nothing replayed yet", output: "-1\n", expected: false},
+		{name: "one of several connections lagging", output: "0\n4096\n", expected: false},
+		{name: "unexpected output", output: "ERROR\n", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caughtUp, err := replicationCaughtUp(tt.output, 1024)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, caughtUp)
+		})
+	}
+}
+
+func TestReplicationLagQueryQuotesName(t *testing.T) {
+	require.Contains(t, replicationLagQuery("cluster-b"), "application_name = 'cluster-b'")
+	require.Contains(t, replicationLagQuery("x'; DROP TABLE t; --"), "application_name = 'x''; DROP TABLE t; --'")
+}
+
+func TestGrantReplicationRoleSkipsExecOnceGranted(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-grant", "default")
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+	cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+	cluster.Status.CurrentPrimary = ddb.Name + "-1"
+
+	podGets := 0
+	funcs := interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*corev1.Pod); ok {
+				podGets++
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+	}
+
+	// Without the condition the primary pod is looked up to exec the check, which fails as there is no pod
+	r := buildDocumentDBReconciler(t, funcs, ddb)
+	require.Error(t, r.grantReplicationRole(ctx, ddb, cluster, &util.ReplicationContext{}))
+	require.Equal(t, 1, podGets)
+	require.False(t, meta.IsStatusConditionTrue(ddb.Status.Conditions, dbpreview.ConditionReplicationRoleGranted))
+
+	// Once the grant is recorded in status, later reconciles do not exec into the primary
+	podGets = 0
+	meta.SetStatusCondition(&ddb.Status.Conditions, metav1.Condition{
+		Type:   dbpreview.ConditionReplicationRoleGranted,
+		Status: metav1.ConditionTrue,
+		Reason: "Granted",
+	})
+	require.NoError(t, r.grantReplicationRole(ctx, ddb, cluster, &util.ReplicationContext{}))
+	require.Zero(t, podGets)
+}
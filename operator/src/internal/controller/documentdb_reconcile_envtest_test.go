@@ -0,0 +1,128 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+var _ = Describe("DocumentDB reconcile against a real API server", func() {
+	const (
+		documentDBName = "documentdb-envtest"
+		timeout        = 10 * time.Second
+		interval       = 250 * time.Millisecond
+	)
+
+	var (
+		ctx        context.Context
+		namespace  string
+		reconciler *DocumentDBReconciler
+	)
+
+	BeforeEach(func() {
+		if testEnv == nil {
+			Skip("KUBEBUILDER_ASSETS is not set; run `make test` to execute envtest specs")
+		}
+		ctx = context.Background()
+
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "documentdb-envtest-"}}
+		Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		namespace = ns.Name
+
+		reconciler = &DocumentDBReconciler{
+			Client:    k8sClient,
+			Scheme:    testScheme,
+			Config:    cfg,
+			Clientset: kubernetes.NewForConfigOrDie(cfg),
+			Recorder:  record.NewFakeRecorder(100),
+		}
+	})
+
+	It("creates the CNPG Cluster, Service and RBAC objects owned by the DocumentDB", func() {
+		ddb := baseDocumentDB(documentDBName, namespace)
+		Expect(k8sClient.Create(ctx, ddb)).To(Succeed())
+
+		// Wait for the cache to observe the DocumentDB so Reconcile reads it with its TypeMeta set
+		Eventually(func() error {
+			return k8sClient.Get(ctx, types.NamespacedName{Name: documentDBName, Namespace: namespace}, &dbpreview.DocumentDB{})
+		}, timeout, interval).Should(Succeed())
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: documentDBName, Namespace: namespace}}
+		cluster := &cnpgv1.Cluster{}
+		Eventually(func() error {
+			if _, err := reconciler.Reconcile(ctx, req); err != nil {
+				return err
+			}
+			return k8sClient.Get(ctx, req.NamespacedName, cluster)
+		}, timeout, interval).Should(Succeed())
+
+		current := &dbpreview.DocumentDB{}
+		Expect(k8sClient.Get(ctx, req.NamespacedName, current)).To(Succeed())
+		expectControlledBy(cluster, current)
+		Expect(cluster.Spec.Instances).To(Equal(current.Spec.InstancesPerNode))
+		Expect(cluster.Spec.ImageName).To(Equal(current.Spec.DocumentDBImage))
+
+		service := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: util.GetDocumentDBServiceName(documentDBName), Namespace: namespace}, service)).To(Succeed())
+		expectControlledBy(service, current)
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+		Expect(service.Spec.ClusterIP).NotTo(BeEmpty())
+
+		for _, obj := range []client.Object{&corev1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+			Expect(k8sClient.Get(ctx, req.NamespacedName, obj)).To(Succeed())
+			Expect(obj.GetOwnerReferences()).To(ContainElement(HaveField("UID", current.UID)), "%T is not owned by the DocumentDB", obj)
+		}
+
+		// A further reconcile must settle rather than fail on the objects it created
+		Eventually(func() error {
+			_, err := reconciler.Reconcile(ctx, req)
+			return err
+		}, timeout, interval).Should(Succeed())
+	})
+
+	It("rejects a NetworkPolicy that would block replication or load balancer clients", func() {
+		replicated := baseDocumentDB(documentDBName+"-replicated", namespace)
+		replicated.Spec.NetworkPolicy = &dbpreview.NetworkPolicyConfiguration{Enabled: true}
+		replicated.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+			CrossCloudNetworkingStrategy: "None",
+			Primary:                      "cluster-a",
+			ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}},
+		}
+		Expect(k8sClient.Create(ctx, replicated)).To(MatchError(ContainSubstring("networkPolicy cannot be enabled with clusterReplication")))
+
+		exposed := baseDocumentDB(documentDBName+"-exposed", namespace)
+		exposed.Spec.ExposeViaService = dbpreview.ExposeViaService{ServiceType: string(corev1.ServiceTypeLoadBalancer)}
+		exposed.Spec.NetworkPolicy = &dbpreview.NetworkPolicyConfiguration{Enabled: true}
+		Expect(k8sClient.Create(ctx, exposed)).To(MatchError(ContainSubstring("requires networkPolicy.ipBlocks")))
+
+		exposed.Spec.NetworkPolicy.IPBlocks = []networkingv1.IPBlock{{CIDR: "203.0.113.0/24"}}
+		Expect(k8sClient.Create(ctx, exposed)).To(Succeed())
+	})
+})
+
+// expectControlledBy asserts obj has a controller owner reference pointing at the DocumentDB.
+func expectControlledBy(obj client.Object, owner *dbpreview.DocumentDB) {
+	ref := metav1.GetControllerOf(obj)
+	ExpectWithOffset(1, ref).NotTo(BeNil(), "%s has no controller owner reference", obj.GetName())
+	ExpectWithOffset(1, ref.UID).To(Equal(owner.UID))
+	ExpectWithOffset(1, ref.Kind).To(Equal("DocumentDB"))
+	ExpectWithOffset(1, ref.APIVersion).To(Equal(dbpreview.GroupVersion.String()))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// leaderElectedManager is the part of the manager needed to register leader-only tasks
+type leaderElectedManager interface {
+	Add(manager.Runnable) error
+	Elected() <-chan struct{}
+}
+
+// leaderTask runs a periodic background task, such as an orphan sweep or token cleanup, on the elected leader only.
+// Every replica of the operator registers the task, but only the one holding the leader election lease runs it.
+type leaderTask struct {
+	name     string
+	interval time.Duration
+	elected  <-chan struct{}
+	task     func(ctx context.Context) error
+}
+
+// NeedLeaderElection makes the manager start the task only once this replica wins leader election
+func (t *leaderTask) NeedLeaderElection() bool {
+	return true
+}
+
+// Start waits for leadership, then runs the task every interval until ctx is cancelled.
+// A failed run is logged and retried at the next interval.
+func (t *leaderTask) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithValues("task", t.name)
+
+	// The manager already holds back leader election runnables, waiting here too keeps the task safe if it is started directly
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-t.elected:
+	}
+
+	logger.Info("Starting leader-only task", "interval", t.interval)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := t.task(ctx); err != nil {
+			logger.Error(err, "Leader-only task failed")
+		}
+	}, t.interval)
+	return nil
+}
+
+// AddLeaderOnlyTask registers a task that runs every interval on the elected leader only.
+// Use it for background work that must not run on every replica of a multi-replica operator deployment.
+func AddLeaderOnlyTask(mgr leaderElectedManager, name string, interval time.Duration, task func(ctx context.Context) error) error {
+	return mgr.Add(&leaderTask{
+		name:     name,
+		interval: interval,
+		elected:  mgr.Elected(),
+		task:     task,
+	})
+}
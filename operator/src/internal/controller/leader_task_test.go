@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+type fakeLeaderElectedManager struct {
+	runnables []manager.Runnable
+	elected   chan struct{}
+}
+
+func (m *fakeLeaderElectedManager) Add(r manager.Runnable) error {
+	m.runnables = append(m.runnables, r)
+	return nil
+}
+
+func (m *fakeLeaderElectedManager) Elected() <-chan struct{} {
+	return m.elected
+}
+
+func TestLeaderOnlyTaskWaitsForLeadership(t *testing.T) {
+	mgr := &fakeLeaderElectedManager{elected: make(chan struct{})}
+	var runs atomic.Int32
+	require.NoError(t, AddLeaderOnlyTask(mgr, "sweep", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	}))
+	require.Len(t, mgr.runnables, 1)
+
+	runnable := mgr.runnables[0]
+	electionRunnable, ok := runnable.(manager.LeaderElectionRunnable)
+	require.True(t, ok)
+	require.True(t, electionRunnable.NeedLeaderElection())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runnable.Start(ctx) }()
+
+	// Without leadership the task never runs
+	time.Sleep(100 * time.Millisecond)
+	require.Zero(t, runs.Load())
+
+	close(mgr.elected)
+	require.Eventually(t, func() bool { return runs.Load() >= 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestLeaderOnlyTaskStopsWithoutLeadership(t *testing.T) {
+	mgr := &fakeLeaderElectedManager{elected: make(chan struct{})}
+	require.NoError(t, AddLeaderOnlyTask(mgr, "token-cleanup", time.Millisecond, func(ctx context.Context) error {
+		t.Error("task ran without leadership")
+		return nil
+	}))
+
+	// A replica that never becomes leader returns cleanly on shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, mgr.runnables[0].Start(ctx))
+}
@@ -0,0 +1,135 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+// deferUntilMaintenanceWindow holds back the disruptive changes between the current and desired CNPG Cluster
+// while the DocumentDB's maintenance window is closed, and records them in the MaintenanceDeferred condition.
+// Returns true if a planned switchover must wait for the window as well. Failovers are never deferred.
+func (r *DocumentDBReconciler) deferUntilMaintenanceWindow(ctx context.Context, current, desired *cnpgv1.Cluster, documentdb *dbpreview.DocumentDB, replicationContext *util.ReplicationContext) (bool, error) {
+	var deferred []string
+	switchoverDeferred := false
+
+	open, nextOpen := r.maintenanceWindowOpen(ctx, documentdb, time.Now())
+	if !open {
+		deferred = deferDisruptiveChanges(current, desired)
+		if switchover := pendingSwitchover(current, desired, documentdb, replicationContext); switchover != "" {
+			deferred = append(deferred, switchover)
+			switchoverDeferred = true
+		}
+	}
+
+	if !documentdb.UpdateMaintenanceDeferredCondition(deferred, nextOpen) {
+		return switchoverDeferred, nil
+	}
+	if len(deferred) > 0 {
+		r.Recorder.Eventf(documentdb, corev1.EventTypeNormal, "MaintenanceDeferred", "Deferring until the maintenance window opens at %s: %s",
+			nextOpen.UTC().Format(time.RFC3339), strings.Join(deferred, "; "))
+		log.FromContext(ctx).Info("Deferring disruptive changes until the maintenance window", "nextOpen", nextOpen, "changes", deferred)
+	}
+	if err := r.Status().Update(ctx, documentdb); err != nil {
+		return switchoverDeferred, fmt.Errorf("failed to update DocumentDB maintenance status: %w", err)
+	}
+	return switchoverDeferred, nil
+}
+
+// maintenanceWindowOpen reports whether disruptive changes may be applied at now and, if not, when the window opens
+// next. A DocumentDB without the maintenance window annotation is always open. An invalid annotation is reported and
+// ignored, so that a typo doesn't hold back changes indefinitely.
+func (r *DocumentDBReconciler) maintenanceWindowOpen(ctx context.Context, documentdb *dbpreview.DocumentDB, now time.Time) (bool, time.Time) {
+	value, ok := documentdb.Annotations[util.MAINTENANCE_WINDOW_ANNOTATION]
+	if !ok {
+		return true, now
+	}
+
+	window, err := util.ParseMaintenanceWindow(value)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Ignoring invalid maintenance window")
+		r.Recorder.Event(documentdb, corev1.EventTypeWarning, "InvalidMaintenanceWindow", err.Error())
+		return true, now
+	}
+
+	nextOpen := window.NextOpen(now)
+	return !nextOpen.After(now), nextOpen
+}
+
+// deferDisruptiveChanges keeps the engine image, gateway image and gateway restart settings of the desired cluster at their
+// current values, so that applying it doesn't restart the instances, and describes each change held back.
+func deferDisruptiveChanges(current, desired *cnpgv1.Cluster) []string {
+	var deferred []string
+
+	if current.Spec.ImageName != "" && desired.Spec.ImageName != current.Spec.ImageName {
+		deferred = append(deferred, fmt.Sprintf("DocumentDB image change to %s", desired.Spec.ImageName))
+		desired.Spec.ImageName = current.Spec.ImageName
+	}
+
+	if len(desired.Spec.Plugins) == 0 {
+		return deferred
+	}
+	pluginName := desired.Spec.Plugins[0].Name
+	currentIndex, currentGatewayImage := gatewayImageParameter(current, pluginName)
+	desiredIndex, desiredGatewayImage := gatewayImageParameter(desired, pluginName)
+	if currentIndex < 0 || desiredIndex < 0 {
+		return deferred
+	}
+	if desired.Spec.Plugins[desiredIndex].Parameters == nil {
+		desired.Spec.Plugins[desiredIndex].Parameters = map[string]string{}
+	}
+	parameters := desired.Spec.Plugins[desiredIndex].Parameters
+
+	if currentGatewayImage != "" && desiredGatewayImage != "" && currentGatewayImage != desiredGatewayImage {
+		deferred = append(deferred, fmt.Sprintf("gateway image change to %s", desiredGatewayImage))
+		parameters[util.GATEWAY_IMAGE_PLUGIN_PARAMETER] = currentGatewayImage
+	}
+
+	for _, parameter := range restartingPluginParameters {
+		currentValue := pluginParameter(current, pluginName, parameter.name)
+		desiredValue := pluginParameter(desired, pluginName, parameter.name)
+		if currentValue == desiredValue {
+			continue
+		}
+		deferred = append(deferred, fmt.Sprintf("gateway restart to change %s", parameter.description))
+		if currentValue == "" {
+			delete(parameters, parameter.name)
+		} else {
+			parameters[parameter.name] = currentValue
+		}
+	}
+
+	return deferred
+}
+
+// pendingSwitchover describes the planned switchover that applying the desired cluster would start, or returns ""
+// when there is none. Only promotions in Switchover mode are planned; failovers are left alone.
+func pendingSwitchover(current, desired *cnpgv1.Cluster, documentdb *dbpreview.DocumentDB, replicationContext *util.ReplicationContext) string {
+	if !documentdb.GracefulSwitchover() {
+		return ""
+	}
+
+	if current.Spec.ReplicaCluster != nil && desired.Spec.ReplicaCluster != nil &&
+		current.Spec.ReplicaCluster.Primary != desired.Spec.ReplicaCluster.Primary &&
+		current.Spec.ReplicaCluster.Primary == current.Spec.ReplicaCluster.Self {
+		return fmt.Sprintf("switchover to cluster %s", desired.Spec.ReplicaCluster.Primary)
+	}
+
+	if replicationContext != nil && replicationContext.IsPrimary() &&
+		documentdb.Status.TargetPrimary != "" && documentdb.Status.TargetPrimary != current.Status.TargetPrimary {
+		return fmt.Sprintf("switchover to instance %s", documentdb.Status.TargetPrimary)
+	}
+
+	return ""
+}
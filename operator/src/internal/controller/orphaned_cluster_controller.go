@@ -0,0 +1,94 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controller
+
+import (
+	"context"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+// OrphanedClusterReconciler deletes the CNPG Clusters created by the operator whose DocumentDB no longer exists,
+// e.g. after the DocumentDB was deleted with --cascade=orphan. Clusters without the operator's managed-by label
+// are never touched.
+type OrphanedClusterReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// +kubebuilder:rbac:groups=postgresql.cnpg.io,resources=clusters,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=documentdb.io,resources=dbs,verbs=get;list;watch
+func (r *OrphanedClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cluster := &cnpgv1.Cluster{}
+	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !isOrphanCandidate(cluster) {
+		return ctrl.Result{}, nil
+	}
+
+	documentDBName := cluster.Labels[util.LABEL_APP]
+	documentdb := &dbpreview.DocumentDB{}
+	err := r.Get(ctx, types.NamespacedName{Name: documentDBName, Namespace: cluster.Namespace}, documentdb)
+	if err == nil {
+		return ctrl.Result{}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		logger.Error(err, "Failed to get the DocumentDB of CNPG Cluster", "documentdb", documentDBName)
+		return ctrl.Result{}, err
+	}
+
+	if err := r.Delete(ctx, cluster); err != nil && !apierrors.IsNotFound(err) {
+		logger.Error(err, "Failed to delete orphaned CNPG Cluster")
+		return ctrl.Result{}, err
+	}
+	r.Recorder.Eventf(cluster, corev1.EventTypeNormal, "OrphanDeleted", "Deleted CNPG Cluster whose DocumentDB %s no longer exists", documentDBName)
+	logger.Info("Deleted orphaned CNPG Cluster", "documentdb", documentDBName)
+	return ctrl.Result{}, nil
+}
+
+// isOrphanCandidate reports whether the cluster was created by the operator and has lost its DocumentDB owner
+// reference, which the garbage collector removes when the DocumentDB is deleted without its dependents.
+// Clusters still owned by a DocumentDB are left to the garbage collector.
+func isOrphanCandidate(cluster *cnpgv1.Cluster) bool {
+	if cluster.DeletionTimestamp != nil || cluster.Labels[util.LABEL_APP] == "" {
+		return false
+	}
+	if cluster.Labels[util.LABEL_MANAGED_BY] != util.MANAGED_BY_DOCUMENTDB_OPERATOR {
+		return false
+	}
+	for _, ownerReference := range cluster.OwnerReferences {
+		if ownerReference.Kind == "DocumentDB" {
+			return false
+		}
+	}
+	return true
+}
+
+// SetupWithManager sets up the controller with the Manager. Every labelled cluster is checked when the operator
+// starts, and again whenever it changes, e.g. when its owner reference is removed.
+func (r *OrphanedClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&cnpgv1.Cluster{}, builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return obj.GetLabels()[util.LABEL_MANAGED_BY] == util.MANAGED_BY_DOCUMENTDB_OPERATOR
+		}))).
+		Named("orphaned-cluster-controller").
+		Complete(r)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controller
+
+import (
+	"context"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+var _ = Describe("Orphaned Cluster Controller", func() {
+	const (
+		namespace      = "default"
+		clusterName    = "test-cluster"
+		documentDBName = "test-documentdb"
+	)
+
+	var (
+		ctx      context.Context
+		scheme   *runtime.Scheme
+		recorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		recorder = record.NewFakeRecorder(10)
+		Expect(dbpreview.AddToScheme(scheme)).To(Succeed())
+		Expect(cnpgv1.AddToScheme(scheme)).To(Succeed())
+	})
+
+	newCluster := func(labels map[string]string, owners ...metav1.OwnerReference) *cnpgv1.Cluster {
+		return &cnpgv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            clusterName,
+				Namespace:       namespace,
+				Labels:          labels,
+				OwnerReferences: owners,
+			},
+		}
+	}
+
+	managedLabels := map[string]string{
+		util.LABEL_APP:        documentDBName,
+		util.LABEL_MANAGED_BY: util.MANAGED_BY_DOCUMENTDB_OPERATOR,
+	}
+
+	reconcileCluster := func(objs ...client.Object) client.Client {
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+		reconciler := &OrphanedClusterReconciler{Client: fakeClient, Scheme: scheme, Recorder: recorder}
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: clusterName, Namespace: namespace}})
+		Expect(err).ToNot(HaveOccurred())
+		return fakeClient
+	}
+
+	clusterExists := func(c client.Client) bool {
+		err := c.Get(ctx, types.NamespacedName{Name: clusterName, Namespace: namespace}, &cnpgv1.Cluster{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return true
+	}
+
+	It("deletes a managed cluster whose DocumentDB no longer exists", func() {
+		c := reconcileCluster(newCluster(managedLabels))
+
+		Expect(clusterExists(c)).To(BeFalse())
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("OrphanDeleted"))
+	})
+
+	It("keeps a managed cluster whose DocumentDB exists", func() {
+		documentdb := &dbpreview.DocumentDB{ObjectMeta: metav1.ObjectMeta{Name: documentDBName, Namespace: namespace}}
+		c := reconcileCluster(newCluster(managedLabels), documentdb)
+
+		Expect(clusterExists(c)).To(BeTrue())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("keeps a cluster still owned by a DocumentDB", func() {
+		owner := metav1.OwnerReference{APIVersion: "documentdb.io/preview", Kind: "DocumentDB", Name: documentDBName, UID: "documentdb-uid"}
+		c := reconcileCluster(newCluster(managedLabels, owner))
+
+		Expect(clusterExists(c)).To(BeTrue())
+	})
+
+	It("never deletes a cluster not created by the operator", func() {
+		c := reconcileCluster(newCluster(map[string]string{util.LABEL_APP: documentDBName}))
+
+		Expect(clusterExists(c)).To(BeTrue())
+		Expect(recorder.Events).To(BeEmpty())
+	})
+})
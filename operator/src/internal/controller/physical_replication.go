@@ -4,6 +4,7 @@
 package controller
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,7 +18,9 @@ import (
 	util "github.com/documentdb/documentdb-operator/internal/utils"
 	fleetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -34,7 +37,15 @@ func (r *DocumentDBReconciler) AddClusterReplicationToClusterSpec(
 	isPrimary := documentdb.Spec.ClusterReplication.Primary == replicationContext.Self
 
 	if replicationContext.IsAzureFleetNetworking() {
-		err := r.CreateServiceImportAndExport(ctx, replicationContext, documentdb)
+		installed, err := r.fleetNetworkingInstalled()
+		if err != nil {
+			return err
+		}
+		if !installed {
+			return fmt.Errorf("the %s cross-cloud networking strategy requires fleet networking: install the fleet-networking member agent and its ServiceExport and MultiClusterService CRDs on this cluster",
+				documentdb.Spec.ClusterReplication.CrossCloudNetworkingStrategy)
+		}
+		err = r.CreateServiceImportAndExport(ctx, replicationContext, documentdb)
 		if err != nil {
 			return err
 		}
@@ -47,14 +58,17 @@ func (r *DocumentDBReconciler) AddClusterReplicationToClusterSpec(
 
 	// No more errors possible, so we can safely edit the spec
 	cnpgCluster.Name = replicationContext.Self
+	if cnpgCluster.Spec.Backup != nil && cnpgCluster.Spec.Backup.BarmanObjectStore != nil {
+		cnpgCluster.Spec.Backup.BarmanObjectStore.ServerName = replicationContext.Self
+	}
 
 	if !isPrimary {
 		cnpgCluster.Spec.InheritedMetadata.Labels[util.LABEL_REPLICATION_CLUSTER_TYPE] = "replica"
 		cnpgCluster.Spec.Bootstrap = &cnpgv1.BootstrapConfiguration{
 			PgBaseBackup: &cnpgv1.BootstrapPgBaseBackup{
 				Source:   documentdb.Spec.ClusterReplication.Primary,
-				Database: "postgres",
-				Owner:    "postgres",
+				Database: cmp.Or(documentdb.Spec.ClusterReplication.ReplicationDatabase, util.DEFAULT_REPLICATION_DATABASE),
+				Owner:    cmp.Or(documentdb.Spec.ClusterReplication.ReplicationOwner, util.DEFAULT_REPLICATION_OWNER),
 			},
 		}
 	} else if documentdb.Spec.ClusterReplication.HighAvailability {
@@ -67,12 +81,14 @@ func (r *DocumentDBReconciler) AddClusterReplicationToClusterSpec(
 				cnpgCluster.Spec.Bootstrap.InitDB.PostInitSQL,
 				"select * from pg_create_physical_replication_slot('wal_replica');")
 		}
-		// Also need to configure quorum writes
-		cnpgCluster.Spec.PostgresConfiguration.Synchronous = &cnpgv1.SynchronousReplicaConfiguration{
-			Method:          cnpgv1.SynchronousReplicaConfigurationMethodAny,
-			Number:          3,
-			StandbyNamesPre: replicationContext.CreateStandbyNamesList(),
-			DataDurability:  cnpgv1.DataDurabilityLevelRequired,
+		// Also need to configure quorum writes, unless there is no standby to acknowledge them
+		if number := replicationContext.SynchronousStandbyNumber(cnpgCluster.Spec.Instances - 1); number > 0 {
+			cnpgCluster.Spec.PostgresConfiguration.Synchronous = &cnpgv1.SynchronousReplicaConfiguration{
+				Method:          cnpgv1.SynchronousReplicaConfigurationMethodAny,
+				Number:          number,
+				StandbyNamesPre: replicationContext.CreateStandbyNamesList(),
+				DataDurability:  cnpgv1.DataDurabilityLevelRequired,
+			}
 		}
 		trueVal := true
 		cnpgCluster.Spec.ReplicationSlots = &cnpgv1.ReplicationSlotsConfiguration{
@@ -100,46 +116,36 @@ func (r *DocumentDBReconciler) AddClusterReplicationToClusterSpec(
 	}
 
 	if replicationContext.IsAzureFleetNetworking() {
-		// need to create services for each of the other clusters
-		cnpgCluster.Spec.Managed = &cnpgv1.ManagedConfiguration{
-			Services: &cnpgv1.ManagedServices{
-				Additional: []cnpgv1.ManagedService{},
-			},
+		// need to create services for each of the other clusters, keeping any managed roles
+		if cnpgCluster.Spec.Managed == nil {
+			cnpgCluster.Spec.Managed = &cnpgv1.ManagedConfiguration{}
+		}
+		cnpgCluster.Spec.Managed.Services = &cnpgv1.ManagedServices{
+			Additional: []cnpgv1.ManagedService{},
 		}
 		for serviceName := range replicationContext.GenerateOutgoingServiceNames(documentdb.Namespace) {
-			cnpgCluster.Spec.Managed.Services.Additional = append(cnpgCluster.Spec.Managed.Services.Additional,
-				cnpgv1.ManagedService{
-					SelectorType: cnpgv1.ServiceSelectorTypeRW,
-					ServiceTemplate: cnpgv1.ServiceTemplateSpec{
-						ObjectMeta: cnpgv1.Metadata{
-							Name: serviceName,
-						},
+			service := cnpgv1.ManagedService{
+				SelectorType: cnpgv1.ServiceSelectorTypeRW,
+				ServiceTemplate: cnpgv1.ServiceTemplateSpec{
+					ObjectMeta: cnpgv1.Metadata{
+						Name: serviceName,
 					},
-				})
+				},
+			}
+			// Expose the gateway of the primary next to Postgres, so clients of the other member clusters reach it
+			if !documentdb.Spec.DisableGateway {
+				service.ServiceTemplate.Spec = corev1.ServiceSpec{Ports: []corev1.ServicePort{gatewayServicePort()}}
+			}
+			cnpgCluster.Spec.Managed.Services.Additional = append(cnpgCluster.Spec.Managed.Services.Additional, service)
 		}
 	}
 	selfHost := documentdb.Name + "-rw." + documentdb.Namespace + ".svc"
-	cnpgCluster.Spec.ExternalClusters = []cnpgv1.ExternalCluster{
-		{
-			Name: replicationContext.Self,
-			ConnectionParameters: map[string]string{
-				"host":   selfHost,
-				"port":   "5432",
-				"dbname": "postgres",
-				"user":   "postgres",
-			},
-		},
-	}
+	// Keep the external server the primary is bootstrapped from, if any
+	cnpgCluster.Spec.ExternalClusters = append(cnpgCluster.Spec.ExternalClusters,
+		replicationExternalCluster(documentdb, replicationContext.Self, selfHost))
 	for clusterName, serviceName := range replicationContext.GenerateExternalClusterServices(documentdb.Namespace, replicationContext.IsAzureFleetNetworking()) {
-		cnpgCluster.Spec.ExternalClusters = append(cnpgCluster.Spec.ExternalClusters, cnpgv1.ExternalCluster{
-			Name: clusterName,
-			ConnectionParameters: map[string]string{
-				"host":   serviceName,
-				"port":   "5432",
-				"dbname": "postgres",
-				"user":   "postgres",
-			},
-		})
+		cnpgCluster.Spec.ExternalClusters = append(cnpgCluster.Spec.ExternalClusters,
+			replicationExternalCluster(documentdb, clusterName, serviceName))
 	}
 
 	return nil
@@ -151,102 +157,215 @@ func (r *DocumentDBReconciler) CreateIstioRemoteServices(ctx context.Context, re
 	// Istio will automatically route traffic through the east-west gateway
 	for _, remoteCluster := range replicationContext.Others {
 		// Create the -rw (read-write/primary) service for each remote cluster
-		serviceNameRW := remoteCluster + "-rw"
-		foundServiceRW := &corev1.Service{}
-		err := r.Get(ctx, types.NamespacedName{Name: serviceNameRW, Namespace: documentdb.Namespace}, foundServiceRW)
-		if err != nil && errors.IsNotFound(err) {
-			log.Log.Info("Creating Istio dummy service for remote cluster", "service", serviceNameRW, "cluster", remoteCluster)
-
-			serviceRW := &corev1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      serviceNameRW,
-					Namespace: documentdb.Namespace,
-					Labels: map[string]string{
-						"cnpg.io/cluster": remoteCluster,
-						"replica_type":    "primary",
-					},
-				},
-				Spec: corev1.ServiceSpec{
-					Ports: []corev1.ServicePort{
-						{
-							Name:       "postgres",
-							Port:       5432,
-							Protocol:   corev1.ProtocolTCP,
-							TargetPort: intstr.FromInt(5432),
-						},
-					},
-					Selector: map[string]string{
-						// Non-matching selector ensures no local endpoints
-						"cnpg.io/cluster": "does-not-exist",
-						"cnpg.io/podRole": "does-not-exist",
-					},
-					SessionAffinity: corev1.ServiceAffinityNone,
-					Type:            corev1.ServiceTypeClusterIP,
-				},
-			}
-
-			err = r.Create(ctx, serviceRW)
+		err := r.createIstioDummyService(ctx, documentdb.Namespace, remoteCluster, remoteCluster+"-rw", corev1.ServicePort{
+			Name:       "postgres",
+			Port:       5432,
+			Protocol:   corev1.ProtocolTCP,
+			TargetPort: intstr.FromInt(5432),
+		})
+		if err != nil {
+			return err
+		}
+		// And the gateway service, so clients can list the gateways of all member clusters
+		if !documentdb.Spec.DisableGateway {
+			err = r.createIstioDummyService(ctx, documentdb.Namespace, remoteCluster, util.GetDocumentDBServiceName(remoteCluster), gatewayServicePort())
 			if err != nil {
-				return fmt.Errorf("failed to create Istio dummy service %s: %w", serviceNameRW, err)
+				return err
 			}
-		} else if err != nil {
-			return fmt.Errorf("failed to check for existing service %s: %w", serviceNameRW, err)
 		}
 	}
 
 	return nil
 }
 
+// createIstioDummyService creates the named service of the remote cluster, selecting no local pods, unless it exists
+func (r *DocumentDBReconciler) createIstioDummyService(ctx context.Context, namespace, remoteCluster, serviceName string, port corev1.ServicePort) error {
+	logger := log.FromContext(ctx)
+	foundService := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: namespace}, foundService)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to check for existing service %s: %w", serviceName, err)
+	}
+
+	logger.Info("Creating Istio dummy service for remote cluster", "service", serviceName, "cluster", remoteCluster)
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"cnpg.io/cluster": remoteCluster,
+				"replica_type":    "primary",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{port},
+			Selector: map[string]string{
+				// Non-matching selector ensures no local endpoints
+				"cnpg.io/cluster": "does-not-exist",
+				"cnpg.io/podRole": "does-not-exist",
+			},
+			SessionAffinity: corev1.ServiceAffinityNone,
+			Type:            corev1.ServiceTypeClusterIP,
+		},
+	}
+	if err := r.Create(ctx, service); err != nil {
+		return fmt.Errorf("failed to create Istio dummy service %s: %w", serviceName, err)
+	}
+	return nil
+}
+
+// gatewayServicePort returns the port of the gateway on the services reaching other member clusters
+func gatewayServicePort() corev1.ServicePort {
+	port := util.GetPortFor(util.GATEWAY_PORT)
+	return corev1.ServicePort{Name: util.SERVICE_PORT_NAME_GATEWAY, Protocol: corev1.ProtocolTCP, Port: port, TargetPort: intstr.FromInt32(port)}
+}
+
+// replicationExternalCluster returns the external cluster entry used to replicate from the member cluster reachable
+// at host. The connection requires TLS. Each member cluster has its own CA, so the server is only verified, and the
+// client certificate only presented, when the replication TLS secret provides a CA shared by all member clusters.
+func replicationExternalCluster(documentdb *dbpreview.DocumentDB, name, host string) cnpgv1.ExternalCluster {
+	external := cnpgv1.ExternalCluster{
+		Name: name,
+		ConnectionParameters: map[string]string{
+			"host":    host,
+			"port":    "5432",
+			"dbname":  cmp.Or(documentdb.Spec.ClusterReplication.ReplicationDatabase, util.DEFAULT_REPLICATION_DATABASE),
+			"user":    cmp.Or(documentdb.Spec.ClusterReplication.ReplicationUser, util.DEFAULT_REPLICATION_USER),
+			"sslmode": util.REPLICATION_SSL_MODE,
+		},
+	}
+	if secretName := documentdb.Spec.ClusterReplication.ReplicationTLSSecret; secretName != "" {
+		external.ConnectionParameters["sslmode"] = util.REPLICATION_VERIFIED_SSL_MODE
+		secretKey := func(key string) *corev1.SecretKeySelector {
+			return &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: secretName}, Key: key}
+		}
+		external.SSLCert = secretKey(corev1.TLSCertKey)
+		external.SSLKey = secretKey(corev1.TLSPrivateKeyKey)
+		external.SSLRootCert = secretKey(util.CA_CERT_SECRET_KEY)
+	}
+	return external
+}
+
+// fleetNetworkingInstalled reports whether the API server serves the fleet-networking ServiceExport and
+// MultiClusterService kinds, which are only available once fleet networking is installed on the member cluster.
+func (r *DocumentDBReconciler) fleetNetworkingInstalled() (bool, error) {
+	for _, kind := range []string{"ServiceExport", "MultiClusterService"} {
+		gvk := fleetv1alpha1.GroupVersion.WithKind(kind)
+		if _, err := r.Client.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			if meta.IsNoMatchError(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to discover %s: %w", gvk.GroupKind(), err)
+		}
+	}
+	return true, nil
+}
+
+// CreateServiceImportAndExport makes sure the fleet ServiceExports of this member cluster's services and the
+// MultiClusterServices importing the other member clusters' services exist with the expected spec. Objects that are
+// already up to date are left alone, so it can run on every reconcile.
 func (r *DocumentDBReconciler) CreateServiceImportAndExport(ctx context.Context, replicationContext *util.ReplicationContext, documentdb *dbpreview.DocumentDB) error {
+	logger := log.FromContext(ctx)
+	created, updated := 0, 0
+
 	for serviceName := range replicationContext.GenerateOutgoingServiceNames(documentdb.Namespace) {
 		foundServiceExport := &fleetv1alpha1.ServiceExport{}
 		err := r.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: documentdb.Namespace}, foundServiceExport)
-		if err != nil && errors.IsNotFound(err) {
-			log.Log.Info("Service Export not found. Creating a new Service Export " + serviceName)
-
-			// Service Export
-			ringServiceExport := &fleetv1alpha1.ServiceExport{
+		if errors.IsNotFound(err) {
+			serviceExport := &fleetv1alpha1.ServiceExport{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      serviceName,
 					Namespace: documentdb.Namespace,
 				},
 			}
-			err = r.Create(ctx, ringServiceExport)
-			if err != nil {
-				return err
+			if err := r.Create(ctx, serviceExport); err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create ServiceExport %s: %w", serviceName, err)
 			}
+			created++
+		} else if err != nil {
+			return fmt.Errorf("failed to get ServiceExport %s: %w", serviceName, err)
 		}
+		// A ServiceExport has no spec, so an existing one can't drift
 	}
 
 	// Below is true because this function is only called if we are fleet enabled
 	for sourceServiceName := range replicationContext.GenerateIncomingServiceNames(documentdb.Namespace) {
+		desiredSpec := fleetv1alpha1.MultiClusterServiceSpec{
+			ServiceImport: fleetv1alpha1.ServiceImportRef{
+				Name: sourceServiceName,
+			},
+		}
+
 		foundMCS := &fleetv1alpha1.MultiClusterService{}
 		err := r.Get(ctx, types.NamespacedName{Name: sourceServiceName, Namespace: documentdb.Namespace}, foundMCS)
-		if err != nil && errors.IsNotFound(err) {
-			log.Log.Info("Multi Cluster Service not found. Creating a new Multi Cluster Service")
-			// Multi Cluster Service
-			foundMCS = &fleetv1alpha1.MultiClusterService{
+		switch {
+		case errors.IsNotFound(err):
+			mcs := &fleetv1alpha1.MultiClusterService{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      sourceServiceName,
 					Namespace: documentdb.Namespace,
 				},
-				Spec: fleetv1alpha1.MultiClusterServiceSpec{
-					ServiceImport: fleetv1alpha1.ServiceImportRef{
-						Name: sourceServiceName,
-					},
-				},
+				Spec: desiredSpec,
 			}
-			err = r.Create(ctx, foundMCS)
-			if err != nil {
-				return err
+			if err := r.Create(ctx, mcs); err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create MultiClusterService %s: %w", sourceServiceName, err)
 			}
+			created++
+		case err != nil:
+			return fmt.Errorf("failed to get MultiClusterService %s: %w", sourceServiceName, err)
+		case !equality.Semantic.DeepEqual(foundMCS.Spec, desiredSpec):
+			foundMCS.Spec = desiredSpec
+			if err := r.Update(ctx, foundMCS); err != nil {
+				return fmt.Errorf("failed to update MultiClusterService %s: %w", sourceServiceName, err)
+			}
+			updated++
 		}
 	}
 
+	if created == 0 && updated == 0 {
+		logger.V(1).Info("Fleet ServiceExports and MultiClusterServices are up to date")
+		return nil
+	}
+	logger.Info("Reconciled fleet ServiceExports and MultiClusterServices", "created", created, "updated", updated)
 	return nil
 }
 
+// TryUpdateCluster diffs the current CNPG Cluster against the desired one, applying the spec changes before any
+// replica cluster transition.
 func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, desired *cnpgv1.Cluster, documentdb *dbpreview.DocumentDB, replicationContext *util.ReplicationContext) (error, time.Duration) {
+	if err := r.updateStorageSize(ctx, current, desired, documentdb); err != nil {
+		return err, time.Second * 10
+	}
+
+	switchoverDeferred, err := r.deferUntilMaintenanceWindow(ctx, current, desired, documentdb, replicationContext)
+	if err != nil {
+		return err, time.Second * 10
+	}
+
+	if err := r.reconcileImageUpgrade(ctx, current, desired, documentdb); err != nil {
+		return err, time.Second * 10
+	}
+
+	updated, err := r.updateMutableClusterFields(ctx, current, desired)
+	if err != nil {
+		return err, time.Second * 10
+	}
+	if updated {
+		// Let CNPG pick up the change before applying any replication transition
+		return nil, r.Requeue.short()
+	}
+
+	return r.updateReplicaCluster(ctx, current, desired, documentdb, replicationContext, switchoverDeferred)
+}
+
+// updateReplicaCluster applies the primary changes of the desired replica cluster configuration, exchanging the
+// promotion token between the demoted and the promoted member clusters. It doesn't depend on a change to the
+// DocumentDB spec, as the token of a peer can become available at any time.
+func (r *DocumentDBReconciler) updateReplicaCluster(ctx context.Context, current, desired *cnpgv1.Cluster, documentdb *dbpreview.DocumentDB, replicationContext *util.ReplicationContext, switchoverDeferred bool) (error, time.Duration) {
+	logger := log.FromContext(ctx)
 	if current.Spec.ReplicaCluster == nil || desired.Spec.ReplicaCluster == nil {
 		// FOR NOW assume that we aren't going to turn on or off physical replication
 		return nil, -1
@@ -269,6 +388,21 @@ func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, de
 
 	if tokenNeedsUpdate || primaryChanged && current.Spec.ReplicaCluster.Primary == current.Spec.ReplicaCluster.Self {
 		// Primary => replica
+		// For a switchover, hold the demotion until the maintenance window opens and the new primary has caught up
+		if !tokenNeedsUpdate && switchoverDeferred {
+			return nil, -1
+		}
+		if !tokenNeedsUpdate && documentdb.GracefulSwitchover() {
+			caughtUp, err := r.replicaCaughtUp(ctx, current, desired.Spec.ReplicaCluster.Primary, replicationContext)
+			if err != nil {
+				return err, time.Second * 10
+			}
+			if !caughtUp {
+				logger.Info("Waiting for the new primary to catch up before demoting", "newPrimary", desired.Spec.ReplicaCluster.Primary, "cluster", current.Name)
+				return nil, r.Requeue.short()
+			}
+		}
+
 		// demote
 		patchOps = append(patchOps, util.JSONPatch{
 			Op:    util.JSON_PATCH_OP_REPLACE,
@@ -303,7 +437,7 @@ func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, de
 			return fmt.Errorf("failed to marshal patch operations: %w", err), time.Second * 10
 		}
 
-		log.Log.Info("Applying patch for Primary => Replica transition", "patch", string(patch), "cluster", current.Name)
+		logger.Info("Applying patch for Primary => Replica transition", "patch", string(patch), "cluster", current.Name)
 
 		err = r.Client.Patch(ctx, current, client.RawPatch(types.JSONPatchType, patch))
 		if err != nil {
@@ -329,7 +463,7 @@ func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, de
 			if err != nil || refreshTime > 0 {
 				return err, refreshTime
 			}
-			log.Log.Info("Token read successfully", "token", token)
+			logger.Info("Promotion token read successfully")
 
 			// Update the configuration with the token
 			replicaClusterConfig.PromotionToken = token
@@ -370,7 +504,7 @@ func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, de
 			return fmt.Errorf("failed to marshal patch operations: %w", err), time.Second * 10
 		}
 
-		log.Log.Info("Applying patch for Replica => Primary transition", "patch", string(patch), "cluster", current.Name, "hasToken", replicaClusterConfig.PromotionToken != "")
+		logger.Info("Applying patch for Replica => Primary transition", "patch", string(patch), "cluster", current.Name, "hasToken", replicaClusterConfig.PromotionToken != "")
 
 		err = r.Client.Patch(ctx, current, client.RawPatch(types.JSONPatchType, patch))
 		if err != nil {
@@ -389,7 +523,7 @@ func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, de
 			return fmt.Errorf("failed to marshal patch operations: %w", err), time.Second * 10
 		}
 
-		log.Log.Info("Applying patch for Replica => Replica transition", "patch", string(patch), "cluster", current.Name)
+		logger.Info("Applying patch for Replica => Replica transition", "patch", string(patch), "cluster", current.Name)
 
 		err = r.Client.Patch(ctx, current, client.RawPatch(types.JSONPatchType, patch))
 		if err != nil {
@@ -401,6 +535,7 @@ func (r *DocumentDBReconciler) TryUpdateCluster(ctx context.Context, current, de
 }
 
 func (r *DocumentDBReconciler) ReadToken(ctx context.Context, namespace string, replicationContext *util.ReplicationContext) (string, error, time.Duration) {
+	logger := log.FromContext(ctx)
 	tokenServiceName := "promotion-token"
 
 	// If we are not using cross-cloud networking, we only need to read the token from the configmap
@@ -421,7 +556,7 @@ func (r *DocumentDBReconciler) ReadToken(ctx context.Context, namespace string,
 		foundService := &corev1.Service{}
 		err := r.Get(ctx, types.NamespacedName{Name: tokenServiceName, Namespace: namespace}, foundService)
 		if err != nil && errors.IsNotFound(err) {
-			log.Log.Info("Creating Istio dummy service for promotion token", "service", tokenServiceName)
+			logger.Info("Creating Istio dummy service for promotion token", "service", tokenServiceName)
 
 			service := &corev1.Service{
 				ObjectMeta: metav1.ObjectMeta{
@@ -455,19 +590,11 @@ func (r *DocumentDBReconciler) ReadToken(ctx context.Context, namespace string,
 		}
 
 		// Read token via HTTP through Istio service mesh
-		tokenRequestUrl := fmt.Sprintf("http://%s.%s.svc", tokenServiceName, namespace)
-		resp, err := http.Get(tokenRequestUrl)
-		if err != nil {
-			return "", fmt.Errorf("failed to get token from service: %w", err), time.Second * 10
-		}
-		defer resp.Body.Close()
-
-		token, err := io.ReadAll(resp.Body)
+		token, err := fetchToken(ctx, fmt.Sprintf("http://%s.%s.svc", tokenServiceName, namespace))
 		if err != nil {
-			return "", fmt.Errorf("failed to read token: %w", err), time.Second * 10
+			return "", err, time.Second * 10
 		}
-
-		return string(token[:]), nil, -1
+		return token, nil, -1
 	}
 
 	// This is the AzureFleet case
@@ -493,19 +620,30 @@ func (r *DocumentDBReconciler) ReadToken(ctx context.Context, namespace string,
 		return "", err, time.Second * 10
 	}
 
-	tokenRequestUrl := fmt.Sprintf("http://%s-%s.fleet-system.svc", namespace, tokenServiceName)
-	resp, err := http.Get(tokenRequestUrl)
+	token, err := fetchToken(ctx, fmt.Sprintf("http://%s-%s.fleet-system.svc", namespace, tokenServiceName))
 	if err != nil {
-		return "", fmt.Errorf("failed to get token from service: %w", err), time.Second * 10
+		return "", err, time.Second * 10
 	}
+	return token, nil, -1
+}
 
-	token, err := io.ReadAll(resp.Body)
+// fetchToken reads the promotion token served at url, giving up when ctx is done.
+func fetchToken(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to read token: %w", err), time.Second * 10
+		return "", fmt.Errorf("failed to get token from service: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Need to convert byte array to byte slice before converting to string
-	return string(token[:]), nil, -1
+	token, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token: %w", err)
+	}
+	return string(token), nil
 }
 
 // TODO make this not have to check the configmap twice
@@ -651,3 +789,31 @@ func (r *DocumentDBReconciler) CreateTokenService(ctx context.Context, token str
 
 	return nil
 }
+
+// fleetMemberClusterHost returns the host of the service imported from the other member cluster once its
+// MultiClusterService is valid, or "" until then. The imported service selects the primary of the other member
+// cluster, exposing its gateway next to Postgres.
+func (r *DocumentDBReconciler) fleetMemberClusterHost(ctx context.Context, replicationContext *util.ReplicationContext, other, namespace string) (string, error) {
+	serviceName := replicationContext.IncomingServiceName(other, namespace)
+	mcs := &fleetv1alpha1.MultiClusterService{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: namespace}, mcs); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	if !meta.IsStatusConditionTrue(mcs.Status.Conditions, string(fleetv1alpha1.MultiClusterServiceValid)) {
+		return "", nil
+	}
+	return util.FleetImportedServiceHost(namespace, serviceName), nil
+}
+
+// istioMemberClusterHost returns the host of the Istio service mirroring the gateway Service of the other member
+// cluster, or its -rw service without the gateway, or "" while the mirroring service doesn't exist.
+func (r *DocumentDBReconciler) istioMemberClusterHost(ctx context.Context, documentdb *dbpreview.DocumentDB, other, namespace string) (string, error) {
+	serviceName := util.GetDocumentDBServiceName(other)
+	if documentdb.Spec.DisableGateway {
+		serviceName = other + "-rw"
+	}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: namespace}, &corev1.Service{}); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	return serviceName + "." + namespace + ".svc", nil
+}
@@ -0,0 +1,576 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	cnpgv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	fleetv1alpha1 "go.goms.io/fleet-networking/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/meta/testrestmapper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
+	cnpg "github.com/documentdb/documentdb-operator/internal/cnpg"
+	util "github.com/documentdb/documentdb-operator/internal/utils"
+)
+
+func TestAddClusterReplicationSynchronousNumber(t *testing.T) {
+	tests := []struct {
+		name             string
+		peers            []string
+		expectedStandbys []string
+		expectedNumber   int
+	}{
+		{
+			name:             "one peer",
+			peers:            []string{"cluster-b"},
+			expectedStandbys: []string{"cluster-b"},
+			expectedNumber:   2,
+		},
+		{
+			name:             "three peers",
+			peers:            []string{"cluster-b", "cluster-c", "cluster-d"},
+			expectedStandbys: []string{"cluster-b", "cluster-c", "cluster-d"},
+			expectedNumber:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("cluster-a", "default")
+			clusterList := []dbpreview.MemberCluster{{Name: "cluster-a"}}
+			for _, peer := range tt.peers {
+				clusterList = append(clusterList, dbpreview.MemberCluster{Name: peer})
+			}
+			ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+				CrossCloudNetworkingStrategy: "None",
+				Primary:                      "cluster-a",
+				ClusterList:                  clusterList,
+				HighAvailability:             true,
+			}
+
+			replicationContext, err := util.GetReplicationContext(ctx, nil, *ddb)
+			require.NoError(t, err)
+			require.True(t, replicationContext.IsPrimary())
+
+			req := ctrl.Request{}
+			req.Name = ddb.Name
+			req.Namespace = ddb.Namespace
+			cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+
+			r := &DocumentDBReconciler{}
+			require.NoError(t, r.AddClusterReplicationToClusterSpec(ctx, ddb, replicationContext, cluster))
+
+			sync := cluster.Spec.PostgresConfiguration.Synchronous
+			require.NotNil(t, sync)
+			require.Equal(t, tt.expectedStandbys, sync.StandbyNamesPre)
+			require.Equal(t, tt.expectedNumber, sync.Number)
+			require.LessOrEqual(t, sync.Number, len(sync.StandbyNamesPre)+cluster.Spec.Instances-1)
+		})
+	}
+}
+
+func TestAddClusterReplicationConnectionSettings(t *testing.T) {
+	tests := []struct {
+		name             string
+		database         string
+		owner            string
+		user             string
+		tlsSecret        string
+		expectedDatabase string
+		expectedOwner    string
+		expectedUser     string
+		expectedSSLMode  string
+	}{
+		{
+			name:             "defaults",
+			expectedDatabase: "postgres",
+			expectedOwner:    "postgres",
+			expectedUser:     "postgres",
+			expectedSSLMode:  "require",
+		},
+		{
+			name:             "dedicated replication role",
+			database:         "replication",
+			owner:            "app",
+			user:             "streaming_replica",
+			expectedDatabase: "replication",
+			expectedOwner:    "app",
+			expectedUser:     "streaming_replica",
+			expectedSSLMode:  "require",
+		},
+		{
+			name:             "replication TLS secret",
+			user:             "streaming_replica",
+			tlsSecret:        "replication-tls",
+			expectedDatabase: "postgres",
+			expectedOwner:    "postgres",
+			expectedUser:     "streaming_replica",
+			expectedSSLMode:  "verify-ca",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("cluster-b", "default")
+			ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+				CrossCloudNetworkingStrategy: "None",
+				Primary:                      "cluster-a",
+				ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}},
+				ReplicationDatabase:          tt.database,
+				ReplicationOwner:             tt.owner,
+				ReplicationUser:              tt.user,
+				ReplicationTLSSecret:         tt.tlsSecret,
+			}
+
+			replicationContext, err := util.GetReplicationContext(ctx, nil, *ddb)
+			require.NoError(t, err)
+			require.False(t, replicationContext.IsPrimary())
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+			cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", false, logr.Discard())
+
+			r := &DocumentDBReconciler{}
+			require.NoError(t, r.AddClusterReplicationToClusterSpec(ctx, ddb, replicationContext, cluster))
+
+			require.NotNil(t, cluster.Spec.Bootstrap.PgBaseBackup)
+			require.Equal(t, tt.expectedDatabase, cluster.Spec.Bootstrap.PgBaseBackup.Database)
+			require.Equal(t, tt.expectedOwner, cluster.Spec.Bootstrap.PgBaseBackup.Owner)
+			require.Len(t, cluster.Spec.ExternalClusters, 2)
+			for _, external := range cluster.Spec.ExternalClusters {
+				require.Equal(t, tt.expectedDatabase, external.ConnectionParameters["dbname"], external.Name)
+				require.Equal(t, tt.expectedUser, external.ConnectionParameters["user"], external.Name)
+				// Replication traffic is always encrypted, and only verified with a replication TLS secret
+				require.Equal(t, tt.expectedSSLMode, external.ConnectionParameters["sslmode"], external.Name)
+				if tt.tlsSecret == "" {
+					require.Nil(t, external.SSLCert, external.Name)
+					require.Nil(t, external.SSLKey, external.Name)
+					require.Nil(t, external.SSLRootCert, external.Name)
+					continue
+				}
+				require.Equal(t, &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tt.tlsSecret},
+					Key:                  "tls.crt",
+				}, external.SSLCert, external.Name)
+				require.Equal(t, &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tt.tlsSecret},
+					Key:                  "tls.key",
+				}, external.SSLKey, external.Name)
+				require.Equal(t, &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: tt.tlsSecret},
+					Key:                  "ca.crt",
+				}, external.SSLRootCert, external.Name)
+			}
+		})
+	}
+}
+
+func TestAddClusterReplicationMembersWithDifferentCAs(t *testing.T) {
+	ctx := context.Background()
+	// Each member cluster has a CA of its own, so none of them may verify a peer against its local CA
+	for _, self := range []string{"cluster-a", "cluster-b"} {
+		ddb := baseDocumentDB(self, "default")
+		ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+			CrossCloudNetworkingStrategy: "None",
+			Primary:                      "cluster-a",
+			ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}},
+		}
+		replicationContext, err := util.GetReplicationContext(ctx, nil, *ddb)
+		require.NoError(t, err)
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+		cluster := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", false, logr.Discard())
+		r := &DocumentDBReconciler{}
+		require.NoError(t, r.AddClusterReplicationToClusterSpec(ctx, ddb, replicationContext, cluster))
+
+		for _, external := range cluster.Spec.ExternalClusters {
+			require.Equal(t, "require", external.ConnectionParameters["sslmode"], self+" to "+external.Name)
+			require.Nil(t, external.SSLRootCert, self+" to "+external.Name)
+			require.Nil(t, external.SSLCert, self+" to "+external.Name)
+		}
+	}
+}
+
+func TestTryUpdateClusterSwitchoverWaitsForCatchUp(t *testing.T) {
+	tests := []struct {
+		name            string
+		promotionMode   string
+		expectedPatches int
+	}{
+		{name: "failover demotes immediately", promotionMode: dbpreview.PromotionModeFailover, expectedPatches: 1},
+		{name: "switchover checks the new primary first", promotionMode: dbpreview.PromotionModeSwitchover, expectedPatches: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ddb := baseDocumentDB("cluster-a", "default")
+			ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+				CrossCloudNetworkingStrategy: "None",
+				Primary:                      "cluster-a",
+				ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}},
+				PromotionMode:                tt.promotionMode,
+			}
+			replicationContext, err := util.GetReplicationContext(ctx, nil, *ddb)
+			require.NoError(t, err)
+
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+			current := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+			require.NoError(t, (&DocumentDBReconciler{}).AddClusterReplicationToClusterSpec(ctx, ddb, replicationContext, current))
+			current.Status.CurrentPrimary = "cluster-a-1"
+
+			patches := 0
+			r := buildDocumentDBReconciler(t, interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					patches++
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}, current)
+
+			// Move the primary to cluster-b
+			ddb.Spec.ClusterReplication.Primary = "cluster-b"
+			desired := cnpg.GetCnpgClusterSpec(req, ddb, "test-image", ddb.Name, "", true, logr.Discard())
+			require.NoError(t, r.AddClusterReplicationToClusterSpec(ctx, ddb, replicationContext, desired))
+
+			existing := &cnpgv1.Cluster{}
+			require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: current.Name, Namespace: current.Namespace}, existing))
+			existing.Status.CurrentPrimary = current.Status.CurrentPrimary
+			err, _ = r.TryUpdateCluster(ctx, existing, desired, ddb, replicationContext)
+
+			require.Equal(t, tt.expectedPatches, patches)
+			if tt.promotionMode == dbpreview.PromotionModeSwitchover {
+				// The catch-up check cannot reach the primary pod, so the demotion is held back
+				require.ErrorContains(t, err, "replication lag")
+			}
+		})
+	}
+}
+
+func TestReconcileReportsReplicationSetupFailure(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-fleet", "default")
+	ddb.Spec.Environment = "kind"
+	ddb.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+		CrossCloudNetworkingStrategy: "AzureFleet",
+		Primary:                      "cluster-a",
+		ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}},
+	}
+	clusterName := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-name", Namespace: "kube-system"},
+		Data:       map[string]string{"name": "cluster-a"},
+	}
+
+	exportAttempts := 0
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if _, ok := obj.(*fleetv1alpha1.ServiceExport); ok {
+				return apierrors.NewNotFound(schema.GroupResource{Group: "networking.fleet.azure.com", Resource: "serviceexports"}, key.Name)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*fleetv1alpha1.ServiceExport); ok {
+				exportAttempts++
+				return errors.New("serviceexports is forbidden")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}, ddb, clusterName)
+	recorder := r.Recorder.(*record.FakeRecorder)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	for range 3 {
+		result, err := r.reconcile(ctx, req)
+		require.NoError(t, err)
+		// The failure is retried at the slow interval instead of the short one
+		require.Equal(t, DefaultRequeueAfterLong, result.RequeueAfter)
+	}
+	require.Equal(t, 3, exportAttempts)
+
+	// No cluster is created without its replication settings
+	clusters := &cnpgv1.ClusterList{}
+	require.NoError(t, r.Client.List(ctx, clusters))
+	require.Empty(t, clusters.Items)
+
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	condition := meta.FindStatusCondition(updated.Status.Conditions, dbpreview.ConditionReplicationConfigured)
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Equal(t, "ConfigurationFailed", condition.Reason)
+	require.Contains(t, condition.Message, "serviceexports is forbidden")
+
+	// The warning is raised once, not on every retry
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "ReplicationConfigurationFailed")
+}
+
+func TestReconcileReportsMissingFleetNetworking(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-fleet", "default")
+	ddb.Spec.Environment = "kind"
+	ddb.Spec.ExposeViaService = dbpreview.ExposeViaService{}
+	ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+		CrossCloudNetworkingStrategy: "AzureFleet",
+		Primary:                      "cluster-a",
+		ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}},
+	}
+	clusterName := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-name", Namespace: "kube-system"},
+		Data:       map[string]string{"name": "cluster-a"},
+	}
+
+	// Without the fleet types the API server does not serve ServiceExports and MultiClusterServices
+	scheme := runtime.NewScheme()
+	require.NoError(t, dbpreview.AddToScheme(scheme))
+	require.NoError(t, cnpgv1.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, storagev1.AddToScheme(scheme))
+	require.NoError(t, rbacv1.AddToScheme(scheme))
+	require.NoError(t, networkingv1.AddToScheme(scheme))
+	require.NoError(t, discoveryv1.AddToScheme(scheme))
+	r := &DocumentDBReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRESTMapper(testrestmapper.TestOnlyStaticRESTMapper(scheme)).
+			WithObjects(ddb, clusterName).
+			WithStatusSubresource(&dbpreview.DocumentDB{}).
+			Build(),
+		Scheme:   scheme,
+		Recorder: record.NewFakeRecorder(10),
+	}
+	recorder := r.Recorder.(*record.FakeRecorder)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: ddb.Name, Namespace: ddb.Namespace}}
+
+	result, err := r.reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRequeueAfterLong, result.RequeueAfter)
+
+	updated := &dbpreview.DocumentDB{}
+	require.NoError(t, r.Client.Get(ctx, req.NamespacedName, updated))
+	condition := meta.FindStatusCondition(updated.Status.Conditions, dbpreview.ConditionReplicationConfigured)
+	require.NotNil(t, condition)
+	require.Equal(t, metav1.ConditionFalse, condition.Status)
+	require.Contains(t, condition.Message, "requires fleet networking")
+
+	require.Len(t, recorder.Events, 1)
+	require.Contains(t, <-recorder.Events, "install the fleet-networking member agent")
+}
+
+func TestFetchTokenHonorsContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := fetchToken(ctx, server.URL)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("token"))
+	}))
+	defer ok.Close()
+	token, err := fetchToken(context.Background(), ok.URL)
+	require.NoError(t, err)
+	require.Equal(t, "token", token)
+}
+
+// TestControllersLogThroughContext guards against logging through the global controller-runtime logger, which drops
+// the controller, reconcile ID and DocumentDB fields of the reconcile's logger.
+func TestControllersLogThroughContext(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		require.NoError(t, err)
+		ast.Inspect(parsed, func(node ast.Node) bool {
+			selector, ok := node.(*ast.SelectorExpr)
+			if !ok || selector.Sel.Name != "Log" {
+				return true
+			}
+			if pkg, ok := selector.X.(*ast.Ident); ok && (pkg.Name == "log" || pkg.Name == "ctrl") {
+				t.Errorf("%s: use log.FromContext(ctx) instead of %s.Log", fset.Position(selector.Pos()), pkg.Name)
+			}
+			return true
+		})
+	}
+}
+
+func TestCreateServiceImportAndExportIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	ddb := baseDocumentDB("ddb-fleet", "default")
+	ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+		CrossCloudNetworkingStrategy: "AzureFleet",
+		Primary:                      "cluster-a",
+		ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}, {Name: "cluster-c"}},
+	}
+	clusterName := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-name", Namespace: "kube-system"},
+		Data:       map[string]string{"name": "cluster-a"},
+	}
+	// The export to cluster-b and the import from cluster-b are present, the import from cluster-c has drifted
+	// and the export to cluster-c is absent
+	presentExport := &fleetv1alpha1.ServiceExport{ObjectMeta: metav1.ObjectMeta{Name: "cluster-a-cluster-b", Namespace: "default"}}
+	presentImport := &fleetv1alpha1.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-b-cluster-a", Namespace: "default"},
+		Spec:       fleetv1alpha1.MultiClusterServiceSpec{ServiceImport: fleetv1alpha1.ServiceImportRef{Name: "cluster-b-cluster-a"}},
+	}
+	driftedImport := &fleetv1alpha1.MultiClusterService{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-c-cluster-a", Namespace: "default"},
+		Spec:       fleetv1alpha1.MultiClusterServiceSpec{ServiceImport: fleetv1alpha1.ServiceImportRef{Name: "stale"}},
+	}
+
+	var writes []string
+	r := buildDocumentDBReconciler(t, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			writes = append(writes, "create "+obj.GetName())
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			writes = append(writes, "update "+obj.GetName())
+			return c.Update(ctx, obj, opts...)
+		},
+	}, clusterName, presentExport, presentImport, driftedImport)
+	replicationContext, err := util.GetReplicationContext(ctx, r.Client, *ddb)
+	require.NoError(t, err)
+
+	require.NoError(t, r.CreateServiceImportAndExport(ctx, replicationContext, ddb))
+	require.Equal(t, []string{"create cluster-a-cluster-c", "update cluster-c-cluster-a"}, writes)
+
+	exports := &fleetv1alpha1.ServiceExportList{}
+	require.NoError(t, r.Client.List(ctx, exports, client.InNamespace("default")))
+	require.Len(t, exports.Items, 2)
+	updated := &fleetv1alpha1.MultiClusterService{}
+	require.NoError(t, r.Client.Get(ctx, client.ObjectKeyFromObject(driftedImport), updated))
+	require.Equal(t, "cluster-c-cluster-a", updated.Spec.ServiceImport.Name)
+
+	// Once everything is in place nothing is written
+	writes = nil
+	require.NoError(t, r.CreateServiceImportAndExport(ctx, replicationContext, ddb))
+	require.Empty(t, writes)
+}
+
+func TestMemberClusterHosts(t *testing.T) {
+	ctx := context.Background()
+	clusterName := func() *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-name", Namespace: "kube-system"},
+			Data:       map[string]string{"name": "cluster-a"},
+		}
+	}
+	newDocumentDB := func(strategy string) *dbpreview.DocumentDB {
+		ddb := baseDocumentDB("cluster-a", "default")
+		ddb.Spec.ClusterReplication = &dbpreview.ClusterReplication{
+			CrossCloudNetworkingStrategy: strategy,
+			Primary:                      "cluster-a",
+			ClusterList:                  []dbpreview.MemberCluster{{Name: "cluster-a"}, {Name: "cluster-b"}, {Name: "cluster-c"}},
+		}
+		return ddb
+	}
+
+	t.Run("fleet", func(t *testing.T) {
+		ddb := newDocumentDB("AzureFleet")
+		r := buildDocumentDBReconciler(t, interceptor.Funcs{}, clusterName())
+		replicationContext, err := util.GetReplicationContext(ctx, r.Client, *ddb)
+		require.NoError(t, err)
+		require.NoError(t, r.CreateServiceImportAndExport(ctx, replicationContext, ddb))
+
+		// Nothing is imported until fleet networking validates the MultiClusterService
+		require.Equal(t, []string{"10.0.0.1"}, r.memberClusterHosts(ctx, ddb, replicationContext, "default", "10.0.0.1"))
+
+		mcs := &fleetv1alpha1.MultiClusterService{}
+		require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: "cluster-b-cluster-a", Namespace: "default"}, mcs))
+		meta.SetStatusCondition(&mcs.Status.Conditions, metav1.Condition{
+			Type:   string(fleetv1alpha1.MultiClusterServiceValid),
+			Status: metav1.ConditionTrue,
+			Reason: "Found",
+		})
+		require.NoError(t, r.Client.Update(ctx, mcs))
+
+		hosts := r.memberClusterHosts(ctx, ddb, replicationContext, "default", "10.0.0.1")
+		require.Equal(t, []string{"10.0.0.1", "default-cluster-b-cluster-a.fleet-system.svc"}, hosts)
+
+		// The imported host is the one the replica of cluster-b replicates from, and it exposes the gateway
+		cluster := &cnpgv1.Cluster{Spec: cnpgv1.ClusterSpec{InheritedMetadata: &cnpgv1.EmbeddedObjectMetadata{Labels: map[string]string{}}}}
+		require.NoError(t, r.AddClusterReplicationToClusterSpec(ctx, ddb, replicationContext, cluster))
+		require.True(t, slices.ContainsFunc(cluster.Spec.ExternalClusters, func(external cnpgv1.ExternalCluster) bool {
+			return external.ConnectionParameters["host"] == hosts[1]
+		}))
+		for _, service := range cluster.Spec.Managed.Services.Additional {
+			require.Equal(t, []corev1.ServicePort{gatewayServicePort()}, service.ServiceTemplate.Spec.Ports)
+		}
+
+		connectionString := util.GenerateMultiHostConnectionString(ddb, hosts, true)
+		require.Contains(t, connectionString, "@10.0.0.1:10260,default-cluster-b-cluster-a.fleet-system.svc:10260/?")
+	})
+
+	t.Run("istio", func(t *testing.T) {
+		ddb := newDocumentDB("Istio")
+		r := buildDocumentDBReconciler(t, interceptor.Funcs{}, clusterName())
+		replicationContext, err := util.GetReplicationContext(ctx, r.Client, *ddb)
+		require.NoError(t, err)
+		require.NoError(t, r.CreateIstioRemoteServices(ctx, replicationContext, ddb))
+
+		hosts := r.memberClusterHosts(ctx, ddb, replicationContext, "default", "10.0.0.1")
+		require.Equal(t, []string{
+			"10.0.0.1",
+			util.GetDocumentDBServiceName("cluster-b") + ".default.svc",
+			util.GetDocumentDBServiceName("cluster-c") + ".default.svc",
+		}, hosts)
+		service := &corev1.Service{}
+		require.NoError(t, r.Client.Get(ctx, types.NamespacedName{Name: util.GetDocumentDBServiceName("cluster-b"), Namespace: "default"}, service))
+		require.Equal(t, []corev1.ServicePort{gatewayServicePort()}, service.Spec.Ports)
+
+		// Without the gateway clients connect to Postgres through the -rw services
+		ddb.Spec.DisableGateway = true
+		hosts = r.memberClusterHosts(ctx, ddb, replicationContext, "default", "10.0.0.1")
+		require.Equal(t, []string{"10.0.0.1", "cluster-b-rw.default.svc", "cluster-c-rw.default.svc"}, hosts)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		ddb := newDocumentDB("None")
+		replicationContext, err := util.GetReplicationContext(ctx, nil, *ddb)
+		require.NoError(t, err)
+		r := buildDocumentDBReconciler(t, interceptor.Funcs{})
+		require.Equal(t, []string{"10.0.0.1"}, r.memberClusterHosts(ctx, ddb, replicationContext, "default", "10.0.0.1"))
+	})
+}
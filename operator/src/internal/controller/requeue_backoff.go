@@ -0,0 +1,77 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package controller
+
+import (
+	"cmp"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// MaxRequeueAfter caps the backoff of an object that keeps requeueing
+	MaxRequeueAfter = 5 * time.Minute
+
+	// requeueJitterFactor adds up to this fraction of the delay, so objects created together don't retry in lockstep
+	requeueJitterFactor = 0.2
+)
+
+// RequeueConfig holds the base intervals a reconciler requeues after while it waits for other resources.
+// Unset intervals default to DefaultRequeueAfterShort and DefaultRequeueAfterLong.
+type RequeueConfig struct {
+	// Short is the interval for waits expected to end soon, such as a Service getting its IP
+	Short time.Duration
+	// Long is the interval for slower waits, such as a new CNPG Cluster starting up
+	Long time.Duration
+}
+
+func (c RequeueConfig) short() time.Duration {
+	return cmp.Or(c.Short, DefaultRequeueAfterShort)
+}
+
+func (c RequeueConfig) long() time.Duration {
+	return cmp.Or(c.Long, DefaultRequeueAfterLong)
+}
+
+// requeueBackoff turns the fixed RequeueAfter intervals returned by a reconciler into jittered, exponentially
+// growing delays per object. The interval returned by the reconciler is the base delay, and it doubles with
+// every consecutive requeue of the same object until the object reconciles without requeueing.
+type requeueBackoff struct {
+	mu       sync.Mutex
+	requeues map[types.NamespacedName]int
+}
+
+func newRequeueBackoff() *requeueBackoff {
+	return &requeueBackoff{requeues: make(map[types.NamespacedName]int)}
+}
+
+// apply replaces result.RequeueAfter with the backed off delay for the object, or resets the object's
+// backoff when the result doesn't requeue after a delay. A nil backoff leaves the result unchanged.
+func (b *requeueBackoff) apply(key types.NamespacedName, result ctrl.Result) ctrl.Result {
+	if b == nil {
+		return result
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if result.RequeueAfter <= 0 {
+		delete(b.requeues, key)
+		return result
+	}
+
+	delay := result.RequeueAfter
+	for i := 0; i < b.requeues[key] && delay < MaxRequeueAfter; i++ {
+		delay *= 2
+	}
+	delay = min(delay, MaxRequeueAfter)
+	b.requeues[key]++
+
+	result.RequeueAfter = wait.Jitter(delay, requeueJitterFactor)
+	return result
+}
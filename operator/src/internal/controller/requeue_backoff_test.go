@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestRequeueBackoffGrowsWithJitter(t *testing.T) {
+	b := newRequeueBackoff()
+	key := types.NamespacedName{Name: "ddb", Namespace: "default"}
+
+	previous := time.Duration(0)
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := DefaultRequeueAfterShort << attempt
+		if delay > MaxRequeueAfter {
+			delay = MaxRequeueAfter
+		}
+
+		got := b.apply(key, ctrl.Result{RequeueAfter: DefaultRequeueAfterShort}).RequeueAfter
+		require.GreaterOrEqual(t, got, delay, "attempt %d", attempt)
+		require.LessOrEqual(t, got, time.Duration(float64(delay)*(1+requeueJitterFactor)), "attempt %d", attempt)
+		if delay < MaxRequeueAfter {
+			require.Greater(t, got, previous, "attempt %d should back off further", attempt)
+		}
+		previous = got
+	}
+
+	// Other objects back off independently
+	other := types.NamespacedName{Name: "other", Namespace: "default"}
+	got := b.apply(other, ctrl.Result{RequeueAfter: DefaultRequeueAfterShort}).RequeueAfter
+	require.Less(t, got, 2*DefaultRequeueAfterShort)
+
+	// A reconcile that doesn't requeue resets the backoff
+	require.Equal(t, ctrl.Result{}, b.apply(key, ctrl.Result{}))
+	got = b.apply(key, ctrl.Result{RequeueAfter: DefaultRequeueAfterShort}).RequeueAfter
+	require.Less(t, got, 2*DefaultRequeueAfterShort)
+}
+
+func TestRequeueBackoffJittersConcurrentObjects(t *testing.T) {
+	b := newRequeueBackoff()
+
+	// Objects created together must not all requeue at the same moment
+	delays := map[time.Duration]bool{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		key := types.NamespacedName{Name: name, Namespace: "default"}
+		delays[b.apply(key, ctrl.Result{RequeueAfter: DefaultRequeueAfterLong}).RequeueAfter] = true
+	}
+	require.Greater(t, len(delays), 1)
+}
+
+func TestNilRequeueBackoffKeepsResult(t *testing.T) {
+	var b *requeueBackoff
+	result := ctrl.Result{RequeueAfter: DefaultRequeueAfterShort}
+	require.Equal(t, result, b.apply(types.NamespacedName{Name: "ddb"}, result))
+}
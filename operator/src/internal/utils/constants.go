@@ -17,29 +17,133 @@ const (
 	DEFAULT_DOCUMENTDB_IMAGE              = DOCUMENTDB_IMAGE_REPOSITORY + ":16"
 	DEFAULT_GATEWAY_IMAGE                 = DOCUMENTDB_IMAGE_REPOSITORY + ":16"
 	DEFAULT_DOCUMENTDB_CREDENTIALS_SECRET = "documentdb-credentials"
+	DEFAULT_REPLICA_SET_NAME              = "rs0"
+	DEFAULT_AUTH_MECHANISM                = "SCRAM-SHA-256"
+
+	// Role created at bootstrap that owns the databases in spec.databases without an explicit owner
+	DEFAULT_DATABASE_OWNER = "documentdb"
 
 	LABEL_APP                      = "app"
-	LABEL_REPLICA_TYPE             = "replica_type"
 	LABEL_ROLE                     = "role"
 	LABEL_NODE_INDEX               = "node_index"
 	LABEL_SERVICE_TYPE             = "service_type"
 	LABEL_REPLICATION_CLUSTER_TYPE = "replication_cluster_type"
 
-	DOCUMENTDB_SERVICE_PREFIX = "documentdb-service-"
+	// Marks the CNPG Clusters created by the operator, the only ones it deletes once their DocumentDB is gone
+	LABEL_MANAGED_BY               = "app.kubernetes.io/managed-by"
+	MANAGED_BY_DOCUMENTDB_OPERATOR = "documentdb-operator"
+
+	// Name of the Postgres container in CNPG instance pods
+	POSTGRES_CONTAINER_NAME = "postgres"
+
+	// Labels set by CNPG on the pods of a cluster, used to select its instances
+	CNPG_CLUSTER_LABEL       = "cnpg.io/cluster"
+	CNPG_INSTANCE_ROLE_LABEL = "cnpg.io/instanceRole"
+	CNPG_POD_ROLE_LABEL      = "cnpg.io/podRole"
+	CNPG_POD_ROLE_INSTANCE   = "instance"
+
+	DOCUMENTDB_SERVICE_PREFIX        = "documentdb-service-"
+	DOCUMENTDB_READER_SERVICE_SUFFIX = "-ro"
+	DOCUMENTDB_POOLER_SUFFIX         = "-pooler"
+	DOCUMENTDB_NETWORK_POLICY_SUFFIX = "-network-policy"
+	DOCUMENTDB_CLONE_BACKUP_SUFFIX   = "-clone"
+
+	// Database, its owner and the role the member clusters replicate with unless set in spec.clusterReplication
+	DEFAULT_REPLICATION_DATABASE = "postgres"
+	DEFAULT_REPLICATION_OWNER    = "postgres"
+	DEFAULT_REPLICATION_USER     = "postgres"
+
+	// sslmode of the connections between member clusters, without and with a replication TLS secret, and the key
+	// of the CA certificate in CA secrets
+	REPLICATION_SSL_MODE          = "require"
+	REPLICATION_VERIFIED_SSL_MODE = "verify-ca"
+	CA_CERT_SECRET_KEY            = "ca.crt"
+
+	// Name of the CNPG external cluster entry of the server a DocumentDB is bootstrapped from
+	EXTERNAL_SOURCE_CLUSTER_NAME = "external-source"
+
+	// Names of the ports of the DocumentDB services
+	SERVICE_PORT_NAME_GATEWAY  = "gateway"
+	SERVICE_PORT_NAME_POSTGRES = "postgres"
+	SERVICE_PORT_NAME_METRICS  = "metrics"
+
+	// Ports of the CNPG instance manager, which the CNPG operator and monitoring reach on every instance
+	CNPG_STATUS_PORT  = 8000
+	CNPG_METRICS_PORT = 9187
+
+	// Names of Services and other resources must be valid DNS labels; longer names are shortened with a hash
+	MAX_RESOURCE_NAME_LENGTH  = 63
+	RESOURCE_NAME_HASH_LENGTH = 8
+
+	// Annotation enabling TLS passthrough on ingress-nginx, required because the gateway is not an HTTP backend
+	INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION = "nginx.ingress.kubernetes.io/ssl-passthrough"
 
 	DEFAULT_SIDECAR_INJECTOR_PLUGIN = "cnpg-i-sidecar-injector.documentdb.io"
 
 	DEFAULT_WAL_REPLICA_PLUGIN = "cnpg-i-wal-replica.documentdb.io"
 
+	// Sidecar injector plugin parameter carrying the gateway image
+	GATEWAY_IMAGE_PLUGIN_PARAMETER = "gatewayImage"
+
+	// Sidecar injector plugin parameter carrying the name of the gateway credential secret
+	CREDENTIAL_SECRET_PLUGIN_PARAMETER = "documentDbCredentialSecret"
+
+	// Sidecar injector plugin parameter carrying the Postgres port the gateway connects to
+	PG_PORT_PLUGIN_PARAMETER = "pgPort"
+
+	// Sidecar injector plugin parameter carrying the host the gateway connects to instead of the local Postgres
+	PG_HOST_PLUGIN_PARAMETER = "pgHost"
+
+	// Sidecar injector plugin parameter carrying the port the gateway serves metrics on
+	GATEWAY_METRICS_PORT_PLUGIN_PARAMETER = "gatewayMetricsPort"
+
+	// Sidecar injector plugin parameter carrying the gateway log level
+	GATEWAY_LOG_LEVEL_PLUGIN_PARAMETER = "gatewayLogLevel"
+
+	// Annotation recording the namespace/name of the Secret a copied Secret was taken from
+	SOURCE_SECRET_ANNOTATION = "documentdb.io/source-secret"
+
+	// Annotation that makes CNPG perform a rolling restart of the cluster instances
+	CNPG_RESTART_ANNOTATION = "kubectl.kubernetes.io/restartedAt"
+
+	// Annotation on a DocumentDB that limits disruptive changes (image changes, restarts and switchovers) to a recurring window
+	MAINTENANCE_WINDOW_ANNOTATION = "documentdb.io/maintenance-window"
+
+	// Application name used by pg_receivewal in the WAL replica, as listed in synchronous standby names
+	WAL_RECEIVER_STANDBY_NAME = "pg_receivewal"
+
 	CNPG_DEFAULT_STOP_DELAY = 30
 
+	// Seconds allowed for a switchover to complete before it is reverted
+	DEFAULT_SWITCHOVER_TIMEOUT = 900
+
 	// JSON Patch paths
-	JSON_PATCH_PATH_REPLICA_CLUSTER      = "/spec/replica"
-	JSON_PATCH_PATH_POSTGRES_CONFIG      = "/spec/postgresql"
-	JSON_PATCH_PATH_POSTGRES_CONFIG_SYNC = "/spec/postgresql/synchronous"
-	JSON_PATCH_PATH_INSTANCES            = "/spec/instances"
-	JSON_PATCH_PATH_PLUGINS              = "/spec/plugins"
-	JSON_PATCH_PATH_REPLICATION_SLOTS    = "/spec/replicationSlots"
+	JSON_PATCH_PATH_REPLICA_CLUSTER         = "/spec/replica"
+	JSON_PATCH_PATH_POSTGRES_CONFIG         = "/spec/postgresql"
+	JSON_PATCH_PATH_POSTGRES_CONFIG_SYNC    = "/spec/postgresql/synchronous"
+	JSON_PATCH_PATH_INSTANCES               = "/spec/instances"
+	JSON_PATCH_PATH_PLUGINS                 = "/spec/plugins"
+	JSON_PATCH_PATH_REPLICATION_SLOTS       = "/spec/replicationSlots"
+	JSON_PATCH_PATH_STORAGE_SIZE            = "/spec/storage/size"
+	JSON_PATCH_PATH_IMAGE_NAME              = "/spec/imageName"
+	JSON_PATCH_PATH_LOG_LEVEL               = "/spec/logLevel"
+	JSON_PATCH_PATH_MAX_STOP_DELAY          = "/spec/stopDelay"
+	JSON_PATCH_PATH_MAX_START_DELAY         = "/spec/startDelay"
+	JSON_PATCH_PATH_MAX_SWITCHOVER_DELAY    = "/spec/switchoverDelay"
+	JSON_PATCH_PATH_POSTGRES_PARAMETERS     = "/spec/postgresql/parameters"
+	JSON_PATCH_PATH_SUPERUSER_ACCESS        = "/spec/enableSuperuserAccess"
+	JSON_PATCH_PATH_SUPERUSER_SECRET        = "/spec/superuserSecret"
+	JSON_PATCH_PATH_PRIORITY_CLASS_NAME     = "/spec/priorityClassName"
+	JSON_PATCH_PATH_PRIMARY_UPDATE_STRATEGY = "/spec/primaryUpdateStrategy"
+	JSON_PATCH_PATH_PRIMARY_UPDATE_METHOD   = "/spec/primaryUpdateMethod"
+	JSON_PATCH_PATH_IMAGE_PULL_SECRETS      = "/spec/imagePullSecrets"
+	JSON_PATCH_PATH_INHERITED_METADATA      = "/spec/inheritedMetadata"
+	JSON_PATCH_PATH_BACKUP                  = "/spec/backup"
+	JSON_PATCH_PATH_BARMAN_OBJECT_STORE     = "/spec/backup/barmanObjectStore"
+	JSON_PATCH_PATH_ANNOTATIONS             = "/metadata/annotations"
+	JSON_PATCH_PATH_LABELS                  = "/metadata/labels"
+	JSON_PATCH_PATH_MANAGED                 = "/spec/managed"
+	JSON_PATCH_PATH_MANAGED_ROLES           = "/spec/managed/roles"
 
 	// JSON Patch operations
 	JSON_PATCH_OP_REPLACE = "replace"
@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package util
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// MaintenanceWindow is a recurring period, in UTC, during which disruptive changes may be applied.
+type MaintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// ParseMaintenanceWindow parses the value of the maintenance window annotation. Two forms are accepted:
+//   - a daily time range "HH:MM-HH:MM", which may wrap around midnight, e.g. "22:00-02:00"
+//   - a standard cron expression followed by the window duration, e.g. "0 2 * * SAT 4h" or "@daily 1h30m"
+func ParseMaintenanceWindow(value string) (*MaintenanceWindow, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("maintenance window is empty")
+	}
+
+	if start, end, found := strings.Cut(value, "-"); found && !strings.ContainsAny(value, " \t") {
+		return parseMaintenanceTimeRange(start, end)
+	}
+
+	separator := strings.LastIndexAny(value, " \t")
+	if separator < 0 {
+		return nil, fmt.Errorf("maintenance window %q must be a time range HH:MM-HH:MM or a cron expression followed by a duration", value)
+	}
+	duration, err := time.ParseDuration(value[separator+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window duration in %q: %w", value, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("maintenance window duration in %q must be positive", value)
+	}
+	schedule, err := cron.ParseStandard(strings.TrimSpace(value[:separator]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window schedule in %q: %w", value, err)
+	}
+	return &MaintenanceWindow{schedule: schedule, duration: duration}, nil
+}
+
+// parseMaintenanceTimeRange turns a daily "HH:MM-HH:MM" range into a schedule starting at the first time
+func parseMaintenanceTimeRange(start, end string) (*MaintenanceWindow, error) {
+	startTime, err := time.Parse("15:04", strings.TrimSpace(start))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window start %q: %w", start, err)
+	}
+	endTime, err := time.Parse("15:04", strings.TrimSpace(end))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window end %q: %w", end, err)
+	}
+
+	duration := endTime.Sub(startTime)
+	if duration < 0 {
+		duration += 24 * time.Hour
+	}
+	if duration == 0 {
+		return nil, fmt.Errorf("maintenance window %s-%s is empty", start, end)
+	}
+
+	schedule, err := cron.ParseStandard(fmt.Sprintf("%d %d * * *", startTime.Minute(), startTime.Hour()))
+	if err != nil {
+		return nil, err
+	}
+	return &MaintenanceWindow{schedule: schedule, duration: duration}, nil
+}
+
+// Contains reports whether t falls inside an occurrence of the window.
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	// The window is open if it started within the last duration
+	return !w.schedule.Next(t.Add(-w.duration)).After(t)
+}
+
+// NextOpen returns t if the window is open at t, or else the time the window next opens.
+func (w *MaintenanceWindow) NextOpen(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	return w.schedule.Next(t.UTC())
+}
@@ -0,0 +1,116 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	// Saturday
+	base := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return base.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name             string
+		value            string
+		now              time.Time
+		expectedContains bool
+		expectedNextOpen time.Time
+	}{
+		{
+			name:             "inside a daily range",
+			value:            "02:00-04:00",
+			now:              at(3, 0),
+			expectedContains: true,
+			expectedNextOpen: at(3, 0),
+		},
+		{
+			name:             "before a daily range",
+			value:            "02:00-04:00",
+			now:              at(1, 59),
+			expectedNextOpen: at(2, 0),
+		},
+		{
+			name:             "end of a daily range is excluded",
+			value:            "02:00-04:00",
+			now:              at(4, 0),
+			expectedNextOpen: at(26, 0),
+		},
+		{
+			name:             "range wrapping midnight after midnight",
+			value:            "22:00-02:00",
+			now:              at(1, 0),
+			expectedContains: true,
+			expectedNextOpen: at(1, 0),
+		},
+		{
+			name:             "range wrapping midnight during the day",
+			value:            "22:00-02:00",
+			now:              at(12, 0),
+			expectedNextOpen: at(22, 0),
+		},
+		{
+			name:             "weekly cron window",
+			value:            "0 2 * * SAT 4h",
+			now:              at(5, 30),
+			expectedContains: true,
+			expectedNextOpen: at(5, 30),
+		},
+		{
+			name:             "weekly cron window closed",
+			value:            "0 2 * * SAT 4h",
+			now:              at(6, 0),
+			expectedNextOpen: at(7*24+2, 0),
+		},
+		{
+			name:             "descriptor with duration",
+			value:            "@daily 1h30m",
+			now:              at(1, 0),
+			expectedContains: true,
+			expectedNextOpen: at(1, 0),
+		},
+		{
+			name:             "other time zones are converted to UTC",
+			value:            "02:00-04:00",
+			now:              at(3, 0).In(time.FixedZone("UTC+5", 5*60*60)),
+			expectedContains: true,
+			expectedNextOpen: at(3, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := ParseMaintenanceWindow(tt.value)
+			if err != nil {
+				t.Fatalf("ParseMaintenanceWindow(%q) returned error: %v", tt.value, err)
+			}
+			if got := window.Contains(tt.now); got != tt.expectedContains {
+				t.Errorf("Contains(%s) = %v, expected %v", tt.now, got, tt.expectedContains)
+			}
+			if got := window.NextOpen(tt.now); !got.Equal(tt.expectedNextOpen) {
+				t.Errorf("NextOpen(%s) = %s, expected %s", tt.now, got, tt.expectedNextOpen)
+			}
+		})
+	}
+}
+
+func TestParseMaintenanceWindowInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"02:00",
+		"02:00-02:00",
+		"25:00-04:00",
+		"0 2 * * SAT",
+		"0 2 * * SAT -1h",
+		"0 2 * * NOPE 4h",
+	} {
+		if _, err := ParseMaintenanceWindow(value); err == nil {
+			t.Errorf("expected ParseMaintenanceWindow(%q) to fail", value)
+		}
+	}
+}
@@ -20,6 +20,8 @@ type ReplicationContext struct {
 	CrossCloudNetworkingStrategy crossCloudNetworkingStrategy
 	Environment                  string
 	StorageClass                 string
+	IncludeWalReceiverInQuorum   bool
+	SynchronousQuorumPercent     int
 	currentLocalPrimary          string
 	targetLocalPrimary           string
 	state                        replicationState
@@ -86,26 +88,31 @@ func GetReplicationContext(ctx context.Context, client client.Client, documentdb
 		PrimaryRegion:                primaryRegion,
 		Environment:                  environment,
 		StorageClass:                 storageClass,
+		IncludeWalReceiverInQuorum:   documentdb.Spec.ClusterReplication.IncludeWalReceiverInQuorum,
+		SynchronousQuorumPercent:     documentdb.Spec.ClusterReplication.SynchronousQuorumPercent,
 		state:                        state,
 		targetLocalPrimary:           documentdb.Status.TargetPrimary,
 		currentLocalPrimary:          documentdb.Status.LocalPrimary,
 	}, nil
 }
 
-// String implements fmt.Stringer interface for better logging output
-func (r ReplicationContext) String() string {
-	stateStr := ""
+// Role returns the replication role of this member cluster: NoReplication, Primary or Replica.
+func (r ReplicationContext) Role() string {
 	switch r.state {
 	case NoReplication:
-		stateStr = "NoReplication"
+		return "NoReplication"
 	case Primary:
-		stateStr = "Primary"
+		return "Primary"
 	case Replica:
-		stateStr = "Replica"
+		return "Replica"
 	}
+	return ""
+}
 
+// String implements fmt.Stringer interface for better logging output
+func (r ReplicationContext) String() string {
 	return fmt.Sprintf("ReplicationContext{Self: %s, State: %s, Others: %v, PrimaryRegion: %s, CurrentLocalPrimary: %s, TargetLocalPrimary: %s}",
-		r.Self, stateStr, r.Others, r.PrimaryRegion, r.currentLocalPrimary, r.targetLocalPrimary)
+		r.Self, r.Role(), r.Others, r.PrimaryRegion, r.currentLocalPrimary, r.targetLocalPrimary)
 }
 
 // Returns true if this instance is the primary or if there is no replication configured.
@@ -140,7 +147,7 @@ func (r ReplicationContext) GenerateExternalClusterServices(namespace string, fl
 		for _, other := range r.Others {
 			serviceName := other + "-rw." + namespace + ".svc"
 			if fleetEnabled {
-				serviceName = namespace + "-" + generateServiceName(other, r.Self, namespace) + ".fleet-system.svc"
+				serviceName = FleetImportedServiceHost(namespace, r.IncomingServiceName(other, namespace))
 			}
 
 			if !yield(other, serviceName) {
@@ -150,11 +157,21 @@ func (r ReplicationContext) GenerateExternalClusterServices(namespace string, fl
 	}
 }
 
+// IncomingServiceName returns the name of the fleet service of the other member cluster imported into this one
+func (r ReplicationContext) IncomingServiceName(other, resourceGroup string) string {
+	return generateServiceName(other, r.Self, resourceGroup)
+}
+
+// FleetImportedServiceHost returns the host of the service fleet networking derives from a MultiClusterService
+func FleetImportedServiceHost(namespace, serviceName string) string {
+	return namespace + "-" + serviceName + ".fleet-system.svc"
+}
+
 // Create an iterator that yields outgoing service names, for use in a for each loop
 func (r ReplicationContext) GenerateIncomingServiceNames(resourceGroup string) func(yield func(string) bool) {
 	return func(yield func(string) bool) {
 		for _, other := range r.Others {
-			serviceName := generateServiceName(other, r.Self, resourceGroup)
+			serviceName := r.IncomingServiceName(other, resourceGroup)
 			if !yield(serviceName) {
 				break
 			}
@@ -189,15 +206,33 @@ func generateServiceName(source, target, resourceGroup string) string {
 }
 
 // Creates the standby names list, which will be all other clusters in addition to "pg_receivewal"
+// when the WAL receiver participates in the quorum
 func (r *ReplicationContext) CreateStandbyNamesList() []string {
-	standbyNames := make([]string, len(r.Others)+1)
-	copy(standbyNames, r.Others)
-	/* TODO re-enable when we have a WAL replica image
-	standbyNames[len(r.Others)] = "pg_receivewal"
-	*/
+	standbyNames := make([]string, 0, len(r.Others)+1)
+	standbyNames = append(standbyNames, r.Others...)
+	if r.IncludeWalReceiverInQuorum {
+		standbyNames = append(standbyNames, WAL_RECEIVER_STANDBY_NAME)
+	}
 	return standbyNames
 }
 
+// SynchronousStandbyNumber returns the number of synchronous standbys required for quorum writes, given the
+// number of local standby instances. The quorum is SynchronousQuorumPercent of the available standbys (rounded up),
+// or a simple majority when unset, and never exceeds the number of standbys that actually exist. It is 0 when there
+// are no standbys, in which case synchronous replication must stay unset or every write would block.
+func (r *ReplicationContext) SynchronousStandbyNumber(localStandbys int) int {
+	available := len(r.CreateStandbyNamesList()) + max(localStandbys, 0)
+	if available == 0 {
+		return 0
+	}
+
+	quorum := available/2 + 1
+	if r.SynchronousQuorumPercent > 0 {
+		quorum = (available*r.SynchronousQuorumPercent + 99) / 100
+	}
+	return max(min(quorum, available), 1)
+}
+
 func splitSelfAndOthers(ctx context.Context, client client.Client, documentdb dbpreview.DocumentDB) (*dbpreview.MemberCluster, []string, error) {
 	selfName := documentdb.Name
 	var err error
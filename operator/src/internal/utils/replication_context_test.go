@@ -0,0 +1,91 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCreateStandbyNamesListAndSynchronousNumber(t *testing.T) {
+	tests := []struct {
+		name           string
+		others         []string
+		includeWal     bool
+		quorumPercent  int
+		localStandbys  int
+		expectedNames  []string
+		expectedNumber int
+	}{
+		{
+			name:           "one peer without WAL receiver uses majority",
+			others:         []string{"cluster-b"},
+			localStandbys:  2,
+			expectedNames:  []string{"cluster-b"},
+			expectedNumber: 2,
+		},
+		{
+			name:           "one peer with WAL receiver uses majority",
+			others:         []string{"cluster-b"},
+			includeWal:     true,
+			localStandbys:  2,
+			expectedNames:  []string{"cluster-b", WAL_RECEIVER_STANDBY_NAME},
+			expectedNumber: 3,
+		},
+		{
+			name:           "three peers use majority",
+			others:         []string{"cluster-b", "cluster-c", "cluster-d"},
+			localStandbys:  2,
+			expectedNames:  []string{"cluster-b", "cluster-c", "cluster-d"},
+			expectedNumber: 3,
+		},
+		{
+			name:           "quorum percent rounds up",
+			others:         []string{"cluster-b", "cluster-c", "cluster-d"},
+			quorumPercent:  25,
+			localStandbys:  2,
+			expectedNames:  []string{"cluster-b", "cluster-c", "cluster-d"},
+			expectedNumber: 2,
+		},
+		{
+			name:           "full quorum never exceeds available standbys",
+			others:         []string{"cluster-b"},
+			quorumPercent:  100,
+			localStandbys:  2,
+			expectedNames:  []string{"cluster-b"},
+			expectedNumber: 3,
+		},
+		{
+			name:           "requires at least one standby",
+			others:         []string{"cluster-b"},
+			quorumPercent:  1,
+			localStandbys:  0,
+			expectedNames:  []string{"cluster-b"},
+			expectedNumber: 1,
+		},
+		{
+			name:           "no standbys leaves synchronous replication unset",
+			others:         []string{},
+			localStandbys:  0,
+			expectedNames:  []string{},
+			expectedNumber: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := &ReplicationContext{Others: tt.others, IncludeWalReceiverInQuorum: tt.includeWal, SynchronousQuorumPercent: tt.quorumPercent}
+
+			names := rc.CreateStandbyNamesList()
+			if !reflect.DeepEqual(names, tt.expectedNames) {
+				t.Errorf("CreateStandbyNamesList() = %v, want %v", names, tt.expectedNames)
+			}
+
+			number := rc.SynchronousStandbyNumber(tt.localStandbys)
+			if number != tt.expectedNumber {
+				t.Errorf("SynchronousStandbyNumber(%d) = %d, want %d", tt.localStandbys, number, tt.expectedNumber)
+			}
+		})
+	}
+}
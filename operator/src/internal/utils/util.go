@@ -4,18 +4,30 @@
 package util
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"maps"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/version"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -36,16 +48,12 @@ func GetDocumentDBServiceDefinition(documentdb *dbpreview.DocumentDB, replicatio
 	}
 	if replicationContext.EndpointEnabled() {
 		selector = map[string]string{
-			LABEL_APP:              documentdb.Name,
-			"cnpg.io/instanceRole": "primary", // Service forwards traffic to CNPG primary instance
+			CNPG_CLUSTER_LABEL:       documentdb.Name, // The CNPG Cluster is named after the DocumentDB instance
+			CNPG_INSTANCE_ROLE_LABEL: "primary",       // Service forwards traffic to CNPG primary instance
 		}
 	}
 
-	// Ensure service name doesn't exceed 63 characters (Kubernetes limit)
-	serviceName := DOCUMENTDB_SERVICE_PREFIX + replicationContext.Self
-	if len(serviceName) > 63 {
-		serviceName = serviceName[:63]
-	}
+	serviceName := GetDocumentDBServiceName(replicationContext.Self)
 
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -65,21 +73,334 @@ func GetDocumentDBServiceDefinition(documentdb *dbpreview.DocumentDB, replicatio
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: selector,
-			Ports: []corev1.ServicePort{
-				{Name: "gateway", Protocol: corev1.ProtocolTCP, Port: GetPortFor(GATEWAY_PORT), TargetPort: intstr.FromInt(int(GetPortFor(GATEWAY_PORT)))},
-			},
-			Type: serviceType,
+			Ports:    getServicePorts(documentdb),
+			Type:     serviceType,
 		},
 	}
 
-	// Add environment-specific annotations for LoadBalancer services
-	if serviceType == corev1.ServiceTypeLoadBalancer {
+	if documentdb.Spec.ExposeViaService.IPFamilyPolicy != "" {
+		ipFamilyPolicy := corev1.IPFamilyPolicy(documentdb.Spec.ExposeViaService.IPFamilyPolicy)
+		service.Spec.IPFamilyPolicy = &ipFamilyPolicy
+	}
+
+	// The external traffic policy only applies to services reachable from outside the cluster
+	if serviceType == corev1.ServiceTypeLoadBalancer && documentdb.Spec.ExposeViaService.ExternalTrafficPolicy != "" {
+		service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicy(documentdb.Spec.ExposeViaService.ExternalTrafficPolicy)
+		service.Spec.HealthCheckNodePort = documentdb.Spec.ExposeViaService.HealthCheckNodePort
+	}
+
+	// Add environment-specific annotations for LoadBalancer services, unless the user manages them
+	if serviceType == corev1.ServiceTypeLoadBalancer && !documentdb.Spec.ExposeViaService.DisableDefaultAnnotations {
 		service.ObjectMeta.Annotations = getEnvironmentSpecificAnnotations(replicationContext.Environment)
 	}
+	if len(documentdb.Spec.ExposeViaService.Annotations) > 0 {
+		if service.ObjectMeta.Annotations == nil {
+			service.ObjectMeta.Annotations = map[string]string{}
+		}
+		maps.Copy(service.ObjectMeta.Annotations, documentdb.Spec.ExposeViaService.Annotations)
+	}
 
 	return service
 }
 
+// getServicePorts returns the ports of the DocumentDB services for the enabled features: the gateway, or Postgres
+// when the gateway is disabled, and the gateway metrics when a metrics port is set. Each port has a stable name, so
+// the node ports allocated for it are kept when other ports are added or removed.
+func getServicePorts(documentdb *dbpreview.DocumentDB) []corev1.ServicePort {
+	servicePort := func(name string, port int32) corev1.ServicePort {
+		return corev1.ServicePort{Name: name, Protocol: corev1.ProtocolTCP, Port: port, TargetPort: intstr.FromInt32(port)}
+	}
+
+	if documentdb.Spec.DisableGateway {
+		return []corev1.ServicePort{servicePort(SERVICE_PORT_NAME_POSTGRES, GetPortFor(POSTGRES_PORT))}
+	}
+
+	ports := []corev1.ServicePort{servicePort(SERVICE_PORT_NAME_GATEWAY, GetPortFor(GATEWAY_PORT))}
+	if metricsPort := documentdb.Spec.GatewayMetricsPort; metricsPort != 0 {
+		ports = append(ports, servicePort(SERVICE_PORT_NAME_METRICS, metricsPort))
+	}
+	return ports
+}
+
+// GetDocumentDBReaderServiceDefinition returns the read-only Service definition for a given DocumentDB instance.
+// It mirrors the primary service but forwards traffic to CNPG replica instances.
+func GetDocumentDBReaderServiceDefinition(documentdb *dbpreview.DocumentDB, replicationContext *ReplicationContext, namespace string, serviceType corev1.ServiceType) *corev1.Service {
+	service := GetDocumentDBServiceDefinition(documentdb, replicationContext, namespace, serviceType)
+	service.Name = GetDocumentDBReaderServiceName(replicationContext.Self)
+	if replicationContext.EndpointEnabled() {
+		service.Spec.Selector[CNPG_INSTANCE_ROLE_LABEL] = "replica" // Service forwards traffic to CNPG replica instances
+	}
+	return service
+}
+
+// GetDocumentDBServiceName returns the name of the gateway Service within the 63 character Kubernetes limit
+func GetDocumentDBServiceName(self string) string {
+	return resourceName(DOCUMENTDB_SERVICE_PREFIX+self, "", DOCUMENTDB_SERVICE_PREFIX+self)
+}
+
+// GetDocumentDBReaderServiceName returns the name of the read-only Service, keeping the suffix within the 63 character limit
+func GetDocumentDBReaderServiceName(self string) string {
+	return resourceName(DOCUMENTDB_SERVICE_PREFIX+self, DOCUMENTDB_READER_SERVICE_SUFFIX, DOCUMENTDB_SERVICE_PREFIX+self)
+}
+
+// GetDocumentDBPoolerName returns the name of the CNPG Pooler, and of its Service, for the given CNPG cluster
+func GetDocumentDBPoolerName(clusterName string) string {
+	return resourceName(clusterName, DOCUMENTDB_POOLER_SUFFIX, clusterName)
+}
+
+// GetDocumentDBNetworkPolicyName returns the name of the NetworkPolicy of the pods of the given CNPG cluster
+func GetDocumentDBNetworkPolicyName(clusterName string) string {
+	return resourceName(clusterName, DOCUMENTDB_NETWORK_POLICY_SUFFIX, clusterName)
+}
+
+// GetCloneBackupName returns the name of the Backup of the source that a cloned DocumentDB is restored from
+func GetCloneBackupName(documentdbName string) string {
+	return resourceName(documentdbName, DOCUMENTDB_CLONE_BACKUP_SUFFIX, documentdbName)
+}
+
+// invalidResourceNameCharacters matches the characters that can't appear in a Kubernetes resource name
+var invalidResourceNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// GetDocumentDBDatabaseName returns the name of the CNPG Database for a Postgres database of the given CNPG cluster.
+// Characters that aren't allowed in resource names are replaced with "-", within the 63 character Kubernetes limit.
+func GetDocumentDBDatabaseName(clusterName, database string) string {
+	source := clusterName + "-" + database
+	name := strings.Trim(invalidResourceNameCharacters.ReplaceAllString(strings.ToLower(source), "-"), "-")
+	return resourceName(name, "", source)
+}
+
+// resourceName returns name followed by suffix when it fits within the 63 character Kubernetes limit and name is
+// source unchanged. Otherwise name is truncated and followed by a hash of source, so that different sources that
+// share a prefix, or differ only in characters replaced in name, don't end up with the same resource name.
+func resourceName(name, suffix, source string) string {
+	if name == source && len(name)+len(suffix) <= MAX_RESOURCE_NAME_LENGTH {
+		return name + suffix
+	}
+	hash := sha256.Sum256([]byte(source))
+	hashSuffix := "-" + hex.EncodeToString(hash[:])[:RESOURCE_NAME_HASH_LENGTH]
+	if maxLen := MAX_RESOURCE_NAME_LENGTH - len(hashSuffix) - len(suffix); len(name) > maxLen {
+		name = name[:maxLen]
+	}
+	return strings.TrimRight(name, "-") + hashSuffix + suffix
+}
+
+// GetDocumentDBIngressDefinition returns the Ingress definition routing the configured host to the gateway Service.
+// The gateway terminates TLS itself, so the Ingress relies on the ingress controller passing TLS through.
+func GetDocumentDBIngressDefinition(documentdb *dbpreview.DocumentDB, replicationContext *ReplicationContext, namespace string) *networkingv1.Ingress {
+	ingressConfig := documentdb.Spec.ExposeViaService.Ingress
+	serviceName := GetDocumentDBServiceName(replicationContext.Self)
+	pathType := networkingv1.PathTypeImplementationSpecific
+
+	annotations := map[string]string{
+		INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION: "true",
+	}
+	for key, value := range ingressConfig.Annotations {
+		annotations[key] = value
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serviceName,
+			Namespace:   namespace,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         documentdb.APIVersion,
+					Kind:               documentdb.Kind,
+					Name:               documentdb.Name,
+					UID:                documentdb.UID,
+					Controller:         &[]bool{true}[0],
+					BlockOwnerDeletion: &[]bool{true}[0],
+				},
+			},
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: ingressConfig.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: serviceName,
+											Port: networkingv1.ServiceBackendPort{Number: GetClientPort(documentdb)},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if ingressConfig.IngressClassName != "" {
+		ingress.Spec.IngressClassName = &ingressConfig.IngressClassName
+	}
+	if ingressConfig.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{Hosts: []string{ingressConfig.Host}, SecretName: ingressConfig.TLSSecretName},
+		}
+	}
+
+	return ingress
+}
+
+// UpsertIngress creates the Ingress if it does not exist, or updates its spec and managed annotations when they drift
+// from the desired state. Annotations added by others, such as ingress controllers or cert-manager, are kept.
+func UpsertIngress(ctx context.Context, c client.Client, ingress *networkingv1.Ingress) error {
+	foundIngress := &networkingv1.Ingress{}
+	err := c.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, foundIngress)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if err := c.Create(ctx, ingress); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			return nil
+		}
+		return err
+	}
+
+	annotationsMatch := true
+	for key, value := range ingress.Annotations {
+		if existing, ok := foundIngress.Annotations[key]; !ok || existing != value {
+			annotationsMatch = false
+		}
+	}
+	if annotationsMatch && equality.Semantic.DeepEqual(foundIngress.Spec, ingress.Spec) {
+		return nil
+	}
+
+	foundIngress.Spec = ingress.Spec
+	if len(ingress.Annotations) > 0 && foundIngress.Annotations == nil {
+		foundIngress.Annotations = map[string]string{}
+	}
+	maps.Copy(foundIngress.Annotations, ingress.Annotations)
+	return c.Update(ctx, foundIngress)
+}
+
+// DeleteIngress deletes the Ingress with the given name in the specified namespace
+func DeleteIngress(ctx context.Context, c client.Client, name, namespace string) error {
+	ingress := &networkingv1.Ingress{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, ingress)
+	if err == nil {
+		if err := c.Delete(ctx, ingress); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDocumentDBNetworkPolicyDefinition returns the NetworkPolicy of the instances of the CNPG cluster. The gateway
+// accepts connections from the pods selected in the spec and from its IP blocks, Postgres only from the pods of the
+// cluster, such as its replicas and poolers, and the instance manager ports stay open for the CNPG operator and
+// monitoring.
+func GetDocumentDBNetworkPolicyDefinition(documentdb *dbpreview.DocumentDB, clusterName, namespace string) *networkingv1.NetworkPolicy {
+	policyConfig := documentdb.Spec.NetworkPolicy
+	tcp := corev1.ProtocolTCP
+	port := func(number int32) networkingv1.NetworkPolicyPort {
+		portNumber := intstr.FromInt32(number)
+		return networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &portNumber}
+	}
+
+	gatewayPeer := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: policyConfig.NamespaceSelector,
+		PodSelector:       policyConfig.PodSelector,
+	}
+	if gatewayPeer.NamespaceSelector == nil && gatewayPeer.PodSelector == nil {
+		// An empty pod selector selects every pod in the namespace of the policy
+		gatewayPeer.PodSelector = &metav1.LabelSelector{}
+	}
+	gatewayPeers := []networkingv1.NetworkPolicyPeer{gatewayPeer}
+	for _, block := range policyConfig.IPBlocks {
+		gatewayPeers = append(gatewayPeers, networkingv1.NetworkPolicyPeer{IPBlock: block.DeepCopy()})
+	}
+
+	instanceManagerPorts := []networkingv1.NetworkPolicyPort{port(CNPG_STATUS_PORT), port(CNPG_METRICS_PORT)}
+	if metricsPort := documentdb.Spec.GatewayMetricsPort; metricsPort != 0 {
+		instanceManagerPorts = append(instanceManagerPorts, port(metricsPort))
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetDocumentDBNetworkPolicyName(clusterName),
+			Namespace: namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         documentdb.APIVersion,
+					Kind:               documentdb.Kind,
+					Name:               documentdb.Name,
+					UID:                documentdb.UID,
+					Controller:         &[]bool{true}[0],
+					BlockOwnerDeletion: &[]bool{true}[0],
+				},
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					CNPG_CLUSTER_LABEL:  clusterName,
+					CNPG_POD_ROLE_LABEL: CNPG_POD_ROLE_INSTANCE,
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{port(GetClientPort(documentdb))},
+					From:  gatewayPeers,
+				},
+				{
+					Ports: []networkingv1.NetworkPolicyPort{port(GetPortFor(POSTGRES_PORT))},
+					From: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{CNPG_CLUSTER_LABEL: clusterName}}},
+					},
+				},
+				{
+					Ports: instanceManagerPorts,
+				},
+			},
+		},
+	}
+}
+
+// UpsertNetworkPolicy creates the NetworkPolicy if it does not exist, or updates its spec to match the desired state.
+func UpsertNetworkPolicy(ctx context.Context, c client.Client, policy *networkingv1.NetworkPolicy) error {
+	foundPolicy := &networkingv1.NetworkPolicy{}
+	err := c.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, foundPolicy)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if err := c.Create(ctx, policy); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			return nil
+		}
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(foundPolicy.Spec, policy.Spec) {
+		return nil
+	}
+	foundPolicy.Spec = policy.Spec
+	return c.Update(ctx, foundPolicy)
+}
+
+// DeleteNetworkPolicy deletes the NetworkPolicy with the given name in the specified namespace
+func DeleteNetworkPolicy(ctx context.Context, c client.Client, name, namespace string) error {
+	policy := &networkingv1.NetworkPolicy{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, policy)
+	if err == nil {
+		if err := c.Delete(ctx, policy); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 // getEnvironmentSpecificAnnotations returns the appropriate service annotations based on the environment
 func getEnvironmentSpecificAnnotations(environment string) map[string]string {
 	switch environment {
@@ -107,6 +428,56 @@ func getEnvironmentSpecificAnnotations(environment string) map[string]string {
 	}
 }
 
+// providerIDEnvironments maps node provider ID prefixes to the corresponding DocumentDB environment
+var providerIDEnvironments = map[string]string{
+	"aws://":   "eks",
+	"azure://": "aks",
+	"gce://":   "gke",
+}
+
+// DetectEnvironment infers the cloud environment from the provider IDs of the cluster nodes.
+// It returns an empty string when there are no nodes, a node has an unknown provider, or the nodes disagree.
+func DetectEnvironment(ctx context.Context, c client.Client) (string, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return "", err
+	}
+
+	detected := ""
+	for i, node := range nodes.Items {
+		environment := ""
+		for prefix, env := range providerIDEnvironments {
+			if strings.HasPrefix(node.Spec.ProviderID, prefix) {
+				environment = env
+				break
+			}
+		}
+		if environment == "" || (i > 0 && environment != detected) {
+			return "", nil
+		}
+		detected = environment
+	}
+	return detected, nil
+}
+
+// GetServiceHost returns the address clients reach the Service on, the load balancer IP or hostname for
+// LoadBalancer services and the cluster IP otherwise, or "" when none is assigned yet. Unlike EnsureServiceIP
+// it doesn't wait for the address.
+func GetServiceHost(service *corev1.Service) string {
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if host := cmp.Or(ingress.IP, ingress.Hostname); host != "" {
+				return host
+			}
+		}
+		return ""
+	}
+	if service.Spec.ClusterIP == corev1.ClusterIPNone {
+		return ""
+	}
+	return service.Spec.ClusterIP
+}
+
 // EnsureServiceIP ensures that the Service has an IP assigned and returns it, or returns an error if not available
 func EnsureServiceIP(ctx context.Context, service *corev1.Service) (string, error) {
 	if service == nil {
@@ -144,6 +515,23 @@ func EnsureServiceIP(ctx context.Context, service *corev1.Service) (string, erro
 	return "", fmt.Errorf("unsupported service type: %s", service.Spec.Type)
 }
 
+// ServiceHasReadyEndpoints reports whether any EndpointSlice of the Service lists a ready endpoint, i.e. whether a
+// gateway behind it is accepting connections. Endpoints without a ready condition are considered ready.
+func ServiceHasReadyEndpoints(ctx context.Context, c client.Client, service *corev1.Service) (bool, error) {
+	endpointSlices := &discoveryv1.EndpointSliceList{}
+	if err := c.List(ctx, endpointSlices, client.InNamespace(service.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: service.Name}); err != nil {
+		return false, err
+	}
+	for _, slice := range endpointSlices.Items {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // UpsertService checks if the Service already exists, and creates it if not.
 func UpsertService(ctx context.Context, c client.Client, service *corev1.Service) (*corev1.Service, error) {
 	log := log.FromContext(ctx)
@@ -164,13 +552,66 @@ func UpsertService(ctx context.Context, c client.Client, service *corev1.Service
 			return nil, err
 		}
 	} else {
-		if err := c.Update(ctx, foundService); err != nil {
-			return nil, err
+		desiredService := desiredServiceState(foundService, service)
+		if !equality.Semantic.DeepEqual(desiredService.Spec, foundService.Spec) || !equality.Semantic.DeepEqual(desiredService.Annotations, foundService.Annotations) {
+			log.Info("Service drifted from the desired state. Patching it: ", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
+			if err := c.Patch(ctx, desiredService, client.MergeFrom(foundService)); err != nil {
+				return nil, err
+			}
+			foundService = desiredService
 		}
 	}
 	return foundService, nil
 }
 
+// desiredServiceState applies the managed fields of the desired Service to a copy of the existing one.
+// Fields allocated by the API server, such as the cluster IPs and node ports, are preserved while the type still
+// uses them, and annotations added by others (e.g. cloud controllers) are kept alongside the managed ones.
+func desiredServiceState(existing, desired *corev1.Service) *corev1.Service {
+	updated := existing.DeepCopy()
+	updated.Spec.Type = desired.Spec.Type
+	usesNodePorts := desired.Spec.Type == corev1.ServiceTypeNodePort || desired.Spec.Type == corev1.ServiceTypeLoadBalancer
+	updated.Spec.Selector = desired.Spec.Selector
+	if desired.Spec.IPFamilyPolicy != nil {
+		updated.Spec.IPFamilyPolicy = desired.Spec.IPFamilyPolicy
+	}
+	if desired.Spec.ExternalTrafficPolicy != "" {
+		updated.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+		if desired.Spec.HealthCheckNodePort != 0 || desired.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyLocal {
+			updated.Spec.HealthCheckNodePort = desired.Spec.HealthCheckNodePort
+		}
+	}
+
+	updated.Spec.Ports = make([]corev1.ServicePort, 0, len(desired.Spec.Ports))
+	for _, port := range desired.Spec.Ports {
+		for _, existingPort := range existing.Spec.Ports {
+			if usesNodePorts && existingPort.Name == port.Name && port.NodePort == 0 {
+				port.NodePort = existingPort.NodePort
+			}
+		}
+		updated.Spec.Ports = append(updated.Spec.Ports, port)
+	}
+
+	// The API server rejects these fields on types that don't use them, e.g. after a LoadBalancer becomes ClusterIP
+	if !usesNodePorts {
+		updated.Spec.ExternalTrafficPolicy = ""
+		updated.Spec.HealthCheckNodePort = 0
+	}
+	if desired.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		updated.Spec.HealthCheckNodePort = 0
+		updated.Spec.AllocateLoadBalancerNodePorts = nil
+		updated.Spec.LoadBalancerClass = nil
+	}
+
+	if len(desired.Annotations) > 0 && updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	for key, value := range desired.Annotations {
+		updated.Annotations[key] = value
+	}
+	return updated
+}
+
 func GetPortFor(name string) int32 {
 	switch name {
 	case POSTGRES_PORT:
@@ -184,6 +625,14 @@ func GetPortFor(name string) int32 {
 	}
 }
 
+// GetClientPort returns the port clients connect to: the gateway port, or the Postgres port when the gateway is disabled
+func GetClientPort(documentdb *dbpreview.DocumentDB) int32 {
+	if documentdb.Spec.DisableGateway {
+		return GetPortFor(POSTGRES_PORT)
+	}
+	return GetPortFor(GATEWAY_PORT)
+}
+
 func getEnvAsInt32(name string, defaultVal int) int32 {
 	if value, exists := os.LookupEnv(name); exists {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -195,19 +644,25 @@ func getEnvAsInt32(name string, defaultVal int) int32 {
 	return int32(defaultVal)
 }
 
-// CreateRole creates a Role with the given name in the specified namespace
-func CreateRole(ctx context.Context, c client.Client, name, namespace string, rules []rbacv1.PolicyRule) error {
+// CreateRole creates a Role owned by the DocumentDB instance, adopting an existing one.
+// The rules of an existing Role are left alone because CNPG reconciles the Role of the same name.
+func CreateRole(ctx context.Context, c client.Client, documentdb *dbpreview.DocumentDB, namespace string, rules []rbacv1.PolicyRule) error {
 	role := &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
+			Name:            documentdb.Name,
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{documentDBOwnerReference(documentdb)},
 		},
 		Rules: rules,
 	}
 	foundRole := &rbacv1.Role{}
-	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, foundRole)
+	err := c.Get(ctx, types.NamespacedName{Name: documentdb.Name, Namespace: namespace}, foundRole)
 	if err == nil {
-		return nil // Role already exists
+		if hasOwnerReference(foundRole.OwnerReferences, documentdb.UID) {
+			return nil
+		}
+		foundRole.OwnerReferences = withOwnerReference(foundRole.OwnerReferences, documentdb)
+		return c.Update(ctx, foundRole)
 	}
 	if errors.IsNotFound(err) {
 		if err := c.Create(ctx, role); err != nil && !errors.IsAlreadyExists(err) {
@@ -219,18 +674,23 @@ func CreateRole(ctx context.Context, c client.Client, name, namespace string, ru
 	return nil
 }
 
-// CreateServiceAccount creates a ServiceAccount with the given name in the specified namespace
-func CreateServiceAccount(ctx context.Context, c client.Client, name, namespace string) error {
+// CreateServiceAccount creates a ServiceAccount owned by the DocumentDB instance, adopting an existing one
+func CreateServiceAccount(ctx context.Context, c client.Client, documentdb *dbpreview.DocumentDB, namespace string) error {
 	serviceAccount := &corev1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
+			Name:            documentdb.Name,
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{documentDBOwnerReference(documentdb)},
 		},
 	}
 	foundServiceAccount := &corev1.ServiceAccount{}
-	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, foundServiceAccount)
+	err := c.Get(ctx, types.NamespacedName{Name: documentdb.Name, Namespace: namespace}, foundServiceAccount)
 	if err == nil {
-		return nil // ServiceAccount already exists
+		if hasOwnerReference(foundServiceAccount.OwnerReferences, documentdb.UID) {
+			return nil
+		}
+		foundServiceAccount.OwnerReferences = withOwnerReference(foundServiceAccount.OwnerReferences, documentdb)
+		return c.Update(ctx, foundServiceAccount)
 	}
 	if errors.IsNotFound(err) {
 		if err := c.Create(ctx, serviceAccount); err != nil && !errors.IsAlreadyExists(err) {
@@ -242,30 +702,35 @@ func CreateServiceAccount(ctx context.Context, c client.Client, name, namespace
 	return nil
 }
 
-// CreateRoleBinding creates a RoleBinding with the given name in the specified namespace
-func CreateRoleBinding(ctx context.Context, c client.Client, name, namespace string) error {
+// CreateRoleBinding creates a RoleBinding owned by the DocumentDB instance, adopting an existing one
+func CreateRoleBinding(ctx context.Context, c client.Client, documentdb *dbpreview.DocumentDB, namespace string) error {
 	roleBinding := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: namespace,
+			Name:            documentdb.Name,
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{documentDBOwnerReference(documentdb)},
 		},
 		Subjects: []rbacv1.Subject{
 			{
 				Kind:      "ServiceAccount",
-				Name:      name,
+				Name:      documentdb.Name,
 				Namespace: namespace,
 			},
 		},
 		RoleRef: rbacv1.RoleRef{
 			Kind:     "Role",
-			Name:     name,
+			Name:     documentdb.Name,
 			APIGroup: "rbac.authorization.k8s.io",
 		},
 	}
 	foundRoleBinding := &rbacv1.RoleBinding{}
-	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, foundRoleBinding)
+	err := c.Get(ctx, types.NamespacedName{Name: documentdb.Name, Namespace: namespace}, foundRoleBinding)
 	if err == nil {
-		return nil // RoleBinding already exists
+		if hasOwnerReference(foundRoleBinding.OwnerReferences, documentdb.UID) {
+			return nil
+		}
+		foundRoleBinding.OwnerReferences = withOwnerReference(foundRoleBinding.OwnerReferences, documentdb)
+		return c.Update(ctx, foundRoleBinding)
 	}
 	if errors.IsNotFound(err) {
 		if err := c.Create(ctx, roleBinding); err != nil && !errors.IsAlreadyExists(err) {
@@ -277,6 +742,36 @@ func CreateRoleBinding(ctx context.Context, c client.Client, name, namespace str
 	return nil
 }
 
+// documentDBOwnerReference returns a non-controller owner reference to the DocumentDB instance.
+// CNPG manages objects of the same name for the cluster, so the DocumentDB does not claim to be their controller.
+func documentDBOwnerReference(documentdb *dbpreview.DocumentDB) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion:         documentdb.APIVersion,
+		Kind:               documentdb.Kind,
+		Name:               documentdb.Name,
+		UID:                documentdb.UID,
+		BlockOwnerDeletion: &[]bool{true}[0], // Block DocumentDB deletion until the object is deleted
+	}
+}
+
+// hasOwnerReference reports whether the owner references include the object with the given UID
+func hasOwnerReference(refs []metav1.OwnerReference, uid types.UID) bool {
+	for _, ref := range refs {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// withOwnerReference appends an owner reference to the DocumentDB instance unless one is already present
+func withOwnerReference(refs []metav1.OwnerReference, documentdb *dbpreview.DocumentDB) []metav1.OwnerReference {
+	if hasOwnerReference(refs, documentdb.UID) {
+		return refs
+	}
+	return append(refs, documentDBOwnerReference(documentdb))
+}
+
 // DeleteServiceAccount deletes the ServiceAccount with the given name in the specified namespace
 func DeleteServiceAccount(ctx context.Context, c client.Client, name, namespace string) error {
 	serviceAccount := &corev1.ServiceAccount{}
@@ -301,6 +796,18 @@ func DeleteRole(ctx context.Context, c client.Client, name, namespace string) er
 	return nil
 }
 
+// DeleteService deletes the Service with the given name in the specified namespace
+func DeleteService(ctx context.Context, c client.Client, name, namespace string) error {
+	service := &corev1.Service{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, service)
+	if err == nil {
+		if err := c.Delete(ctx, service); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteRoleBinding deletes the RoleBinding with the given name in the specified namespace
 func DeleteRoleBinding(ctx context.Context, c client.Client, name, namespace string) error {
 	roleBinding := &rbacv1.RoleBinding{}
@@ -313,18 +820,147 @@ func DeleteRoleBinding(ctx context.Context, c client.Client, name, namespace str
 	return nil
 }
 
+// CredentialSecretSource returns the Secret holding the gateway credentials, which may be in another namespace
+func CredentialSecretSource(documentdb *dbpreview.DocumentDB) types.NamespacedName {
+	source := types.NamespacedName{Name: documentdb.Spec.DocumentDbCredentialSecret, Namespace: documentdb.Spec.DocumentDbCredentialSecretNamespace}
+	if source.Name == "" {
+		source.Name = DEFAULT_DOCUMENTDB_CREDENTIALS_SECRET
+	}
+	if source.Namespace == "" {
+		source.Namespace = documentdb.Namespace
+	}
+	return source
+}
+
+// ProvidedTLSSecretSource returns the Secret configured for the Provided gateway TLS mode, which may be in
+// another namespace. It returns false when no provided secret is configured.
+func ProvidedTLSSecretSource(documentdb *dbpreview.DocumentDB) (types.NamespacedName, bool) {
+	if documentdb.Spec.TLS == nil || documentdb.Spec.TLS.Gateway == nil || documentdb.Spec.TLS.Gateway.Provided == nil {
+		return types.NamespacedName{}, false
+	}
+	provided := documentdb.Spec.TLS.Gateway.Provided
+	source := types.NamespacedName{Name: provided.SecretName, Namespace: provided.Namespace}
+	if source.Namespace == "" {
+		source.Namespace = documentdb.Namespace
+	}
+	return source, source.Name != ""
+}
+
+// CopySecret keeps a copy of a Secret from another namespace in the DocumentDB's namespace, under the same name,
+// because pods cannot mount Secrets across namespaces. The copy is controlled by the DocumentDB instance and
+// annotated with its source; an existing Secret that is not a copy of the source is never overwritten.
+func CopySecret(ctx context.Context, c client.Client, documentdb *dbpreview.DocumentDB, source types.NamespacedName) error {
+	sourceSecret := &corev1.Secret{}
+	if err := c.Get(ctx, source, sourceSecret); err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", source, err)
+	}
+
+	ownerRef := documentDBOwnerReference(documentdb)
+	ownerRef.Controller = &[]bool{true}[0]
+	copied := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       documentdb.Namespace,
+			Annotations:     map[string]string{SOURCE_SECRET_ANNOTATION: source.String()},
+			OwnerReferences: []metav1.OwnerReference{ownerRef},
+		},
+		Type: sourceSecret.Type,
+		Data: sourceSecret.Data,
+	}
+
+	foundSecret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: source.Name, Namespace: documentdb.Namespace}, foundSecret)
+	if errors.IsNotFound(err) {
+		return c.Create(ctx, copied)
+	}
+	if err != nil {
+		return err
+	}
+	if foundSecret.Annotations[SOURCE_SECRET_ANNOTATION] != source.String() {
+		return fmt.Errorf("secret %s/%s already exists and is not a copy of %s", documentdb.Namespace, source.Name, source)
+	}
+	if equality.Semantic.DeepEqual(foundSecret.Data, sourceSecret.Data) {
+		return nil
+	}
+	foundSecret.Data = sourceSecret.Data
+	return c.Update(ctx, foundSecret)
+}
+
 // GenerateConnectionString returns a MongoDB connection string for the DocumentDB instance.
-// When trustTLS is true, tlsAllowInvalidCertificates is omitted for strict verification.
+// trustTLS reports whether the gateway serves a certificate from spec.tls. Unless spec.tlsInsecureSkipVerify
+// is set, such certificates are verified strictly, pointing tlsCAFile at <secret>-ca.crt, the file clients save
+// the ca.crt of the certificate secret to.
 func GenerateConnectionString(documentdb *dbpreview.DocumentDB, serviceIp string, trustTLS bool) string {
-	secretName := documentdb.Spec.DocumentDbCredentialSecret
-	if secretName == "" {
-		secretName = DEFAULT_DOCUMENTDB_CREDENTIALS_SECRET
+	return generateConnectionString(documentdb, []string{serviceIp}, trustTLS)
+}
+
+// GenerateMultiHostConnectionString returns a connection string listing the gateway endpoints of all the given
+// hosts, so that clients can fail over between the member clusters of a replicated DocumentDB.
+func GenerateMultiHostConnectionString(documentdb *dbpreview.DocumentDB, hosts []string, trustTLS bool) string {
+	return generateConnectionString(documentdb, hosts, trustTLS)
+}
+
+func generateConnectionString(documentdb *dbpreview.DocumentDB, hosts []string, trustTLS bool) string {
+	if documentdb.Spec.DisableGateway {
+		return generatePostgresConnectionString(documentdb, hosts)
+	}
+	secretName := CredentialSecretSource(documentdb).Name
+	authMechanism := documentdb.Spec.AuthMechanism
+	if authMechanism == "" {
+		authMechanism = DEFAULT_AUTH_MECHANISM
+	}
+	// directConnection defaults to false in MongoDB URIs, so it is only emitted when enabled.
+	// Drivers reject it with more than one host.
+	options := fmt.Sprintf("authMechanism=%s&tls=true", authMechanism)
+	if len(hosts) == 1 && (documentdb.Spec.DirectConnection == nil || *documentdb.Spec.DirectConnection) {
+		options = "directConnection=true&" + options
 	}
-	conn := fmt.Sprintf("mongodb://$(kubectl get secret %s -n %s -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret %s -n %s -o jsonpath='{.data.password}' | base64 -d)@%s:%d/?directConnection=true&authMechanism=SCRAM-SHA-256&tls=true", secretName, documentdb.Namespace, secretName, documentdb.Namespace, serviceIp, GetPortFor(GATEWAY_PORT))
-	if !trustTLS {
+	hostPorts := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		hostPorts = append(hostPorts, clientHostPort(documentdb, host))
+	}
+	conn := fmt.Sprintf("mongodb://$(kubectl get secret %s -n %s -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret %s -n %s -o jsonpath='{.data.password}' | base64 -d)@%s/?%s", secretName, documentdb.Namespace, secretName, documentdb.Namespace, strings.Join(hostPorts, ","), options)
+	if documentdb.Spec.TLSInsecureSkipVerify || !trustTLS {
 		conn += "&tlsAllowInvalidCertificates=true"
+	} else if caSecret := gatewayCASecretName(documentdb); caSecret != "" {
+		// The client saves ca.crt of the secret under this name; running the connection string must not write files
+		conn += "&tlsCAFile=" + url.QueryEscape(caSecret+"-ca.crt")
+	}
+	replicaSetName := documentdb.Spec.ReplicaSetName
+	if replicaSetName == "" {
+		replicaSetName = DEFAULT_REPLICA_SET_NAME
+	}
+	return conn + "&replicaSet=" + url.QueryEscape(replicaSetName)
+}
+
+// gatewayCASecretName returns the TLS secret holding the CA that issued the gateway certificate, or "" when
+// the secret carries no CA, in which case the certificate is assumed to chain to a CA trusted by clients.
+func gatewayCASecretName(documentdb *dbpreview.DocumentDB) string {
+	if documentdb.Status.TLS == nil || !documentdb.Status.TLS.Ready || documentdb.Status.TLS.CABundle == "" {
+		return ""
+	}
+	return documentdb.Status.TLS.SecretName
+}
+
+// generatePostgresConnectionString returns a postgres:// URI for clients of a DocumentDB without the gateway. Postgres
+// serves the certificate of the CNPG cluster, so TLS is required without verifying it. With several hosts, libpq
+// clients connect to whichever one accepts writes.
+func generatePostgresConnectionString(documentdb *dbpreview.DocumentDB, hosts []string) string {
+	secretName := CredentialSecretSource(documentdb).Name
+	hostPorts := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		hostPorts = append(hostPorts, clientHostPort(documentdb, host))
 	}
-	return conn + "&replicaSet=rs0"
+	conn := fmt.Sprintf("postgres://$(kubectl get secret %s -n %s -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret %s -n %s -o jsonpath='{.data.password}' | base64 -d)@%s/postgres?sslmode=require", secretName, documentdb.Namespace, secretName, documentdb.Namespace, strings.Join(hostPorts, ","))
+	if len(hosts) > 1 {
+		conn += "&target_session_attrs=read-write"
+	}
+	return conn
+}
+
+// clientHostPort joins the host with the client port, bracketing IPv6 addresses as required in URIs
+func clientHostPort(documentdb *dbpreview.DocumentDB, host string) string {
+	return net.JoinHostPort(host, strconv.Itoa(int(GetClientPort(documentdb))))
 }
 
 // GetGatewayImageForDocumentDB returns the gateway image for a DocumentDB instance.
@@ -333,20 +969,7 @@ func GetGatewayImageForDocumentDB(documentdb *dbpreview.DocumentDB) string {
 	if documentdb.Spec.GatewayImage != "" {
 		return documentdb.Spec.GatewayImage
 	}
-
-	// TODO: Uncomment when we publish custom gateway images
-	// // Use spec-level documentDBVersion if set
-	// if documentdb.Spec.DocumentDBVersion != "" {
-	// 	return fmt.Sprintf("%s:%s", DOCUMENTDB_IMAGE_REPOSITORY, documentdb.Spec.DocumentDBVersion)
-	// }
-
-	// // Use global documentDbVersion if set
-	// if version := os.Getenv(DOCUMENTDB_VERSION_ENV); version != "" {
-	// 	return fmt.Sprintf("%s:%s", DOCUMENTDB_IMAGE_REPOSITORY, version)
-	// }
-
-	// Fall back to default
-	return DEFAULT_GATEWAY_IMAGE
+	return getImageForVersion(documentdb, DEFAULT_GATEWAY_IMAGE)
 }
 
 // GetDocumentDBImageForInstance returns the documentdb engine image.
@@ -355,20 +978,64 @@ func GetDocumentDBImageForInstance(documentdb *dbpreview.DocumentDB) string {
 	if documentdb.Spec.DocumentDBImage != "" {
 		return documentdb.Spec.DocumentDBImage
 	}
+	return getImageForVersion(documentdb, DEFAULT_DOCUMENTDB_IMAGE)
+}
 
-	// TODO: Uncomment when we publish custom documentdb images
-	// // Use spec-level documentDBVersion if set
-	// if documentdb.Spec.DocumentDBVersion != "" {
-	// 	return fmt.Sprintf("%s:%s", DOCUMENTDB_IMAGE_REPOSITORY, documentdb.Spec.DocumentDBVersion)
-	// }
+// getImageForVersion resolves the image for spec.documentDBVersion, falling back to env.DOCUMENTDB_VERSION and then the default image
+func getImageForVersion(documentdb *dbpreview.DocumentDB, defaultImage string) string {
+	// Use spec-level documentDBVersion if set
+	if documentdb.Spec.DocumentDBVersion != "" {
+		return fmt.Sprintf("%s:%s", DOCUMENTDB_IMAGE_REPOSITORY, documentdb.Spec.DocumentDBVersion)
+	}
 
-	// // Use global documentDbVersion if set
-	// if version := os.Getenv(DOCUMENTDB_VERSION_ENV); version != "" {
-	// 	return fmt.Sprintf("%s:%s", DOCUMENTDB_IMAGE_REPOSITORY, version)
-	// }
+	// Use global documentDbVersion if set
+	if version := os.Getenv(DOCUMENTDB_VERSION_ENV); version != "" {
+		return fmt.Sprintf("%s:%s", DOCUMENTDB_IMAGE_REPOSITORY, version)
+	}
 
 	// Fall back to default
-	return DEFAULT_DOCUMENTDB_IMAGE
+	return defaultImage
+}
+
+// imageVersionPattern matches dotted numeric versions within an image tag, e.g. "16" or "0.106.0" in "pg17-0.106.0"
+var imageVersionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// CompareImageVersions compares the versions in the tags of two images.
+// Returns -1, 0 or 1 if the desired image is older, the same or newer than the current one,
+// or an error if either tag doesn't contain a version or the versions use different schemes (e.g. "16" and "0.106.0").
+func CompareImageVersions(current, desired string) (int, error) {
+	currentVersion, err := imageVersion(current)
+	if err != nil {
+		return 0, err
+	}
+	desiredVersion, err := imageVersion(desired)
+	if err != nil {
+		return 0, err
+	}
+	if len(currentVersion.Components()) != len(desiredVersion.Components()) {
+		return 0, fmt.Errorf("cannot compare versions of images %q and %q", current, desired)
+	}
+	return desiredVersion.Compare(currentVersion.String())
+}
+
+// imageVersion extracts the last version found in the tag of an image reference
+func imageVersion(image string) (*version.Version, error) {
+	reference, _, _ := strings.Cut(image, "@")
+	tag := ""
+	if idx := strings.LastIndex(reference, ":"); idx > strings.LastIndex(reference, "/") {
+		tag = reference[idx+1:]
+	}
+
+	matches := imageVersionPattern.FindAllString(tag, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("image %q does not have a versioned tag", image)
+	}
+	versionString := matches[len(matches)-1]
+	if !strings.Contains(versionString, ".") {
+		// ParseGeneric requires at least a major and minor component
+		versionString += ".0"
+	}
+	return version.ParseGeneric(versionString)
 }
 
 func GenerateServiceName(source, target, resourceGroup string) string {
@@ -4,12 +4,30 @@
 package util
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	texttemplate "text/template"
 
 	dbpreview "github.com/documentdb/documentdb-operator/api/preview"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 func TestGenerateServiceName(t *testing.T) {
@@ -88,6 +106,66 @@ func TestGenerateServiceName(t *testing.T) {
 	}
 }
 
+func TestResourceNamesOfLongClusterNames(t *testing.T) {
+	// Both names share the first 60 characters, so plain truncation would give them the same resource names
+	prefix := strings.Repeat("a", 60)
+	first, second := prefix+"-first", prefix+"-second"
+
+	namers := map[string]func(string) string{
+		"service":        GetDocumentDBServiceName,
+		"reader service": GetDocumentDBReaderServiceName,
+		"pooler":         GetDocumentDBPoolerName,
+		"database":       func(cluster string) string { return GetDocumentDBDatabaseName(cluster, "orders") },
+	}
+	for kind, namer := range namers {
+		t.Run(kind, func(t *testing.T) {
+			firstName, secondName := namer(first), namer(second)
+			if firstName == secondName {
+				t.Errorf("%s names of %q and %q collide: %q", kind, first, second, firstName)
+			}
+			for _, name := range []string{firstName, secondName} {
+				if len(name) > MAX_RESOURCE_NAME_LENGTH {
+					t.Errorf("%s name %q is longer than %d characters", kind, name, MAX_RESOURCE_NAME_LENGTH)
+				}
+			}
+			if namer(first) != firstName {
+				t.Errorf("%s name of %q is not stable", kind, first)
+			}
+		})
+	}
+}
+
+func TestResourceNamesOfShortClusterNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{name: "service", got: GetDocumentDBServiceName("my-cluster"), expected: "documentdb-service-my-cluster"},
+		{name: "reader service", got: GetDocumentDBReaderServiceName("my-cluster"), expected: "documentdb-service-my-cluster-ro"},
+		{name: "pooler", got: GetDocumentDBPoolerName("my-cluster"), expected: "my-cluster-pooler"},
+		{name: "database", got: GetDocumentDBDatabaseName("my-cluster", "orders"), expected: "my-cluster-orders"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("got %q, expected %q", tt.got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBDatabaseNameSanitizesWithoutCollisions(t *testing.T) {
+	underscore := GetDocumentDBDatabaseName("my-cluster", "order_items")
+	hyphen := GetDocumentDBDatabaseName("my-cluster", "order-items")
+	if underscore == hyphen {
+		t.Errorf("database names order_items and order-items collide: %q", underscore)
+	}
+	if !strings.HasPrefix(underscore, "my-cluster-order-items-") {
+		t.Errorf("GetDocumentDBDatabaseName(%q, %q) = %q, expected a sanitized name with a hash suffix", "my-cluster", "order_items", underscore)
+	}
+}
+
 func TestGenerateConnectionString(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -162,7 +240,7 @@ func TestGenerateConnectionString(t *testing.T) {
 			},
 			serviceIp:      "2001:0db8:85a3:0000:0000:8a2e:0370:7334",
 			trustTLS:       true,
-			expectedPrefix: "mongodb://$(kubectl get secret ipv6-secret -n default -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret ipv6-secret -n default -o jsonpath='{.data.password}' | base64 -d)@2001:0db8:85a3:0000:0000:8a2e:0370:7334:10260/?directConnection=true&authMechanism=SCRAM-SHA-256&tls=true",
+			expectedPrefix: "mongodb://$(kubectl get secret ipv6-secret -n default -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret ipv6-secret -n default -o jsonpath='{.data.password}' | base64 -d)@[2001:0db8:85a3:0000:0000:8a2e:0370:7334]:10260/?directConnection=true&authMechanism=SCRAM-SHA-256&tls=true",
 			expectedSuffix: "&replicaSet=rs0",
 			description:    "Should support IPv6 addresses",
 		},
@@ -183,6 +261,23 @@ func TestGenerateConnectionString(t *testing.T) {
 			expectedSuffix: "&tlsAllowInvalidCertificates=true&replicaSet=rs0",
 			description:    "Should correctly use the DocumentDB instance's namespace",
 		},
+		{
+			name: "custom replica set name",
+			documentdb: &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rs-db",
+					Namespace: "default",
+				},
+				Spec: dbpreview.DocumentDBSpec{
+					ReplicaSetName: "documentdb-rs",
+				},
+			},
+			serviceIp:      "10.0.0.60",
+			trustTLS:       true,
+			expectedPrefix: "mongodb://$(kubectl get secret documentdb-credentials -n default -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret documentdb-credentials -n default -o jsonpath='{.data.password}' | base64 -d)@10.0.0.60:10260/?directConnection=true&authMechanism=SCRAM-SHA-256&tls=true",
+			expectedSuffix: "&replicaSet=documentdb-rs",
+			description:    "Should use the configured replica set name",
+		},
 	}
 
 	for _, tt := range tests {
@@ -211,14 +306,22 @@ func TestGenerateConnectionString(t *testing.T) {
 				t.Error("Connection string should contain 'tls=true'")
 			}
 
-			// Verify SCRAM-SHA-256 auth mechanism
-			if !contains(result, "authMechanism=SCRAM-SHA-256") {
-				t.Error("Connection string should contain 'authMechanism=SCRAM-SHA-256'")
+			// Verify auth mechanism, which defaults to SCRAM-SHA-256
+			expectedAuthMechanism := "authMechanism=SCRAM-SHA-256"
+			if tt.documentdb.Spec.AuthMechanism != "" {
+				expectedAuthMechanism = "authMechanism=" + tt.documentdb.Spec.AuthMechanism
+			}
+			if !contains(result, expectedAuthMechanism) {
+				t.Errorf("Connection string should contain %q", expectedAuthMechanism)
 			}
 
-			// Verify replicaSet parameter
-			if !contains(result, "replicaSet=rs0") {
-				t.Error("Connection string should contain 'replicaSet=rs0'")
+			// Verify replicaSet parameter, which defaults to rs0
+			expectedReplicaSet := "replicaSet=rs0"
+			if tt.documentdb.Spec.ReplicaSetName != "" {
+				expectedReplicaSet = "replicaSet=" + tt.documentdb.Spec.ReplicaSetName
+			}
+			if !contains(result, expectedReplicaSet) {
+				t.Errorf("Connection string should contain %q", expectedReplicaSet)
 			}
 
 			// Verify tlsAllowInvalidCertificates based on trustTLS
@@ -246,6 +349,214 @@ func TestGenerateConnectionString(t *testing.T) {
 }
 
 // Helper function to check if a string contains a substring
+
+func TestGenerateConnectionStringAuthMechanism(t *testing.T) {
+	tests := []struct {
+		name          string
+		authMechanism string
+		expected      string
+	}{
+		{
+			name:          "default mechanism",
+			authMechanism: "",
+			expected:      "authMechanism=SCRAM-SHA-256",
+		},
+		{
+			name:          "SCRAM-SHA-256",
+			authMechanism: "SCRAM-SHA-256",
+			expected:      "authMechanism=SCRAM-SHA-256",
+		},
+		{
+			name:          "SCRAM-SHA-1",
+			authMechanism: "SCRAM-SHA-1",
+			expected:      "authMechanism=SCRAM-SHA-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "auth-db",
+					Namespace: "default",
+				},
+				Spec: dbpreview.DocumentDBSpec{
+					AuthMechanism: tt.authMechanism,
+				},
+			}
+
+			result := GenerateConnectionString(documentdb, "10.0.0.1", true)
+			if !contains(result, "&"+tt.expected+"&") {
+				t.Errorf("GenerateConnectionString() = %q; expected it to contain %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateConnectionStringDirectConnection(t *testing.T) {
+	tests := []struct {
+		name             string
+		directConnection *bool
+		expectedPrefix   string
+	}{
+		{
+			name:             "defaults to direct connection",
+			directConnection: nil,
+			expectedPrefix:   "@10.0.0.1:10260/?directConnection=true&authMechanism=SCRAM-SHA-256&tls=true",
+		},
+		{
+			name:             "direct connection enabled",
+			directConnection: ptr.To(true),
+			expectedPrefix:   "@10.0.0.1:10260/?directConnection=true&authMechanism=SCRAM-SHA-256&tls=true",
+		},
+		{
+			name:             "direct connection disabled",
+			directConnection: ptr.To(false),
+			expectedPrefix:   "@10.0.0.1:10260/?authMechanism=SCRAM-SHA-256&tls=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "direct-db",
+					Namespace: "default",
+				},
+				Spec: dbpreview.DocumentDBSpec{
+					DirectConnection: tt.directConnection,
+				},
+			}
+
+			result := GenerateConnectionString(documentdb, "10.0.0.1", true)
+			if !contains(result, tt.expectedPrefix) {
+				t.Errorf("GenerateConnectionString() = %q; expected it to contain %q", result, tt.expectedPrefix)
+			}
+			if tt.directConnection != nil && !*tt.directConnection && contains(result, "directConnection") {
+				t.Errorf("GenerateConnectionString() = %q; expected no directConnection option", result)
+			}
+		})
+	}
+}
+
+func TestGenerateMultiHostConnectionString(t *testing.T) {
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet-db", Namespace: "default"},
+	}
+
+	result := GenerateMultiHostConnectionString(documentdb, []string{"10.0.0.1", "gateway.cluster-b.example.com"}, true)
+	expectedHosts := "@10.0.0.1:10260,gateway.cluster-b.example.com:10260/?authMechanism=SCRAM-SHA-256&tls=true"
+	if !contains(result, expectedHosts) {
+		t.Errorf("GenerateMultiHostConnectionString() = %q; expected it to contain %q", result, expectedHosts)
+	}
+	// Drivers reject directConnection with more than one host
+	if contains(result, "directConnection") {
+		t.Errorf("GenerateMultiHostConnectionString() = %q; expected no directConnection option", result)
+	}
+	if !contains(result, "&replicaSet=rs0") {
+		t.Errorf("GenerateMultiHostConnectionString() = %q; expected the replica set name", result)
+	}
+}
+
+func TestGenerateConnectionStringWithoutGateway(t *testing.T) {
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "raw-db", Namespace: "default"},
+		Spec:       dbpreview.DocumentDBSpec{DisableGateway: true},
+	}
+
+	expected := "postgres://$(kubectl get secret documentdb-credentials -n default -o jsonpath='{.data.username}' | base64 -d):$(kubectl get secret documentdb-credentials -n default -o jsonpath='{.data.password}' | base64 -d)@10.0.0.1:5432/postgres?sslmode=require"
+	if result := GenerateConnectionString(documentdb, "10.0.0.1", true); result != expected {
+		t.Errorf("GenerateConnectionString() = %q; expected %q", result, expected)
+	}
+
+	result := GenerateMultiHostConnectionString(documentdb, []string{"10.0.0.1", "fd00::1"}, true)
+	if !contains(result, "@10.0.0.1:5432,[fd00::1]:5432/postgres?sslmode=require&target_session_attrs=read-write") {
+		t.Errorf("GenerateMultiHostConnectionString() = %q; expected both Postgres endpoints and read-write sessions", result)
+	}
+}
+
+func TestGenerateConnectionStringTLSVerification(t *testing.T) {
+	readyStatus := &dbpreview.TLSStatus{Ready: true, SecretName: "tls-db-gateway-cert-tls", CABundle: "ca-pem"}
+	readyWithoutCA := &dbpreview.TLSStatus{Ready: true, SecretName: "tls-db-gateway-cert-tls"}
+	caFileHint := "&tlsCAFile=tls-db-gateway-cert-tls-ca.crt"
+
+	tests := []struct {
+		name               string
+		mode               string
+		status             *dbpreview.TLSStatus
+		insecureSkipVerify bool
+		expectedSuffix     string
+	}{
+		{
+			name:           "TLS not ready falls back to skipping verification",
+			mode:           "SelfSigned",
+			status:         &dbpreview.TLSStatus{Ready: false},
+			expectedSuffix: "&tls=true&tlsAllowInvalidCertificates=true&replicaSet=rs0",
+		},
+		{
+			name:           "self-signed certificate is verified against its CA",
+			mode:           "SelfSigned",
+			status:         readyStatus,
+			expectedSuffix: "&tls=true" + caFileHint + "&replicaSet=rs0",
+		},
+		{
+			name:           "cert-manager certificate is verified against its CA",
+			mode:           "CertManager",
+			status:         readyStatus,
+			expectedSuffix: "&tls=true" + caFileHint + "&replicaSet=rs0",
+		},
+		{
+			name:           "provided certificate without CA is verified against trusted CAs",
+			mode:           "Provided",
+			status:         readyWithoutCA,
+			expectedSuffix: "&tls=true&replicaSet=rs0",
+		},
+		{
+			name:           "provided certificate with CA is verified against its CA",
+			mode:           "Provided",
+			status:         readyStatus,
+			expectedSuffix: "&tls=true" + caFileHint + "&replicaSet=rs0",
+		},
+		{
+			name:               "insecureSkipVerify overrides an available CA",
+			mode:               "SelfSigned",
+			status:             readyStatus,
+			insecureSkipVerify: true,
+			expectedSuffix:     "&tls=true&tlsAllowInvalidCertificates=true&replicaSet=rs0",
+		},
+		{
+			name:               "insecureSkipVerify overrides a provided certificate",
+			mode:               "Provided",
+			status:             readyStatus,
+			insecureSkipVerify: true,
+			expectedSuffix:     "&tls=true&tlsAllowInvalidCertificates=true&replicaSet=rs0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "tls-db",
+					Namespace: "default",
+				},
+				Spec: dbpreview.DocumentDBSpec{
+					TLSInsecureSkipVerify: tt.insecureSkipVerify,
+					TLS: &dbpreview.TLSConfiguration{
+						Gateway: &dbpreview.GatewayTLS{Mode: tt.mode},
+					},
+				},
+				Status: dbpreview.DocumentDBStatus{TLS: tt.status},
+			}
+
+			result := GenerateConnectionString(documentdb, "10.0.0.1", tt.status.Ready)
+			if !strings.HasSuffix(result, tt.expectedSuffix) {
+				t.Errorf("GenerateConnectionString() = %q; expected suffix %q", result, tt.expectedSuffix)
+			}
+		})
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && containsHelper(s, substr))
 }
@@ -284,7 +595,7 @@ func TestGetDocumentDBServiceDefinition_CNPGLabels(t *testing.T) {
 			endpointEnabled: true,
 			serviceType:     corev1.ServiceTypeLoadBalancer,
 			expectedSelector: map[string]string{
-				"app":                  "test-documentdb",
+				"cnpg.io/cluster":      "test-documentdb",
 				"cnpg.io/instanceRole": "primary",
 			},
 			description: "When endpoint is enabled, service should use CNPG labels for failover support",
@@ -295,7 +606,7 @@ func TestGetDocumentDBServiceDefinition_CNPGLabels(t *testing.T) {
 			endpointEnabled: true,
 			serviceType:     corev1.ServiceTypeClusterIP,
 			expectedSelector: map[string]string{
-				"app":                  "test-documentdb",
+				"cnpg.io/cluster":      "test-documentdb",
 				"cnpg.io/instanceRole": "primary",
 			},
 			description: "Service type should not affect selector labels",
@@ -306,7 +617,7 @@ func TestGetDocumentDBServiceDefinition_CNPGLabels(t *testing.T) {
 			endpointEnabled: true,
 			serviceType:     corev1.ServiceTypeLoadBalancer,
 			expectedSelector: map[string]string{
-				"app":                  "my-db-cluster",
+				"cnpg.io/cluster":      "my-db-cluster",
 				"cnpg.io/instanceRole": "primary",
 			},
 			description: "Cluster label should match DocumentDB instance name",
@@ -389,3 +700,1160 @@ func TestGetDocumentDBServiceDefinition_CNPGLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestServiceSelectorsMatchCNPGInstanceLabels(t *testing.T) {
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "labels-db", Namespace: "test-namespace"},
+	}
+	replicationContext := &ReplicationContext{Self: "labels-db", state: NoReplication}
+
+	// Labels CNPG sets on the instance pods of the "labels-db" cluster
+	primaryPod := labels.Set{"cnpg.io/cluster": "labels-db", "cnpg.io/instanceName": "labels-db-1", "cnpg.io/instanceRole": "primary", "role": "primary"}
+	replicaPod := labels.Set{"cnpg.io/cluster": "labels-db", "cnpg.io/instanceName": "labels-db-2", "cnpg.io/instanceRole": "replica", "role": "replica"}
+	otherClusterPod := labels.Set{"cnpg.io/cluster": "other-db", "cnpg.io/instanceName": "other-db-1", "cnpg.io/instanceRole": "primary", "role": "primary"}
+
+	primary := labels.SelectorFromSet(GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP).Spec.Selector)
+	if !primary.Matches(primaryPod) || primary.Matches(replicaPod) || primary.Matches(otherClusterPod) {
+		t.Errorf("Primary service selector %v should only match the primary instance of the cluster", primary)
+	}
+
+	reader := labels.SelectorFromSet(GetDocumentDBReaderServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP).Spec.Selector)
+	if !reader.Matches(replicaPod) || reader.Matches(primaryPod) || reader.Matches(otherClusterPod) {
+		t.Errorf("Reader service selector %v should only match the replica instances of the cluster", reader)
+	}
+}
+
+func TestGetDocumentDBServiceDefinitionIPFamilyPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		ipFamilyPolicy string
+		expected       *corev1.IPFamilyPolicy
+	}{
+		{name: "unset uses cluster default", ipFamilyPolicy: "", expected: nil},
+		{name: "prefer dual stack", ipFamilyPolicy: "PreferDualStack", expected: ptr.To(corev1.IPFamilyPolicyPreferDualStack)},
+		{name: "require dual stack", ipFamilyPolicy: "RequireDualStack", expected: ptr.To(corev1.IPFamilyPolicyRequireDualStack)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace"},
+				Spec: dbpreview.DocumentDBSpec{
+					ExposeViaService: dbpreview.ExposeViaService{ServiceType: "ClusterIP", IPFamilyPolicy: tt.ipFamilyPolicy},
+				},
+			}
+			replicationContext := &ReplicationContext{Self: "test-documentdb", state: NoReplication}
+
+			for _, service := range []*corev1.Service{
+				GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP),
+				GetDocumentDBReaderServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP),
+			} {
+				if !reflect.DeepEqual(service.Spec.IPFamilyPolicy, tt.expected) {
+					t.Errorf("Service %s: expected ipFamilyPolicy %v, got %v", service.Name, tt.expected, service.Spec.IPFamilyPolicy)
+				}
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBServiceDefinitionExternalTrafficPolicy(t *testing.T) {
+	tests := []struct {
+		name                string
+		serviceType         corev1.ServiceType
+		policy              string
+		healthCheckNodePort int32
+		expectedPolicy      corev1.ServiceExternalTrafficPolicy
+		expectedPort        int32
+	}{
+		{name: "unset uses Kubernetes default", serviceType: corev1.ServiceTypeLoadBalancer},
+		{name: "LoadBalancer with Local", serviceType: corev1.ServiceTypeLoadBalancer, policy: "Local", expectedPolicy: corev1.ServiceExternalTrafficPolicyLocal},
+		{name: "LoadBalancer with health check node port", serviceType: corev1.ServiceTypeLoadBalancer, policy: "Local", healthCheckNodePort: 32000, expectedPolicy: corev1.ServiceExternalTrafficPolicyLocal, expectedPort: 32000},
+		{name: "ClusterIP ignores the policy", serviceType: corev1.ServiceTypeClusterIP, policy: "Local", healthCheckNodePort: 32000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace"},
+				Spec: dbpreview.DocumentDBSpec{
+					ExposeViaService: dbpreview.ExposeViaService{
+						ServiceType:           string(tt.serviceType),
+						ExternalTrafficPolicy: tt.policy,
+						HealthCheckNodePort:   tt.healthCheckNodePort,
+					},
+				},
+			}
+			replicationContext := &ReplicationContext{Self: "test-documentdb", state: NoReplication}
+
+			service := GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", tt.serviceType)
+			if service.Spec.ExternalTrafficPolicy != tt.expectedPolicy {
+				t.Errorf("expected externalTrafficPolicy %q, got %q", tt.expectedPolicy, service.Spec.ExternalTrafficPolicy)
+			}
+			if service.Spec.HealthCheckNodePort != tt.expectedPort {
+				t.Errorf("expected healthCheckNodePort %d, got %d", tt.expectedPort, service.Spec.HealthCheckNodePort)
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBServiceDefinitionAnnotations(t *testing.T) {
+	tests := []struct {
+		name               string
+		environment        string
+		serviceType        corev1.ServiceType
+		annotations        map[string]string
+		disableAnnotations bool
+		expected           map[string]string
+	}{
+		{
+			name:        "LoadBalancer gets environment defaults",
+			environment: "aks",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			expected:    map[string]string{"service.beta.kubernetes.io/azure-load-balancer-external": "true"},
+		},
+		{
+			name:        "user annotations override defaults",
+			environment: "aks",
+			serviceType: corev1.ServiceTypeLoadBalancer,
+			annotations: map[string]string{"service.beta.kubernetes.io/azure-load-balancer-external": "false", "team": "data"},
+			expected:    map[string]string{"service.beta.kubernetes.io/azure-load-balancer-external": "false", "team": "data"},
+		},
+		{
+			name:               "defaults disabled",
+			environment:        "eks",
+			serviceType:        corev1.ServiceTypeLoadBalancer,
+			disableAnnotations: true,
+			expected:           nil,
+		},
+		{
+			name:               "defaults disabled keeps user annotations",
+			environment:        "gke",
+			serviceType:        corev1.ServiceTypeLoadBalancer,
+			annotations:        map[string]string{"networking.gke.io/load-balancer-type": "Internal"},
+			disableAnnotations: true,
+			expected:           map[string]string{"networking.gke.io/load-balancer-type": "Internal"},
+		},
+		{
+			name:        "ClusterIP gets only user annotations",
+			environment: "aks",
+			serviceType: corev1.ServiceTypeClusterIP,
+			annotations: map[string]string{"team": "data"},
+			expected:    map[string]string{"team": "data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace"},
+				Spec: dbpreview.DocumentDBSpec{
+					ExposeViaService: dbpreview.ExposeViaService{
+						ServiceType:               string(tt.serviceType),
+						Annotations:               tt.annotations,
+						DisableDefaultAnnotations: tt.disableAnnotations,
+					},
+				},
+			}
+			replicationContext := &ReplicationContext{Self: "test-documentdb", Environment: tt.environment, state: NoReplication}
+
+			service := GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", tt.serviceType)
+			if len(service.Annotations) != len(tt.expected) || (len(tt.expected) > 0 && !reflect.DeepEqual(service.Annotations, tt.expected)) {
+				t.Errorf("expected annotations %v, got %v", tt.expected, service.Annotations)
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBServiceDefinitionMetricsPort(t *testing.T) {
+	tests := []struct {
+		name          string
+		metricsPort   int32
+		expectedPorts []string
+	}{
+		{name: "metrics port unset", expectedPorts: []string{"gateway"}},
+		{name: "metrics port set", metricsPort: 9187, expectedPorts: []string{"gateway", "metrics"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace"},
+				Spec: dbpreview.DocumentDBSpec{
+					GatewayMetricsPort: tt.metricsPort,
+					ExposeViaService:   dbpreview.ExposeViaService{ServiceType: "ClusterIP"},
+				},
+			}
+			replicationContext := &ReplicationContext{Self: "test-documentdb", state: NoReplication}
+
+			for _, service := range []*corev1.Service{
+				GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP),
+				GetDocumentDBReaderServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP),
+			} {
+				var ports []string
+				for _, port := range service.Spec.Ports {
+					ports = append(ports, port.Name)
+					if port.Name == "metrics" && (port.Port != tt.metricsPort || port.TargetPort.IntVal != tt.metricsPort) {
+						t.Errorf("Service %s: expected metrics port %d, got %v", service.Name, tt.metricsPort, port)
+					}
+				}
+				if !reflect.DeepEqual(ports, tt.expectedPorts) {
+					t.Errorf("Service %s: expected ports %v, got %v", service.Name, tt.expectedPorts, ports)
+				}
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBServiceDefinitionWithoutGateway(t *testing.T) {
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace"},
+		Spec: dbpreview.DocumentDBSpec{
+			DisableGateway:     true,
+			GatewayMetricsPort: 9187,
+			ExposeViaService:   dbpreview.ExposeViaService{ServiceType: "ClusterIP"},
+		},
+	}
+	replicationContext := &ReplicationContext{Self: "test-documentdb", state: NoReplication}
+
+	for _, service := range []*corev1.Service{
+		GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP),
+		GetDocumentDBReaderServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP),
+	} {
+		// Only Postgres is exposed, as the metrics are served by the gateway
+		if len(service.Spec.Ports) != 1 {
+			t.Fatalf("Service %s: expected only the postgres port, got %v", service.Name, service.Spec.Ports)
+		}
+		port := service.Spec.Ports[0]
+		if port.Name != "postgres" || port.Port != 5432 || port.TargetPort.IntVal != 5432 {
+			t.Errorf("Service %s: expected postgres port 5432, got %v", service.Name, port)
+		}
+	}
+}
+
+func TestGetServicePorts(t *testing.T) {
+	tests := []struct {
+		name           string
+		disableGateway bool
+		metricsPort    int32
+		expectedPorts  map[string]int32
+	}{
+		{name: "gateway", expectedPorts: map[string]int32{"gateway": 10260}},
+		{name: "gateway with metrics", metricsPort: 9187, expectedPorts: map[string]int32{"gateway": 10260, "metrics": 9187}},
+		{name: "postgres", disableGateway: true, expectedPorts: map[string]int32{"postgres": 5432}},
+		{name: "postgres ignores gateway metrics", disableGateway: true, metricsPort: 9187, expectedPorts: map[string]int32{"postgres": 5432}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				Spec: dbpreview.DocumentDBSpec{DisableGateway: tt.disableGateway, GatewayMetricsPort: tt.metricsPort},
+			}
+
+			ports := map[string]int32{}
+			for _, port := range getServicePorts(documentdb) {
+				if port.Protocol != corev1.ProtocolTCP || port.TargetPort.IntVal != port.Port {
+					t.Errorf("port %s: expected a TCP port targeting %d, got %v", port.Name, port.Port, port)
+				}
+				ports[port.Name] = port.Port
+			}
+			if !reflect.DeepEqual(ports, tt.expectedPorts) {
+				t.Errorf("expected ports %v, got %v", tt.expectedPorts, ports)
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBReaderServiceDefinition(t *testing.T) {
+	longName := "a-very-long-documentdb-cluster-name-that-exceeds-the-service-limit"
+
+	tests := []struct {
+		name             string
+		documentDBName   string
+		endpointEnabled  bool
+		expectedName     string
+		expectedSelector map[string]string
+	}{
+		{
+			name:            "endpoint enabled - should select replicas",
+			documentDBName:  "test-documentdb",
+			endpointEnabled: true,
+			expectedName:    "documentdb-service-test-documentdb-ro",
+			expectedSelector: map[string]string{
+				"cnpg.io/cluster":      "test-documentdb",
+				"cnpg.io/instanceRole": "replica",
+			},
+		},
+		{
+			name:            "endpoint disabled - should have disabled selector",
+			documentDBName:  "test-documentdb",
+			endpointEnabled: false,
+			expectedName:    "documentdb-service-test-documentdb-ro",
+			expectedSelector: map[string]string{
+				"disabled": "true",
+			},
+		},
+		{
+			name:            "long name - should keep reader suffix within limit",
+			documentDBName:  longName,
+			endpointEnabled: true,
+			expectedName:    "documentdb-service-a-very-long-documentdb-cluster-n-191362d4-ro",
+			expectedSelector: map[string]string{
+				"cnpg.io/cluster":      longName,
+				"cnpg.io/instanceRole": "replica",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "documentdb.io/preview",
+					Kind:       "DocumentDB",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tt.documentDBName,
+					Namespace: "test-namespace",
+					UID:       types.UID("test-uid-123"),
+				},
+			}
+
+			replicationContext := &ReplicationContext{
+				Self:        tt.documentDBName,
+				Environment: "test",
+				state:       NoReplication,
+			}
+			if !tt.endpointEnabled {
+				replicationContext.state = Primary
+				replicationContext.currentLocalPrimary = "different-primary"
+				replicationContext.targetLocalPrimary = "target-primary"
+			}
+
+			service := GetDocumentDBReaderServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP)
+
+			if service.Name != tt.expectedName {
+				t.Errorf("Expected reader service name %q, got %q", tt.expectedName, service.Name)
+			}
+			if len(service.Name) > 63 {
+				t.Errorf("Expected reader service name to be at most 63 characters, got %d", len(service.Name))
+			}
+
+			if len(service.Spec.Selector) != len(tt.expectedSelector) {
+				t.Errorf("Expected selector %v, got %v", tt.expectedSelector, service.Spec.Selector)
+			}
+			for key, expectedValue := range tt.expectedSelector {
+				if actualValue := service.Spec.Selector[key]; actualValue != expectedValue {
+					t.Errorf("Expected selector[%q] = %q, got %q", key, expectedValue, actualValue)
+				}
+			}
+
+			// The primary service definition must be unaffected by the reader selector
+			primary := GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP)
+			if tt.endpointEnabled && primary.Spec.Selector["cnpg.io/instanceRole"] != "primary" {
+				t.Errorf("Expected primary service to keep selecting the primary, got %v", primary.Spec.Selector)
+			}
+			if primary.Name == service.Name {
+				t.Errorf("Expected reader service name to differ from primary service name %q", primary.Name)
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBIngressDefinition(t *testing.T) {
+	tests := []struct {
+		name                string
+		ingress             dbpreview.IngressConfiguration
+		expectedClass       string
+		expectedTLSSecret   string
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:    "host only - defaults to ssl passthrough",
+			ingress: dbpreview.IngressConfiguration{Host: "documentdb.example.com"},
+			expectedAnnotations: map[string]string{
+				INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION: "true",
+			},
+		},
+		{
+			name: "class, tls secret and custom annotations",
+			ingress: dbpreview.IngressConfiguration{
+				IngressClassName: "nginx",
+				Host:             "documentdb.example.com",
+				TLSSecretName:    "documentdb-ingress-tls",
+				Annotations:      map[string]string{"example.com/custom": "value"},
+			},
+			expectedClass:     "nginx",
+			expectedTLSSecret: "documentdb-ingress-tls",
+			expectedAnnotations: map[string]string{
+				INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION: "true",
+				"example.com/custom":                     "value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ingressConfig := tt.ingress
+			documentdb := &dbpreview.DocumentDB{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "documentdb.io/preview",
+					Kind:       "DocumentDB",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-documentdb",
+					Namespace: "test-namespace",
+					UID:       types.UID("test-uid-123"),
+				},
+				Spec: dbpreview.DocumentDBSpec{
+					ExposeViaService: dbpreview.ExposeViaService{
+						ServiceType: "ClusterIP",
+						Ingress:     &ingressConfig,
+					},
+				},
+			}
+			replicationContext := &ReplicationContext{Self: "test-documentdb", state: NoReplication}
+
+			ingress := GetDocumentDBIngressDefinition(documentdb, replicationContext, "test-namespace")
+
+			if ingress.Name != "documentdb-service-test-documentdb" || ingress.Namespace != "test-namespace" {
+				t.Errorf("Unexpected ingress name %s/%s", ingress.Namespace, ingress.Name)
+			}
+			if len(ingress.OwnerReferences) != 1 || ingress.OwnerReferences[0].Name != "test-documentdb" {
+				t.Errorf("Expected owner reference to the DocumentDB instance, got %v", ingress.OwnerReferences)
+			}
+
+			if len(ingress.Spec.Rules) != 1 || ingress.Spec.Rules[0].Host != tt.ingress.Host {
+				t.Fatalf("Expected a single rule for host %q, got %v", tt.ingress.Host, ingress.Spec.Rules)
+			}
+			paths := ingress.Spec.Rules[0].HTTP.Paths
+			if len(paths) != 1 || paths[0].Backend.Service == nil {
+				t.Fatalf("Expected a single service backend, got %v", paths)
+			}
+			backend := paths[0].Backend.Service
+			if backend.Name != "documentdb-service-test-documentdb" || backend.Port.Number != GetPortFor(GATEWAY_PORT) {
+				t.Errorf("Expected backend to target the gateway service, got %s:%d", backend.Name, backend.Port.Number)
+			}
+
+			if tt.expectedClass == "" && ingress.Spec.IngressClassName != nil {
+				t.Errorf("Expected no ingress class, got %q", *ingress.Spec.IngressClassName)
+			}
+			if tt.expectedClass != "" && (ingress.Spec.IngressClassName == nil || *ingress.Spec.IngressClassName != tt.expectedClass) {
+				t.Errorf("Expected ingress class %q, got %v", tt.expectedClass, ingress.Spec.IngressClassName)
+			}
+
+			if tt.expectedTLSSecret == "" && len(ingress.Spec.TLS) != 0 {
+				t.Errorf("Expected no TLS section, got %v", ingress.Spec.TLS)
+			}
+			if tt.expectedTLSSecret != "" && (len(ingress.Spec.TLS) != 1 || ingress.Spec.TLS[0].SecretName != tt.expectedTLSSecret) {
+				t.Errorf("Expected TLS secret %q, got %v", tt.expectedTLSSecret, ingress.Spec.TLS)
+			}
+
+			if len(ingress.Annotations) != len(tt.expectedAnnotations) {
+				t.Errorf("Expected annotations %v, got %v", tt.expectedAnnotations, ingress.Annotations)
+			}
+			for key, expectedValue := range tt.expectedAnnotations {
+				if ingress.Annotations[key] != expectedValue {
+					t.Errorf("Expected annotation %q = %q, got %q", key, expectedValue, ingress.Annotations[key])
+				}
+			}
+		})
+	}
+}
+
+func TestGetDocumentDBNetworkPolicyDefinition(t *testing.T) {
+	appsNamespaceSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "apps"}}
+	clientSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"role": "client"}}
+
+	tests := []struct {
+		name                 string
+		networkPolicy        dbpreview.NetworkPolicyConfiguration
+		gatewayMetricsPort   int32
+		expectedGatewayPeers []networkingv1.NetworkPolicyPeer
+		expectedOpenPorts    []int32
+	}{
+		{
+			name:                 "no selectors - only the DocumentDB namespace reaches the gateway",
+			networkPolicy:        dbpreview.NetworkPolicyConfiguration{Enabled: true},
+			expectedGatewayPeers: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+			expectedOpenPorts:    []int32{CNPG_STATUS_PORT, CNPG_METRICS_PORT},
+		},
+		{
+			name:                 "namespace and pod selectors with gateway metrics",
+			networkPolicy:        dbpreview.NetworkPolicyConfiguration{Enabled: true, NamespaceSelector: appsNamespaceSelector, PodSelector: clientSelector},
+			gatewayMetricsPort:   9090,
+			expectedGatewayPeers: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: appsNamespaceSelector, PodSelector: clientSelector}},
+			expectedOpenPorts:    []int32{CNPG_STATUS_PORT, CNPG_METRICS_PORT, 9090},
+		},
+		{
+			name: "IP blocks - load balancer clients reach the gateway",
+			networkPolicy: dbpreview.NetworkPolicyConfiguration{Enabled: true, IPBlocks: []networkingv1.IPBlock{
+				{CIDR: "203.0.113.0/24"},
+				{CIDR: "10.224.0.0/16", Except: []string{"10.224.1.0/24"}},
+			}},
+			expectedGatewayPeers: []networkingv1.NetworkPolicyPeer{
+				{PodSelector: &metav1.LabelSelector{}},
+				{IPBlock: &networkingv1.IPBlock{CIDR: "203.0.113.0/24"}},
+				{IPBlock: &networkingv1.IPBlock{CIDR: "10.224.0.0/16", Except: []string{"10.224.1.0/24"}}},
+			},
+			expectedOpenPorts: []int32{CNPG_STATUS_PORT, CNPG_METRICS_PORT},
+		},
+	}
+
+	ports := func(policyPorts []networkingv1.NetworkPolicyPort) []int32 {
+		var numbers []int32
+		for _, port := range policyPorts {
+			if port.Protocol == nil || *port.Protocol != corev1.ProtocolTCP {
+				t.Errorf("Expected TCP port, got %v", port.Protocol)
+			}
+			numbers = append(numbers, port.Port.IntVal)
+		}
+		return numbers
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networkPolicy := tt.networkPolicy
+			documentdb := &dbpreview.DocumentDB{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "documentdb.io/preview", Kind: "DocumentDB"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace", UID: types.UID("test-uid-123")},
+				Spec: dbpreview.DocumentDBSpec{
+					NetworkPolicy:      &networkPolicy,
+					GatewayMetricsPort: tt.gatewayMetricsPort,
+				},
+			}
+
+			policy := GetDocumentDBNetworkPolicyDefinition(documentdb, "test-documentdb", "test-namespace")
+
+			if policy.Name != "test-documentdb-network-policy" || policy.Namespace != "test-namespace" {
+				t.Errorf("Unexpected network policy name %s/%s", policy.Namespace, policy.Name)
+			}
+			if len(policy.OwnerReferences) != 1 || policy.OwnerReferences[0].UID != documentdb.UID {
+				t.Errorf("Expected owner reference to the DocumentDB instance, got %v", policy.OwnerReferences)
+			}
+			expectedPodSelector := map[string]string{CNPG_CLUSTER_LABEL: "test-documentdb", CNPG_POD_ROLE_LABEL: "instance"}
+			if !reflect.DeepEqual(policy.Spec.PodSelector.MatchLabels, expectedPodSelector) {
+				t.Errorf("Expected pod selector %v, got %v", expectedPodSelector, policy.Spec.PodSelector.MatchLabels)
+			}
+			if !reflect.DeepEqual(policy.Spec.PolicyTypes, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}) {
+				t.Errorf("Expected an ingress policy, got %v", policy.Spec.PolicyTypes)
+			}
+			if len(policy.Spec.Ingress) != 3 {
+				t.Fatalf("Expected 3 ingress rules, got %d", len(policy.Spec.Ingress))
+			}
+
+			gatewayRule := policy.Spec.Ingress[0]
+			if !reflect.DeepEqual(ports(gatewayRule.Ports), []int32{10260}) {
+				t.Errorf("Expected the gateway rule to allow port 10260, got %v", ports(gatewayRule.Ports))
+			}
+			if !reflect.DeepEqual(gatewayRule.From, tt.expectedGatewayPeers) {
+				t.Errorf("Expected gateway peers %v, got %v", tt.expectedGatewayPeers, gatewayRule.From)
+			}
+
+			postgresRule := policy.Spec.Ingress[1]
+			if !reflect.DeepEqual(ports(postgresRule.Ports), []int32{5432}) {
+				t.Errorf("Expected the Postgres rule to allow port 5432, got %v", ports(postgresRule.Ports))
+			}
+			expectedPostgresPeer := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{CNPG_CLUSTER_LABEL: "test-documentdb"}}}
+			if !reflect.DeepEqual(postgresRule.From, []networkingv1.NetworkPolicyPeer{expectedPostgresPeer}) {
+				t.Errorf("Expected Postgres to be reachable only from the cluster pods, got %v", postgresRule.From)
+			}
+
+			openRule := policy.Spec.Ingress[2]
+			if len(openRule.From) != 0 || !reflect.DeepEqual(ports(openRule.Ports), tt.expectedOpenPorts) {
+				t.Errorf("Expected ports %v open to all sources, got %v from %v", tt.expectedOpenPorts, ports(openRule.Ports), openRule.From)
+			}
+		})
+	}
+}
+
+func TestUpsertNetworkPolicyUpdatesExistingSpec(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-documentdb", Namespace: "test-namespace"},
+		Spec:       dbpreview.DocumentDBSpec{NetworkPolicy: &dbpreview.NetworkPolicyConfiguration{Enabled: true}},
+	}
+
+	if err := UpsertNetworkPolicy(ctx, c, GetDocumentDBNetworkPolicyDefinition(documentdb, "test-documentdb", "test-namespace")); err != nil {
+		t.Fatalf("UpsertNetworkPolicy() create returned error: %v", err)
+	}
+
+	clientSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"role": "client"}}
+	documentdb.Spec.NetworkPolicy.PodSelector = clientSelector
+	policy := GetDocumentDBNetworkPolicyDefinition(documentdb, "test-documentdb", "test-namespace")
+	if err := UpsertNetworkPolicy(ctx, c, policy); err != nil {
+		t.Fatalf("UpsertNetworkPolicy() update returned error: %v", err)
+	}
+
+	found := &networkingv1.NetworkPolicy{}
+	if err := c.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, found); err != nil {
+		t.Fatalf("Failed to get network policy: %v", err)
+	}
+	if !reflect.DeepEqual(found.Spec.Ingress[0].From[0].PodSelector, clientSelector) {
+		t.Errorf("Expected the gateway pod selector to be updated, got %v", found.Spec.Ingress[0].From[0].PodSelector)
+	}
+
+	if err := DeleteNetworkPolicy(ctx, c, policy.Name, policy.Namespace); err != nil {
+		t.Fatalf("DeleteNetworkPolicy() returned error: %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, found); err == nil {
+		t.Error("Expected network policy to be deleted")
+	}
+}
+
+func TestUpsertServiceCorrectsDrift(t *testing.T) {
+	ctx := context.Background()
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "drift-db", Namespace: "test-namespace"},
+	}
+	replicationContext := &ReplicationContext{Self: "drift-db", Environment: "aks"}
+	desired := GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeLoadBalancer)
+
+	// Existing service that was edited by hand after creation
+	existing := desired.DeepCopy()
+	existing.Spec.ClusterIP = "10.0.0.42"
+	existing.Spec.Selector = map[string]string{"app": "something-else"}
+	existing.Spec.Ports = []corev1.ServicePort{{Name: "gateway", Protocol: corev1.ProtocolTCP, Port: 27017, TargetPort: intstr.FromInt(27017), NodePort: 30100}}
+	existing.Annotations = map[string]string{"example.com/added-by-cloud": "true"}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing).Build()
+
+	found, err := UpsertService(ctx, c, desired.DeepCopy())
+	if err != nil {
+		t.Fatalf("UpsertService() returned error: %v", err)
+	}
+
+	current := &corev1.Service{}
+	if err := c.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current); err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	for _, svc := range []*corev1.Service{found, current} {
+		if !reflect.DeepEqual(svc.Spec.Selector, desired.Spec.Selector) {
+			t.Errorf("Expected selector %v, got %v", desired.Spec.Selector, svc.Spec.Selector)
+		}
+		if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].Port != GetPortFor(GATEWAY_PORT) {
+			t.Errorf("Expected the gateway port to be restored, got %+v", svc.Spec.Ports)
+		}
+		if svc.Spec.Ports[0].NodePort != 30100 {
+			t.Errorf("Expected the allocated node port to be preserved, got %d", svc.Spec.Ports[0].NodePort)
+		}
+		if svc.Spec.ClusterIP != "10.0.0.42" {
+			t.Errorf("Expected the cluster IP to be preserved, got %q", svc.Spec.ClusterIP)
+		}
+		for key, value := range desired.Annotations {
+			if svc.Annotations[key] != value {
+				t.Errorf("Expected annotation %q = %q, got %q", key, value, svc.Annotations[key])
+			}
+		}
+		if svc.Annotations["example.com/added-by-cloud"] != "true" {
+			t.Error("Expected annotations added by others to be kept")
+		}
+	}
+}
+
+func TestUpsertServiceLoadBalancerToClusterIP(t *testing.T) {
+	ctx := context.Background()
+	documentdb := &dbpreview.DocumentDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "type-db", Namespace: "test-namespace"},
+	}
+	replicationContext := &ReplicationContext{Self: "type-db", Environment: "aks"}
+	desired := GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeClusterIP)
+
+	// The same Service as allocated by the API server while it was a LoadBalancer
+	existing := GetDocumentDBServiceDefinition(documentdb, replicationContext, "test-namespace", corev1.ServiceTypeLoadBalancer)
+	existing.Spec.ClusterIP = "10.0.0.43"
+	existing.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyLocal
+	existing.Spec.HealthCheckNodePort = 32000
+	existing.Spec.AllocateLoadBalancerNodePorts = ptr.To(true)
+	for i := range existing.Spec.Ports {
+		existing.Spec.Ports[i].NodePort = 30200 + int32(i)
+	}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing).Build()
+
+	if _, err := UpsertService(ctx, c, desired.DeepCopy()); err != nil {
+		t.Fatalf("UpsertService() returned error: %v", err)
+	}
+
+	current := &corev1.Service{}
+	if err := c.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, current); err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	if current.Spec.Type != corev1.ServiceTypeClusterIP {
+		t.Errorf("Expected type ClusterIP, got %q", current.Spec.Type)
+	}
+	for _, port := range current.Spec.Ports {
+		if port.NodePort != 0 {
+			t.Errorf("Expected no node port on ClusterIP port %q, got %d", port.Name, port.NodePort)
+		}
+	}
+	if current.Spec.ExternalTrafficPolicy != "" || current.Spec.HealthCheckNodePort != 0 {
+		t.Errorf("Expected the external traffic policy and health check node port to be cleared, got %q and %d",
+			current.Spec.ExternalTrafficPolicy, current.Spec.HealthCheckNodePort)
+	}
+	if current.Spec.AllocateLoadBalancerNodePorts != nil {
+		t.Error("Expected allocateLoadBalancerNodePorts to be cleared")
+	}
+	if current.Spec.ClusterIP != "10.0.0.43" {
+		t.Errorf("Expected the cluster IP to be preserved, got %q", current.Spec.ClusterIP)
+	}
+}
+
+func TestUpsertIngressUpdatesExistingSpec(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).Build()
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "documentdb-service-test", Namespace: "test-namespace"},
+		Spec:       networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "old.example.com"}}},
+	}
+	if err := UpsertIngress(ctx, c, ingress.DeepCopy()); err != nil {
+		t.Fatalf("UpsertIngress() create returned error: %v", err)
+	}
+
+	ingress.Spec.Rules[0].Host = "new.example.com"
+	if err := UpsertIngress(ctx, c, ingress.DeepCopy()); err != nil {
+		t.Fatalf("UpsertIngress() update returned error: %v", err)
+	}
+
+	found := &networkingv1.Ingress{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found); err != nil {
+		t.Fatalf("Failed to get ingress: %v", err)
+	}
+	if found.Spec.Rules[0].Host != "new.example.com" {
+		t.Errorf("Expected ingress host to be updated, got %q", found.Spec.Rules[0].Host)
+	}
+
+	if err := DeleteIngress(ctx, c, ingress.Name, ingress.Namespace); err != nil {
+		t.Fatalf("DeleteIngress() returned error: %v", err)
+	}
+	if err := c.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found); err == nil {
+		t.Error("Expected ingress to be deleted")
+	}
+}
+
+func TestUpsertIngressKeepsUnmanagedAnnotations(t *testing.T) {
+	ctx := context.Background()
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "documentdb-service-test",
+			Namespace:   "test-namespace",
+			Annotations: map[string]string{INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION: "true"},
+		},
+		Spec: networkingv1.IngressSpec{Rules: []networkingv1.IngressRule{{Host: "db.example.com"}}},
+	}
+	// An annotation added by cert-manager after the Ingress was created
+	existing := ingress.DeepCopy()
+	existing.Annotations["cert-manager.io/issuer"] = "letsencrypt"
+
+	updates := 0
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing).WithInterceptorFuncs(interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			updates++
+			return c.Update(ctx, obj, opts...)
+		},
+	}).Build()
+
+	if err := UpsertIngress(ctx, c, ingress.DeepCopy()); err != nil {
+		t.Fatalf("UpsertIngress() returned error: %v", err)
+	}
+	if updates != 0 {
+		t.Errorf("Expected no update of an unchanged Ingress, got %d", updates)
+	}
+
+	ingress.Annotations[INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION] = "false"
+	if err := UpsertIngress(ctx, c, ingress.DeepCopy()); err != nil {
+		t.Fatalf("UpsertIngress() returned error: %v", err)
+	}
+	if updates != 1 {
+		t.Errorf("Expected a single update of the drifted annotation, got %d", updates)
+	}
+
+	found := &networkingv1.Ingress{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found); err != nil {
+		t.Fatalf("Failed to get ingress: %v", err)
+	}
+	if found.Annotations["cert-manager.io/issuer"] != "letsencrypt" {
+		t.Errorf("Expected the unmanaged annotation to be kept, got %v", found.Annotations)
+	}
+	if found.Annotations[INGRESS_NGINX_SSL_PASSTHROUGH_ANNOTATION] != "false" {
+		t.Errorf("Expected the managed annotation to be updated, got %v", found.Annotations)
+	}
+}
+
+func TestCreateRBACObjectsSetOwnerReferences(t *testing.T) {
+	ctx := context.Background()
+	documentdb := &dbpreview.DocumentDB{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "documentdb.io/preview", Kind: "DocumentDB"},
+		ObjectMeta: metav1.ObjectMeta{Name: "rbac-db", Namespace: "test-namespace", UID: types.UID("ddb-uid")},
+	}
+	// A ServiceAccount left behind by an earlier operator version without owner references
+	existing := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "rbac-db", Namespace: "test-namespace"}}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(existing).Build()
+
+	rules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}
+	if err := CreateRole(ctx, c, documentdb, "test-namespace", rules); err != nil {
+		t.Fatalf("CreateRole() returned error: %v", err)
+	}
+	if err := CreateServiceAccount(ctx, c, documentdb, "test-namespace"); err != nil {
+		t.Fatalf("CreateServiceAccount() returned error: %v", err)
+	}
+	if err := CreateRoleBinding(ctx, c, documentdb, "test-namespace"); err != nil {
+		t.Fatalf("CreateRoleBinding() returned error: %v", err)
+	}
+
+	key := types.NamespacedName{Name: "rbac-db", Namespace: "test-namespace"}
+	for _, obj := range []client.Object{&rbacv1.Role{}, &corev1.ServiceAccount{}, &rbacv1.RoleBinding{}} {
+		if err := c.Get(ctx, key, obj); err != nil {
+			t.Fatalf("Failed to get %T: %v", obj, err)
+		}
+		refs := obj.GetOwnerReferences()
+		if len(refs) != 1 || refs[0].UID != documentdb.UID || refs[0].Kind != "DocumentDB" {
+			t.Errorf("Expected %T to be owned by the DocumentDB, got %+v", obj, refs)
+		}
+	}
+
+	// Running again must not duplicate the owner reference
+	if err := CreateServiceAccount(ctx, c, documentdb, "test-namespace"); err != nil {
+		t.Fatalf("CreateServiceAccount() returned error: %v", err)
+	}
+	sa := &corev1.ServiceAccount{}
+	if err := c.Get(ctx, key, sa); err != nil {
+		t.Fatalf("Failed to get ServiceAccount: %v", err)
+	}
+	if len(sa.OwnerReferences) != 1 {
+		t.Errorf("Expected a single owner reference, got %+v", sa.OwnerReferences)
+	}
+}
+
+func TestCopySecretAcrossNamespaces(t *testing.T) {
+	ctx := context.Background()
+	documentdb := &dbpreview.DocumentDB{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "documentdb.io/preview", Kind: "DocumentDB"},
+		ObjectMeta: metav1.ObjectMeta{Name: "copy-db", Namespace: "app-namespace", UID: types.UID("ddb-uid")},
+		Spec: dbpreview.DocumentDBSpec{
+			DocumentDbCredentialSecret:          "shared-credentials",
+			DocumentDbCredentialSecretNamespace: "secrets-namespace",
+		},
+	}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-credentials", Namespace: "secrets-namespace"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"username": []byte("user"), "password": []byte("first")},
+	}
+	c := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(source).Build()
+
+	sourceKey := CredentialSecretSource(documentdb)
+	if sourceKey != (types.NamespacedName{Name: "shared-credentials", Namespace: "secrets-namespace"}) {
+		t.Fatalf("Expected the credential secret in secrets-namespace, got %s", sourceKey)
+	}
+	if err := CopySecret(ctx, c, documentdb, sourceKey); err != nil {
+		t.Fatalf("CopySecret() returned error: %v", err)
+	}
+
+	copyKey := types.NamespacedName{Name: "shared-credentials", Namespace: "app-namespace"}
+	copied := &corev1.Secret{}
+	if err := c.Get(ctx, copyKey, copied); err != nil {
+		t.Fatalf("Failed to get copied secret: %v", err)
+	}
+	if !reflect.DeepEqual(copied.Data, source.Data) || copied.Type != corev1.SecretTypeOpaque {
+		t.Errorf("Expected the copy to match the source, got type %s data %v", copied.Type, copied.Data)
+	}
+	if copied.Annotations[SOURCE_SECRET_ANNOTATION] != "secrets-namespace/shared-credentials" {
+		t.Errorf("Expected the source annotation, got %v", copied.Annotations)
+	}
+	if ref := metav1.GetControllerOf(copied); ref == nil || ref.UID != documentdb.UID {
+		t.Errorf("Expected the copy to be controlled by the DocumentDB, got %+v", copied.OwnerReferences)
+	}
+
+	// Rotating the source is propagated to the copy
+	source.Data["password"] = []byte("second")
+	if err := c.Update(ctx, source); err != nil {
+		t.Fatalf("Failed to update source secret: %v", err)
+	}
+	if err := CopySecret(ctx, c, documentdb, sourceKey); err != nil {
+		t.Fatalf("CopySecret() returned error: %v", err)
+	}
+	if err := c.Get(ctx, copyKey, copied); err != nil {
+		t.Fatalf("Failed to get copied secret: %v", err)
+	}
+	if string(copied.Data["password"]) != "second" {
+		t.Errorf("Expected the rotated password in the copy, got %q", copied.Data["password"])
+	}
+
+	// A secret of the same name that is not a copy of the source is never overwritten
+	unrelated := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway-tls", Namespace: "app-namespace"},
+		Data:       map[string][]byte{"tls.crt": []byte("local")},
+	}
+	tlsSource := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway-tls", Namespace: "secrets-namespace"},
+		Data:       map[string][]byte{"tls.crt": []byte("shared")},
+	}
+	c = fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(unrelated, tlsSource).Build()
+	if err := CopySecret(ctx, c, documentdb, client.ObjectKeyFromObject(tlsSource)); err == nil {
+		t.Errorf("Expected an error when a different secret of the same name exists")
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(unrelated), unrelated); err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if string(unrelated.Data["tls.crt"]) != "local" {
+		t.Errorf("Expected the existing secret to be left alone, got %q", unrelated.Data["tls.crt"])
+	}
+
+	// A missing source is reported as not found so callers can wait for it
+	if err := CopySecret(ctx, c, documentdb, types.NamespacedName{Name: "missing", Namespace: "secrets-namespace"}); !errors.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestProvidedTLSSecretSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		tls      *dbpreview.TLSConfiguration
+		expected types.NamespacedName
+		ok       bool
+	}{
+		{name: "no tls", tls: nil},
+		{name: "self-signed", tls: &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "SelfSigned"}}},
+		{
+			name:     "same namespace",
+			tls:      &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "Provided", Provided: &dbpreview.ProvidedTLS{SecretName: "gw-tls"}}},
+			expected: types.NamespacedName{Name: "gw-tls", Namespace: "app-namespace"},
+			ok:       true,
+		},
+		{
+			name:     "other namespace",
+			tls:      &dbpreview.TLSConfiguration{Gateway: &dbpreview.GatewayTLS{Mode: "Provided", Provided: &dbpreview.ProvidedTLS{SecretName: "gw-tls", Namespace: "secrets-namespace"}}},
+			expected: types.NamespacedName{Name: "gw-tls", Namespace: "secrets-namespace"},
+			ok:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			documentdb := &dbpreview.DocumentDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "tls-db", Namespace: "app-namespace"},
+				Spec:       dbpreview.DocumentDBSpec{TLS: tt.tls},
+			}
+			source, ok := ProvidedTLSSecretSource(documentdb)
+			if ok != tt.ok || (ok && source != tt.expected) {
+				t.Errorf("ProvidedTLSSecretSource() = %s, %v; expected %s, %v", source, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCompareImageVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		desired     string
+		expected    int
+		expectError bool
+	}{
+		{name: "newer patch", current: "repo/documentdb:0.106.0", desired: "repo/documentdb:0.106.1", expected: 1},
+		{name: "older minor", current: "repo/documentdb:0.107.0", desired: "repo/documentdb:0.106.0", expected: -1},
+		{name: "same version different repository", current: "a.io/documentdb:0.106.0", desired: "b.io/documentdb:0.106.0", expected: 0},
+		{name: "prefixed tags", current: "repo/documentdb:pg17-0.106.0", desired: "repo/documentdb:pg17-0.105.0", expected: -1},
+		{name: "single component tags", current: "repo/documentdb:16", desired: "repo/documentdb:17", expected: 1},
+		{name: "registry port is not a tag", current: "registry:5000/documentdb:0.106.0", desired: "registry:5000/documentdb:0.107.0", expected: 1},
+		{name: "digest is ignored", current: "repo/documentdb:0.106.0@sha256:abc", desired: "repo/documentdb:0.107.0", expected: 1},
+		{name: "untagged image", current: "repo/documentdb", desired: "repo/documentdb:0.107.0", expectError: true},
+		{name: "non version tag", current: "repo/documentdb:latest", desired: "repo/documentdb:0.107.0", expectError: true},
+		{name: "different version schemes", current: "repo/documentdb:16", desired: "repo/documentdb:0.107.0", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CompareImageVersions(tt.current, tt.desired)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("CompareImageVersions(%q, %q) expected error, got %d", tt.current, tt.desired, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompareImageVersions(%q, %q) returned error: %v", tt.current, tt.desired, err)
+			}
+			if result != tt.expected {
+				t.Errorf("CompareImageVersions(%q, %q) = %d; expected %d", tt.current, tt.desired, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestImageResolutionPrecedence(t *testing.T) {
+	tests := []struct {
+		name            string
+		documentDBImage string
+		gatewayImage    string
+		version         string
+		envVersion      string
+		expectedEngine  string
+		expectedGateway string
+	}{
+		{
+			name:            "explicit images win over version and env",
+			documentDBImage: "custom/documentdb:1.0.0",
+			gatewayImage:    "custom/gateway:1.0.0",
+			version:         "0.107.0",
+			envVersion:      "0.106.0",
+			expectedEngine:  "custom/documentdb:1.0.0",
+			expectedGateway: "custom/gateway:1.0.0",
+		},
+		{
+			name:            "spec version wins over env",
+			version:         "0.107.0",
+			envVersion:      "0.106.0",
+			expectedEngine:  DOCUMENTDB_IMAGE_REPOSITORY + ":0.107.0",
+			expectedGateway: DOCUMENTDB_IMAGE_REPOSITORY + ":0.107.0",
+		},
+		{
+			name:            "env version used when spec version is empty",
+			envVersion:      "0.106.0",
+			expectedEngine:  DOCUMENTDB_IMAGE_REPOSITORY + ":0.106.0",
+			expectedGateway: DOCUMENTDB_IMAGE_REPOSITORY + ":0.106.0",
+		},
+		{
+			name:            "defaults when nothing is set",
+			expectedEngine:  DEFAULT_DOCUMENTDB_IMAGE,
+			expectedGateway: DEFAULT_GATEWAY_IMAGE,
+		},
+		{
+			name:            "explicit engine image with version only resolves gateway",
+			documentDBImage: "custom/documentdb:1.0.0",
+			version:         "0.107.0",
+			expectedEngine:  "custom/documentdb:1.0.0",
+			expectedGateway: DOCUMENTDB_IMAGE_REPOSITORY + ":0.107.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(DOCUMENTDB_VERSION_ENV, tt.envVersion)
+
+			documentdb := &dbpreview.DocumentDB{
+				Spec: dbpreview.DocumentDBSpec{
+					DocumentDBImage:   tt.documentDBImage,
+					GatewayImage:      tt.gatewayImage,
+					DocumentDBVersion: tt.version,
+				},
+			}
+
+			if engine := GetDocumentDBImageForInstance(documentdb); engine != tt.expectedEngine {
+				t.Errorf("GetDocumentDBImageForInstance() = %q; expected %q", engine, tt.expectedEngine)
+			}
+			if gateway := GetGatewayImageForDocumentDB(documentdb); gateway != tt.expectedGateway {
+				t.Errorf("GetGatewayImageForDocumentDB() = %q; expected %q", gateway, tt.expectedGateway)
+			}
+		})
+	}
+}
+
+func TestChartDocumentDBVersionResolution(t *testing.T) {
+	template := readChartDocumentDBVersionEnv(t)
+
+	tests := []struct {
+		name              string
+		documentDbVersion string
+		expectedImage     string
+	}{
+		{
+			name:          "chart default keeps the default images",
+			expectedImage: DEFAULT_DOCUMENTDB_IMAGE,
+		},
+		{
+			name:              "explicit documentDbVersion selects the images",
+			documentDbVersion: "0.107.0",
+			expectedImage:     DOCUMENTDB_IMAGE_REPOSITORY + ":0.107.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered := &strings.Builder{}
+			values := map[string]any{
+				"Values": map[string]any{"documentDbVersion": tt.documentDbVersion},
+				"Chart":  map[string]any{"AppVersion": "0.1.3"},
+			}
+			if err := template.Execute(rendered, values); err != nil {
+				t.Fatalf("Failed to render the chart environment: %v", err)
+			}
+			envVersion := ""
+			if _, after, found := strings.Cut(rendered.String(), "value: "); found {
+				envVersion = strings.Trim(strings.TrimSpace(after), `"`)
+			}
+			t.Setenv(DOCUMENTDB_VERSION_ENV, envVersion)
+
+			documentdb := &dbpreview.DocumentDB{}
+			if engine := GetDocumentDBImageForInstance(documentdb); engine != tt.expectedImage {
+				t.Errorf("GetDocumentDBImageForInstance() = %q; expected %q", engine, tt.expectedImage)
+			}
+			if gateway := GetGatewayImageForDocumentDB(documentdb); gateway != tt.expectedImage {
+				t.Errorf("GetGatewayImageForDocumentDB() = %q; expected %q", gateway, tt.expectedImage)
+			}
+		})
+	}
+}
+
+// readChartDocumentDBVersionEnv returns the template of the DOCUMENTDB_VERSION variable in the operator Deployment
+// of the Helm chart.
+func readChartDocumentDBVersionEnv(t *testing.T) *texttemplate.Template {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join("..", "..", "..", "documentdb-helm-chart", "templates", "09_documentdb_operator.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read the operator chart template: %v", err)
+	}
+	chart := string(content)
+	end := strings.Index(chart, "- name: "+DOCUMENTDB_VERSION_ENV)
+	if end < 0 {
+		t.Fatalf("Expected the chart to set %s", DOCUMENTDB_VERSION_ENV)
+	}
+	start := strings.LastIndex(chart[:end], "{{-")
+	length := strings.Index(chart[end:], "{{- end }}")
+	if start < 0 || length < 0 {
+		t.Fatalf("Expected %s to be set conditionally", DOCUMENTDB_VERSION_ENV)
+	}
+
+	parsed, err := texttemplate.New("env").Funcs(texttemplate.FuncMap{"quote": strconv.Quote}).Parse(chart[start : end+length+len("{{- end }}")])
+	if err != nil {
+		t.Fatalf("Failed to parse the chart environment: %v", err)
+	}
+	return parsed
+}
+
+func TestDetectEnvironment(t *testing.T) {
+	tests := []struct {
+		name        string
+		providerIDs []string
+		expected    string
+	}{
+		{name: "aws nodes", providerIDs: []string{"aws:///us-east-1a/i-0abc", "aws:///us-east-1b/i-0def"}, expected: "eks"},
+		{name: "azure nodes", providerIDs: []string{"azure:///subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/vmss/virtualMachines/0"}, expected: "aks"},
+		{name: "gce nodes", providerIDs: []string{"gce://project/us-central1-a/node-1"}, expected: "gke"},
+		{name: "mixed providers", providerIDs: []string{"aws:///us-east-1a/i-0abc", "gce://project/us-central1-a/node-1"}, expected: ""},
+		{name: "unknown provider", providerIDs: []string{"kind://docker/kind/kind-control-plane"}, expected: ""},
+		{name: "missing provider ID", providerIDs: []string{"azure:///subscriptions/sub/vm-0", ""}, expected: ""},
+		{name: "no nodes", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme)
+			for i, providerID := range tt.providerIDs {
+				builder = builder.WithObjects(&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)},
+					Spec:       corev1.NodeSpec{ProviderID: providerID},
+				})
+			}
+
+			environment, err := DetectEnvironment(context.Background(), builder.Build())
+			if err != nil {
+				t.Fatalf("DetectEnvironment() returned error: %v", err)
+			}
+			if environment != tt.expected {
+				t.Errorf("DetectEnvironment() = %q, expected %q", environment, tt.expected)
+			}
+		})
+	}
+}